@@ -4,13 +4,21 @@ import (
 	"github.com/DanKo-code/TODO-list/internal/server"
 	"github.com/DanKo-code/TODO-list/pkg/logger"
 	"os"
+	_ "time/tzdata"
 )
 
 func main() {
 
 	//Test workflows
 
-	app, err := server.NewApp(os.Getenv("APP_ADDRESS"), os.Getenv("DB_DRIVER"), os.Getenv("DB_NAME"))
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := server.Migrate(os.Getenv("DB_DRIVER"), os.Getenv("DB_NAME"), os.Args[2:], os.Stdout); err != nil {
+			logger.FatalLogger.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	app, err := server.NewApp(os.Getenv("APP_ADDRESS"), os.Getenv("GRPC_ADDRESS"), os.Getenv("DB_DRIVER"), os.Getenv("DB_NAME"))
 	if err != nil {
 		logger.FatalLogger.Fatal("Failed to initialize app")
 	}
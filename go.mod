@@ -3,3 +3,19 @@ module github.com/DanKo-code/TODO-list
 go 1.23.3
 
 require github.com/mattn/go-sqlite3 v1.14.24
+
+require golang.org/x/crypto v0.39.0
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/lib/pq v1.10.9
+	google.golang.org/grpc v1.75.1
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+)
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type userIdKey struct{}
+
+type timeZoneKey struct{}
+
+// WithUserId returns a copy of ctx carrying the id of the authenticated user.
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserId returns the id of the authenticated user stored in ctx.
+func UserId(ctx context.Context) (string, bool) {
+	userId, ok := ctx.Value(userIdKey{}).(string)
+	return userId, ok && userId != ""
+}
+
+// WithTimeZone returns a copy of ctx carrying the time zone dates of the
+// request are interpreted in.
+func WithTimeZone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, timeZoneKey{}, loc)
+}
+
+// TimeZone returns the time zone stored in ctx, UTC if there is none.
+func TimeZone(ctx context.Context) *time.Location {
+	loc, ok := ctx.Value(timeZoneKey{}).(*time.Location)
+	if !ok || loc == nil {
+		return time.UTC
+	}
+
+	return loc
+}
@@ -8,3 +8,15 @@ import (
 type TaskChecker interface {
 	StartOverdueStatusChecker(ctx context.Context, interval time.Duration, stopChan <-chan struct{})
 }
+
+type ReminderScheduler interface {
+	StartReminderScheduler(ctx context.Context, interval time.Duration, stopChan <-chan struct{})
+}
+
+type WebhookDispatcher interface {
+	StartWebhookDispatcher(ctx context.Context, interval time.Duration, stopChan <-chan struct{})
+}
+
+type TrashPurger interface {
+	StartTrashPurger(ctx context.Context, interval, retention time.Duration, stopChan <-chan struct{})
+}
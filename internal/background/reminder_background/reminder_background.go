@@ -0,0 +1,38 @@
+package reminder_background
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"time"
+)
+
+type ReminderScheduler struct {
+	usecase usecase.ReminderUseCase
+}
+
+func NewReminderScheduler(useCase usecase.ReminderUseCase) *ReminderScheduler {
+	return &ReminderScheduler{
+		usecase: useCase,
+	}
+}
+
+// StartReminderScheduler sends due reminders on every tick. Reminders are
+// stored, so the ones that fell due while the server was down are sent on the
+// first tick after a restart. A failed run is retried on the next tick.
+func (rs *ReminderScheduler) StartReminderScheduler(ctx context.Context, interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := rs.usecase.SendDueReminders(ctx); err != nil {
+				logger.ErrorLogger.Printf("Error sending reminders: %v", err)
+			}
+		case <-stopChan:
+			logger.InfoLogger.Println("Stopping reminder scheduler")
+			return
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package reminder_background
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase/reminder_usecase"
+	"testing"
+	"time"
+)
+
+func TestStartReminderScheduler(t *testing.T) {
+	t.Run("keeps running after an error", func(t *testing.T) {
+		calls := 0
+		mockUseCase := &reminder_usecase.MockReminderUseCase{
+			SendDueRemindersFunc: func(ctx context.Context) error {
+				calls++
+				return errors.New("send error")
+			},
+		}
+
+		rs := NewReminderScheduler(mockUseCase)
+
+		stopChan := make(chan struct{})
+		interval := 10 * time.Millisecond
+
+		go func() {
+			time.Sleep(55 * time.Millisecond)
+			close(stopChan)
+		}()
+
+		rs.StartReminderScheduler(context.Background(), interval, stopChan)
+
+		if !mockUseCase.Called || calls < 2 {
+			t.Errorf("expected SendDueReminders to be called on every tick, got %d calls", calls)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package trash_background
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"time"
+)
+
+type TrashPurger struct {
+	usecase usecase.TaskUseCase
+}
+
+func NewTrashPurger(useCase usecase.TaskUseCase) *TrashPurger {
+	return &TrashPurger{
+		usecase: useCase,
+	}
+}
+
+// StartTrashPurger permanently deletes tasks that have been in the trash for
+// longer than retention on every tick. A failed run is retried on the next
+// tick.
+func (tp *TrashPurger) StartTrashPurger(ctx context.Context, interval, retention time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			count, err := tp.usecase.PurgeTrash(ctx, retention)
+			if err != nil {
+				logger.ErrorLogger.Printf("Error purging trash: %v", err)
+				continue
+			}
+			if count > 0 {
+				logger.InfoLogger.Printf("Purged %d tasks from the trash", count)
+			}
+		case <-stopChan:
+			logger.InfoLogger.Println("Stopping trash purger")
+			return
+		}
+	}
+}
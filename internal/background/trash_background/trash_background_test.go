@@ -0,0 +1,65 @@
+package trash_background
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartTrashPurger(t *testing.T) {
+	t.Run("purges with the retention and stops", func(t *testing.T) {
+		var calls int32
+		mockUseCase := &task_usecase.MockTaskUseCase{
+			PurgeTrashFunc: func(ctx context.Context, retention time.Duration) (int64, error) {
+				if retention != 720*time.Hour {
+					t.Errorf("expected retention %v, got %v", 720*time.Hour, retention)
+				}
+				atomic.AddInt32(&calls, 1)
+				return 1, nil
+			},
+		}
+
+		tp := NewTrashPurger(mockUseCase)
+
+		stopChan := make(chan struct{})
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(stopChan)
+		}()
+
+		tp.StartTrashPurger(context.Background(), 10*time.Millisecond, 720*time.Hour, stopChan)
+
+		if atomic.LoadInt32(&calls) == 0 {
+			t.Error("expected PurgeTrash to be called")
+		}
+	})
+
+	t.Run("keeps running after an error", func(t *testing.T) {
+		var calls int32
+		mockUseCase := &task_usecase.MockTaskUseCase{
+			PurgeTrashFunc: func(ctx context.Context, retention time.Duration) (int64, error) {
+				atomic.AddInt32(&calls, 1)
+				return 0, errors.New("purge error")
+			},
+		}
+
+		tp := NewTrashPurger(mockUseCase)
+
+		stopChan := make(chan struct{})
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(stopChan)
+		}()
+
+		tp.StartTrashPurger(context.Background(), 10*time.Millisecond, time.Hour, stopChan)
+
+		if atomic.LoadInt32(&calls) < 2 {
+			t.Errorf("expected PurgeTrash to be retried, got %d calls", calls)
+		}
+	})
+}
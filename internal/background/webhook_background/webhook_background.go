@@ -0,0 +1,38 @@
+package webhook_background
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"time"
+)
+
+type WebhookDispatcher struct {
+	usecase usecase.WebhookUseCase
+}
+
+func NewWebhookDispatcher(useCase usecase.WebhookUseCase) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		usecase: useCase,
+	}
+}
+
+// StartWebhookDispatcher sends queued webhook deliveries on every tick. The
+// queue is stored, so deliveries left over from before a restart are sent on
+// the first tick after it. A failed run is retried on the next tick.
+func (wd *WebhookDispatcher) StartWebhookDispatcher(ctx context.Context, interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := wd.usecase.DeliverPending(ctx); err != nil {
+				logger.ErrorLogger.Printf("Error delivering webhooks: %v", err)
+			}
+		case <-stopChan:
+			logger.InfoLogger.Println("Stopping webhook dispatcher")
+			return
+		}
+	}
+}
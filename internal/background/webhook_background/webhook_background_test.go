@@ -0,0 +1,37 @@
+package webhook_background
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase/webhook_usecase"
+	"testing"
+	"time"
+)
+
+func TestStartWebhookDispatcher(t *testing.T) {
+	t.Run("keeps running after an error", func(t *testing.T) {
+		calls := 0
+		mockUseCase := &webhook_usecase.MockWebhookUseCase{
+			DeliverPendingFunc: func(ctx context.Context) error {
+				calls++
+				return errors.New("delivery error")
+			},
+		}
+
+		wd := NewWebhookDispatcher(mockUseCase)
+
+		stopChan := make(chan struct{})
+		interval := 10 * time.Millisecond
+
+		go func() {
+			time.Sleep(55 * time.Millisecond)
+			close(stopChan)
+		}()
+
+		wd.StartWebhookDispatcher(context.Background(), interval, stopChan)
+
+		if !mockUseCase.Called || calls < 2 {
+			t.Errorf("expected DeliverPending to be called on every tick, got %d calls", calls)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// Package clock is the time source of the use cases, so tests can freeze it.
+package clock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	mu     sync.RWMutex
+	frozen *time.Time
+)
+
+// Now returns the current time in UTC, or the frozen time while there is one.
+func Now() time.Time {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if frozen != nil {
+		return *frozen
+	}
+
+	return time.Now().UTC()
+}
+
+// Timestamp returns Now in RFC 3339, the format timestamps are stored in.
+func Timestamp() string {
+	return Now().Format(time.RFC3339)
+}
+
+// Freeze makes Now return at, in UTC, until the test and its cleanups are
+// over. Freezing again moves the frozen time. Tests that freeze the clock
+// must not run in parallel, since it is shared.
+func Freeze(tb testing.TB, at time.Time) {
+	tb.Helper()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	at = at.UTC()
+	frozen = &at
+
+	tb.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		frozen = nil
+	})
+}
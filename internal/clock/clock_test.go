@@ -0,0 +1,22 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	local := time.Date(2024, 11, 22, 13, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	t.Run("frozen", func(t *testing.T) {
+		Freeze(t, local)
+
+		if got := Timestamp(); got != "2024-11-22T10:30:00Z" {
+			t.Errorf("expected the frozen time in UTC, got %s", got)
+		}
+	})
+
+	if got := Now(); got.Equal(local) || got.Location() != time.UTC {
+		t.Errorf("expected the clock to run again in UTC after the test, got %v", got)
+	}
+}
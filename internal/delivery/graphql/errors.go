@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+var (
+	InvalidIdFormat = errors.New("id must be on uuid format")
+	QueryIsRequired = errors.New("query is required")
+	NotValidRequest = errors.New("request body must be a JSON object with query, operationName and variables")
+	MutationOverGet = errors.New("mutations must be sent with POST")
+)
+
+// Error codes in the extensions of an error, following the codes common
+// GraphQL servers use.
+const (
+	CodeBadUserInput    = "BAD_USER_INPUT"
+	CodeNotFound        = "NOT_FOUND"
+	CodeConflict        = "CONFLICT"
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeNotImplemented  = "NOT_IMPLEMENTED"
+	CodeInternal        = "INTERNAL_SERVER_ERROR"
+)
+
+type location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type gqlError struct {
+	Message    string            `json:"message"`
+	Locations  []location        `json:"locations,omitempty"`
+	Path       path              `json:"path,omitempty"`
+	Extensions map[string]string `json:"extensions,omitempty"`
+}
+
+// inputError is a request the dtos rejected.
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e inputError) Unwrap() error {
+	return e.err
+}
+
+func badInput(err error) error {
+	return inputError{err}
+}
+
+// errorCode classifies an error of a resolver the same way the REST handlers
+// choose the status of their response.
+func errorCode(err error) string {
+	switch {
+	case errors.As(err, &inputError{}),
+		errors.Is(err, internalErrors.InvalidCursor),
+		errors.Is(err, internalErrors.DueDateInPast),
+		errors.Is(err, internalErrors.SubtaskDepthExceeded):
+		return CodeBadUserInput
+	case errors.Is(err, internalErrors.TaskNotFound),
+		errors.Is(err, internalErrors.ProjectNotFound),
+		errors.Is(err, internalErrors.BlockerNotFound),
+		errors.Is(err, internalErrors.TagNotFound):
+		return CodeNotFound
+	case errors.Is(err, internalErrors.VersionConflict),
+		errors.Is(err, internalErrors.ProjectArchived),
+		errors.Is(err, internalErrors.TaskBlocked),
+		errors.Is(err, internalErrors.DependencyCycle),
+		errors.Is(err, internalErrors.ParentTaskCompleted):
+		return CodeConflict
+	case errors.Is(err, internalErrors.SearchUnavailable):
+		return CodeNotImplemented
+	case errors.Is(err, internalErrors.Unauthorized):
+		return CodeUnauthenticated
+	}
+
+	return CodeInternal
+}
+
+// fieldError reports the failure of a field; unexpected errors are logged
+// and hidden from the client.
+func fieldError(err error, loc location, p path) *gqlError {
+	code := errorCode(err)
+
+	message := err.Error()
+	if code == CodeInternal {
+		logger.ErrorLogger.Printf("failed to resolve %v: %v", p, err)
+		message = "internal error"
+	}
+
+	return &gqlError{
+		Message:    message,
+		Locations:  []location{loc},
+		Path:       p,
+		Extensions: map[string]string{"code": code},
+	}
+}
@@ -0,0 +1,227 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// request is the state of one GraphQL request shared by its resolvers.
+type request struct {
+	ctx    context.Context
+	loader *loader
+	errors []*gqlError
+}
+
+// object is a completed object that keeps the order of its fields in JSON.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: map[string]interface{}{}}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+type path []interface{}
+
+func (p path) with(key interface{}) path {
+	next := make(path, len(p), len(p)+1)
+	copy(next, p)
+	return append(next, key)
+}
+
+// execute resolves the fields on root. The fields of a mutation run one
+// after the other in the order of the query.
+func (r *request) execute(s *schema, root *objectType, fields []*plannedField) interface{} {
+	objects, invalid := r.selection(s, root, fields, []interface{}{nil}, []path{{}})
+	if invalid[0] {
+		return nil
+	}
+	return objects[0]
+}
+
+// selection resolves the fields of t for all sources at once. invalid marks
+// the objects that must become null because a non-null field is null.
+func (r *request) selection(s *schema, t *objectType, fields []*plannedField, sources []interface{}, paths []path) ([]*object, []bool) {
+	objects := make([]*object, len(sources))
+	invalid := make([]bool, len(sources))
+	for i := range objects {
+		objects[i] = newObject()
+	}
+
+	for _, f := range fields {
+		if f.def == nil {
+			for _, o := range objects {
+				o.set(f.key, t.name)
+			}
+			continue
+		}
+
+		fieldPaths := make([]path, len(paths))
+		for i, p := range paths {
+			fieldPaths[i] = p.with(f.key)
+		}
+
+		values, err := f.def.resolve(r, sources, f.args)
+		if err == nil && len(values) != len(sources) {
+			err = fmt.Errorf("resolver of %s.%s returned %d values for %d sources", t.name, f.name, len(values), len(sources))
+		}
+
+		if err != nil {
+			for i := range sources {
+				r.errors = append(r.errors, fieldError(err, f.location, fieldPaths[i]))
+				objects[i].set(f.key, nil)
+				invalid[i] = invalid[i] || f.def.typ.nonNull
+			}
+			continue
+		}
+
+		completed, bad := r.complete(s, f, f.def.typ, values, fieldPaths)
+		for i := range sources {
+			objects[i].set(f.key, completed[i])
+			invalid[i] = invalid[i] || bad[i]
+		}
+	}
+
+	return objects, invalid
+}
+
+// complete turns resolved values into their JSON form. bad marks the values
+// that are null where the type forbids it; the null then propagates to the
+// closest nullable field above.
+func (r *request) complete(s *schema, f *plannedField, t *typeRef, values []interface{}, paths []path) ([]interface{}, []bool) {
+	out := make([]interface{}, len(values))
+	bad := make([]bool, len(values))
+
+	// nullify records a null value of a non-null type.
+	nullify := func(i int) {
+		if t.nonNull {
+			r.errors = append(r.errors, &gqlError{
+				Message:   fmt.Sprintf("cannot return null for non-nullable field %s", f.name),
+				Locations: []location{f.location},
+				Path:      paths[i],
+			})
+			bad[i] = true
+		}
+	}
+
+	switch {
+	case t.elem != nil:
+		var items []interface{}
+		var itemPaths []path
+		var owners []int
+
+		for i, v := range values {
+			if v == nil {
+				nullify(i)
+				continue
+			}
+
+			list := v.([]interface{})
+			out[i] = make([]interface{}, len(list))
+			for j, item := range list {
+				items = append(items, item)
+				itemPaths = append(itemPaths, paths[i].with(j))
+				owners = append(owners, i)
+			}
+		}
+
+		completed, itemBad := r.complete(s, f, t.elem, items, itemPaths)
+
+		positions := make(map[int]int)
+		for k, i := range owners {
+			if out[i] == nil {
+				continue
+			}
+
+			if itemBad[k] {
+				// An invalid item makes the whole list null.
+				out[i] = nil
+				bad[i] = t.nonNull
+				continue
+			}
+
+			out[i].([]interface{})[positions[i]] = completed[k]
+			positions[i]++
+		}
+	case s.objects[t.name] != nil:
+		var sources []interface{}
+		var sourcePaths []path
+		var owners []int
+
+		for i, v := range values {
+			if v == nil {
+				nullify(i)
+				continue
+			}
+			sources = append(sources, v)
+			sourcePaths = append(sourcePaths, paths[i])
+			owners = append(owners, i)
+		}
+
+		if len(sources) > 0 {
+			objects, invalid := r.selection(s, s.objects[t.name], f.children, sources, sourcePaths)
+			for k, i := range owners {
+				if invalid[k] {
+					bad[i] = t.nonNull
+					continue
+				}
+				out[i] = objects[k]
+			}
+		}
+	default:
+		for i, v := range values {
+			if v == nil {
+				nullify(i)
+				continue
+			}
+
+			if e := s.enums[t.name]; e != nil {
+				name, ok := e.serialize(v.(string))
+				if !ok {
+					r.errors = append(r.errors, fieldError(fmt.Errorf("%q is not a value of enum %s", v, t.name), f.location, paths[i]))
+					bad[i] = t.nonNull
+					continue
+				}
+				v = name
+			}
+
+			out[i] = v
+		}
+	}
+
+	return out, bad
+}
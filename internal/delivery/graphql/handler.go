@@ -0,0 +1,117 @@
+// Package graphql serves the tasks over GraphQL on /graphql. The schema is
+// defined in resolvers.go and printed in schema.graphql.
+package graphql
+
+import (
+	"encoding/json"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+// maxBodySize limits the size of a request; complexity limits the rest.
+const maxBodySize = 1 << 20
+
+type Handler struct {
+	taskUseCase    usecase.TaskUseCase
+	projectUseCase usecase.ProjectUseCase
+	schema         *schema
+}
+
+func NewHandler(taskUseCase usecase.TaskUseCase, projectUseCase usecase.ProjectUseCase) *Handler {
+	return &Handler{
+		taskUseCase:    taskUseCase,
+		projectUseCase: projectUseCase,
+		schema:         newSchemaFor(taskUseCase),
+	}
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// result is the response to a request that was executed; data is null when
+// a non-null field at the top failed.
+type result struct {
+	Data   interface{} `json:"data"`
+	Errors []*gqlError `json:"errors,omitempty"`
+}
+
+// failure is the response to a request that could not be executed.
+type failure struct {
+	Errors []*gqlError `json:"errors"`
+}
+
+func writeResponse(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeFailure(w http.ResponseWriter, status int, err error) {
+	writeResponse(w, status, failure{Errors: []*gqlError{{Message: err.Error()}}})
+}
+
+// readRequest reads the request from the JSON body of a POST or from the
+// query, operationName and variables parameters of a GET.
+func readRequest(r *http.Request) (*graphqlRequest, error) {
+	req := &graphqlRequest{}
+
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+
+		if variables := params.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, NotValidRequest
+			}
+		}
+	} else {
+		if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize)).Decode(req); err != nil {
+			return nil, NotValidRequest
+		}
+	}
+
+	if req.Query == "" {
+		return nil, QueryIsRequired
+	}
+
+	return req, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(r)
+	if err != nil {
+		writeFailure(w, http.StatusBadRequest, err)
+		return
+	}
+
+	doc, err := parse(req.Query)
+	if err != nil {
+		writeFailure(w, http.StatusBadRequest, err)
+		return
+	}
+
+	root, fields, err := plan(h.schema, doc, req.OperationName, req.Variables)
+	if err != nil {
+		writeFailure(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// GET requests must be safe to repeat.
+	if r.Method == http.MethodGet && root == h.schema.mutation {
+		w.Header().Set("Allow", http.MethodPost)
+		writeFailure(w, http.StatusMethodNotAllowed, MutationOverGet)
+		return
+	}
+
+	exec := &request{
+		ctx:    r.Context(),
+		loader: newLoader(h.taskUseCase, h.projectUseCase),
+	}
+	data := exec.execute(h.schema, root, fields)
+
+	writeResponse(w, http.StatusOK, result{Data: data, Errors: exec.errors})
+}
@@ -0,0 +1,317 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase/project_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	parentId  = "11111111-1111-4111-8111-111111111111"
+	childId   = "22222222-2222-4222-8222-222222222222"
+	siblingId = "33333333-3333-4333-8333-333333333333"
+	blockerId = "44444444-4444-4444-8444-444444444444"
+	projectId = "55555555-5555-4555-8555-555555555555"
+)
+
+func serve(h *Handler, method, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if method == http.MethodGet {
+		req = httptest.NewRequest(method, "/graphql?query="+url.QueryEscape(body), nil)
+	} else {
+		req = httptest.NewRequest(method, "/graphql", strings.NewReader(body))
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func query(q string, variables map[string]interface{}) string {
+	body, _ := json.Marshal(map[string]interface{}{"query": q, "variables": variables})
+	return string(body)
+}
+
+func TestSchemaFile(t *testing.T) {
+	expected, err := os.ReadFile("schema.graphql")
+	if err != nil {
+		t.Fatalf("failed to read schema.graphql: %v", err)
+	}
+
+	if printed := newSchemaFor(nil).print(); printed != string(expected) {
+		t.Errorf("schema.graphql is out of date, expected:\n%s", printed)
+	}
+}
+
+func TestBatchedLookups(t *testing.T) {
+	tasks := map[string]*models.Task{
+		parentId:  {Id: parentId, Title: "Parent", ProjectId: projectId},
+		childId:   {Id: childId, Title: "Child", ParentId: parentId, BlockedBy: []string{blockerId}, ProjectId: projectId},
+		siblingId: {Id: siblingId, Title: "Sibling", ParentId: parentId, BlockedBy: []string{blockerId, childId}},
+		blockerId: {Id: blockerId, Title: "Blocker", Priority: models.PriorityHigh},
+	}
+
+	var byIdsCalls [][]string
+	subtasksCalls, projectsCalls := 0, 0
+
+	taskUseCase := &task_usecase.MockTaskUseCase{
+		GetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+			return &dtos.TaskPage{Tasks: []*models.Task{tasks[childId], tasks[siblingId]}, NextCursor: "next"}, nil
+		},
+		GetTasksByIdsFunc: func(ctx context.Context, ids []string) ([]*models.Task, error) {
+			sorted := append([]string{}, ids...)
+			sort.Strings(sorted)
+			byIdsCalls = append(byIdsCalls, sorted)
+
+			var found []*models.Task
+			for _, id := range ids {
+				found = append(found, tasks[id])
+			}
+			return found, nil
+		},
+		GetSubtasksOfFunc: func(ctx context.Context, parentIds []string) (map[string][]*models.Task, error) {
+			subtasksCalls++
+			return map[string][]*models.Task{}, nil
+		},
+	}
+
+	projectUseCase := &project_usecase.MockProjectUseCase{
+		GetProjectsFunc: func(ctx context.Context, includeArchived bool) ([]*models.Project, error) {
+			projectsCalls++
+			return []*models.Project{{Id: projectId, Name: "Home"}}, nil
+		},
+	}
+
+	h := NewHandler(taskUseCase, projectUseCase)
+
+	w := serve(h, http.MethodPost, query(`{
+		tasks(filter: {priorities: [HIGH, LOW]}, first: 2) {
+			tasks {
+				title
+				parent { title project { name } }
+				blockers { title priority }
+				subtasks { id }
+				...on Task { project { name } }
+			}
+			nextCursor
+		}
+	}`, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	expected := `{"data":{"tasks":{"tasks":[` +
+		`{"title":"Child","parent":{"title":"Parent","project":{"name":"Home"}},"blockers":[{"title":"Blocker","priority":"HIGH"}],"subtasks":[],"project":{"name":"Home"}},` +
+		`{"title":"Sibling","parent":{"title":"Parent","project":{"name":"Home"}},"blockers":[{"title":"Blocker","priority":"HIGH"},{"title":"Child","priority":"NONE"}],"subtasks":[],"project":null}` +
+		`],"nextCursor":"next"}}}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("expected response %s, got %s", expected, w.Body.String())
+	}
+
+	// The page primes the loader, so the parent and the blocker that are not
+	// on it are fetched with one call each; the child is not fetched again.
+	if len(byIdsCalls) != 2 || strings.Join(byIdsCalls[0], ",") != parentId || strings.Join(byIdsCalls[1], ",") != blockerId {
+		t.Errorf("expected one lookup of the parent and one of the blocker, got %v", byIdsCalls)
+	}
+
+	if subtasksCalls != 1 || projectsCalls != 1 {
+		t.Errorf("expected one lookup of subtasks and projects, got %d and %d", subtasksCalls, projectsCalls)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	var created *dtos.CreateTaskCommand
+	var moved *dtos.MoveTaskCommand
+
+	taskUseCase := &task_usecase.MockTaskUseCase{
+		CreateTaskFunc: func(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+			created = cmd
+			return &models.Task{Id: childId, Title: cmd.Title, Priority: cmd.Priority, Version: 1}, nil
+		},
+		MoveTaskFunc: func(ctx context.Context, id string, cmd *dtos.MoveTaskCommand) (*models.Task, error) {
+			moved = cmd
+			return nil, internalErrors.VersionConflict
+		},
+	}
+
+	h := NewHandler(taskUseCase, &project_usecase.MockProjectUseCase{})
+
+	w := serve(h, http.MethodPost, query(`mutation Create($input: CreateTaskInput!, $version: Int!) {
+		created: createTask(input: $input) { id title priority version }
+		moveTask(id: "`+childId+`", projectId: null, version: $version) { id }
+	}`, map[string]interface{}{
+		"input":   map[string]interface{}{"title": "Task", "priority": "URGENT", "allDay": true, "dueDate": "2030-01-01"},
+		"version": 3,
+	}))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	expected := `{"data":{"created":{"id":"` + childId + `","title":"Task","priority":"URGENT","version":1},"moveTask":null},` +
+		`"errors":[{"message":"` + internalErrors.VersionConflict.Error() + `","locations":[{"line":3,"column":3}],"path":["moveTask"],"extensions":{"code":"CONFLICT"}}]}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("expected response %s, got %s", expected, w.Body.String())
+	}
+
+	if created == nil || created.Priority != models.PriorityUrgent || created.AllDay == nil || !*created.AllDay {
+		t.Errorf("unexpected create command: %+v", created)
+	}
+
+	if moved == nil || moved.ProjectId != nil || moved.Version == nil || *moved.Version != 3 {
+		t.Errorf("unexpected move command: %+v", moved)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	taskUseCase := &task_usecase.MockTaskUseCase{
+		GetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return nil, internalErrors.TaskNotFound
+		},
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		body               string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			name:               "no query",
+			method:             http.MethodPost,
+			body:               `{}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"` + QueryIsRequired.Error() + `"}]}`,
+		},
+		{
+			name:               "syntax error",
+			method:             http.MethodPost,
+			body:               query(`{ task(id: "1") { id }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"syntax error at 1:23: unexpected end of document"}]}`,
+		},
+		{
+			name:               "unknown field",
+			method:             http.MethodPost,
+			body:               query(`{ task(id: "1") { owner } }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"cannot query field \"owner\" on type \"Task\""}]}`,
+		},
+		{
+			name:               "missing variable",
+			method:             http.MethodPost,
+			body:               query(`query($id: ID!) { task(id: $id) { id } }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"variable $id of type ID! is required"}]}`,
+		},
+		{
+			name:               "wrong enum",
+			method:             http.MethodPost,
+			body:               query(`{ tasks(sort: NAME) { nextCursor } }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"field \"tasks\": argument \"sort\": expected a value of enum TaskSort, got NAME"}]}`,
+		},
+		{
+			name:               "too complex",
+			method:             http.MethodPost,
+			body:               query(`{ tasks(first: 100) { tasks { subtasks { subtasks { subtasks { id } } } } } }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"query complexity 111201 exceeds the limit of 10000"}]}`,
+		},
+		{
+			name:               "too deep",
+			method:             http.MethodPost,
+			body:               query(`{ task(id: "1") { parent { parent { parent { parent { parent { parent { parent { parent { parent { id } } } } } } } } } } }`, nil),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"errors":[{"message":"query depth exceeds the limit of 10"}]}`,
+		},
+		{
+			name:               "mutation over get",
+			method:             http.MethodGet,
+			body:               `mutation { deleteTask(id: "1", version: 1) }`,
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedResponse:   `{"errors":[{"message":"` + MutationOverGet.Error() + `"}]}`,
+		},
+		{
+			name:               "invalid id",
+			method:             http.MethodGet,
+			body:               `{ task(id: "1") { id } }`,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"data":{"task":null},"errors":[{"message":"` + InvalidIdFormat.Error() + `","locations":[{"line":1,"column":3}],"path":["task"],"extensions":{"code":"BAD_USER_INPUT"}}]}`,
+		},
+		{
+			name:               "not found",
+			method:             http.MethodGet,
+			body:               `{ task(id: "` + childId + `") { id } }`,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"data":{"task":null},"errors":[{"message":"` + internalErrors.TaskNotFound.Error() + `","locations":[{"line":1,"column":3}],"path":["task"],"extensions":{"code":"NOT_FOUND"}}]}`,
+		},
+		{
+			name:               "skipped field",
+			method:             http.MethodGet,
+			body:               `{ __typename task(id: "1") @skip(if: true) { id } }`,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"data":{"__typename":"Query"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(taskUseCase, &project_usecase.MockProjectUseCase{})
+
+			w := serve(h, tt.method, tt.body)
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+
+			if strings.TrimSpace(w.Body.String()) != tt.expectedResponse {
+				t.Errorf("expected response %s, got %s", tt.expectedResponse, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFragmentExpansion(t *testing.T) {
+	// Every fragment spreads the next one twice, so expanding the chain
+	// would select 2^22 fields.
+	var b strings.Builder
+	b.WriteString(`{ ...F0 }`)
+	for i := 0; i < 22; i++ {
+		fmt.Fprintf(&b, ` fragment F%d on Query { ...F%d ...F%d }`, i, i+1, i+1)
+	}
+	b.WriteString(` fragment F22 on Query { __typename }`)
+
+	h := NewHandler(&task_usecase.MockTaskUseCase{}, &project_usecase.MockProjectUseCase{})
+
+	start := time.Now()
+	w := serve(h, http.MethodPost, query(b.String(), nil))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the query to be rejected quickly, took %s", elapsed)
+	}
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	expected := `{"errors":[{"message":"query selects more than 10000 fields"}]}`
+	if strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("expected response %s, got %s", expected, w.Body.String())
+	}
+}
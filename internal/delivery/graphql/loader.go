@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+)
+
+// loader batches and caches the lookups of one request. Since the executor
+// resolves a field for all tasks of a list at once, the parents of a page of
+// tasks take one query through the loader instead of one per task.
+type loader struct {
+	taskUseCase    usecase.TaskUseCase
+	projectUseCase usecase.ProjectUseCase
+
+	// tasks holds nil for ids that were looked up and not found.
+	tasks    map[string]*models.Task
+	projects map[string]*models.Project
+}
+
+func newLoader(taskUseCase usecase.TaskUseCase, projectUseCase usecase.ProjectUseCase) *loader {
+	l := &loader{taskUseCase: taskUseCase, projectUseCase: projectUseCase}
+	l.reset()
+	return l
+}
+
+// reset forgets what was loaded; mutations call it since they may change
+// the tasks loaded before.
+func (l *loader) reset() {
+	l.tasks = map[string]*models.Task{}
+	l.projects = nil
+}
+
+// prime caches tasks that were loaded some other way, e.g. as a page.
+func (l *loader) prime(tasks ...*models.Task) {
+	for _, task := range tasks {
+		l.tasks[task.Id] = task
+	}
+}
+
+// loadTasks returns the tasks with the given ids, fetching the ones not
+// cached yet with one call. Missing ids map to nil.
+func (l *loader) loadTasks(ctx context.Context, ids []string) (map[string]*models.Task, error) {
+	var missing []string
+	seen := map[string]bool{}
+
+	for _, id := range ids {
+		if _, ok := l.tasks[id]; ok || seen[id] || id == "" {
+			continue
+		}
+		seen[id] = true
+		missing = append(missing, id)
+	}
+
+	if len(missing) > 0 {
+		tasks, err := l.taskUseCase.GetTasksByIds(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range missing {
+			l.tasks[id] = nil
+		}
+		l.prime(tasks...)
+	}
+
+	return l.tasks, nil
+}
+
+// loadSubtasks returns the direct subtasks of the given tasks with one call.
+func (l *loader) loadSubtasks(ctx context.Context, parentIds []string) (map[string][]*models.Task, error) {
+	subtasks, err := l.taskUseCase.GetSubtasksOf(ctx, parentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tasks := range subtasks {
+		l.prime(tasks...)
+	}
+
+	return subtasks, nil
+}
+
+// loadProjects returns all projects of the user by id; a user has few
+// enough of them to load them all at once.
+func (l *loader) loadProjects(ctx context.Context) (map[string]*models.Project, error) {
+	if l.projects != nil {
+		return l.projects, nil
+	}
+
+	projects, err := l.projectUseCase.GetProjects(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	l.projects = make(map[string]*models.Project, len(projects))
+	for _, project := range projects {
+		l.projects[project.Id] = project
+	}
+
+	return l.projects, nil
+}
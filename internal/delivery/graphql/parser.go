@@ -0,0 +1,789 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parser covers the executable part of GraphQL: operations with
+// variables, fields with aliases and arguments, fragments and the @skip and
+// @include directives. Type system definitions are not accepted.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind         string
+	name         string
+	variables    []*variableDefinition
+	selectionSet []selection
+}
+
+type variableDefinition struct {
+	name         string
+	typ          *typeRef
+	defaultValue value
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selectionSet  []selection
+}
+
+// selection is a *field, a *fragmentSpread or an *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias        string
+	name         string
+	arguments    []*argument
+	directives   []*directive
+	selectionSet []selection
+	line         int
+	column       int
+}
+
+// responseKey is the name of the field in the result.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+}
+
+// value is a literal of the query: nil for null, bool, int64, float64,
+// string, enumValue, variable, []value or objectValue.
+type value interface{}
+
+type enumValue string
+
+type variable string
+
+type objectValue []*argument
+
+const (
+	tokenEOF = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind   int
+	value  string
+	line   int
+	column int
+}
+
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	lineAt int
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %d:%d: %s", l.line, l.pos-l.lineAt+1, fmt.Sprintf(format, args...))
+}
+
+// skipIgnored skips white space, commas and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',', '\r':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.line++
+			l.lineAt = l.pos
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.src[l.pos:], "\uFEFF") {
+				l.pos += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+
+	tok := token{line: l.line, column: l.pos - l.lineAt + 1}
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.ContainsRune("!$():=@[]{}|&", rune(c)):
+		l.pos++
+		tok.kind, tok.value = tokenPunctuator, string(c)
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			return tok, l.errorf("unexpected %q", c)
+		}
+		l.pos += 3
+		tok.kind, tok.value = tokenPunctuator, "..."
+	case isNameStart(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		tok.kind, tok.value = tokenName, l.src[start:l.pos]
+	case c == '-' || isDigit(c):
+		return l.number(tok)
+	case c == '"':
+		return l.string(tok)
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		return tok, l.errorf("unexpected %q", r)
+	}
+
+	return tok, nil
+}
+
+func (l *lexer) number(tok token) (token, error) {
+	start := l.pos
+	tok.kind = tokenInt
+
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+
+	digits := func() bool {
+		from := l.pos
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return l.pos > from
+	}
+
+	if !digits() {
+		return tok, l.errorf("invalid number")
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		tok.kind = tokenFloat
+		if !digits() {
+			return tok, l.errorf("invalid number")
+		}
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		tok.kind = tokenFloat
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if !digits() {
+			return tok, l.errorf("invalid number")
+		}
+	}
+
+	if l.pos < len(l.src) && (isNameStart(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return tok, l.errorf("invalid number")
+	}
+
+	tok.value = l.src[start:l.pos]
+	return tok, nil
+}
+
+func (l *lexer) string(tok token) (token, error) {
+	tok.kind = tokenString
+
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		l.pos += 3
+		end := strings.Index(l.src[l.pos:], `"""`)
+		if end < 0 {
+			return tok, l.errorf("unterminated string")
+		}
+		raw := l.src[l.pos : l.pos+end]
+		l.line += strings.Count(raw, "\n")
+		l.pos += end + 3
+		tok.value = strings.TrimSpace(raw)
+		return tok, nil
+	}
+
+	l.pos++
+	var b strings.Builder
+
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return tok, l.errorf("unterminated string")
+		}
+
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			tok.value = b.String()
+			return tok, nil
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return tok, l.errorf("unterminated string")
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return tok, l.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return tok, l.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return tok, l.errorf("invalid escape \\%c", esc)
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// parse reads a query document.
+func parse(src string) (*document, error) {
+	p := &parser{lexer: &lexer{src: src, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: map[string]*fragment{}}
+
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			selectionSet, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selectionSet: selectionSet})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokenName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("there can be only one fragment named %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(kind int, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("syntax error at %d:%d: unexpected end of document", p.tok.line, p.tok.column)
+	}
+	return fmt.Errorf("syntax error at %d:%d: unexpected %q", p.tok.line, p.tok.column, p.tok.value)
+}
+
+func (p *parser) expect(kind int, value string) error {
+	if !p.peek(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// skip consumes the punctuator if it is next.
+func (p *parser) skip(value string) (bool, error) {
+	if !p.peek(tokenPunctuator, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(tokenPunctuator, ")") {
+			def, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	selectionSet, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selectionSet = selectionSet
+
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (*variableDefinition, error) {
+	if err := p.expect(tokenPunctuator, "$"); err != nil {
+		return nil, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenPunctuator, ":"); err != nil {
+		return nil, err
+	}
+
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+
+	def := &variableDefinition{name: name, typ: typ}
+
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		def.defaultValue, err = p.value(true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return def, nil
+}
+
+func (p *parser) typeRef() (*typeRef, error) {
+	t := &typeRef{}
+
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return nil, err
+		}
+		t.elem = elem
+	} else {
+		t.name, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip("!"); err != nil {
+		return nil, err
+	} else if ok {
+		t.nonNull = true
+	}
+
+	return t, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	f := &fragment{}
+
+	var err error
+	f.name, err = p.name()
+	if err != nil {
+		return nil, err
+	}
+	if f.name == "on" {
+		return nil, fmt.Errorf("syntax error: a fragment cannot be named \"on\"")
+	}
+
+	if err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+
+	f.typeCondition, err = p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	f.selectionSet, err = p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+
+	for !p.peek(tokenPunctuator, "}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.unexpected()
+	}
+
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		return p.fragmentSelection()
+	}
+
+	f := &field{line: p.tok.line, column: p.tok.column}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		name, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+
+	if p.peek(tokenPunctuator, "(") {
+		f.arguments, err = p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.directives, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek(tokenPunctuator, "{") {
+		f.selectionSet, err = p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (p *parser) fragmentSelection() (selection, error) {
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		spread := &fragmentSpread{name: p.tok.value}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		var err error
+		spread.directives, err = p.directives()
+		if err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+
+	inline := &inlineFragment{}
+
+	if ok := p.peek(tokenName, "on"); ok {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		inline.typeCondition = name
+	}
+
+	var err error
+	inline.directives, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	inline.selectionSet, err = p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	return inline, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return nil, err
+	}
+
+	var args []*argument
+
+	for !p.peek(tokenPunctuator, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, &argument{name: name, value: v})
+	}
+
+	if len(args) == 0 {
+		return nil, p.unexpected()
+	}
+
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		d := &directive{name: name}
+		if p.peek(tokenPunctuator, "(") {
+			d.arguments, err = p.arguments(false)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		directives = append(directives, d)
+	}
+
+	return directives, nil
+}
+
+// value reads a literal; constant ones cannot refer to variables.
+func (p *parser) value(constant bool) (value, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			return variable(name), nil
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := []value{}
+			for !p.peek(tokenPunctuator, "]") {
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			object := objectValue{}
+			for !p.peek(tokenPunctuator, "}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(tokenPunctuator, ":"); err != nil {
+					return nil, err
+				}
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, &argument{name: name, value: v})
+			}
+			return object, p.advance()
+		}
+	case tokenInt:
+		i, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d:%d: integer %s is out of range", tok.line, tok.column, tok.value)
+		}
+		return i, p.advance()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d:%d: float %s is out of range", tok.line, tok.column, tok.value)
+		}
+		return f, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		var v value
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.value)
+		}
+		return v, p.advance()
+	}
+
+	return nil, p.unexpected()
+}
@@ -0,0 +1,397 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+const (
+	// MaxDepth bounds how deep selections can be nested.
+	MaxDepth = 10
+	// MaxComplexity bounds the number of fields a query may resolve,
+	// estimated with the requested or assumed lengths of the lists.
+	MaxComplexity = 10000
+)
+
+// plannedField is a field of the operation checked against the schema, with
+// its arguments coerced and the fields selected below it merged.
+type plannedField struct {
+	key  string
+	name string
+	// def is nil for __typename.
+	def      *fieldDef
+	args     map[string]interface{}
+	children []*plannedField
+	location location
+}
+
+type planner struct {
+	schema    *schema
+	doc       *document
+	variables map[string]interface{}
+	defined   map[string]*variableDefinition
+	// spreads are the fragments being expanded, to detect cycles.
+	spreads []string
+	// expanded counts the selections collected so far, so that fragments
+	// spreading each other several times are rejected before they are
+	// expanded exponentially.
+	expanded int
+}
+
+// plan picks the operation to run, checks it against the schema and coerces
+// its variables and arguments.
+func plan(s *schema, doc *document, operationName string, variables map[string]interface{}) (*objectType, []*plannedField, error) {
+	var op *operation
+
+	for _, o := range doc.operations {
+		if operationName == "" || o.name == operationName {
+			if op != nil {
+				return nil, nil, fmt.Errorf("operationName is required for a document with several operations")
+			}
+			op = o
+		}
+	}
+
+	if op == nil {
+		return nil, nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	var root *objectType
+	switch op.kind {
+	case "query":
+		root = s.query
+	case "mutation":
+		root = s.mutation
+	default:
+		return nil, nil, fmt.Errorf("%s operations are not supported, task changes are streamed on /tasks/events", op.kind)
+	}
+
+	p := &planner{
+		schema:    s,
+		doc:       doc,
+		variables: map[string]interface{}{},
+		defined:   map[string]*variableDefinition{},
+	}
+
+	for _, def := range op.variables {
+		if _, ok := p.defined[def.name]; ok {
+			return nil, nil, fmt.Errorf("there can be only one variable named $%s", def.name)
+		}
+		p.defined[def.name] = def
+
+		if !s.isInputType(def.typ) {
+			return nil, nil, fmt.Errorf("variable $%s cannot be of type %s", def.name, def.typ)
+		}
+
+		v, ok := variables[def.name]
+		if !ok && def.defaultValue != nil {
+			v, ok = literalValue(def.defaultValue, nil)
+		}
+
+		if !ok {
+			if def.typ.nonNull {
+				return nil, nil, fmt.Errorf("variable $%s of type %s is required", def.name, def.typ)
+			}
+			continue
+		}
+
+		if _, err := s.coerce(v, def.typ); err != nil {
+			return nil, nil, fmt.Errorf("variable $%s: %v", def.name, err)
+		}
+		p.variables[def.name] = v
+	}
+
+	fields, err := p.fields(root, op.selectionSet, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c := complexity(fields); c > MaxComplexity {
+		return nil, nil, fmt.Errorf("query complexity %d exceeds the limit of %d", c, MaxComplexity)
+	}
+
+	return root, fields, nil
+}
+
+// checkVariables reports variables the operation does not define.
+func (p *planner) checkVariables(v value) error {
+	switch v := v.(type) {
+	case variable:
+		if _, ok := p.defined[string(v)]; !ok {
+			return fmt.Errorf("variable $%s is not defined", v)
+		}
+	case []value:
+		for _, item := range v {
+			if err := p.checkVariables(item); err != nil {
+				return err
+			}
+		}
+	case objectValue:
+		for _, f := range v {
+			if err := p.checkVariables(f.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// included evaluates the @skip and @include directives.
+func (p *planner) included(directives []*directive) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			return false, fmt.Errorf("directive @%s takes a single argument \"if\"", d.name)
+		}
+
+		if err := p.checkVariables(d.arguments[0].value); err != nil {
+			return false, err
+		}
+
+		v, _ := literalValue(d.arguments[0].value, p.variables)
+		cond, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("argument \"if\" of @%s must be a Boolean", d.name)
+		}
+
+		if cond == (d.name == "skip") {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collect lists the fields selected on the type, expanding fragments and
+// grouping fields by their response key.
+func (p *planner) collect(t *objectType, selections []selection, groups []*fieldGroup) ([]*fieldGroup, error) {
+	for _, s := range selections {
+		var directives []*directive
+		switch s := s.(type) {
+		case *field:
+			directives = s.directives
+		case *fragmentSpread:
+			directives = s.directives
+		case *inlineFragment:
+			directives = s.directives
+		}
+
+		ok, err := p.included(directives)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		p.expanded++
+		if p.expanded > MaxComplexity {
+			return nil, fmt.Errorf("query selects more than %d fields", MaxComplexity)
+		}
+
+		switch s := s.(type) {
+		case *field:
+			found := false
+			for _, g := range groups {
+				if g.key == s.responseKey() {
+					g.fields = append(g.fields, s)
+					found = true
+				}
+			}
+			if !found {
+				groups = append(groups, &fieldGroup{key: s.responseKey(), fields: []*field{s}})
+			}
+		case *fragmentSpread:
+			f, ok := p.doc.fragments[s.name]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %q", s.name)
+			}
+
+			for _, name := range p.spreads {
+				if name == s.name {
+					return nil, fmt.Errorf("fragment %q spreads itself", s.name)
+				}
+			}
+
+			if err := p.checkCondition(f.typeCondition); err != nil {
+				return nil, err
+			}
+			if f.typeCondition != t.name {
+				continue
+			}
+
+			p.spreads = append(p.spreads, s.name)
+			groups, err = p.collect(t, f.selectionSet, groups)
+			p.spreads = p.spreads[:len(p.spreads)-1]
+			if err != nil {
+				return nil, err
+			}
+		case *inlineFragment:
+			if s.typeCondition != "" {
+				if err := p.checkCondition(s.typeCondition); err != nil {
+					return nil, err
+				}
+				if s.typeCondition != t.name {
+					continue
+				}
+			}
+
+			groups, err = p.collect(t, s.selectionSet, groups)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+func (p *planner) checkCondition(name string) error {
+	if p.schema.objects[name] == nil {
+		return fmt.Errorf("unknown type %q in fragment", name)
+	}
+	return nil
+}
+
+func (p *planner) fields(t *objectType, selections []selection, depth int) ([]*plannedField, error) {
+	if depth > MaxDepth {
+		return nil, fmt.Errorf("query depth exceeds the limit of %d", MaxDepth)
+	}
+
+	groups, err := p.collect(t, selections, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make([]*plannedField, 0, len(groups))
+
+	for _, g := range groups {
+		first := g.fields[0]
+		f := &plannedField{
+			key:      g.key,
+			name:     first.name,
+			location: location{Line: first.line, Column: first.column},
+		}
+
+		var selections []selection
+		for _, other := range g.fields {
+			if other.name != first.name {
+				return nil, fmt.Errorf("fields %q conflict because %s and %s are different fields", g.key, first.name, other.name)
+			}
+			selections = append(selections, other.selectionSet...)
+		}
+
+		if first.name == "__typename" {
+			if len(first.arguments) > 0 || len(selections) > 0 {
+				return nil, fmt.Errorf("field __typename takes no arguments or selections")
+			}
+			planned = append(planned, f)
+			continue
+		}
+
+		f.def = t.field(first.name)
+		if f.def == nil {
+			return nil, fmt.Errorf("cannot query field %q on type %q", first.name, t.name)
+		}
+
+		f.args, err = p.arguments(f.def, first.arguments)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", first.name, err)
+		}
+
+		if object := p.schema.objects[f.def.typ.named()]; object != nil {
+			if len(selections) == 0 {
+				return nil, fmt.Errorf("field %q of type %s must have a selection of subfields", first.name, f.def.typ)
+			}
+
+			f.children, err = p.fields(object, selections, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		} else if len(selections) > 0 {
+			return nil, fmt.Errorf("field %q of type %s cannot have a selection of subfields", first.name, f.def.typ)
+		}
+
+		planned = append(planned, f)
+	}
+
+	return planned, nil
+}
+
+func (p *planner) arguments(def *fieldDef, arguments []*argument) (map[string]interface{}, error) {
+	given := map[string]value{}
+
+	for _, a := range arguments {
+		if def.arg(a.name) == nil {
+			return nil, fmt.Errorf("unknown argument %q", a.name)
+		}
+		if _, ok := given[a.name]; ok {
+			return nil, fmt.Errorf("there can be only one argument named %q", a.name)
+		}
+		if err := p.checkVariables(a.value); err != nil {
+			return nil, err
+		}
+		given[a.name] = a.value
+	}
+
+	args := map[string]interface{}{}
+
+	for _, a := range def.args {
+		v, ok := literalValue(given[a.name], p.variables)
+		if _, present := given[a.name]; !present {
+			ok = false
+		}
+
+		if !ok {
+			if a.typ.nonNull {
+				return nil, fmt.Errorf("argument %q of type %s is required", a.name, a.typ)
+			}
+			continue
+		}
+
+		value, err := p.schema.coerce(v, a.typ)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", a.name, err)
+		}
+		args[a.name] = value
+	}
+
+	return args, nil
+}
+
+// complexity counts the fields of the selection, multiplying the fields
+// below a list by its length.
+func complexity(fields []*plannedField) int {
+	total := 0
+
+	for _, f := range fields {
+		total++
+
+		if f.def == nil || len(f.children) == 0 {
+			continue
+		}
+
+		size := 1
+		if f.def.listSize > 0 {
+			size = f.def.listSize
+		}
+		if n, ok := f.args[f.def.listArg].(int); ok && n > 0 {
+			size = n
+		}
+
+		total += size * complexity(f.children)
+		if total > MaxComplexity {
+			// Stop before deeply nested lists overflow the count.
+			return total
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,662 @@
+package graphql
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+)
+
+// listSize is the length assumed for lists without a limit, e.g. the
+// blockers of a task, when estimating the complexity of a query.
+const listSize = 10
+
+func arg(name, typ string) *inputValue {
+	return &inputValue{name: name, typ: mustType(typ)}
+}
+
+// sourceField resolves every source on its own; it suits fields that need no
+// lookups.
+func sourceField(name, typ string, get func(source interface{}) interface{}) *fieldDef {
+	return &fieldDef{
+		name: name,
+		typ:  mustType(typ),
+		resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+			values := make([]interface{}, len(sources))
+			for i, source := range sources {
+				values[i] = get(source)
+			}
+			return values, nil
+		},
+	}
+}
+
+func taskField(name, typ string, get func(task *models.Task) interface{}) *fieldDef {
+	return sourceField(name, typ, func(source interface{}) interface{} {
+		return get(source.(*models.Task))
+	})
+}
+
+func projectField(name, typ string, get func(project *models.Project) interface{}) *fieldDef {
+	return sourceField(name, typ, func(source interface{}) interface{} {
+		return get(source.(*models.Project))
+	})
+}
+
+// rootField resolves a field of Query or Mutation, which have a single
+// source.
+func rootField(name, typ string, args []*inputValue, resolve func(r *request, args map[string]interface{}) (interface{}, error)) *fieldDef {
+	return &fieldDef{
+		name: name,
+		typ:  mustType(typ),
+		args: args,
+		resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+			value, err := resolve(r, args)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{value}, nil
+		},
+	}
+}
+
+// optional turns empty strings into null.
+func optional(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func intArg(args map[string]interface{}, name string) int {
+	i, _ := args[name].(int)
+	return i
+}
+
+func boolArg(args map[string]interface{}, name string) *bool {
+	b, ok := args[name].(bool)
+	if !ok {
+		return nil
+	}
+	return &b
+}
+
+func versionArg(args map[string]interface{}, name string) *int64 {
+	i, ok := args[name].(int)
+	if !ok {
+		return nil
+	}
+	v := int64(i)
+	return &v
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	list, _ := args[name].([]interface{})
+
+	var values []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+func objectArg(args map[string]interface{}, name string) map[string]interface{} {
+	object, _ := args[name].(map[string]interface{})
+	if object == nil {
+		return map[string]interface{}{}
+	}
+	return object
+}
+
+func checkId(id string) error {
+	if !helper.IsValidUUID(id) {
+		return badInput(InvalidIdFormat)
+	}
+	return nil
+}
+
+func taskList(tasks []*models.Task) []interface{} {
+	list := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		list[i] = task
+	}
+	return list
+}
+
+// returnTask returns the task, or an untyped nil so that it completes as null.
+func returnTask(task *models.Task, err error) (interface{}, error) {
+	if err != nil || task == nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+var taskListArgs = []*inputValue{
+	arg("filter", "TaskFilter"),
+	arg("sort", "TaskSort"),
+	arg("order", "SortOrder"),
+	arg("first", "Int"),
+	arg("after", "String"),
+}
+
+// getTasksQuery builds the query of the tasks field from its arguments.
+func getTasksQuery(args map[string]interface{}) (*dtos.GetTasksQuery, error) {
+	filter := objectArg(args, "filter")
+
+	query := &dtos.GetTasksQuery{
+		Completed:  boolArg(filter, "completed"),
+		Overdue:    boolArg(filter, "overdue"),
+		DueFrom:    stringArg(filter, "dueFrom"),
+		DueTo:      stringArg(filter, "dueTo"),
+		Title:      stringArg(filter, "title"),
+		ProjectId:  stringArg(filter, "projectId"),
+		SeriesId:   stringArg(filter, "seriesId"),
+		Tags:       stringsArg(filter, "tags"),
+		TagMode:    stringArg(filter, "tagMode"),
+		Priorities: stringsArg(filter, "priorities"),
+		Sort:       stringArg(args, "sort"),
+		Order:      stringArg(args, "order"),
+		Limit:      intArg(args, "first"),
+		Cursor:     stringArg(args, "after"),
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, badInput(err)
+	}
+
+	for _, id := range []string{query.ProjectId, query.SeriesId} {
+		if id != "" {
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return query, nil
+}
+
+func createTaskCommand(input map[string]interface{}) (*dtos.CreateTaskCommand, error) {
+	cmd := &dtos.CreateTaskCommand{
+		Title:       stringArg(input, "title"),
+		Description: stringArg(input, "description"),
+		DueDate:     stringArg(input, "dueDate"),
+		AllDay:      boolArg(input, "allDay"),
+		Priority:    stringArg(input, "priority"),
+		ProjectId:   stringArg(input, "projectId"),
+		Recurrence:  stringArg(input, "recurrence"),
+	}
+
+	if err := cmd.Validate(); err != nil {
+		return nil, badInput(err)
+	}
+
+	if cmd.ProjectId != "" {
+		if err := checkId(cmd.ProjectId); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmd, nil
+}
+
+func newSchemaFor(taskUseCase usecase.TaskUseCase) *schema {
+	enums := []*enumType{
+		{name: "Priority", values: []string{"NONE", "LOW", "MEDIUM", "HIGH", "URGENT"}},
+		{name: "TaskSort", values: []string{"SMART", "PRIORITY", "DUE_DATE", "TITLE", "CREATED", "CREATED_AT", "UPDATED_AT", "COMPLETED_AT"}},
+		{name: "SortOrder", values: []string{"ASC", "DESC"}},
+		{name: "TagMode", values: []string{"ANY", "ALL"}},
+	}
+
+	inputs := []*inputType{
+		{name: "TaskFilter", fields: []*inputValue{
+			arg("completed", "Boolean"),
+			arg("overdue", "Boolean"),
+			arg("dueFrom", "String"),
+			arg("dueTo", "String"),
+			arg("title", "String"),
+			arg("projectId", "ID"),
+			arg("seriesId", "ID"),
+			arg("tags", "[String!]"),
+			arg("tagMode", "TagMode"),
+			arg("priorities", "[Priority!]"),
+		}},
+		{name: "CreateTaskInput", fields: []*inputValue{
+			arg("title", "String!"),
+			arg("description", "String"),
+			arg("dueDate", "String"),
+			arg("allDay", "Boolean"),
+			arg("priority", "Priority"),
+			arg("projectId", "ID"),
+			arg("recurrence", "String"),
+		}},
+		{name: "UpdateTaskInput", fields: []*inputValue{
+			arg("title", "String"),
+			arg("description", "String"),
+			arg("dueDate", "String"),
+			arg("allDay", "Boolean"),
+			arg("priority", "Priority"),
+			arg("recurrence", "String"),
+			arg("version", "Int!"),
+		}},
+	}
+
+	task := &objectType{name: "Task", fields: []*fieldDef{
+		taskField("id", "ID!", func(t *models.Task) interface{} { return t.Id }),
+		taskField("title", "String!", func(t *models.Task) interface{} { return t.Title }),
+		taskField("description", "String!", func(t *models.Task) interface{} { return t.Description }),
+		taskField("dueDate", "String", func(t *models.Task) interface{} { return optional(t.DueDate) }),
+		taskField("allDay", "Boolean!", func(t *models.Task) interface{} { return t.AllDay }),
+		taskField("overdue", "Boolean!", func(t *models.Task) interface{} { return t.Overdue }),
+		taskField("completed", "Boolean!", func(t *models.Task) interface{} { return t.Completed }),
+		taskField("priority", "Priority!", func(t *models.Task) interface{} {
+			if t.Priority == "" {
+				return models.PriorityNone
+			}
+			return t.Priority
+		}),
+		taskField("version", "Int!", func(t *models.Task) interface{} { return t.Version }),
+		taskField("createdAt", "String", func(t *models.Task) interface{} { return optional(t.CreatedAt) }),
+		taskField("updatedAt", "String", func(t *models.Task) interface{} { return optional(t.UpdatedAt) }),
+		taskField("completedAt", "String", func(t *models.Task) interface{} { return optional(t.CompletedAt) }),
+		taskField("progress", "Int", func(t *models.Task) interface{} {
+			if t.Progress == nil {
+				return nil
+			}
+			return *t.Progress
+		}),
+		taskField("recurrence", "String", func(t *models.Task) interface{} { return optional(t.Recurrence) }),
+		taskField("seriesId", "ID", func(t *models.Task) interface{} { return optional(t.SeriesId) }),
+		taskField("occurrence", "Int", func(t *models.Task) interface{} {
+			if t.SeriesId == "" {
+				return nil
+			}
+			return t.Occurrence
+		}),
+		taskField("tags", "[String!]!", func(t *models.Task) interface{} {
+			tags := make([]interface{}, len(t.Tags))
+			for i, tag := range t.Tags {
+				tags[i] = tag
+			}
+			return tags
+		}),
+		taskField("blocked", "Boolean!", func(t *models.Task) interface{} { return t.Blocked }),
+		taskField("projectId", "ID", func(t *models.Task) interface{} { return optional(t.ProjectId) }),
+		taskField("parentId", "ID", func(t *models.Task) interface{} { return optional(t.ParentId) }),
+		{
+			name: "project",
+			typ:  mustType("Project"),
+			resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				values := make([]interface{}, len(sources))
+
+				var projects map[string]*models.Project
+				for i, source := range sources {
+					projectId := source.(*models.Task).ProjectId
+					if projectId == "" {
+						continue
+					}
+
+					if projects == nil {
+						var err error
+						projects, err = r.loader.loadProjects(r.ctx)
+						if err != nil {
+							return nil, err
+						}
+					}
+
+					if project, ok := projects[projectId]; ok {
+						values[i] = project
+					}
+				}
+
+				return values, nil
+			},
+		},
+		{
+			name: "parent",
+			typ:  mustType("Task"),
+			resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				ids := make([]string, len(sources))
+				for i, source := range sources {
+					ids[i] = source.(*models.Task).ParentId
+				}
+
+				tasks, err := r.loader.loadTasks(r.ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+
+				values := make([]interface{}, len(sources))
+				for i, id := range ids {
+					if task := tasks[id]; task != nil {
+						values[i] = task
+					}
+				}
+
+				return values, nil
+			},
+		},
+		{
+			name:     "blockers",
+			typ:      mustType("[Task!]!"),
+			listSize: listSize,
+			resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				var ids []string
+				for _, source := range sources {
+					ids = append(ids, source.(*models.Task).BlockedBy...)
+				}
+
+				tasks, err := r.loader.loadTasks(r.ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+
+				values := make([]interface{}, len(sources))
+				for i, source := range sources {
+					blockers := []interface{}{}
+					for _, id := range source.(*models.Task).BlockedBy {
+						if task := tasks[id]; task != nil {
+							blockers = append(blockers, task)
+						}
+					}
+					values[i] = blockers
+				}
+
+				return values, nil
+			},
+		},
+		{
+			name:     "subtasks",
+			typ:      mustType("[Task!]!"),
+			listSize: listSize,
+			resolve: func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+				ids := make([]string, len(sources))
+				for i, source := range sources {
+					ids[i] = source.(*models.Task).Id
+				}
+
+				subtasks, err := r.loader.loadSubtasks(r.ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+
+				values := make([]interface{}, len(sources))
+				for i, id := range ids {
+					values[i] = taskList(subtasks[id])
+				}
+
+				return values, nil
+			},
+		},
+	}}
+
+	project := &objectType{name: "Project", fields: []*fieldDef{
+		projectField("id", "ID!", func(p *models.Project) interface{} { return p.Id }),
+		projectField("name", "String!", func(p *models.Project) interface{} { return p.Name }),
+		projectField("description", "String!", func(p *models.Project) interface{} { return p.Description }),
+		projectField("archived", "Boolean!", func(p *models.Project) interface{} { return p.Archived }),
+		projectField("createdAt", "String", func(p *models.Project) interface{} { return optional(p.CreatedAt) }),
+		projectField("updatedAt", "String", func(p *models.Project) interface{} { return optional(p.UpdatedAt) }),
+	}}
+
+	taskConnection := &objectType{name: "TaskConnection", fields: []*fieldDef{
+		sourceField("tasks", "[Task!]!", func(source interface{}) interface{} {
+			return taskList(source.(*dtos.TaskPage).Tasks)
+		}),
+		sourceField("nextCursor", "String", func(source interface{}) interface{} {
+			return optional(source.(*dtos.TaskPage).NextCursor)
+		}),
+	}}
+
+	searchResult := &objectType{name: "SearchResult", fields: []*fieldDef{
+		sourceField("task", "Task!", func(source interface{}) interface{} { return source.(*dtos.SearchResult).Task }),
+		sourceField("rank", "Float!", func(source interface{}) interface{} { return source.(*dtos.SearchResult).Rank }),
+		sourceField("titleHighlight", "String!", func(source interface{}) interface{} { return source.(*dtos.SearchResult).TitleHighlight }),
+		sourceField("snippet", "String!", func(source interface{}) interface{} { return source.(*dtos.SearchResult).Snippet }),
+	}}
+
+	searchConnection := &objectType{name: "SearchConnection", fields: []*fieldDef{
+		sourceField("results", "[SearchResult!]!", func(source interface{}) interface{} {
+			results := source.(*dtos.SearchPage).Results
+			list := make([]interface{}, len(results))
+			for i, result := range results {
+				list[i] = result
+			}
+			return list
+		}),
+		sourceField("nextCursor", "String", func(source interface{}) interface{} {
+			return optional(source.(*dtos.SearchPage).NextCursor)
+		}),
+	}}
+
+	tasksField := rootField("tasks", "TaskConnection", taskListArgs, func(r *request, args map[string]interface{}) (interface{}, error) {
+		query, err := getTasksQuery(args)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := taskUseCase.GetTasks(r.ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		r.loader.prime(page.Tasks...)
+		return page, nil
+	})
+	tasksField.listArg, tasksField.listSize = "first", dtos.DefaultTasksLimit
+
+	searchField := rootField("searchTasks", "SearchConnection", []*inputValue{arg("query", "String!"), arg("first", "Int"), arg("after", "String")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+		query := &dtos.SearchTasksQuery{
+			Query:  stringArg(args, "query"),
+			Limit:  intArg(args, "first"),
+			Cursor: stringArg(args, "after"),
+		}
+
+		if err := query.Validate(); err != nil {
+			return nil, badInput(err)
+		}
+
+		page, err := taskUseCase.SearchTasks(r.ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range page.Results {
+			r.loader.prime(result.Task)
+		}
+		return page, nil
+	})
+	searchField.listArg, searchField.listSize = "first", dtos.DefaultTasksLimit
+
+	query := &objectType{name: "Query", fields: []*fieldDef{
+		rootField("task", "Task", []*inputValue{arg("id", "ID!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			task, err := taskUseCase.GetTask(r.ctx, id)
+			if err != nil {
+				return nil, err
+			}
+
+			r.loader.prime(task)
+			return task, nil
+		}),
+		tasksField,
+		searchField,
+	}}
+
+	mutation := &objectType{name: "Mutation", fields: []*fieldDef{
+		rootField("createTask", "Task", []*inputValue{arg("input", "CreateTaskInput!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			cmd, err := createTaskCommand(objectArg(args, "input"))
+			if err != nil {
+				return nil, err
+			}
+
+			return returnTask(taskUseCase.CreateTask(r.ctx, cmd))
+		}),
+		rootField("createSubtask", "Task", []*inputValue{arg("parentId", "ID!"), arg("input", "CreateTaskInput!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			parentId := stringArg(args, "parentId")
+			if err := checkId(parentId); err != nil {
+				return nil, err
+			}
+
+			cmd, err := createTaskCommand(objectArg(args, "input"))
+			if err != nil {
+				return nil, err
+			}
+
+			return returnTask(taskUseCase.CreateSubtask(r.ctx, parentId, cmd))
+		}),
+		rootField("updateTask", "Task", []*inputValue{arg("id", "ID!"), arg("input", "UpdateTaskInput!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			input := objectArg(args, "input")
+			cmd := &dtos.UpdateTaskCommand{
+				Title:       stringArg(input, "title"),
+				Description: stringArg(input, "description"),
+				DueDate:     stringArg(input, "dueDate"),
+				AllDay:      boolArg(input, "allDay"),
+				Priority:    stringArg(input, "priority"),
+				Version:     versionArg(input, "version"),
+			}
+
+			// A null recurrence stops the series like an empty one.
+			if _, ok := input["recurrence"]; ok {
+				recurrence := stringArg(input, "recurrence")
+				cmd.Recurrence = &recurrence
+			}
+
+			if err := cmd.Validate(); err != nil {
+				return nil, badInput(err)
+			}
+
+			return returnTask(taskUseCase.UpdateTask(r.ctx, id, cmd))
+		}),
+		rootField("deleteTask", "Boolean", []*inputValue{arg("id", "ID!"), arg("version", "Int!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			if err := taskUseCase.DeleteTask(r.ctx, id, *versionArg(args, "version")); err != nil {
+				return nil, err
+			}
+
+			return true, nil
+		}),
+		rootField("setTaskCompleted", "Task", []*inputValue{arg("id", "ID!"), arg("completed", "Boolean!"), arg("version", "Int!"), arg("force", "Boolean")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			cmd := &dtos.ChangeTaskCompletionStatusCommand{
+				Completed: boolArg(args, "completed"),
+				Version:   versionArg(args, "version"),
+			}
+			if force := boolArg(args, "force"); force != nil {
+				cmd.Force = *force
+			}
+
+			if err := cmd.Validate(); err != nil {
+				return nil, badInput(err)
+			}
+
+			return returnTask(taskUseCase.ChangeTaskCompletionStatus(r.ctx, id, cmd))
+		}),
+		rootField("moveTask", "Task", []*inputValue{arg("id", "ID!"), arg("projectId", "ID"), arg("version", "Int!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			cmd := &dtos.MoveTaskCommand{Version: versionArg(args, "version")}
+			if projectId := stringArg(args, "projectId"); projectId != "" {
+				if err := checkId(projectId); err != nil {
+					return nil, err
+				}
+				cmd.ProjectId = &projectId
+			}
+
+			if err := cmd.Validate(); err != nil {
+				return nil, badInput(err)
+			}
+
+			return returnTask(taskUseCase.MoveTask(r.ctx, id, cmd))
+		}),
+		rootField("addTaskTag", "Task", []*inputValue{arg("id", "ID!"), arg("name", "String!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			cmd := &dtos.TagCommand{Name: stringArg(args, "name")}
+			if err := cmd.Validate(); err != nil {
+				return nil, badInput(err)
+			}
+
+			return returnTask(taskUseCase.AddTaskTag(r.ctx, id, cmd))
+		}),
+		rootField("removeTaskTag", "Task", []*inputValue{arg("id", "ID!"), arg("name", "String!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id := stringArg(args, "id")
+			if err := checkId(id); err != nil {
+				return nil, err
+			}
+
+			return returnTask(taskUseCase.RemoveTaskTag(r.ctx, id, stringArg(args, "name")))
+		}),
+		rootField("addTaskBlocker", "Task", []*inputValue{arg("id", "ID!"), arg("blockerId", "ID!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id, blockerId := stringArg(args, "id"), stringArg(args, "blockerId")
+			for _, id := range []string{id, blockerId} {
+				if err := checkId(id); err != nil {
+					return nil, err
+				}
+			}
+
+			return returnTask(taskUseCase.AddTaskBlocker(r.ctx, id, &dtos.BlockerCommand{BlockerId: blockerId}))
+		}),
+		rootField("removeTaskBlocker", "Task", []*inputValue{arg("id", "ID!"), arg("blockerId", "ID!")}, func(r *request, args map[string]interface{}) (interface{}, error) {
+			r.loader.reset()
+
+			id, blockerId := stringArg(args, "id"), stringArg(args, "blockerId")
+			for _, id := range []string{id, blockerId} {
+				if err := checkId(id); err != nil {
+					return nil, err
+				}
+			}
+
+			return returnTask(taskUseCase.RemoveTaskBlocker(r.ctx, id, blockerId))
+		}),
+	}}
+
+	return newSchema(enums, inputs, []*objectType{query, mutation, task, project, taskConnection, searchConnection, searchResult})
+}
@@ -0,0 +1,370 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// typeRef is a type as written in the schema or a variable definition, e.g.
+// [Task!]! is a non-null list with elem Task!.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// named returns the name of the type without list and non-null wrappers.
+func (t *typeRef) named() string {
+	if t.elem != nil {
+		return t.elem.named()
+	}
+	return t.name
+}
+
+func mustType(s string) *typeRef {
+	p := &parser{lexer: &lexer{src: s, line: 1}}
+
+	t, err := func() (*typeRef, error) {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return p.typeRef()
+	}()
+	if err != nil || p.tok.kind != tokenEOF {
+		panic(fmt.Sprintf("graphql: invalid type %q", s))
+	}
+
+	return t
+}
+
+// resolveFunc resolves a field for a batch of sources at once, so that a
+// field of every task in a list costs one lookup. It returns one value per
+// source: nil, a scalar, a source of the object type or a []interface{} of
+// them for lists.
+type resolveFunc func(r *request, sources []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+type fieldDef struct {
+	name string
+	typ  *typeRef
+	args []*inputValue
+	// listArg is the argument that limits the length of the returned list
+	// and listSize the length assumed when it is not given; the complexity
+	// of the selection below the field is multiplied by it.
+	listArg  string
+	listSize int
+	resolve  resolveFunc
+}
+
+func (f *fieldDef) arg(name string) *inputValue {
+	for _, a := range f.args {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// inputValue is an argument of a field or a field of an input object.
+type inputValue struct {
+	name string
+	typ  *typeRef
+}
+
+type objectType struct {
+	name   string
+	fields []*fieldDef
+}
+
+func (o *objectType) field(name string) *fieldDef {
+	for _, f := range o.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type inputType struct {
+	name   string
+	fields []*inputValue
+}
+
+// enumType values are written in upper case in queries and are lower case
+// everywhere else, e.g. DUE_DATE is the due_date sort of dtos.
+type enumType struct {
+	name   string
+	values []string
+}
+
+func (e *enumType) parse(s string) (string, bool) {
+	for _, v := range e.values {
+		if v == s {
+			return strings.ToLower(s), true
+		}
+	}
+	return "", false
+}
+
+func (e *enumType) serialize(s string) (string, bool) {
+	name := strings.ToUpper(s)
+	for _, v := range e.values {
+		if v == name {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+var scalars = []string{"ID", "String", "Int", "Float", "Boolean"}
+
+type schema struct {
+	query    *objectType
+	mutation *objectType
+	objects  map[string]*objectType
+	inputs   map[string]*inputType
+	enums    map[string]*enumType
+	// order is the order of the named types in the printed schema.
+	order []string
+}
+
+func newSchema(enums []*enumType, inputs []*inputType, objects []*objectType) *schema {
+	s := &schema{
+		objects: map[string]*objectType{},
+		inputs:  map[string]*inputType{},
+		enums:   map[string]*enumType{},
+	}
+
+	for _, e := range enums {
+		s.enums[e.name] = e
+		s.order = append(s.order, e.name)
+	}
+	for _, i := range inputs {
+		s.inputs[i.name] = i
+		s.order = append(s.order, i.name)
+	}
+	for _, o := range objects {
+		s.objects[o.name] = o
+		s.order = append(s.order, o.name)
+	}
+
+	s.query = s.objects["Query"]
+	s.mutation = s.objects["Mutation"]
+
+	return s
+}
+
+func isScalar(name string) bool {
+	for _, s := range scalars {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isInputType reports whether variables may have the type.
+func (s *schema) isInputType(t *typeRef) bool {
+	name := t.named()
+	return isScalar(name) || s.enums[name] != nil || s.inputs[name] != nil
+}
+
+// print writes the schema in the GraphQL schema definition language.
+func (s *schema) print() string {
+	var b strings.Builder
+
+	for i, name := range s.order {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		switch {
+		case s.enums[name] != nil:
+			fmt.Fprintf(&b, "enum %s {\n", name)
+			for _, v := range s.enums[name].values {
+				fmt.Fprintf(&b, "  %s\n", v)
+			}
+		case s.inputs[name] != nil:
+			fmt.Fprintf(&b, "input %s {\n", name)
+			for _, f := range s.inputs[name].fields {
+				fmt.Fprintf(&b, "  %s: %s\n", f.name, f.typ)
+			}
+		default:
+			fmt.Fprintf(&b, "type %s {\n", name)
+			for _, f := range s.objects[name].fields {
+				b.WriteString("  " + f.name)
+				if len(f.args) > 0 {
+					args := make([]string, len(f.args))
+					for i, a := range f.args {
+						args[i] = a.name + ": " + a.typ.String()
+					}
+					b.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				fmt.Fprintf(&b, ": %s\n", f.typ)
+			}
+		}
+
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+// literalValue turns a literal of the query into the form of a JSON variable,
+// substituting variables. The second result is false for a variable that was
+// not provided, which counts as an absent value.
+func literalValue(v value, variables map[string]interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := variables[string(v)]
+		return value, ok
+	case []value:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			value, ok := literalValue(item, variables)
+			if !ok {
+				value = nil
+			}
+			list = append(list, value)
+		}
+		return list, true
+	case objectValue:
+		object := make(map[string]interface{}, len(v))
+		for _, f := range v {
+			if value, ok := literalValue(f.value, variables); ok {
+				object[f.name] = value
+			}
+		}
+		return object, true
+	default:
+		return v, true
+	}
+}
+
+// coerce checks an input value in the form of a JSON variable against the
+// type and converts it to the Go value resolvers get: string, int, float64,
+// bool, []interface{} or map[string]interface{} holding only the fields that
+// were given.
+func (s *schema) coerce(v interface{}, t *typeRef) (interface{}, error) {
+	if v == nil {
+		if t.nonNull {
+			return nil, fmt.Errorf("expected a non-null value of type %s", t)
+		}
+		return nil, nil
+	}
+
+	if t.elem != nil {
+		items, ok := v.([]interface{})
+		if !ok {
+			// A single value is accepted as a list of one.
+			items = []interface{}{v}
+		}
+
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			value, err := s.coerce(item, t.elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	}
+
+	if e := s.enums[t.name]; e != nil {
+		var name string
+		switch v := v.(type) {
+		case enumValue:
+			name = string(v)
+		case string:
+			name = v
+		}
+
+		value, ok := e.parse(name)
+		if !ok {
+			return nil, fmt.Errorf("expected a value of enum %s, got %v", t.name, v)
+		}
+		return value, nil
+	}
+
+	if input := s.inputs[t.name]; input != nil {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object of type %s", t.name)
+		}
+
+		for name := range object {
+			found := false
+			for _, f := range input.fields {
+				found = found || f.name == name
+			}
+			if !found {
+				return nil, fmt.Errorf("field %q is not defined by type %s", name, t.name)
+			}
+		}
+
+		coerced := make(map[string]interface{}, len(object))
+		for _, f := range input.fields {
+			value, ok := object[f.name]
+			if !ok {
+				if f.typ.nonNull {
+					return nil, fmt.Errorf("field %s.%s of type %s is required", t.name, f.name, f.typ)
+				}
+				continue
+			}
+
+			value, err := s.coerce(value, f.typ)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", t.name, f.name, err)
+			}
+			coerced[f.name] = value
+		}
+		return coerced, nil
+	}
+
+	switch t.name {
+	case "ID", "String":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		if n, ok := v.(int64); ok && t.name == "ID" {
+			return strconv.FormatInt(n, 10), nil
+		}
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "Int":
+		switch n := v.(type) {
+		case int64:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		case float64:
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch n := v.(type) {
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected a value of type %s, got %v", t, v)
+}
@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+// TimeZoneKey overrides the time zone of the user for a single call.
+const TimeZoneKey = "x-time-zone"
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// authenticate puts the id and the time zone of the user the call carries a
+// bearer token of into the context, the same way rest.AuthMiddleware does.
+func authenticate(ctx context.Context, useCase usecase.AuthUseCase) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	token := ""
+	scheme, value, found := strings.Cut(firstValue(md, "authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(value)
+	}
+
+	user, err := useCase.Authenticate(ctx, token)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.Unauthorized) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return nil, toStatus(err)
+	}
+
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil || user.TimeZone == "" {
+		loc = time.UTC
+	}
+
+	if name := firstValue(md, TimeZoneKey); name != "" {
+		if err := dtos.ValidateTimeZone(name); err != nil {
+			return nil, invalidArgument(err)
+		}
+		loc, _ = time.LoadLocation(name)
+	}
+
+	ctx = auth.WithUserId(ctx, user.Id)
+	ctx = auth.WithTimeZone(ctx, loc)
+
+	return ctx, nil
+}
+
+// UnaryAuthInterceptor rejects calls without a valid bearer token.
+func UnaryAuthInterceptor(useCase usecase.AuthUseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, useCase)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream replaces the context of a stream with an authenticated one.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamAuthInterceptor rejects streams without a valid bearer token.
+func StreamAuthInterceptor(useCase usecase.AuthUseCase) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), useCase)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
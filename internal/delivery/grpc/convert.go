@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/delivery/grpc/pb"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+func toTask(task *models.Task) *pb.Task {
+	t := &pb.Task{
+		Id:          task.Id,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		AllDay:      task.AllDay,
+		Overdue:     task.Overdue,
+		Completed:   task.Completed,
+		Priority:    task.Priority,
+		Version:     task.Version,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		CompletedAt: task.CompletedAt,
+		ProjectId:   task.ProjectId,
+		ParentId:    task.ParentId,
+		Recurrence:  task.Recurrence,
+		SeriesId:    task.SeriesId,
+		Occurrence:  int32(task.Occurrence),
+		Tags:        task.Tags,
+		BlockedBy:   task.BlockedBy,
+		Blocked:     task.Blocked,
+		OwnerId:     task.OwnerId,
+	}
+
+	if task.Progress != nil {
+		progress := int32(*task.Progress)
+		t.Progress = &progress
+	}
+
+	return t
+}
+
+func toTasks(page *dtos.TaskPage) *pb.ListTasksResponse {
+	resp := &pb.ListTasksResponse{NextCursor: page.NextCursor}
+
+	for _, task := range page.Tasks {
+		resp.Tasks = append(resp.Tasks, toTask(task))
+	}
+
+	return resp
+}
+
+func toCreateTaskCommand(req *pb.CreateTaskRequest) *dtos.CreateTaskCommand {
+	return &dtos.CreateTaskCommand{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     req.GetDueDate(),
+		AllDay:      req.AllDay,
+		Priority:    req.GetPriority(),
+		ProjectId:   req.GetProjectId(),
+		Recurrence:  req.GetRecurrence(),
+	}
+}
+
+func toGetTasksQuery(req *pb.ListTasksRequest) *dtos.GetTasksQuery {
+	return &dtos.GetTasksQuery{
+		Completed:  req.Completed,
+		Overdue:    req.Overdue,
+		DueFrom:    req.GetDueFrom(),
+		DueTo:      req.GetDueTo(),
+		Title:      req.GetTitle(),
+		Sort:       req.GetSort(),
+		Order:      req.GetOrder(),
+		Cursor:     req.GetCursor(),
+		Limit:      int(req.GetLimit()),
+		Tags:       req.GetTags(),
+		TagMode:    req.GetTagMode(),
+		Priorities: req.GetPriorities(),
+		SeriesId:   req.GetSeriesId(),
+	}
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	InvalidIdFormat  = errors.New("id must be on uuid format")
+	EventStreamEnded = errors.New("event stream ended, watch again with the last event id")
+)
+
+// invalidArgument reports a request the dtos rejected, with their message.
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// toStatus converts an error of the use case into the gRPC status matching
+// the HTTP status the REST handlers answer it with.
+func toStatus(err error) error {
+	code := codes.Internal
+
+	switch {
+	case errors.Is(err, internalErrors.TaskNotFound),
+		errors.Is(err, internalErrors.ProjectNotFound),
+		errors.Is(err, internalErrors.BlockerNotFound),
+		errors.Is(err, internalErrors.TagNotFound):
+		code = codes.NotFound
+	case errors.Is(err, internalErrors.InvalidCursor),
+		errors.Is(err, internalErrors.DueDateInPast),
+		errors.Is(err, internalErrors.SubtaskDepthExceeded):
+		code = codes.InvalidArgument
+	case errors.Is(err, internalErrors.VersionConflict):
+		code = codes.Aborted
+	case errors.Is(err, internalErrors.ProjectArchived),
+		errors.Is(err, internalErrors.TaskBlocked),
+		errors.Is(err, internalErrors.DependencyCycle),
+		errors.Is(err, internalErrors.ParentTaskCompleted):
+		code = codes.FailedPrecondition
+	case errors.Is(err, internalErrors.SearchUnavailable):
+		code = codes.Unimplemented
+	case errors.Is(err, internalErrors.Unauthorized):
+		code = codes.Unauthenticated
+	}
+
+	if code == codes.Internal {
+		logger.ErrorLogger.Printf("gRPC call failed: %v", err)
+		return status.Error(code, "internal error")
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,440 @@
+// Package grpc serves the task use case over gRPC, next to the REST API.
+package grpc
+
+//go:generate protoc -I proto --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative todo.proto
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/delivery/grpc/pb"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResetEvent tells the watcher that events were lost and it should reload
+// the tasks, the same as the reset event of the SSE stream.
+const ResetEvent = "reset"
+
+type Server struct {
+	pb.UnimplementedTaskServiceServer
+
+	useCase usecase.TaskUseCase
+	bus     *events.Bus
+}
+
+func NewServer(useCase usecase.TaskUseCase, bus *events.Bus) *Server {
+	return &Server{useCase: useCase, bus: bus}
+}
+
+// NewGRPCServer returns a gRPC server with the task service registered that
+// requires a bearer token in the authorization metadata of every call.
+func NewGRPCServer(authUseCase usecase.AuthUseCase, srv *Server) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryAuthInterceptor(authUseCase)),
+		grpc.StreamInterceptor(StreamAuthInterceptor(authUseCase)),
+	)
+	pb.RegisterTaskServiceServer(server, srv)
+
+	return server
+}
+
+func checkId(id string) error {
+	if !helper.IsValidUUID(id) {
+		return invalidArgument(InvalidIdFormat)
+	}
+
+	return nil
+}
+
+func (s *Server) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	cmd := toCreateTaskCommand(req)
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if cmd.ProjectId != "" {
+		if err := checkId(cmd.ProjectId); err != nil {
+			return nil, err
+		}
+	}
+
+	task, err := s.useCase.CreateTask(ctx, cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) CreateSubtask(ctx context.Context, req *pb.CreateSubtaskRequest) (*pb.Task, error) {
+	if err := checkId(req.GetParentId()); err != nil {
+		return nil, err
+	}
+
+	cmd := toCreateTaskCommand(req.GetTask())
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if cmd.ProjectId != "" {
+		if err := checkId(cmd.ProjectId); err != nil {
+			return nil, err
+		}
+	}
+
+	task, err := s.useCase.CreateSubtask(ctx, req.GetParentId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	task, err := s.useCase.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func validateGetTasksQuery(req *pb.ListTasksRequest) (*dtos.GetTasksQuery, error) {
+	query := toGetTasksQuery(req)
+
+	err := query.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if query.SeriesId != "" {
+		if err := checkId(query.SeriesId); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+func (s *Server) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	query, err := validateGetTasksQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.useCase.GetTasks(ctx, query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTasks(page), nil
+}
+
+func (s *Server) ListSubtasks(ctx context.Context, req *pb.ListSubtasksRequest) (*pb.ListTasksResponse, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	query, err := validateGetTasksQuery(req.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.useCase.GetSubtasks(ctx, req.GetId(), query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTasks(page), nil
+}
+
+func (s *Server) SearchTasks(ctx context.Context, req *pb.SearchTasksRequest) (*pb.SearchTasksResponse, error) {
+	query := &dtos.SearchTasksQuery{
+		Query:  req.GetQuery(),
+		Cursor: req.GetCursor(),
+		Limit:  int(req.GetLimit()),
+	}
+
+	err := query.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	page, err := s.useCase.SearchTasks(ctx, query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.SearchTasksResponse{NextCursor: page.NextCursor}
+	for _, result := range page.Results {
+		resp.Results = append(resp.Results, &pb.SearchResult{
+			Task:           toTask(result.Task),
+			Rank:           result.Rank,
+			TitleHighlight: result.TitleHighlight,
+			Snippet:        result.Snippet,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *Server) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	cmd := &dtos.UpdateTaskCommand{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     req.GetDueDate(),
+		AllDay:      req.AllDay,
+		Priority:    req.GetPriority(),
+		Recurrence:  req.Recurrence,
+		Version:     req.Version,
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	task, err := s.useCase.UpdateTask(ctx, req.GetId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if req.Version == nil {
+		return nil, invalidArgument(dtos.VersionIsRequired)
+	}
+
+	err := s.useCase.DeleteTask(ctx, req.GetId(), req.GetVersion())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.DeleteTaskResponse{}, nil
+}
+
+func (s *Server) SetTaskCompleted(ctx context.Context, req *pb.SetTaskCompletedRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	cmd := &dtos.ChangeTaskCompletionStatusCommand{
+		Completed: req.Completed,
+		Version:   req.Version,
+		Force:     req.GetForce(),
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	task, err := s.useCase.ChangeTaskCompletionStatus(ctx, req.GetId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) MoveTask(ctx context.Context, req *pb.MoveTaskRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	cmd := &dtos.MoveTaskCommand{
+		ProjectId: req.ProjectId,
+		Version:   req.Version,
+	}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if cmd.ProjectId != nil && *cmd.ProjectId != "" {
+		if err := checkId(*cmd.ProjectId); err != nil {
+			return nil, err
+		}
+	}
+
+	task, err := s.useCase.MoveTask(ctx, req.GetId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) AddTaskTag(ctx context.Context, req *pb.AddTaskTagRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	cmd := &dtos.TagCommand{Name: req.GetName()}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	task, err := s.useCase.AddTaskTag(ctx, req.GetId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) RemoveTaskTag(ctx context.Context, req *pb.RemoveTaskTagRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	task, err := s.useCase.RemoveTaskTag(ctx, req.GetId(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) AddTaskBlocker(ctx context.Context, req *pb.AddTaskBlockerRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	cmd := &dtos.BlockerCommand{BlockerId: req.GetBlockerId()}
+
+	err := cmd.Validate()
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if err := checkId(cmd.BlockerId); err != nil {
+		return nil, err
+	}
+
+	task, err := s.useCase.AddTaskBlocker(ctx, req.GetId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func (s *Server) RemoveTaskBlocker(ctx context.Context, req *pb.RemoveTaskBlockerRequest) (*pb.Task, error) {
+	if err := checkId(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := checkId(req.GetBlockerId()); err != nil {
+		return nil, err
+	}
+
+	task, err := s.useCase.RemoveTaskBlocker(ctx, req.GetId(), req.GetBlockerId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toTask(task), nil
+}
+
+func toTaskEvent(message events.Message) *pb.TaskEvent {
+	return &pb.TaskEvent{
+		Id:         message.Id,
+		Type:       message.Event.Type,
+		EventId:    message.Event.Id,
+		OccurredAt: message.Event.OccurredAt,
+		Task:       toTask(message.Event.Task),
+	}
+}
+
+// WatchTasks streams the events of the tasks of the user that pass the
+// filter. A watcher that passes the id of the last event it received gets
+// the events it missed first, or a reset event if they are no longer kept.
+func (s *Server) WatchTasks(req *pb.WatchTasksRequest, stream pb.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+
+	userId, _ := auth.UserId(ctx)
+
+	filter := &dtos.SubscribeCommand{
+		ProjectId:  req.GetProjectId(),
+		Tags:       req.GetTags(),
+		TagMode:    req.GetTagMode(),
+		Priorities: req.GetPriorities(),
+		Completed:  req.Completed,
+	}
+
+	err := filter.Validate()
+	if err != nil {
+		return invalidArgument(err)
+	}
+
+	if filter.ProjectId != "" {
+		if err := checkId(filter.ProjectId); err != nil {
+			return err
+		}
+	}
+
+	sub := s.bus.Subscribe(userId, req.GetLastEventId())
+	defer sub.Close()
+
+	if sub.Lost {
+		if err := stream.Send(&pb.TaskEvent{Id: sub.LastId, Type: ResetEvent}); err != nil {
+			return err
+		}
+	}
+
+	send := func(message events.Message) error {
+		if !filter.Matches(message.Event.Task) {
+			return nil
+		}
+
+		return stream.Send(toTaskEvent(message))
+	}
+
+	for _, message := range sub.Missed {
+		if err := send(message); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case message, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, EventStreamEnded.Error())
+			}
+
+			if err := send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package grpc
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/delivery/grpc/pb"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase/auth_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+const (
+	testToken  = "secret-token"
+	testUserId = "7b0e4d4e-7c5a-4a43-9c1c-3f0c2b5f0a11"
+	testTaskId = "a495465c-d177-48e1-8954-516bba76d541"
+)
+
+// newTestClient serves srv over an in-memory connection and returns a client
+// whose calls carry the test token.
+func newTestClient(t *testing.T, useCase *task_usecase.MockTaskUseCase, bus *events.Bus) pb.TaskServiceClient {
+	t.Helper()
+
+	authUseCase := &auth_usecase.MockAuthUseCase{
+		AuthenticateFunc: func(ctx context.Context, token string) (*models.User, error) {
+			if token != testToken {
+				return nil, internalErrors.Unauthorized
+			}
+			return &models.User{Id: testUserId, TimeZone: "Europe/Minsk"}, nil
+		},
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(authUseCase, NewServer(useCase, bus))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewTaskServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestAuth(t *testing.T) {
+	var gotUserId, gotZone string
+
+	useCase := &task_usecase.MockTaskUseCase{
+		GetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			gotUserId, _ = auth.UserId(ctx)
+			gotZone = auth.TimeZone(ctx).String()
+			return &models.Task{Id: id}, nil
+		},
+	}
+
+	client := newTestClient(t, useCase, events.NewBus(10, 10))
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		expectedCode codes.Code
+		expectedZone string
+	}{
+		{
+			name:         "no token",
+			ctx:          context.Background(),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "wrong token",
+			ctx:          withToken("wrong"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "user time zone",
+			ctx:          withToken(testToken),
+			expectedCode: codes.OK,
+			expectedZone: "Europe/Minsk",
+		},
+		{
+			name:         "time zone override",
+			ctx:          metadata.AppendToOutgoingContext(withToken(testToken), TimeZoneKey, "Asia/Tokyo"),
+			expectedCode: codes.OK,
+			expectedZone: "Asia/Tokyo",
+		},
+		{
+			name:         "unknown time zone",
+			ctx:          metadata.AppendToOutgoingContext(withToken(testToken), TimeZoneKey, "Mars/Olympus"),
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserId, gotZone = "", ""
+
+			_, err := client.GetTask(tt.ctx, &pb.GetTaskRequest{Id: testTaskId})
+			if status.Code(err) != tt.expectedCode {
+				t.Fatalf("expected code %v, got %v", tt.expectedCode, err)
+			}
+
+			if tt.expectedCode != codes.OK {
+				return
+			}
+
+			if gotUserId != testUserId {
+				t.Errorf("expected user %s, got %s", testUserId, gotUserId)
+			}
+
+			if gotZone != tt.expectedZone {
+				t.Errorf("expected time zone %s, got %s", tt.expectedZone, gotZone)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	version := int64(1)
+
+	tests := []struct {
+		name            string
+		call            func(client pb.TaskServiceClient) error
+		useCase         *task_usecase.MockTaskUseCase
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{
+			name: "invalid id",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.GetTask(withToken(testToken), &pb.GetTaskRequest{Id: "1"})
+				return err
+			},
+			useCase:         &task_usecase.MockTaskUseCase{},
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: InvalidIdFormat.Error(),
+		},
+		{
+			name: "task not found",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.GetTask(withToken(testToken), &pb.GetTaskRequest{Id: testTaskId})
+				return err
+			},
+			useCase: &task_usecase.MockTaskUseCase{
+				GetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return nil, internalErrors.TaskNotFound
+				},
+			},
+			expectedCode:    codes.NotFound,
+			expectedMessage: internalErrors.TaskNotFound.Error(),
+		},
+		{
+			name: "validation error",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.CreateTask(withToken(testToken), &pb.CreateTaskRequest{Description: "no title"})
+				return err
+			},
+			useCase:         &task_usecase.MockTaskUseCase{},
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: dtos.TitleIsRequired.Error(),
+		},
+		{
+			name: "version required",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.DeleteTask(withToken(testToken), &pb.DeleteTaskRequest{Id: testTaskId})
+				return err
+			},
+			useCase:         &task_usecase.MockTaskUseCase{},
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: dtos.VersionIsRequired.Error(),
+		},
+		{
+			name: "version conflict",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.UpdateTask(withToken(testToken), &pb.UpdateTaskRequest{Id: testTaskId, Title: "New", Version: &version})
+				return err
+			},
+			useCase: &task_usecase.MockTaskUseCase{
+				UpdateTaskFunc: func(ctx context.Context, id string, cmd *dtos.UpdateTaskCommand) (*models.Task, error) {
+					return nil, internalErrors.VersionConflict
+				},
+			},
+			expectedCode:    codes.Aborted,
+			expectedMessage: internalErrors.VersionConflict.Error(),
+		},
+		{
+			name: "task blocked",
+			call: func(client pb.TaskServiceClient) error {
+				completed := true
+				_, err := client.SetTaskCompleted(withToken(testToken), &pb.SetTaskCompletedRequest{Id: testTaskId, Completed: &completed, Version: &version})
+				return err
+			},
+			useCase: &task_usecase.MockTaskUseCase{
+				ChangeTaskCompletionStatusFunc: func(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error) {
+					return nil, internalErrors.TaskBlocked
+				},
+			},
+			expectedCode:    codes.FailedPrecondition,
+			expectedMessage: internalErrors.TaskBlocked.Error(),
+		},
+		{
+			name: "internal error is hidden",
+			call: func(client pb.TaskServiceClient) error {
+				_, err := client.ListTasks(withToken(testToken), &pb.ListTasksRequest{})
+				return err
+			},
+			useCase: &task_usecase.MockTaskUseCase{
+				GetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+					return nil, context.DeadlineExceeded
+				},
+			},
+			expectedCode:    codes.Internal,
+			expectedMessage: "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.useCase, events.NewBus(10, 10))
+
+			st, _ := status.FromError(tt.call(client))
+			if st.Code() != tt.expectedCode {
+				t.Errorf("expected code %v, got %v", tt.expectedCode, st.Code())
+			}
+
+			if st.Message() != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, st.Message())
+			}
+		})
+	}
+}
+
+func TestCreateTask(t *testing.T) {
+	allDay := true
+
+	useCase := &task_usecase.MockTaskUseCase{
+		CreateTaskFunc: func(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+			if cmd.AllDay == nil || !*cmd.AllDay {
+				t.Errorf("expected all_day to be passed")
+			}
+			progress := 50
+			return &models.Task{Id: testTaskId, Title: cmd.Title, DueDate: cmd.DueDate, Priority: cmd.Priority, Progress: &progress, Version: 1}, nil
+		},
+	}
+
+	client := newTestClient(t, useCase, events.NewBus(10, 10))
+
+	task, err := client.CreateTask(withToken(testToken), &pb.CreateTaskRequest{Title: "Task", DueDate: "2030-01-01", AllDay: &allDay, Priority: "high"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if task.Id != testTaskId || task.Title != "Task" || task.Priority != "high" || task.GetProgress() != 50 || task.Version != 1 {
+		t.Errorf("unexpected task: %v", task)
+	}
+}
+
+func TestWatchTasks(t *testing.T) {
+	bus := events.NewBus(10, 10)
+	client := newTestClient(t, &task_usecase.MockTaskUseCase{}, bus)
+
+	publish := func(id, priority string) {
+		bus.Publish(context.Background(), &events.Event{
+			Id:      id,
+			Type:    events.TaskUpdated,
+			OwnerId: testUserId,
+			Task:    &models.Task{Id: testTaskId, Priority: priority},
+		})
+	}
+
+	sub := bus.Subscribe(testUserId, "")
+	publish("first", "low")
+	first := <-sub.C
+	sub.Close()
+
+	publish("second", "low")
+	publish("third", "high")
+
+	stream, err := client.WatchTasks(withToken(testToken), &pb.WatchTasksRequest{LastEventId: first.Id, Priorities: []string{"high"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.EventId != "third" || event.Type != events.TaskUpdated || event.Task.Priority != "high" {
+		t.Errorf("expected the missed high priority event, got %v", event)
+	}
+
+	bus.Close()
+
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected %v when the bus closes, got %v", codes.Unavailable, err)
+	}
+}
+
+func TestWatchTasksLost(t *testing.T) {
+	client := newTestClient(t, &task_usecase.MockTaskUseCase{}, events.NewBus(10, 10))
+
+	stream, err := client.WatchTasks(withToken(testToken), &pb.WatchTasksRequest{LastEventId: "0-5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.Type != ResetEvent {
+		t.Errorf("expected a %s event, got %v", ResetEvent, event)
+	}
+}
+
+func TestWatchTasksInvalidFilter(t *testing.T) {
+	client := newTestClient(t, &task_usecase.MockTaskUseCase{}, events.NewBus(10, 10))
+
+	stream, err := client.WatchTasks(withToken(testToken), &pb.WatchTasksRequest{TagMode: "some"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = stream.Recv()
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected %v, got %v", codes.InvalidArgument, err)
+	}
+}
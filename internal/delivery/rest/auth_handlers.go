@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+type AuthHandlers struct {
+	useCase usecase.AuthUseCase
+}
+
+func NewAuthHandlers(useCase usecase.AuthUseCase) *AuthHandlers {
+	return &AuthHandlers{useCase}
+}
+
+func (h *AuthHandlers) Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmd := dtos.RegisterCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.UsernameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.useCase.Register(ctx, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.UserAlreadyExists) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, user, http.StatusCreated)
+}
+
+func (h *AuthHandlers) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userId, _ := auth.UserId(ctx)
+
+	cmd := dtos.UpdateUserCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.NoParamsToUpdate, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.useCase.UpdateUser(ctx, userId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.UserNotFound) {
+			WriteErrToResponseBody(w, internalErrors.Unauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, user, http.StatusOK)
+}
+
+func (h *AuthHandlers) Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmd := dtos.LoginCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.UsernameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.useCase.Login(ctx, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.InvalidCredentials) {
+			WriteErrToResponseBody(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, token, http.StatusOK)
+}
+
+func (h *AuthHandlers) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.useCase.Logout(ctx, bearerToken(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandlers) Me(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userId, _ := auth.UserId(ctx)
+
+	user, err := h.useCase.GetUser(ctx, userId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.UserNotFound) {
+			WriteErrToResponseBody(w, internalErrors.Unauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, user, http.StatusOK)
+}
@@ -8,4 +8,17 @@ var (
 	NoParamsToUpdate                 = errors.New("at least 1 parameter must be set to update")
 	NoParamsToCreate                 = errors.New("at least 1 parameter(title) must be set to create")
 	NoParamsToChangeCompletionStatus = errors.New("completion status is required")
+	InvalidCompletedParam            = errors.New("completed must be true or false")
+	InvalidOverdueParam              = errors.New("overdue must be true or false")
+	InvalidLimitParam                = errors.New("limit must be an integer")
+	VersionParamIsRequired           = errors.New("version query parameter is required")
+	InvalidVersionParam              = errors.New("version must be an integer")
+	InvalidArchivedParam             = errors.New("archived must be true or false")
+	InvalidCascadeParam              = errors.New("cascade must be true or false")
+	NoParamsToArchive                = errors.New("archived is required")
+	PreconditionFailed               = errors.New("task has been modified, If-Match precondition failed")
+	UnknownMessageType               = errors.New("type must be any of: subscribe, unsubscribe, create, update, complete")
+	NotValidMessage                  = errors.New("message must be a JSON object")
+	SubscriptionNotFound             = errors.New("subscription not found")
+	TooManySubscriptions             = errors.New("too many subscriptions on this connection")
 )
@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// heartbeatInterval keeps idle streams from being closed by proxies.
+	heartbeatInterval = 15 * time.Second
+	// reconnectDelay is how long browsers wait before reconnecting, in ms.
+	reconnectDelay = 3000
+
+	// ResetEvent tells the client that events were lost and it should reload
+	// the tasks it shows.
+	ResetEvent = "reset"
+)
+
+type EventHandlers struct {
+	bus *events.Bus
+}
+
+func NewEventHandlers(bus *events.Bus) *EventHandlers {
+	return &EventHandlers{bus}
+}
+
+func writeEvent(w io.Writer, id, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, eventType, body)
+	return err
+}
+
+// StreamTaskEvents streams the task events of the user as Server-Sent Events.
+// A client that reconnects with Last-Event-ID first gets the events it
+// missed, or a reset event if they are no longer kept.
+func (h *EventHandlers) StreamTaskEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		WriteErrToResponseBody(w, internalErrors.Unauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.ErrorLogger.Printf("Streaming is not supported by %T", w)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	lastId := r.Header.Get("Last-Event-ID")
+	if lastId == "" {
+		lastId = r.URL.Query().Get("last_event_id")
+	}
+
+	sub := h.bus.Subscribe(userId, lastId)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay); err != nil {
+		return
+	}
+
+	if sub.Lost {
+		if err := writeEvent(w, sub.LastId, ResetEvent, struct{}{}); err != nil {
+			return
+		}
+	}
+
+	for _, message := range sub.Missed {
+		if err := writeEvent(w, message.Id, message.Event.Type, message.Event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case message, ok := <-sub.C:
+			if !ok {
+				// Dropped or shutting down, the client reconnects and resumes.
+				return
+			}
+			if err := writeEvent(w, message.Id, message.Event.Type, message.Event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -1,9 +1,11 @@
 package rest
 
 import (
+	"context"
 	"errors"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
 	"github.com/DanKo-code/TODO-list/internal/usecase"
 	"net/http"
 )
@@ -42,30 +44,187 @@ func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if cmd.ProjectId != "" && !isValidUUID(cmd.ProjectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
 	task, err := h.useCase.CreateTask(ctx, &cmd)
 	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.DueDateInPast) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, internalErrors.ProjectArchived) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	WriteToResponseBody(w, task)
+	WriteToResponseBody(w, task, http.StatusOK)
 }
 
 func (h *Handlers) GetTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	tasks, err := h.useCase.GetTasks(ctx)
+	query, err := ReadGetTasksQuery(r)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = query.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if query.SeriesId != "" && !isValidUUID(query.SeriesId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetTasks(ctx, query)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.InvalidCursor) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if page.Tasks == nil {
+		page.Tasks = []*models.Task{}
+	}
+
+	WriteToResponseBody(w, page, http.StatusOK)
+}
+
+func (h *Handlers) GetTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.GetTask(ctx, taskId)
 	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	if tasks == nil {
-		WriteToResponseBody(w, []struct{}{})
+	etag := TaskETag(task)
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+// expectedVersion returns the version a write expects the task to have: the
+// one the request carries, or else the one of the task its If-Match header
+// matches. The header is compared with the tag of the current representation
+// of the task, and the repository compares the version with the stored one in
+// the same statement that writes, so the write applies only to the state the
+// precondition was evaluated on. It writes the error response itself and
+// reports whether the request may proceed.
+func (h *Handlers) expectedVersion(w http.ResponseWriter, r *http.Request, taskId string, version *int64) (*int64, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return version, true
+	}
+
+	task, err := h.useCase.GetTask(r.Context(), taskId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+			return nil, false
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !etagMatches(ifMatch, TaskETag(task), false) || (version != nil && *version != task.Version) {
+		WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+		return nil, false
+	}
+
+	return &task.Version, true
+}
+
+// preconditionFailed reports whether a write failed because the task no
+// longer matches the If-Match header of the request.
+func preconditionFailed(r *http.Request, err error) bool {
+	if r.Header.Get("If-Match") == "" {
+		return false
+	}
+
+	return errors.Is(err, internalErrors.VersionConflict) || errors.Is(err, internalErrors.TaskNotFound)
+}
+
+func (h *Handlers) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query, err := ReadSearchTasksQuery(r)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = query.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.SearchTasks(ctx, query)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.InvalidCursor) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, internalErrors.SearchUnavailable) {
+			WriteErrToResponseBody(w, err, http.StatusNotImplemented)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	WriteToResponseBody(w, tasks)
+	if page.Results == nil {
+		page.Results = []*dtos.SearchResult{}
+	}
+
+	WriteToResponseBody(w, page, http.StatusOK)
 }
 
 func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -90,6 +249,12 @@ func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, ok := h.expectedVersion(w, r, taskId, cmd.Version)
+	if !ok {
+		return
+	}
+	cmd.Version = version
+
 	err = cmd.Validate()
 	if err != nil {
 		WriteErrToResponseBody(w, err, http.StatusBadRequest)
@@ -99,16 +264,32 @@ func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	utask, err := h.useCase.UpdateTask(ctx, taskId, &cmd)
 	if err != nil {
 
+		if preconditionFailed(r, err) {
+			WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+			return
+		}
+
 		if errors.Is(err, internalErrors.TaskNotFound) {
 			WriteErrToResponseBody(w, err, http.StatusNotFound)
 			return
 		}
 
+		if errors.Is(err, internalErrors.DueDateInPast) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, internalErrors.VersionConflict) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	WriteToResponseBody(w, utask)
+	w.Header().Set("ETag", TaskETag(utask))
+	WriteToResponseBody(w, utask, http.StatusOK)
 }
 
 func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
@@ -120,13 +301,39 @@ func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.useCase.DeleteTask(ctx, taskId)
+	// The version may come from the If-Match header instead of the query.
+	var version *int64
+	if r.URL.Query().Get("version") != "" || r.Header.Get("If-Match") == "" {
+		v, err := ReadVersionQueryParam(r)
+		if err != nil {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+		version = &v
+	}
+
+	version, ok = h.expectedVersion(w, r, taskId, version)
+	if !ok {
+		return
+	}
+
+	err := h.useCase.DeleteTask(ctx, taskId, *version)
 	if err != nil {
+		if preconditionFailed(r, err) {
+			WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+			return
+		}
+
 		if errors.Is(err, internalErrors.TaskNotFound) {
 			WriteErrToResponseBody(w, err, http.StatusNotFound)
 			return
 		}
 
+		if errors.Is(err, internalErrors.VersionConflict) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -156,13 +363,143 @@ func (h *Handlers) ChangeTaskCompletionStatus(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	version, ok := h.expectedVersion(w, r, taskId, cmd.Version)
+	if !ok {
+		return
+	}
+	cmd.Version = version
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	updatedTask, err := h.useCase.ChangeTaskCompletionStatus(ctx, taskId, &cmd)
+	if err != nil {
+
+		if preconditionFailed(r, err) {
+			WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+			return
+		}
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.VersionConflict) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		if errors.Is(err, internalErrors.TaskBlocked) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", TaskETag(updatedTask))
+	WriteToResponseBody(w, updatedTask, http.StatusOK)
+}
+
+func (h *Handlers) MoveTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.MoveTaskCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.VersionIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.expectedVersion(w, r, taskId, cmd.Version)
+	if !ok {
+		return
+	}
+	cmd.Version = version
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if cmd.ProjectId != nil && *cmd.ProjectId != "" && !isValidUUID(*cmd.ProjectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	movedTask, err := h.useCase.MoveTask(ctx, taskId, &cmd)
+	if err != nil {
+
+		if preconditionFailed(r, err) {
+			WriteErrToResponseBody(w, PreconditionFailed, http.StatusPreconditionFailed)
+			return
+		}
+
+		if errors.Is(err, internalErrors.TaskNotFound) || errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.VersionConflict) || errors.Is(err, internalErrors.ProjectArchived) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", TaskETag(movedTask))
+	WriteToResponseBody(w, movedTask, http.StatusOK)
+}
+
+func (h *Handlers) AddTaskTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.TagCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.TagNameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
 	err = cmd.Validate()
 	if err != nil {
 		WriteErrToResponseBody(w, err, http.StatusBadRequest)
 		return
 	}
 
-	updatedTask, err := h.useCase.ChangeTaskCompletionStatus(ctx, taskId, *cmd.Completed)
+	task, err := h.useCase.AddTaskTag(ctx, taskId, &cmd)
 	if err != nil {
 
 		if errors.Is(err, internalErrors.TaskNotFound) {
@@ -174,5 +511,368 @@ func (h *Handlers) ChangeTaskCompletionStatus(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	WriteToResponseBody(w, updatedTask)
+	w.Header().Set("ETag", TaskETag(task))
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) RemoveTaskTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	name, _ := ctx.Value("name").(string)
+	if err := dtos.ValidateTagName(name); err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.RemoveTaskTag(ctx, taskId, name)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) || errors.Is(err, internalErrors.TagNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", TaskETag(task))
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) AddTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.BlockerCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.BlockerIdIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !isValidUUID(cmd.BlockerId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.AddTaskBlocker(ctx, taskId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) || errors.Is(err, internalErrors.BlockerNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.DependencyCycle) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", TaskETag(task))
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) RemoveTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	blockerId, ok := ctx.Value("blocker_id").(string)
+	if !ok || !isValidUUID(blockerId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.RemoveTaskBlocker(ctx, taskId, blockerId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", TaskETag(task))
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) CreateSubtask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	parentId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(parentId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.CreateTaskCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, NoParamsToCreate, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.CreateSubtask(ctx, parentId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.SubtaskDepthExceeded) || errors.Is(err, internalErrors.DueDateInPast) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, internalErrors.ParentTaskCompleted) || errors.Is(err, internalErrors.ProjectArchived) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) GetSubtasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	query, err := ReadGetTasksQuery(r)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = query.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetSubtasks(ctx, taskId, query)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.InvalidCursor) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if page.Tasks == nil {
+		page.Tasks = []*models.Task{}
+	}
+
+	WriteToResponseBody(w, page, http.StatusOK)
+}
+
+func (h *Handlers) GetTrash(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.useCase.GetTrash(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if tasks == nil {
+		tasks = []*models.Task{}
+	}
+
+	WriteToResponseBody(w, tasks, http.StatusOK)
+}
+
+func (h *Handlers) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.useCase.RestoreTask(ctx, taskId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.ParentTaskDeleted) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, task, http.StatusOK)
+}
+
+func (h *Handlers) PurgeTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	err := h.useCase.PurgeTask(ctx, taskId)
+	if err != nil {
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	query, err := ReadGetHistoryQuery(r)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = query.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetTaskHistory(ctx, taskId, query)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.InvalidCursor) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, page, http.StatusOK)
+}
+
+func (h *Handlers) Undo(w http.ResponseWriter, r *http.Request) {
+	h.revertChanges(w, r, h.useCase.Undo)
+}
+
+func (h *Handlers) Redo(w http.ResponseWriter, r *http.Request) {
+	h.revertChanges(w, r, h.useCase.Redo)
+}
+
+// revertChanges serves Undo and Redo; without a body one change is reverted.
+func (h *Handlers) revertChanges(w http.ResponseWriter, r *http.Request, revert func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)) {
+	cmd := dtos.UndoCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil && err.Error() != NoBody {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := revert(r.Context(), &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.NothingToUndo) || errors.Is(err, internalErrors.NothingToRedo) ||
+			errors.Is(err, internalErrors.UndoConflict) || errors.Is(err, internalErrors.VersionConflict) ||
+			errors.Is(err, internalErrors.ParentTaskDeleted) || errors.Is(err, internalErrors.ProjectArchived) ||
+			errors.Is(err, internalErrors.ProjectNotFound) || errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, result, http.StatusOK)
 }
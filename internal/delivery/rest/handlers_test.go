@@ -1,13 +1,25 @@
 package rest
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/auth"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository/memory"
+	"github.com/DanKo-code/TODO-list/internal/usecase/auth_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/project_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/reminder_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/tag_usecase"
 	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/webhook_usecase"
+	"github.com/gorilla/websocket"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -26,10 +38,10 @@ func TestCreateTaskHandler(t *testing.T) {
 			name:        "success",
 			requestBody: `{"title":"Test Task","description":"This is a test task"," due_date":"2024-11-22"}`,
 			mockCreateTaskFunc: func(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
-				return &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false}, nil
+				return &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false, Version: 1}, nil
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   `{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false}`,
+			expectedResponse:   `{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false,"version":1}`,
 		},
 		{
 			name:        "no body",
@@ -97,37 +109,86 @@ func TestCreateTaskHandler(t *testing.T) {
 func TestGetTasksHandler(t *testing.T) {
 	tests := []struct {
 		name               string
-		mockGetTasksFunc   func(ctx context.Context) ([]*models.Task, error)
+		url                string
+		mockGetTasksFunc   func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
 		expectedStatusCode int
 		expectedResponse   string
 	}{
 		{
 			name: "success",
-			mockGetTasksFunc: func(ctx context.Context) ([]*models.Task, error) {
-				return []*models.Task{
-					{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false},
+			url:  "/tasks",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return &dtos.TaskPage{
+					Tasks: []*models.Task{
+						{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false, Version: 1},
+					},
+					NextCursor: "next",
 				}, nil
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   fmt.Sprintf(`[{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false}]`),
+			expectedResponse:   fmt.Sprintf(`{"tasks":[{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false,"version":1}],"next_cursor":"next"}`),
 		},
 		{
-			name: "success",
-			mockGetTasksFunc: func(ctx context.Context) ([]*models.Task, error) {
-				return nil, nil
+			name: "empty",
+			url:  "/tasks",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return &dtos.TaskPage{}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   fmt.Sprintf(`{"tasks":[]}`),
+		},
+		{
+			name: "query params are passed to use case",
+			url:  "/tasks?completed=false&overdue=true&due_from=2024-11-01&due_to=2024-11-30&title=test&sort=due_date&order=desc&limit=10&cursor=abc",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				if query.Completed == nil || *query.Completed ||
+					query.Overdue == nil || !*query.Overdue ||
+					query.DueFrom != "2024-11-01" || query.DueTo != "2024-11-30" ||
+					query.Title != "test" || query.Sort != dtos.SortByDueDate || query.Order != dtos.OrderDesc ||
+					query.Limit != 10 || query.Cursor != "abc" {
+					return nil, fmt.Errorf("unexpected query: %+v", query)
+				}
+				return &dtos.TaskPage{}, nil
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   fmt.Sprintf(`[]`),
+			expectedResponse:   fmt.Sprintf(`{"tasks":[]}`),
+		},
+		{
+			name: "invalid completed param",
+			url:  "/tasks?completed=maybe",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return nil, nil
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, InvalidCompletedParam),
+		},
+		{
+			name: "invalid sort",
+			url:  "/tasks?sort=importance",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return nil, nil
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, dtos.NotValidSort),
+		},
+		{
+			name: "invalid cursor",
+			url:  "/tasks?cursor=abc",
+			mockGetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return nil, internalErrors.InvalidCursor
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.InvalidCursor),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUseCase := &task_usecase.MockTaskUseCase{
-				GetTaskFunc: tt.mockGetTasksFunc,
+				GetTasksFunc: tt.mockGetTasksFunc,
 			}
 			h := NewHandlers(mockUseCase)
-			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
 			w := httptest.NewRecorder()
 			h.GetTasks(w, req)
 			resp := w.Result()
@@ -147,11 +208,177 @@ func TestGetTasksHandler(t *testing.T) {
 	}
 }
 
+func TestGetTaskHandler(t *testing.T) {
+	task := &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false, Version: 1}
+
+	tests := []struct {
+		name               string
+		id                 string
+		ifNoneMatch        string
+		mockGetTaskFunc    func(ctx context.Context, id string) (*models.Task, error)
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			name: "success",
+			id:   "a495465c-d177-48e1-8954-516bba76d541",
+			mockGetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return task, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false,"version":1}`,
+		},
+		{
+			name:        "not modified",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			ifNoneMatch: `"other", ` + TaskETag(task),
+			mockGetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return task, nil
+			},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:        "stale etag",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			ifNoneMatch: `"other"`,
+			mockGetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return task, nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "task not found",
+			id:   "a495465c-d177-48e1-8954-516bba76d541",
+			mockGetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return nil, internalErrors.TaskNotFound
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.TaskNotFound),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &task_usecase.MockTaskUseCase{
+				GetTaskFunc: tt.mockGetTaskFunc,
+			}
+			h := NewHandlers(mockUseCase)
+
+			ctx := context.WithValue(context.Background(), "id", tt.id)
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/tasks/"+tt.id, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			h.GetTask(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if resp.StatusCode != http.StatusNotFound && resp.Header.Get("ETag") != TaskETag(task) {
+				t.Errorf("expected etag %s, got %s", TaskETag(task), resp.Header.Get("ETag"))
+			}
+			if tt.expectedResponse != "" {
+				var buf bytes.Buffer
+				buf.ReadFrom(resp.Body)
+
+				if strings.TrimSpace(buf.String()) != tt.expectedResponse {
+					t.Errorf("expected %s, got %s", tt.expectedResponse, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSearchTasksHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		url                 string
+		mockSearchTasksFunc func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error)
+		expectedStatusCode  int
+		expectedResponse    string
+	}{
+		{
+			name: "success",
+			url:  "/tasks/search?q=test&limit=5",
+			mockSearchTasksFunc: func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+				if query.Query != "test" || query.Limit != 5 {
+					return nil, fmt.Errorf("unexpected query: %+v", query)
+				}
+				return &dtos.SearchPage{Results: []*dtos.SearchResult{{
+					Task:           &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Version: 1},
+					Rank:           -1.5,
+					TitleHighlight: "<mark>Test</mark> Task",
+				}}}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"results":[{"task":{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"","due_date":"","overdue":false,"completed":false,"version":1},"rank":-1.5,"title_highlight":"\u003cmark\u003eTest\u003c/mark\u003e Task","snippet":""}]}`,
+		},
+		{
+			name: "no results",
+			url:  "/tasks/search?q=test",
+			mockSearchTasksFunc: func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+				return &dtos.SearchPage{}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"results":[]}`,
+		},
+		{
+			name: "query is required",
+			url:  "/tasks/search?q=%20",
+			mockSearchTasksFunc: func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+				return nil, nil
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, dtos.SearchQueryIsRequired),
+		},
+		{
+			name: "search unavailable",
+			url:  "/tasks/search?q=test",
+			mockSearchTasksFunc: func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+				return nil, internalErrors.SearchUnavailable
+			},
+			expectedStatusCode: http.StatusNotImplemented,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.SearchUnavailable),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &task_usecase.MockTaskUseCase{
+				SearchTasksFunc: tt.mockSearchTasksFunc,
+			}
+			router := NewRouter(NewHandlers(mockUseCase), NewAuthHandlers(&auth_usecase.MockAuthUseCase{}), NewProjectHandlers(&project_usecase.MockProjectUseCase{}), NewTagHandlers(&tag_usecase.MockTagUseCase{}), NewReminderHandlers(&reminder_usecase.MockReminderUseCase{}), NewWebhookHandlers(&webhook_usecase.MockWebhookUseCase{}), NewEventHandlers(events.NewBus(1, 1)), NewSocketHandlers(mockUseCase, events.NewBus(1, 1)), http.NotFoundHandler())
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if tt.expectedResponse != "" {
+				var buf bytes.Buffer
+				buf.ReadFrom(resp.Body)
+
+				if strings.TrimSpace(buf.String()) != tt.expectedResponse {
+					t.Errorf("expected %s, got %s", tt.expectedResponse, buf.String())
+				}
+			}
+		})
+	}
+}
+
 func TestUpdateTaskHandler(t *testing.T) {
+	currentTask := &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Version: 4}
+
 	tests := []struct {
 		name               string
 		id                 string
 		requestBody        string
+		ifMatch            string
 		mockUpdateTaskFunc func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error)
 		expectedStatusCode int
 		expectedResponse   string
@@ -159,7 +386,7 @@ func TestUpdateTaskHandler(t *testing.T) {
 		{
 			name:        "success",
 			id:          "a495465c-d177-48e1-8954-516bba76d541",
-			requestBody: `{"title":"Test Task","description":"This is a test task","due_date":"2024-11-28"}`,
+			requestBody: `{"title":"Test Task","description":"This is a test task","due_date":"2024-11-28","version":1}`,
 			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
 				return &models.Task{
 					Id:          "a495465c-d177-48e1-8954-516bba76d541",
@@ -168,15 +395,16 @@ func TestUpdateTaskHandler(t *testing.T) {
 					DueDate:     "2024-11-22",
 					Overdue:     false,
 					Completed:   false,
+					Version:     1,
 				}, nil
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   fmt.Sprintf(`{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false}`),
+			expectedResponse:   fmt.Sprintf(`{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":false,"version":1}`),
 		},
 		{
 			name:        "invalid id format",
 			id:          "1",
-			requestBody: `{"title":"Test Task","description":"This is a test task","due_date":"2024-11-28"}`,
+			requestBody: `{"title":"Test Task","description":"This is a test task","due_date":"2024-11-28","version":1}`,
 			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
 				return &models.Task{
 					Id:          "a495465c-d177-48e1-8954-516bba76d541",
@@ -185,16 +413,76 @@ func TestUpdateTaskHandler(t *testing.T) {
 					DueDate:     "2024-11-22",
 					Overdue:     false,
 					Completed:   false,
+					Version:     1,
 				}, nil
 			},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, InvalidIdFormat),
 		},
+		{
+			name:        "version is required",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			requestBody: `{"title":"Test Task"}`,
+			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
+				return nil, nil
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, dtos.VersionIsRequired),
+		},
+		{
+			name:        "version conflict",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			requestBody: `{"title":"Test Task","version":1}`,
+			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
+				return nil, internalErrors.VersionConflict
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.VersionConflict),
+		},
+		{
+			name:        "if-match supplies the version",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			requestBody: `{"title":"Test Task"}`,
+			ifMatch:     TaskETag(currentTask),
+			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
+				if *updateTaskCommand.Version != 4 {
+					t.Errorf("expected version 4, got %d", *updateTaskCommand.Version)
+				}
+				return &models.Task{Id: id, Title: "Test Task", Version: 5}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:        "if-match of a weak tag",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			requestBody: `{"title":"Test Task"}`,
+			ifMatch:     "W/" + TaskETag(currentTask),
+			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
+				t.Error("expected UpdateTask not to be called")
+				return nil, nil
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, PreconditionFailed),
+		},
+		{
+			name:        "if-match changed concurrently",
+			id:          "a495465c-d177-48e1-8954-516bba76d541",
+			requestBody: `{"title":"Test Task","version":4}`,
+			ifMatch:     TaskETag(currentTask),
+			mockUpdateTaskFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
+				return nil, internalErrors.VersionConflict
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, PreconditionFailed),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUseCase := &task_usecase.MockTaskUseCase{
+				GetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return currentTask, nil
+				},
 				UpdateTaskFunc: tt.mockUpdateTaskFunc,
 			}
 			h := NewHandlers(mockUseCase)
@@ -204,6 +492,9 @@ func TestUpdateTaskHandler(t *testing.T) {
 			ctx = context.WithValue(ctx, "id", tt.id)
 
 			req := httptest.NewRequestWithContext(ctx, http.MethodPut, "/tasks/a495465c-d177-48e1-8954-516bba76d541", strings.NewReader(tt.requestBody))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 			h.UpdateTask(w, req)
 			resp := w.Result()
@@ -224,33 +515,125 @@ func TestUpdateTaskHandler(t *testing.T) {
 }
 
 func TestDeleteTaskHandler(t *testing.T) {
+	currentTask := &models.Task{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Version: 3}
+
 	tests := []struct {
 		name               string
 		id                 string
-		mockDeleteTaskFunc func(ctx context.Context, id string) error
+		query              string
+		ifMatch            string
+		mockDeleteTaskFunc func(ctx context.Context, id string, version int64) error
 		expectedStatusCode int
 	}{
 		{
-			name: "success",
-			id:   "a495465c-d177-48e1-8954-516bba76d541",
-			mockDeleteTaskFunc: func(ctx context.Context, id string) error {
+			name:  "success",
+			id:    "a495465c-d177-48e1-8954-516bba76d541",
+			query: "?version=1",
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
 				return nil
 			},
 			expectedStatusCode: http.StatusOK,
 		},
 		{
-			name: "task not found",
+			name: "version param is required",
 			id:   "a495465c-d177-48e1-8954-516bba76d541",
-			mockDeleteTaskFunc: func(ctx context.Context, id string) error {
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				t.Error("expected DeleteTask not to be called")
+				return nil
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:  "task not found",
+			id:    "a495465c-d177-48e1-8954-516bba76d541",
+			query: "?version=1",
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
 				return internalErrors.TaskNotFound
 			},
 			expectedStatusCode: http.StatusNotFound,
 		},
+		{
+			name:  "version conflict",
+			id:    "a495465c-d177-48e1-8954-516bba76d541",
+			query: "?version=1",
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				return internalErrors.VersionConflict
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:    "if-match satisfied",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			query:   "?version=3",
+			ifMatch: TaskETag(currentTask),
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				return nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "if-match supplies the version",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			ifMatch: `"other", ` + TaskETag(currentTask),
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				if version != 3 {
+					t.Errorf("expected version 3, got %d", version)
+				}
+				return nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "if-match of any task",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			ifMatch: "*",
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				if version != 3 {
+					t.Errorf("expected version 3, got %d", version)
+				}
+				return nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "if-match failed",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			query:   "?version=1",
+			ifMatch: `"stale"`,
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				t.Error("expected DeleteTask not to be called")
+				return nil
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "if-match of another version",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			query:   "?version=1",
+			ifMatch: TaskETag(currentTask),
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				t.Error("expected DeleteTask not to be called")
+				return nil
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "if-match changed concurrently",
+			id:      "a495465c-d177-48e1-8954-516bba76d541",
+			ifMatch: TaskETag(currentTask),
+			mockDeleteTaskFunc: func(ctx context.Context, id string, version int64) error {
+				return internalErrors.VersionConflict
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUseCase := &task_usecase.MockTaskUseCase{
+				GetTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return currentTask, nil
+				},
 				DeleteTaskFunc: tt.mockDeleteTaskFunc,
 			}
 			h := NewHandlers(mockUseCase)
@@ -259,7 +642,10 @@ func TestDeleteTaskHandler(t *testing.T) {
 
 			ctx = context.WithValue(ctx, "id", tt.id)
 
-			req := httptest.NewRequestWithContext(ctx, http.MethodDelete, "/tasks/a495465c-d177-48e1-8954-516bba76d541", nil)
+			req := httptest.NewRequestWithContext(ctx, http.MethodDelete, "/tasks/a495465c-d177-48e1-8954-516bba76d541"+tt.query, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 			h.DeleteTask(w, req)
 			resp := w.Result()
@@ -276,15 +662,15 @@ func TestChangeTaskCompletionHandler(t *testing.T) {
 		name                         string
 		id                           string
 		requestBody                  string
-		mockChangeTaskCompletionFunc func(ctx context.Context, id string, completionStatus bool) (*models.Task, error)
+		mockChangeTaskCompletionFunc func(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error)
 		expectedStatusCode           int
 		expectedResponse             string
 	}{
 		{
 			name:        "success",
 			id:          "a495465c-d177-48e1-8954-516bba76d541",
-			requestBody: `{"completed":true}`,
-			mockChangeTaskCompletionFunc: func(ctx context.Context, id string, completionStatus bool) (*models.Task, error) {
+			requestBody: `{"completed":true,"version":1}`,
+			mockChangeTaskCompletionFunc: func(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error) {
 				return &models.Task{
 					Id:          "a495465c-d177-48e1-8954-516bba76d541",
 					Title:       "Test Task",
@@ -292,10 +678,11 @@ func TestChangeTaskCompletionHandler(t *testing.T) {
 					DueDate:     "2024-11-22",
 					Overdue:     false,
 					Completed:   true,
+					Version:     2,
 				}, nil
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedResponse:   fmt.Sprintf(`{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":true}`),
+			expectedResponse:   fmt.Sprintf(`{"id":"a495465c-d177-48e1-8954-516bba76d541","title":"Test Task","description":"This is a test task","due_date":"2024-11-22","overdue":false,"completed":true,"version":2}`),
 		},
 	}
 	for _, tt := range tests {
@@ -328,3 +715,758 @@ func TestChangeTaskCompletionHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoreTaskHandler(t *testing.T) {
+	tests := []struct {
+		name                string
+		id                  string
+		mockRestoreTaskFunc func(ctx context.Context, id string) (*models.Task, error)
+		expectedStatusCode  int
+	}{
+		{
+			name: "success",
+			id:   "a495465c-d177-48e1-8954-516bba76d541",
+			mockRestoreTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return &models.Task{Id: id, Title: "Test Task", Version: 3}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid id",
+			id:                 "invalid-id",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "task not in the trash",
+			id:   "a495465c-d177-48e1-8954-516bba76d541",
+			mockRestoreTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return nil, internalErrors.TaskNotFound
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name: "parent in the trash",
+			id:   "a495465c-d177-48e1-8954-516bba76d541",
+			mockRestoreTaskFunc: func(ctx context.Context, id string) (*models.Task, error) {
+				return nil, internalErrors.ParentTaskDeleted
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &task_usecase.MockTaskUseCase{
+				RestoreTaskFunc: tt.mockRestoreTaskFunc,
+			}
+			h := NewHandlers(mockUseCase)
+
+			ctx := context.WithValue(context.Background(), "id", tt.id)
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/tasks/"+tt.id+"/restore", nil)
+			w := httptest.NewRecorder()
+			h.RestoreTask(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestGetTaskHistoryHandler(t *testing.T) {
+	tests := []struct {
+		name                   string
+		url                    string
+		mockGetTaskHistoryFunc func(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
+		expectedStatusCode     int
+		expectedResponse       string
+	}{
+		{
+			name: "success",
+			url:  "/tasks/a495465c-d177-48e1-8954-516bba76d541/history?limit=1",
+			mockGetTaskHistoryFunc: func(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+				if query.Limit != 1 {
+					t.Errorf("expected limit 1, got %d", query.Limit)
+				}
+				return &dtos.HistoryPage{
+					Entries: []*models.HistoryEntry{{
+						Id:        "e1",
+						TaskId:    id,
+						Action:    models.HistoryUpdated,
+						ActorId:   "u1",
+						Changes:   []models.FieldChange{{Field: "title", Before: []byte(`"Draft"`), After: []byte(`"Report"`)}},
+						Version:   2,
+						CreatedAt: "2024-11-22T10:30:00Z",
+					}},
+					NextCursor: "next",
+				}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"entries":[{"id":"e1","task_id":"a495465c-d177-48e1-8954-516bba76d541","action":"updated","actor_id":"u1","changes":[{"field":"title","before":"Draft","after":"Report"}],"version":2,"created_at":"2024-11-22T10:30:00Z"}],"next_cursor":"next"}`,
+		},
+		{
+			name:               "invalid limit",
+			url:                "/tasks/a495465c-d177-48e1-8954-516bba76d541/history?limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid cursor",
+			url:  "/tasks/a495465c-d177-48e1-8954-516bba76d541/history?cursor=broken",
+			mockGetTaskHistoryFunc: func(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+				return nil, internalErrors.InvalidCursor
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "task not found",
+			url:  "/tasks/a495465c-d177-48e1-8954-516bba76d541/history",
+			mockGetTaskHistoryFunc: func(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+				return nil, internalErrors.TaskNotFound
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &task_usecase.MockTaskUseCase{
+				GetTaskHistoryFunc: tt.mockGetTaskHistoryFunc,
+			}
+			h := NewHandlers(mockUseCase)
+
+			ctx := context.WithValue(context.Background(), "id", "a495465c-d177-48e1-8954-516bba76d541")
+
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+			h.GetTaskHistory(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if tt.expectedResponse != "" {
+				var buf bytes.Buffer
+				buf.ReadFrom(resp.Body)
+
+				if strings.TrimSpace(buf.String()) != tt.expectedResponse {
+					t.Errorf("expected %s, got %s", tt.expectedResponse, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestUndoHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		mockUndoFunc       func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			name: "success",
+			body: `{"count":2}`,
+			mockUndoFunc: func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+				if cmd.Count != 2 {
+					t.Errorf("expected count 2, got %d", cmd.Count)
+				}
+				return &dtos.UndoResult{
+					Entries: []*models.HistoryEntry{{
+						Id:        "e2",
+						TaskId:    "t1",
+						Action:    models.HistoryUndone,
+						ActorId:   "u1",
+						Changes:   []models.FieldChange{{Field: "title", Before: []byte(`"Report"`), After: []byte(`"Draft"`)}},
+						Version:   3,
+						Reverts:   "e1",
+						CreatedAt: "2024-11-22T10:30:00Z",
+					}},
+					Tasks: []*models.Task{{Id: "t1", Title: "Draft", DueDate: "2024-11-23", Version: 3}},
+				}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   `{"entries":[{"id":"e2","task_id":"t1","action":"undone","actor_id":"u1","changes":[{"field":"title","before":"Report","after":"Draft"}],"version":3,"reverts":"e1","created_at":"2024-11-22T10:30:00Z"}],"tasks":[{"id":"t1","title":"Draft","description":"","due_date":"2024-11-23","overdue":false,"completed":false,"version":3}]}`,
+		},
+		{
+			name: "one change without body",
+			mockUndoFunc: func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+				if cmd.Count != 1 {
+					t.Errorf("expected count 1, got %d", cmd.Count)
+				}
+				return &dtos.UndoResult{}, nil
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid count",
+			body:               `{"count":21}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   `{"error":"count must be between 1 and 20"}`,
+		},
+		{
+			name: "nothing to undo",
+			body: `{}`,
+			mockUndoFunc: func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+				return nil, internalErrors.NothingToUndo
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name: "conflict",
+			body: `{"count":3}`,
+			mockUndoFunc: func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+				return nil, internalErrors.UndoConflict
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponse:   `{"error":"task was changed since the change to revert"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &task_usecase.MockTaskUseCase{
+				UndoFunc: tt.mockUndoFunc,
+			}
+			h := NewHandlers(mockUseCase)
+
+			req := httptest.NewRequest(http.MethodPost, "/undo", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.Undo(w, req)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if tt.expectedResponse != "" {
+				var buf bytes.Buffer
+				buf.ReadFrom(resp.Body)
+
+				if strings.TrimSpace(buf.String()) != tt.expectedResponse {
+					t.Errorf("expected %s, got %s", tt.expectedResponse, buf.String())
+				}
+			}
+		})
+	}
+}
+
+// TestTaskHandlersOnMemoryRepository goes through the router and the real
+// use case with an in-memory store behind it.
+func TestTaskHandlersOnMemoryRepository(t *testing.T) {
+	store := memory.NewStore()
+	taskUseCase := task_usecase.NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	router := NewRouter(NewHandlers(taskUseCase), NewAuthHandlers(&auth_usecase.MockAuthUseCase{}), NewProjectHandlers(&project_usecase.MockProjectUseCase{}), NewTagHandlers(&tag_usecase.MockTagUseCase{}), NewReminderHandlers(&reminder_usecase.MockReminderUseCase{}), NewWebhookHandlers(&webhook_usecase.MockWebhookUseCase{}), NewEventHandlers(events.NewBus(1, 1)), NewSocketHandlers(taskUseCase, events.NewBus(1, 1)), http.NotFoundHandler())
+
+	do := func(method, url, body string) (int, string) {
+		ctx := auth.WithUserId(context.Background(), "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90")
+		req := httptest.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+
+	status, body := do(http.MethodPost, "/tasks", `{"title":"Test Task"}`)
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+	var task models.Task
+	if err := json.Unmarshal([]byte(body), &task); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, body = do(http.MethodGet, "/tasks?title=test", ""); status != http.StatusOK || !strings.Contains(body, task.Id) {
+		t.Errorf("expected the task in the list, got %d: %s", status, body)
+	}
+
+	if status, _ = do(http.MethodDelete, fmt.Sprintf("/tasks/%s?version=%d", task.Id, task.Version+1), ""); status != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, status)
+	}
+	if status, _ = do(http.MethodDelete, fmt.Sprintf("/tasks/%s?version=%d", task.Id, task.Version), ""); status != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if status, _ = do(http.MethodGet, "/tasks/"+task.Id, ""); status != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, status)
+	}
+
+	if status, body = do(http.MethodGet, "/trash", ""); status != http.StatusOK || !strings.Contains(body, task.Id) {
+		t.Errorf("expected the task in the trash, got %d: %s", status, body)
+	}
+	if status, body = do(http.MethodPost, "/tasks/"+task.Id+"/restore", ""); status != http.StatusOK || !strings.Contains(body, `"version":3`) {
+		t.Errorf("expected the restored task, got %d: %s", status, body)
+	}
+	if status, _ = do(http.MethodDelete, "/trash/"+task.Id, ""); status != http.StatusNotFound {
+		t.Errorf("expected status %d for a task outside the trash, got %d", http.StatusNotFound, status)
+	}
+
+	if status, _ = do(http.MethodDelete, fmt.Sprintf("/tasks/%s?version=3", task.Id), ""); status != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if status, _ = do(http.MethodDelete, "/trash/"+task.Id, ""); status != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if status, body = do(http.MethodGet, "/trash", ""); status != http.StatusOK || body != "[]" {
+		t.Errorf("expected an empty trash, got %d: %s", status, body)
+	}
+	if status, _ = do(http.MethodPost, "/tasks/"+task.Id+"/restore", ""); status != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, status)
+	}
+
+	// Without a history there is nothing to undo.
+	if status, body = do(http.MethodPost, "/undo", ""); status != http.StatusConflict {
+		t.Errorf("expected status %d, got %d: %s", http.StatusConflict, status, body)
+	}
+}
+
+// TestTaskETagOnMemoryRepository checks that the tag of a task changes when
+// its blocker is completed, which does not write the task itself.
+func TestTaskETagOnMemoryRepository(t *testing.T) {
+	store := memory.NewStore()
+	taskUseCase := task_usecase.NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	h := NewHandlers(taskUseCase)
+	ctx := auth.WithUserId(context.Background(), "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90")
+
+	create := func(title string) *models.Task {
+		task, err := taskUseCase.CreateTask(ctx, &dtos.CreateTaskCommand{Title: title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	task, blocker := create("Paint walls"), create("Buy paint")
+	if _, err := taskUseCase.AddTaskBlocker(ctx, task.Id, &dtos.BlockerCommand{BlockerId: blocker.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(ifNoneMatch string) *http.Response {
+		req := httptest.NewRequestWithContext(context.WithValue(ctx, "id", task.Id), http.MethodGet, "/tasks/"+task.Id, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.GetTask(w, req)
+		return w.Result()
+	}
+
+	etag := get("").Header.Get("ETag")
+	if resp := get(etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, resp.StatusCode)
+	}
+
+	completed := true
+	if _, err := taskUseCase.ChangeTaskCompletionStatus(ctx, blocker.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &blocker.Version}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := get(etag)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d once the blocker is completed, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Errorf("expected a new etag once the blocker is completed, got %s again", etag)
+	}
+}
+
+func TestRegisterHandler(t *testing.T) {
+	mockAuthUseCase := &auth_usecase.MockAuthUseCase{
+		RegisterFunc: func(ctx context.Context, cmd *dtos.RegisterCommand) (*models.User, error) {
+			return &models.User{Id: "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90", Username: cmd.Username, TimeZone: "UTC"}, nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"username":"alice","password":"secret123"}`))
+	w := httptest.NewRecorder()
+
+	NewAuthHandlers(mockAuthUseCase).Register(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	if values := resp.Header.Values("Content-Type"); len(values) != 1 || values[0] != "application/json" {
+		t.Errorf("expected a single JSON content type, got %v", values)
+	}
+
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	expected := `{"id":"5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90","username":"alice","created_at":"","time_zone":"UTC"}`
+	if strings.TrimSpace(buf.String()) != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	mockAuthUseCase := &auth_usecase.MockAuthUseCase{
+		AuthenticateFunc: func(ctx context.Context, token string) (*models.User, error) {
+			if token != "valid" {
+				return nil, internalErrors.Unauthorized
+			}
+			return &models.User{Id: "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"}, nil
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, _ := auth.UserId(r.Context())
+		w.Write([]byte(userId))
+	})
+
+	tests := []struct {
+		name               string
+		path               string
+		authorization      string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			name:               "valid token",
+			path:               "/tasks",
+			authorization:      "Bearer valid",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90",
+		},
+		{
+			name:               "missing token",
+			path:               "/tasks",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.Unauthorized.Error()),
+		},
+		{
+			name:               "invalid token",
+			path:               "/tasks",
+			authorization:      "Bearer invalid",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.Unauthorized.Error()),
+		},
+		{
+			name:               "query token on stream",
+			path:               "/tasks/events?access_token=valid",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90",
+		},
+		{
+			name:               "query token elsewhere",
+			path:               "/tasks?access_token=valid",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, internalErrors.Unauthorized.Error()),
+		},
+		{
+			name:               "public path",
+			path:               "/auth/login",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			AuthMiddleware(mockAuthUseCase, next).ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if tt.expectedStatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header")
+			}
+
+			body := new(bytes.Buffer)
+			body.ReadFrom(resp.Body)
+			if strings.TrimSpace(body.String()) != tt.expectedResponse {
+				t.Errorf("expected response body %s, got %s", tt.expectedResponse, body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareTimeZone(t *testing.T) {
+	mockAuthUseCase := &auth_usecase.MockAuthUseCase{
+		AuthenticateFunc: func(ctx context.Context, token string) (*models.User, error) {
+			return &models.User{Id: "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90", TimeZone: "Europe/Moscow"}, nil
+		},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(auth.TimeZone(r.Context()).String()))
+	})
+
+	tests := []struct {
+		name               string
+		timeZone           string
+		expectedStatusCode int
+		expectedResponse   string
+	}{
+		{
+			name:               "user time zone",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   "Europe/Moscow",
+		},
+		{
+			name:               "header overrides user time zone",
+			timeZone:           "America/New_York",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   "America/New_York",
+		},
+		{
+			name:               "invalid header",
+			timeZone:           "Mars/Olympus",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponse:   fmt.Sprintf(`{"error":"%s"}`, dtos.NotValidTimeZone.Error()),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.Header.Set("Authorization", "Bearer valid")
+			if tt.timeZone != "" {
+				req.Header.Set(TimeZoneHeader, tt.timeZone)
+			}
+			w := httptest.NewRecorder()
+
+			AuthMiddleware(mockAuthUseCase, next).ServeHTTP(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+
+			body := new(bytes.Buffer)
+			body.ReadFrom(resp.Body)
+			if strings.TrimSpace(body.String()) != tt.expectedResponse {
+				t.Errorf("expected response body %s, got %s", tt.expectedResponse, body.String())
+			}
+		})
+	}
+}
+
+func TestStreamTaskEventsHandler(t *testing.T) {
+	const userId = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+
+	bus := events.NewBus(10, 10)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewEventHandlers(bus).StreamTaskEvents(w, r.WithContext(auth.WithUserId(r.Context(), userId)))
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	publish := func(ownerId, title string) {
+		bus.Publish(context.Background(), &events.Event{Id: title, Type: events.TaskUpdated, OwnerId: ownerId, Task: &models.Task{Title: title}})
+	}
+
+	// readEvent returns the id, the type and the data of the next event.
+	readEvent := func(reader *bufio.Reader) (string, string, string) {
+		var id, eventType, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+
+			switch {
+			case line == "" && eventType != "":
+				return id, eventType, data
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				eventType = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	connect := func(lastId string) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if lastId != "" {
+			req.Header.Set("Last-Event-ID", lastId)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %s", resp.Header.Get("Content-Type"))
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	// The headers are sent after subscribing, so nothing published from here
+	// on is missed.
+	resp, reader := connect("")
+
+	publish("another", "foreign")
+	publish(userId, "first")
+	publish(userId, "second")
+
+	firstId, eventType, data := readEvent(reader)
+	if eventType != events.TaskUpdated || !strings.Contains(data, `"title":"first"`) {
+		t.Fatalf("expected the first own event, got %s %s", eventType, data)
+	}
+	resp.Body.Close()
+
+	resp, reader = connect(firstId)
+	if _, _, data := readEvent(reader); !strings.Contains(data, `"title":"second"`) {
+		t.Errorf("expected to resume after the last event, got %s", data)
+	}
+	resp.Body.Close()
+
+	resp, reader = connect("unknown-1")
+	if _, eventType, _ := readEvent(reader); eventType != ResetEvent {
+		t.Errorf("expected a reset for an unknown id, got %s", eventType)
+	}
+	resp.Body.Close()
+}
+
+func TestSocketHandler(t *testing.T) {
+	const (
+		userId    = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+		projectId = "0c9f6a2b-6a3e-4d1b-9e25-5b8f7f0d3c11"
+		taskId    = "a495465c-d177-48e1-8954-516bba76d541"
+	)
+
+	mockUseCase := &task_usecase.MockTaskUseCase{
+		CreateTaskFunc: func(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+			if id, _ := auth.UserId(ctx); id != userId {
+				t.Errorf("expected commands to run as the user, got %q", id)
+			}
+			return &models.Task{Id: taskId, Title: cmd.Title, ProjectId: cmd.ProjectId, Version: 1, OwnerId: userId}, nil
+		},
+		ChangeTaskCompletionStatusFunc: func(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error) {
+			return nil, internalErrors.VersionConflict
+		},
+		UpdateTaskFunc: func(ctx context.Context, id string, cmd *dtos.UpdateTaskCommand) (*models.Task, error) {
+			return nil, errors.New("disk I/O error")
+		},
+	}
+
+	bus := events.NewBus(10, 10)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewSocketHandlers(mockUseCase, bus).ServeSocket(w, r.WithContext(auth.WithUserId(r.Context(), userId)))
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	send := func(message string) map[string]interface{} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatal(err)
+		}
+
+		reply := map[string]interface{}{}
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	tests := []struct {
+		name          string
+		message       string
+		expectedReply string
+	}{
+		{
+			name:          "subscribe to a project",
+			message:       `{"id":"1","type":"subscribe","data":{"project_id":"` + projectId + `"}}`,
+			expectedReply: `map[id:1 subscription_id:1 type:ack]`,
+		},
+		{
+			name:          "subscribe to urgent tasks",
+			message:       `{"id":"2","type":"subscribe","data":{"priorities":["urgent"]}}`,
+			expectedReply: `map[id:2 subscription_id:2 type:ack]`,
+		},
+		{
+			name:          "not valid filter",
+			message:       `{"id":"3","type":"subscribe","data":{"tag_mode":"some"}}`,
+			expectedReply: fmt.Sprintf(`map[code:400 error:%s id:3 type:error]`, dtos.NotValidTagMode),
+		},
+		{
+			name:          "create",
+			message:       `{"id":"4","type":"create","data":{"title":"live","project_id":"` + projectId + `"}}`,
+			expectedReply: `map[id:4 task:map[completed:false description: due_date: id:` + taskId + ` overdue:false owner_id:` + userId + ` project_id:` + projectId + ` title:live version:1] type:ack]`,
+		},
+		{
+			name:          "create without data",
+			message:       `{"id":"5","type":"create"}`,
+			expectedReply: fmt.Sprintf(`map[code:400 error:%s id:5 type:error]`, NoParamsToCreate),
+		},
+		{
+			name:          "complete with a stale version",
+			message:       `{"id":"6","type":"complete","task_id":"` + taskId + `","data":{"completed":true,"version":1}}`,
+			expectedReply: fmt.Sprintf(`map[code:409 error:%s id:6 type:error]`, internalErrors.VersionConflict),
+		},
+		{
+			name:          "update fails internally",
+			message:       `{"id":"7","type":"update","task_id":"` + taskId + `","data":{"title":"x","version":1}}`,
+			expectedReply: `map[code:500 error:Internal Server Error id:7 type:error]`,
+		},
+		{
+			name:          "not valid task id",
+			message:       `{"id":"8","type":"complete","task_id":"1","data":{"completed":true,"version":1}}`,
+			expectedReply: fmt.Sprintf(`map[code:400 error:%s id:8 type:error]`, InvalidIdFormat),
+		},
+		{
+			name:          "unknown type",
+			message:       `{"id":"9","type":"delete"}`,
+			expectedReply: fmt.Sprintf(`map[code:400 error:%s id:9 type:error]`, UnknownMessageType),
+		},
+		{
+			name:          "not valid message",
+			message:       `hello`,
+			expectedReply: fmt.Sprintf(`map[code:400 error:%s type:error]`, NotValidMessage),
+		},
+		{
+			name:          "unsubscribe unknown",
+			message:       `{"id":"10","type":"unsubscribe","subscription_id":"7"}`,
+			expectedReply: fmt.Sprintf(`map[code:404 error:%s id:10 type:error]`, SubscriptionNotFound),
+		},
+		{
+			name:          "unsubscribe",
+			message:       `{"id":"11","type":"unsubscribe","subscription_id":"2"}`,
+			expectedReply: `map[id:11 subscription_id:2 type:ack]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reply := fmt.Sprint(send(tt.message)); reply != tt.expectedReply {
+				t.Errorf("expected reply %s, got %s", tt.expectedReply, reply)
+			}
+		})
+	}
+
+	t.Run("events of subscribed tasks", func(t *testing.T) {
+		bus.Publish(context.Background(), &events.Event{Id: "e1", Type: events.TaskCreated, OwnerId: userId, Task: &models.Task{Id: "other", Priority: "urgent", OwnerId: userId}})
+		bus.Publish(context.Background(), &events.Event{Id: "e2", Type: events.TaskCreated, OwnerId: "another", Task: &models.Task{Id: "foreign", ProjectId: projectId, OwnerId: "another"}})
+		bus.Publish(context.Background(), &events.Event{Id: "e3", Type: events.TaskUpdated, OwnerId: userId, Task: &models.Task{Id: taskId, ProjectId: projectId, OwnerId: userId}})
+
+		reply := socketResponse{}
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Type != SocketEvent || reply.SubscriptionId != "1" || reply.Event.Id != "e3" || reply.Event.Task.Id != taskId {
+			t.Errorf("expected only the event of the project task, got %+v", reply)
+		}
+	})
+}
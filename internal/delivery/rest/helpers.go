@@ -1,10 +1,16 @@
 package rest
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
 	"github.com/DanKo-code/TODO-list/pkg/logger"
 	"net/http"
-	"regexp"
+	"strconv"
+	"strings"
 )
 
 func ReadFromRequestBody(request *http.Request, result interface{}) error {
@@ -17,8 +23,119 @@ func ReadFromRequestBody(request *http.Request, result interface{}) error {
 	return nil
 }
 
-func WriteToResponseBody(writer http.ResponseWriter, response interface{}) {
+func ReadGetTasksQuery(request *http.Request) (*dtos.GetTasksQuery, error) {
+	values := request.URL.Query()
+
+	query := &dtos.GetTasksQuery{
+		DueFrom:    values.Get("due_from"),
+		DueTo:      values.Get("due_to"),
+		Title:      values.Get("title"),
+		Sort:       values.Get("sort"),
+		Order:      values.Get("order"),
+		Cursor:     values.Get("cursor"),
+		Tags:       values["tag"],
+		TagMode:    values.Get("tag_mode"),
+		Priorities: values["priority"],
+		SeriesId:   values.Get("series_id"),
+	}
+
+	if v := values.Get("completed"); v != "" {
+		completed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, InvalidCompletedParam
+		}
+		query.Completed = &completed
+	}
+
+	if v := values.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, InvalidOverdueParam
+		}
+		query.Overdue = &overdue
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, InvalidLimitParam
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+func ReadSearchTasksQuery(request *http.Request) (*dtos.SearchTasksQuery, error) {
+	values := request.URL.Query()
+
+	query := &dtos.SearchTasksQuery{
+		Query:  strings.TrimSpace(values.Get("q")),
+		Cursor: values.Get("cursor"),
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, InvalidLimitParam
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+func ReadGetHistoryQuery(request *http.Request) (*dtos.GetHistoryQuery, error) {
+	values := request.URL.Query()
+
+	query := &dtos.GetHistoryQuery{
+		Cursor: values.Get("cursor"),
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, InvalidLimitParam
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// ReadBoolQueryParam returns false when the parameter is absent and invalidErr
+// when it is not a boolean.
+func ReadBoolQueryParam(request *http.Request, name string, invalidErr error) (bool, error) {
+	v := request.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, invalidErr
+	}
+
+	return value, nil
+}
+
+func ReadVersionQueryParam(request *http.Request) (int64, error) {
+	v := request.URL.Query().Get("version")
+	if v == "" {
+		return 0, VersionParamIsRequired
+	}
+
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, InvalidVersionParam
+	}
+
+	return version, nil
+}
+
+func WriteToResponseBody(writer http.ResponseWriter, response interface{}, status int) {
 	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(status)
 	encoder := json.NewEncoder(writer)
 	encoder.Encode(response)
 }
@@ -33,6 +150,42 @@ func WriteErrToResponseBody(w http.ResponseWriter, err error, status int) {
 }
 
 func isValidUUID(uuid string) bool {
-	regex := regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[1-5][a-f0-9]{3}-[89ab][a-f0-9]{3}-[a-f0-9]{12}$`)
-	return regex.MatchString(uuid)
+	return helper.IsValidUUID(uuid)
+}
+
+// TaskETag returns a strong entity tag of the representation of the task, a
+// hash of its encoding. The version alone would not do: the representation
+// also carries state derived from other tasks, such as whether its blockers
+// are done and the progress of its subtasks, which changes without a write of
+// the task itself.
+func TaskETag(task *models.Task) string {
+	body, _ := json.Marshal(task)
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether etag is listed in an If-Match or If-None-Match
+// header value. Weak comparison ignores the W/ prefix as RFC 9110 requires for
+// If-None-Match, while strong comparison never matches weak tags.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimeZoneHeader overrides the time zone of the user for a single request.
+const TimeZoneHeader = "X-Time-Zone"
+
+// publicPaths can be requested without a token.
+var publicPaths = map[string]bool{
+	"/auth/register": true,
+	"/auth/login":    true,
+}
+
+// streamPaths also take the token from the access_token query parameter,
+// since browsers cannot set headers on EventSource and WebSocket connections.
+var streamPaths = map[string]bool{
+	"/tasks/events": true,
+	"/ws":           true,
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		if streamPaths[r.URL.Path] {
+			return r.URL.Query().Get("access_token")
+		}
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// requestTimeZone returns the zone from TimeZoneHeader if the request has one
+// and the zone saved for the user otherwise.
+func requestTimeZone(r *http.Request, user *models.User) (*time.Location, error) {
+	if name := r.Header.Get(TimeZoneHeader); name != "" {
+		if err := dtos.ValidateTimeZone(name); err != nil {
+			return nil, err
+		}
+		return time.LoadLocation(name)
+	}
+
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil || user.TimeZone == "" {
+		return time.UTC, nil
+	}
+
+	return loc, nil
+}
+
+// AuthMiddleware rejects requests without a valid bearer token and puts the
+// id and the time zone of the authenticated user into the request context.
+func AuthMiddleware(useCase usecase.AuthUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := useCase.Authenticate(r.Context(), bearerToken(r))
+		if err != nil {
+
+			if errors.Is(err, internalErrors.Unauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="todo-list"`)
+				WriteErrToResponseBody(w, err, http.StatusUnauthorized)
+				return
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		loc, err := requestTimeZone(r, user)
+		if err != nil {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		ctx := auth.WithUserId(r.Context(), user.Id)
+		ctx = auth.WithTimeZone(ctx, loc)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,261 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+type ProjectHandlers struct {
+	useCase usecase.ProjectUseCase
+}
+
+func NewProjectHandlers(useCase usecase.ProjectUseCase) *ProjectHandlers {
+	return &ProjectHandlers{useCase}
+}
+
+func (h *ProjectHandlers) CreateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmd := dtos.CreateProjectCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.ProjectNameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.useCase.CreateProject(ctx, &cmd)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, project, http.StatusOK)
+}
+
+func (h *ProjectHandlers) GetProjects(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	includeArchived, err := ReadBoolQueryParam(r, "archived", InvalidArchivedParam)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	projects, err := h.useCase.GetProjects(ctx, includeArchived)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if projects == nil {
+		projects = []*models.Project{}
+	}
+
+	WriteToResponseBody(w, projects, http.StatusOK)
+}
+
+func (h *ProjectHandlers) GetProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(projectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.useCase.GetProject(ctx, projectId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, project, http.StatusOK)
+}
+
+func (h *ProjectHandlers) GetProjectTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(projectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	query, err := ReadGetTasksQuery(r)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = query.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.useCase.GetProjectTasks(ctx, projectId, query)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.InvalidCursor) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if page.Tasks == nil {
+		page.Tasks = []*models.Task{}
+	}
+
+	WriteToResponseBody(w, page, http.StatusOK)
+}
+
+func (h *ProjectHandlers) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(projectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.UpdateProjectCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, NoParamsToUpdate, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.useCase.UpdateProject(ctx, projectId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, project, http.StatusOK)
+}
+
+func (h *ProjectHandlers) ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(projectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.ArchiveProjectCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, NoParamsToArchive, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	project, err := h.useCase.ArchiveProject(ctx, projectId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, project, http.StatusOK)
+}
+
+func (h *ProjectHandlers) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(projectId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cascade, err := ReadBoolQueryParam(r, "cascade", InvalidCascadeParam)
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.useCase.DeleteProject(ctx, projectId, cascade)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.ProjectHasTasks) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
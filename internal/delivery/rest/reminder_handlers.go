@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+type ReminderHandlers struct {
+	useCase usecase.ReminderUseCase
+}
+
+func NewReminderHandlers(useCase usecase.ReminderUseCase) *ReminderHandlers {
+	return &ReminderHandlers{useCase}
+}
+
+func (h *ReminderHandlers) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.CreateReminderCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.ReminderTimeIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	reminder, err := h.useCase.CreateReminder(ctx, taskId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.ReminderInPast) {
+			WriteErrToResponseBody(w, err, http.StatusBadRequest)
+			return
+		}
+
+		if errors.Is(err, internalErrors.TaskHasNoDueDate) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, reminder, http.StatusOK)
+}
+
+func (h *ReminderHandlers) GetReminders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	reminders, err := h.useCase.GetReminders(ctx, taskId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, reminders, http.StatusOK)
+}
+
+func (h *ReminderHandlers) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(taskId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	reminderId, ok := ctx.Value("reminder_id").(string)
+	if !ok || !isValidUUID(reminderId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	err := h.useCase.DeleteReminder(ctx, taskId, reminderId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TaskNotFound) || errors.Is(err, internalErrors.ReminderNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
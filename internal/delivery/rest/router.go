@@ -3,45 +3,108 @@ package rest
 import (
 	"context"
 	"net/http"
+	"sort"
 	"strings"
 )
 
 type Router struct {
 	routes map[string]map[string]http.HandlerFunc
+	paths  []string
 }
 
-func NewRouter(handlers *Handlers) *Router {
+func NewRouter(handlers *Handlers, authHandlers *AuthHandlers, projectHandlers *ProjectHandlers, tagHandlers *TagHandlers, reminderHandlers *ReminderHandlers, webhookHandlers *WebhookHandlers, eventHandlers *EventHandlers, socketHandlers *SocketHandlers, graphqlHandler http.Handler) *Router {
 	router := &Router{
 		routes: make(map[string]map[string]http.HandlerFunc),
 	}
 
+	router.addRoute(http.MethodPost, "/auth/register", authHandlers.Register)
+	router.addRoute(http.MethodPost, "/auth/login", authHandlers.Login)
+	router.addRoute(http.MethodPost, "/auth/logout", authHandlers.Logout)
+	router.addRoute(http.MethodGet, "/auth/me", authHandlers.Me)
+	router.addRoute(http.MethodPatch, "/auth/me", authHandlers.UpdateMe)
+
 	router.addRoute(http.MethodPost, "/tasks", handlers.CreateTask)
 	router.addRoute(http.MethodGet, "/tasks", handlers.GetTasks)
+	router.addRoute(http.MethodGet, "/tasks/search", handlers.SearchTasks)
+	router.addRoute(http.MethodGet, "/tasks/events", eventHandlers.StreamTaskEvents)
+	router.addRoute(http.MethodGet, "/tasks/{id}", handlers.GetTask)
 	router.addRoute(http.MethodPut, "/tasks/{id}", handlers.UpdateTask)
 	router.addRoute(http.MethodDelete, "/tasks/{id}", handlers.DeleteTask)
 	router.addRoute(http.MethodPatch, "/tasks/{id}/complete", handlers.ChangeTaskCompletionStatus)
+	router.addRoute(http.MethodPatch, "/tasks/{id}/project", handlers.MoveTask)
+	router.addRoute(http.MethodPost, "/tasks/{id}/tags", handlers.AddTaskTag)
+	router.addRoute(http.MethodDelete, "/tasks/{id}/tags/{name}", handlers.RemoveTaskTag)
+	router.addRoute(http.MethodPost, "/tasks/{id}/blockers", handlers.AddTaskBlocker)
+	router.addRoute(http.MethodDelete, "/tasks/{id}/blockers/{blocker_id}", handlers.RemoveTaskBlocker)
+	router.addRoute(http.MethodGet, "/tasks/{id}/subtasks", handlers.GetSubtasks)
+	router.addRoute(http.MethodPost, "/tasks/{id}/subtasks", handlers.CreateSubtask)
+	router.addRoute(http.MethodGet, "/tasks/{id}/reminders", reminderHandlers.GetReminders)
+	router.addRoute(http.MethodPost, "/tasks/{id}/reminders", reminderHandlers.CreateReminder)
+	router.addRoute(http.MethodDelete, "/tasks/{id}/reminders/{reminder_id}", reminderHandlers.DeleteReminder)
+	router.addRoute(http.MethodPost, "/tasks/{id}/restore", handlers.RestoreTask)
+	router.addRoute(http.MethodGet, "/tasks/{id}/history", handlers.GetTaskHistory)
+
+	router.addRoute(http.MethodGet, "/trash", handlers.GetTrash)
+	router.addRoute(http.MethodDelete, "/trash/{id}", handlers.PurgeTask)
+
+	router.addRoute(http.MethodPost, "/undo", handlers.Undo)
+	router.addRoute(http.MethodPost, "/redo", handlers.Redo)
+
+	router.addRoute(http.MethodPost, "/projects", projectHandlers.CreateProject)
+	router.addRoute(http.MethodGet, "/projects", projectHandlers.GetProjects)
+	router.addRoute(http.MethodGet, "/projects/{id}", projectHandlers.GetProject)
+	router.addRoute(http.MethodPut, "/projects/{id}", projectHandlers.UpdateProject)
+	router.addRoute(http.MethodDelete, "/projects/{id}", projectHandlers.DeleteProject)
+	router.addRoute(http.MethodPatch, "/projects/{id}/archive", projectHandlers.ArchiveProject)
+	router.addRoute(http.MethodGet, "/projects/{id}/tasks", projectHandlers.GetProjectTasks)
+
+	router.addRoute(http.MethodPost, "/tags", tagHandlers.CreateTag)
+	router.addRoute(http.MethodGet, "/tags", tagHandlers.GetTags)
+	router.addRoute(http.MethodPut, "/tags/{id}", tagHandlers.RenameTag)
+	router.addRoute(http.MethodDelete, "/tags/{id}", tagHandlers.DeleteTag)
+
+	router.addRoute(http.MethodPost, "/webhooks", webhookHandlers.CreateWebhook)
+	router.addRoute(http.MethodGet, "/webhooks", webhookHandlers.GetWebhooks)
+	router.addRoute(http.MethodGet, "/webhooks/{id}", webhookHandlers.GetWebhook)
+	router.addRoute(http.MethodPut, "/webhooks/{id}", webhookHandlers.UpdateWebhook)
+	router.addRoute(http.MethodDelete, "/webhooks/{id}", webhookHandlers.DeleteWebhook)
+	router.addRoute(http.MethodGet, "/webhooks/{id}/deliveries", webhookHandlers.GetDeliveries)
+
+	router.addRoute(http.MethodGet, "/ws", socketHandlers.ServeSocket)
+
+	router.addRoute(http.MethodPost, "/graphql", graphqlHandler.ServeHTTP)
+	router.addRoute(http.MethodGet, "/graphql", graphqlHandler.ServeHTTP)
 
 	return router
 }
 
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	methodNotAllowed := false
 
-	for routPath, methods := range r.routes {
-		params, match := matchRoute(routPath, req.URL.Path)
+	for _, routePath := range r.paths {
+		params, match := matchRoute(routePath, req.URL.Path)
+		if !match {
+			continue
+		}
 
-		if match {
-			if handler, ok := methods[req.Method]; ok {
-				ctx := req.Context()
+		handler, ok := r.routes[routePath][req.Method]
+		if !ok {
+			methodNotAllowed = true
+			continue
+		}
 
-				for key, value := range params {
-					ctx = context.WithValue(ctx, key, value)
-				}
-				handler(w, req.WithContext(ctx))
-				return
-			}
+		ctx := req.Context()
 
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		for key, value := range params {
+			ctx = context.WithValue(ctx, key, value)
 		}
+		handler(w, req.WithContext(ctx))
+		return
+	}
+
+	if methodNotAllowed {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
 	http.NotFound(w, req)
@@ -50,10 +113,35 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 func (r *Router) addRoute(method, path string, handler http.HandlerFunc) {
 	if r.routes[path] == nil {
 		r.routes[path] = make(map[string]http.HandlerFunc)
+		r.paths = append(r.paths, path)
+
+		// Static segments take precedence over parameters, so /tasks/search
+		// is matched before /tasks/{id}.
+		sort.SliceStable(r.paths, func(i, j int) bool {
+			return lessSpecific(r.paths[j], r.paths[i])
+		})
 	}
 	r.routes[path][method] = handler
 }
 
+// lessSpecific reports whether route a has a parameter where route b has a
+// static segment at the first position they differ in kind.
+func lessSpecific(a, b string) bool {
+	aParts := strings.Split(a, "/")
+	bParts := strings.Split(b, "/")
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aParam := strings.HasPrefix(aParts[i], "{")
+		bParam := strings.HasPrefix(bParts[i], "{")
+
+		if aParam != bParam {
+			return aParam
+		}
+	}
+
+	return false
+}
+
 func matchRoute(routePath, requestPath string) (map[string]string, bool) {
 	routeParts := strings.Split(routePath, "/")
 	requestParts := strings.Split(requestPath, "/")
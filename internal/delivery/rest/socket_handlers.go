@@ -0,0 +1,390 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Message types of the socket protocol. Clients send the first five, the
+// server answers every message with an ack or an error carrying the same id
+// and sends an event for each subscription a changed task matches.
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketCreate      = "create"
+	SocketUpdate      = "update"
+	SocketComplete    = "complete"
+
+	SocketAck   = "ack"
+	SocketError = "error"
+	SocketEvent = "event"
+)
+
+const (
+	socketWriteTimeout = 10 * time.Second
+	// socketPongTimeout closes connections that stopped answering pings.
+	socketPongTimeout  = 60 * time.Second
+	socketPingInterval = 30 * time.Second
+	socketMaxMessage   = 64 << 10
+	socketQueueSize    = 64
+
+	maxSocketSubscriptions = 20
+)
+
+type socketRequest struct {
+	Id             string          `json:"id"`
+	Type           string          `json:"type"`
+	TaskId         string          `json:"task_id"`
+	SubscriptionId string          `json:"subscription_id"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// socketResponse is a message to the client. Code and Error of an error are
+// the status and the message the REST API answers the same failure with.
+type socketResponse struct {
+	Id             string        `json:"id,omitempty"`
+	Type           string        `json:"type"`
+	SubscriptionId string        `json:"subscription_id,omitempty"`
+	Task           *models.Task  `json:"task,omitempty"`
+	Event          *events.Event `json:"event,omitempty"`
+	Code           int           `json:"code,omitempty"`
+	Error          string        `json:"error,omitempty"`
+}
+
+type SocketHandlers struct {
+	useCase  usecase.TaskUseCase
+	bus      *events.Bus
+	upgrader websocket.Upgrader
+}
+
+func NewSocketHandlers(useCase usecase.TaskUseCase, bus *events.Bus) *SocketHandlers {
+	return &SocketHandlers{
+		useCase: useCase,
+		bus:     bus,
+		upgrader: websocket.Upgrader{
+			// Sockets are authenticated with a token rather than cookies,
+			// so another site cannot open one on behalf of the user.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// socketErrorCode returns the status the task handlers answer err with.
+func socketErrorCode(err error) int {
+	switch {
+	case errors.Is(err, internalErrors.TaskNotFound), errors.Is(err, internalErrors.ProjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, internalErrors.VersionConflict), errors.Is(err, internalErrors.TaskBlocked), errors.Is(err, internalErrors.ProjectArchived):
+		return http.StatusConflict
+	case errors.Is(err, internalErrors.DueDateInPast):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ServeSocket upgrades the request to a WebSocket on which the user can
+// subscribe to changes of their tasks and create, update and complete tasks.
+func (h *SocketHandlers) ServeSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		WriteErrToResponseBody(w, internalErrors.Unauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered the request.
+		logger.ErrorLogger.Printf("Failed to upgrade to websocket: %v", err)
+		return
+	}
+
+	sub := h.bus.Subscribe(userId, "")
+	defer sub.Close()
+
+	s := &socket{
+		conn:          conn,
+		useCase:       h.useCase,
+		out:           make(chan *socketResponse, socketQueueSize),
+		readerDone:    make(chan struct{}),
+		writerDone:    make(chan struct{}),
+		subscriptions: map[string]*dtos.SubscribeCommand{},
+	}
+
+	go s.writeLoop(sub.C)
+	s.readLoop(ctx)
+	<-s.writerDone
+}
+
+// socket is one connection. Messages are read and handled one at a time by
+// readLoop; writeLoop is the only writer, as the connection requires.
+type socket struct {
+	conn       *websocket.Conn
+	useCase    usecase.TaskUseCase
+	out        chan *socketResponse
+	readerDone chan struct{}
+	writerDone chan struct{}
+
+	mu               sync.Mutex
+	subscriptions    map[string]*dtos.SubscribeCommand
+	lastSubscription int
+}
+
+func (s *socket) readLoop(ctx context.Context) {
+	defer close(s.readerDone)
+
+	s.conn.SetReadLimit(socketMaxMessage)
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongTimeout))
+	})
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(socketPongTimeout))
+
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.ErrorLogger.Printf("Websocket closed: %v", err)
+			}
+			return
+		}
+
+		req := socketRequest{}
+		var resp *socketResponse
+		if err := json.Unmarshal(data, &req); err != nil {
+			resp = fail(&req, NotValidMessage, http.StatusBadRequest)
+		} else {
+			resp = s.handle(ctx, &req)
+		}
+
+		select {
+		case s.out <- resp:
+		case <-s.writerDone:
+			return
+		}
+	}
+}
+
+func (s *socket) writeLoop(messages <-chan events.Message) {
+	defer close(s.writerDone)
+	defer s.conn.Close()
+
+	ping := time.NewTicker(socketPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case resp := <-s.out:
+			if err := s.write(resp); err != nil {
+				return
+			}
+		case message, ok := <-messages:
+			if !ok {
+				// The client fell behind or the server is shutting down; it
+				// should reconnect and reload what it shows.
+				closing := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "event stream ended")
+				s.conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(socketWriteTimeout))
+				return
+			}
+
+			for _, id := range s.matching(message.Event.Task) {
+				if err := s.write(&socketResponse{Type: SocketEvent, SubscriptionId: id, Event: message.Event}); err != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteTimeout)); err != nil {
+				return
+			}
+		case <-s.readerDone:
+			return
+		}
+	}
+}
+
+func (s *socket) write(resp *socketResponse) error {
+	s.conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+	return s.conn.WriteJSON(resp)
+}
+
+// matching returns the ids of the subscriptions the task matches, in the
+// order they were made.
+func (s *socket) matching(task *models.Task) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := []string{}
+	for id, filter := range s.subscriptions {
+		if filter.Matches(task) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	return ids
+}
+
+func fail(req *socketRequest, err error, code int) *socketResponse {
+	message := err.Error()
+	if code == http.StatusInternalServerError {
+		message = http.StatusText(code)
+	}
+
+	return &socketResponse{Id: req.Id, Type: SocketError, Code: code, Error: message}
+}
+
+// decodeData reads the data of the request into cmd; missing data is
+// reported as missing.
+func decodeData(req *socketRequest, cmd interface{}, missing error) error {
+	if len(req.Data) == 0 || string(req.Data) == "null" {
+		return missing
+	}
+
+	return json.Unmarshal(req.Data, cmd)
+}
+
+func (s *socket) handle(ctx context.Context, req *socketRequest) *socketResponse {
+	switch req.Type {
+	case SocketSubscribe:
+		return s.subscribe(req)
+	case SocketUnsubscribe:
+		return s.unsubscribe(req)
+	case SocketCreate:
+		return s.create(ctx, req)
+	case SocketUpdate:
+		return s.update(ctx, req)
+	case SocketComplete:
+		return s.complete(ctx, req)
+	default:
+		return fail(req, UnknownMessageType, http.StatusBadRequest)
+	}
+}
+
+func (s *socket) subscribe(req *socketRequest) *socketResponse {
+	filter := &dtos.SubscribeCommand{}
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, filter); err != nil {
+			return fail(req, err, http.StatusBadRequest)
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	if filter.ProjectId != "" && !isValidUUID(filter.ProjectId) {
+		return fail(req, InvalidIdFormat, http.StatusBadRequest)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subscriptions) >= maxSocketSubscriptions {
+		return fail(req, TooManySubscriptions, http.StatusBadRequest)
+	}
+
+	s.lastSubscription++
+	id := strconv.Itoa(s.lastSubscription)
+	s.subscriptions[id] = filter
+
+	return &socketResponse{Id: req.Id, Type: SocketAck, SubscriptionId: id}
+}
+
+func (s *socket) unsubscribe(req *socketRequest) *socketResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscriptions[req.SubscriptionId]; !ok {
+		return fail(req, SubscriptionNotFound, http.StatusNotFound)
+	}
+	delete(s.subscriptions, req.SubscriptionId)
+
+	return &socketResponse{Id: req.Id, Type: SocketAck, SubscriptionId: req.SubscriptionId}
+}
+
+func (s *socket) create(ctx context.Context, req *socketRequest) *socketResponse {
+	cmd := dtos.CreateTaskCommand{}
+	if err := decodeData(req, &cmd, NoParamsToCreate); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	if err := cmd.Validate(); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	if cmd.ProjectId != "" && !isValidUUID(cmd.ProjectId) {
+		return fail(req, InvalidIdFormat, http.StatusBadRequest)
+	}
+
+	task, err := s.useCase.CreateTask(ctx, &cmd)
+	if err != nil {
+		return fail(req, err, socketErrorCode(err))
+	}
+
+	return &socketResponse{Id: req.Id, Type: SocketAck, Task: task}
+}
+
+func (s *socket) update(ctx context.Context, req *socketRequest) *socketResponse {
+	if !isValidUUID(req.TaskId) {
+		return fail(req, InvalidIdFormat, http.StatusBadRequest)
+	}
+
+	cmd := dtos.UpdateTaskCommand{}
+	if err := decodeData(req, &cmd, NoParamsToUpdate); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	if err := cmd.Validate(); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	task, err := s.useCase.UpdateTask(ctx, req.TaskId, &cmd)
+	if err != nil {
+		return fail(req, err, socketErrorCode(err))
+	}
+
+	return &socketResponse{Id: req.Id, Type: SocketAck, Task: task}
+}
+
+func (s *socket) complete(ctx context.Context, req *socketRequest) *socketResponse {
+	if !isValidUUID(req.TaskId) {
+		return fail(req, InvalidIdFormat, http.StatusBadRequest)
+	}
+
+	cmd := dtos.ChangeTaskCompletionStatusCommand{}
+	if err := decodeData(req, &cmd, NoParamsToChangeCompletionStatus); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	if err := cmd.Validate(); err != nil {
+		return fail(req, err, http.StatusBadRequest)
+	}
+
+	task, err := s.useCase.ChangeTaskCompletionStatus(ctx, req.TaskId, &cmd)
+	if err != nil {
+		return fail(req, err, socketErrorCode(err))
+	}
+
+	return &socketResponse{Id: req.Id, Type: SocketAck, Task: task}
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+type TagHandlers struct {
+	useCase usecase.TagUseCase
+}
+
+func NewTagHandlers(useCase usecase.TagUseCase) *TagHandlers {
+	return &TagHandlers{useCase}
+}
+
+func (h *TagHandlers) CreateTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmd := dtos.TagCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.TagNameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.useCase.CreateTag(ctx, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TagAlreadyExists) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, tag, http.StatusOK)
+}
+
+func (h *TagHandlers) GetTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tags, err := h.useCase.GetTags(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+
+	WriteToResponseBody(w, tags, http.StatusOK)
+}
+
+func (h *TagHandlers) RenameTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tagId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(tagId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.TagCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.TagNameIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.useCase.RenameTag(ctx, tagId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TagNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, internalErrors.TagAlreadyExists) {
+			WriteErrToResponseBody(w, err, http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, tag, http.StatusOK)
+}
+
+func (h *TagHandlers) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tagId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(tagId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	err := h.useCase.DeleteTag(ctx, tagId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.TagNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
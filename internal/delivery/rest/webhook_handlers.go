@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"net/http"
+)
+
+type WebhookHandlers struct {
+	useCase usecase.WebhookUseCase
+}
+
+func NewWebhookHandlers(useCase usecase.WebhookUseCase) *WebhookHandlers {
+	return &WebhookHandlers{useCase}
+}
+
+func (h *WebhookHandlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cmd := dtos.CreateWebhookCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.WebhookURLIsRequired, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.useCase.CreateWebhook(ctx, &cmd)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, webhook, http.StatusOK)
+}
+
+func (h *WebhookHandlers) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.useCase.GetWebhooks(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, webhooks, http.StatusOK)
+}
+
+func (h *WebhookHandlers) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(webhookId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.useCase.GetWebhook(ctx, webhookId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.WebhookNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, webhook, http.StatusOK)
+}
+
+func (h *WebhookHandlers) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(webhookId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	cmd := dtos.UpdateWebhookCommand{}
+	err := ReadFromRequestBody(r, &cmd)
+	if err != nil {
+
+		if err.Error() == NoBody {
+			WriteErrToResponseBody(w, dtos.NoParamsToUpdate, http.StatusBadRequest)
+			return
+		}
+
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = cmd.Validate()
+	if err != nil {
+		WriteErrToResponseBody(w, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.useCase.UpdateWebhook(ctx, webhookId, &cmd)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.WebhookNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, webhook, http.StatusOK)
+}
+
+func (h *WebhookHandlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(webhookId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	err := h.useCase.DeleteWebhook(ctx, webhookId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.WebhookNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *WebhookHandlers) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookId, ok := ctx.Value("id").(string)
+	if !ok || !isValidUUID(webhookId) {
+		WriteErrToResponseBody(w, InvalidIdFormat, http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.useCase.GetDeliveries(ctx, webhookId)
+	if err != nil {
+
+		if errors.Is(err, internalErrors.WebhookNotFound) {
+			WriteErrToResponseBody(w, err, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	WriteToResponseBody(w, deliveries, http.StatusOK)
+}
@@ -0,0 +1,84 @@
+package dtos
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"net/mail"
+	"regexp"
+)
+
+var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,64}$`)
+
+type RegisterCommand struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	TimeZone string `json:"time_zone"`
+}
+
+type LoginCommand struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type UpdateUserCommand struct {
+	TimeZone string `json:"time_zone"`
+	// Email replaces the address when set, an empty string removes it.
+	Email *string `json:"email"`
+}
+
+type AuthToken struct {
+	Token     string       `json:"token"`
+	ExpiresAt string       `json:"expires_at"`
+	User      *models.User `json:"user"`
+}
+
+func (cmd *RegisterCommand) Validate() error {
+	if cmd.Username == "" {
+		return UsernameIsRequired
+	}
+	if !usernameRegex.MatchString(cmd.Username) {
+		return NotValidUsername
+	}
+
+	// bcrypt ignores everything after 72 bytes.
+	if len(cmd.Password) < 8 || len(cmd.Password) > 72 {
+		return NotValidPassword
+	}
+
+	if cmd.TimeZone != "" {
+		return ValidateTimeZone(cmd.TimeZone)
+	}
+
+	return nil
+}
+
+func (cmd *UpdateUserCommand) Validate() error {
+	if cmd.TimeZone == "" && cmd.Email == nil {
+		return NoParamsToUpdate
+	}
+
+	if cmd.TimeZone != "" {
+		if err := ValidateTimeZone(cmd.TimeZone); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Email != nil && *cmd.Email != "" {
+		address, err := mail.ParseAddress(*cmd.Email)
+		if err != nil || address.Address != *cmd.Email || len(*cmd.Email) > 254 {
+			return NotValidEmail
+		}
+	}
+
+	return nil
+}
+
+func (cmd *LoginCommand) Validate() error {
+	if cmd.Username == "" {
+		return UsernameIsRequired
+	}
+	if cmd.Password == "" {
+		return PasswordIsRequired
+	}
+
+	return nil
+}
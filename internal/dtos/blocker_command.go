@@ -0,0 +1,14 @@
+package dtos
+
+// BlockerCommand makes the task wait for the task with BlockerId.
+type BlockerCommand struct {
+	BlockerId string `json:"blocker_id"`
+}
+
+func (cmd *BlockerCommand) Validate() error {
+	if cmd.BlockerId == "" {
+		return BlockerIdIsRequired
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
 package dtos
 
 type ChangeTaskCompletionStatusCommand struct {
-	Completed *bool `json:"completed"`
+	Completed *bool  `json:"completed"`
+	Version   *int64 `json:"version"`
+	// Force completes the task even if some of its blockers are still open.
+	Force bool `json:"force"`
 }
 
 func (cmd *ChangeTaskCompletionStatusCommand) Validate() error {
@@ -9,5 +12,9 @@ func (cmd *ChangeTaskCompletionStatusCommand) Validate() error {
 		return CompletedIsRequired
 	}
 
+	if cmd.Version == nil {
+		return VersionIsRequired
+	}
+
 	return nil
 }
@@ -2,13 +2,30 @@ package dtos
 
 import "time"
 
-func isValidDate(date string) bool {
-	parsedDate, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return false
+const DateLayout = "2006-01-02"
+
+// isValidDueDate accepts a calendar date or an RFC 3339 timestamp. Whether
+// the date is in the past depends on the time zone of the user, so that is
+// checked by the use case.
+func isValidDueDate(date string) bool {
+	if _, err := time.Parse(DateLayout, date); err == nil {
+		return true
+	}
+
+	_, err := time.Parse(time.RFC3339, date)
+	return err == nil
+}
+
+// ValidateTimeZone checks that name is an IANA time zone such as
+// "Europe/Minsk".
+func ValidateTimeZone(name string) error {
+	if name == "" || name == "Local" {
+		return NotValidTimeZone
 	}
 
-	today := time.Now().Truncate(24 * time.Hour)
+	if _, err := time.LoadLocation(name); err != nil {
+		return NotValidTimeZone
+	}
 
-	return !parsedDate.Before(today)
+	return nil
 }
@@ -1,9 +1,15 @@
 package dtos
 
+import "github.com/DanKo-code/TODO-list/internal/models"
+
 type CreateTaskCommand struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	DueDate     string `json:"due_date"`
+	AllDay      *bool  `json:"all_day"`
+	Priority    string `json:"priority"`
+	ProjectId   string `json:"project_id"`
+	Recurrence  string `json:"recurrence"`
 }
 
 func (cmd *CreateTaskCommand) Validate() error {
@@ -21,10 +27,26 @@ func (cmd *CreateTaskCommand) Validate() error {
 	}
 
 	if cmd.DueDate != "" {
-		if !isValidDate(cmd.DueDate) {
+		if !isValidDueDate(cmd.DueDate) {
 			return NotValidDateFormat
 		}
 	}
 
+	if cmd.AllDay != nil && cmd.DueDate == "" {
+		return AllDayRequiresDueDate
+	}
+
+	if cmd.Priority != "" {
+		if _, ok := models.PriorityLevel(cmd.Priority); !ok {
+			return NotValidPriority
+		}
+	}
+
+	if cmd.Recurrence != "" {
+		if _, ok := models.ParseRecurrence(cmd.Recurrence); !ok {
+			return NotValidRecurrence
+		}
+	}
+
 	return nil
 }
@@ -6,7 +6,38 @@ var (
 	TitleIsRequired           = errors.New("title is required")
 	TitleMaxLenExceeded       = errors.New("title cannot exceed 255 characters")
 	DescriptionMaxLenExceeded = errors.New("description cannot exceed 500 characters")
-	NotValidDateFormat        = errors.New("due_date must be in format YYYY-MM-DD and not less than today")
+	NotValidDateFormat        = errors.New("due_date must be a date in format YYYY-MM-DD or an RFC 3339 date-time")
+	AllDayRequiresDueDate     = errors.New("all_day can only be set together with due_date")
+	NotValidTimeZone          = errors.New("time zone must be an IANA time zone name, e.g. Europe/Minsk")
 	NoParamsToUpdate          = errors.New("at least 1 parameter must be set to update")
 	CompletedIsRequired       = errors.New("completed is required")
+	VersionIsRequired         = errors.New("version is required")
+	NotValidPriority          = errors.New("priority must be one of: none, low, medium, high, urgent")
+	NotValidRecurrence        = errors.New("recurrence must be an RRULE with FREQ=DAILY, WEEKLY or MONTHLY and optional INTERVAL, BYDAY, BYMONTHDAY, UNTIL or COUNT")
+	NotValidSort              = errors.New("sort must be one of: smart, priority, due_date, title, created, created_at, updated_at, completed_at")
+	NotValidOrder             = errors.New("order must be asc or desc")
+	NotValidLimit             = errors.New("limit must be between 1 and 100")
+	SearchQueryIsRequired     = errors.New("q is required")
+	SearchQueryMaxLenExceeded = errors.New("q cannot exceed 255 characters")
+	UsernameIsRequired        = errors.New("username is required")
+	NotValidUsername          = errors.New("username must be 3-64 characters of letters, digits, '_', '.' or '-'")
+	PasswordIsRequired        = errors.New("password is required")
+	NotValidEmail             = errors.New("email must be a plain address, e.g. user@example.com")
+	NotValidPassword          = errors.New("password must be between 8 and 72 bytes long")
+	ProjectNameIsRequired     = errors.New("name is required")
+	ProjectNameMaxLenExceeded = errors.New("name cannot exceed 255 characters")
+	ArchivedIsRequired        = errors.New("archived is required")
+	TagNameIsRequired         = errors.New("name is required")
+	NotValidTagName           = errors.New("tag name must be 1-50 letters, digits, '_', '.', ':' or '-'")
+	NotValidTagMode           = errors.New("tag_mode must be any or all")
+	BlockerIdIsRequired       = errors.New("blocker_id is required")
+	ReminderTimeIsRequired    = errors.New("exactly one of remind_at or before is required")
+	NotValidRemindAt          = errors.New("remind_at must be an RFC 3339 date-time")
+	NotValidBefore            = errors.New("before must be a positive duration of whole seconds up to 8784h, e.g. 30m or 24h")
+	WebhookURLIsRequired      = errors.New("url is required")
+	NotValidWebhookURL        = errors.New("url must be an absolute http or https URL of at most 2048 characters")
+	NotValidWebhookSecret     = errors.New("secret must be between 16 and 256 characters long")
+	NotValidEventType         = errors.New("events must be any of: task.created, task.updated, task.completed, task.deleted, task.overdue, task.restored")
+	NotValidUndoCount         = errors.New("count must be between 1 and 20")
+	NotValidDueRange          = errors.New("due_from and due_to must be in format YYYY-MM-DD and due_from must not be after due_to")
 )
@@ -0,0 +1,29 @@
+package dtos
+
+import "github.com/DanKo-code/TODO-list/internal/models"
+
+type GetHistoryQuery struct {
+	Cursor string
+	Limit  int
+
+	// TaskId is set by the use case from the path, ActorId when it reads the
+	// changes of the user.
+	TaskId  string
+	ActorId string
+}
+
+type HistoryPage struct {
+	Entries    []*models.HistoryEntry `json:"entries"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+func (q *GetHistoryQuery) Validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultTasksLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxTasksLimit {
+		return NotValidLimit
+	}
+
+	return nil
+}
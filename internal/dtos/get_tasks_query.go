@@ -0,0 +1,126 @@
+package dtos
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"time"
+)
+
+const (
+	SortBySmart    = "smart"
+	SortByPriority = "priority"
+	SortByDueDate  = "due_date"
+	SortByTitle    = "title"
+	SortByCreated  = "created"
+
+	SortByCreatedAt   = "created_at"
+	SortByUpdatedAt   = "updated_at"
+	SortByCompletedAt = "completed_at"
+
+	TagModeAny = "any"
+	TagModeAll = "all"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	DefaultTasksLimit = 50
+	MaxTasksLimit     = 100
+)
+
+type GetTasksQuery struct {
+	Completed  *bool
+	Overdue    *bool
+	DueFrom    string
+	DueTo      string
+	Title      string
+	Sort       string
+	Order      string
+	Cursor     string
+	Limit      int
+	ProjectId  string
+	ParentId   string
+	SeriesId   string
+	Tags       []string
+	Priorities []string
+	TagMode    string
+
+	// OwnerId is set by the use case from the authenticated user.
+	OwnerId string
+	// DueAfter and DueUntil are the UTC bounds of due_at the use case derives
+	// from DueFrom and DueTo in the time zone of the request.
+	DueAfter string
+	DueUntil string
+}
+
+// SubtaskCounts are the numbers of direct subtasks of a task.
+type SubtaskCounts struct {
+	Total     int
+	Completed int
+}
+
+type TaskPage struct {
+	Tasks      []*models.Task `json:"tasks"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func (q *GetTasksQuery) Validate() error {
+	switch q.Sort {
+	case "":
+		q.Sort = SortBySmart
+	case SortBySmart, SortByPriority, SortByDueDate, SortByTitle, SortByCreated, SortByCreatedAt, SortByUpdatedAt, SortByCompletedAt:
+	default:
+		return NotValidSort
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = OrderAsc
+	case OrderAsc, OrderDesc:
+	default:
+		return NotValidOrder
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultTasksLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxTasksLimit {
+		return NotValidLimit
+	}
+
+	if q.DueFrom != "" {
+		if _, err := time.Parse("2006-01-02", q.DueFrom); err != nil {
+			return NotValidDueRange
+		}
+	}
+	if q.DueTo != "" {
+		if _, err := time.Parse("2006-01-02", q.DueTo); err != nil {
+			return NotValidDueRange
+		}
+	}
+	if q.DueFrom != "" && q.DueTo != "" && q.DueFrom > q.DueTo {
+		return NotValidDueRange
+	}
+
+	switch q.TagMode {
+	case "":
+		q.TagMode = TagModeAny
+	case TagModeAny, TagModeAll:
+	default:
+		return NotValidTagMode
+	}
+	for _, priority := range q.Priorities {
+		if _, ok := models.PriorityLevel(priority); !ok {
+			return NotValidPriority
+		}
+	}
+	for _, tag := range q.Tags {
+		if err := ValidateTagName(tag); err != nil {
+			return err
+		}
+	}
+
+	if len(q.Title) > 255 {
+		return TitleMaxLenExceeded
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package dtos
+
+type CreateProjectCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UpdateProjectCommand struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ArchiveProjectCommand struct {
+	Archived *bool `json:"archived"`
+}
+
+// MoveTaskCommand moves a task into a project; a null project_id takes the
+// task out of its project.
+type MoveTaskCommand struct {
+	ProjectId *string `json:"project_id"`
+	Version   *int64  `json:"version"`
+}
+
+func (cmd *CreateProjectCommand) Validate() error {
+	if cmd.Name == "" {
+		return ProjectNameIsRequired
+	}
+	if len(cmd.Name) > 255 {
+		return ProjectNameMaxLenExceeded
+	}
+
+	if len(cmd.Description) > 500 {
+		return DescriptionMaxLenExceeded
+	}
+
+	return nil
+}
+
+func (cmd *UpdateProjectCommand) Validate() error {
+	if cmd.Name == "" && cmd.Description == "" {
+		return NoParamsToUpdate
+	}
+
+	if len(cmd.Name) > 255 {
+		return ProjectNameMaxLenExceeded
+	}
+
+	if len(cmd.Description) > 500 {
+		return DescriptionMaxLenExceeded
+	}
+
+	return nil
+}
+
+func (cmd *ArchiveProjectCommand) Validate() error {
+	if cmd.Archived == nil {
+		return ArchivedIsRequired
+	}
+
+	return nil
+}
+
+func (cmd *MoveTaskCommand) Validate() error {
+	if cmd.Version == nil {
+		return VersionIsRequired
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package dtos
+
+import "time"
+
+// MaxReminderBefore is how long before the due date a reminder can fire.
+const MaxReminderBefore = 366 * 24 * time.Hour
+
+// CreateReminderCommand sets either an absolute RemindAt or a duration
+// Before the due date of the task, e.g. "30m" or "24h".
+type CreateReminderCommand struct {
+	RemindAt string `json:"remind_at"`
+	Before   string `json:"before"`
+}
+
+func (cmd *CreateReminderCommand) Validate() error {
+	if (cmd.RemindAt == "") == (cmd.Before == "") {
+		return ReminderTimeIsRequired
+	}
+
+	if cmd.RemindAt != "" {
+		if _, err := time.Parse(time.RFC3339, cmd.RemindAt); err != nil {
+			return NotValidRemindAt
+		}
+	}
+
+	if cmd.Before != "" {
+		before, err := time.ParseDuration(cmd.Before)
+		if err != nil || before <= 0 || before > MaxReminderBefore || before%time.Second != 0 {
+			return NotValidBefore
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package dtos
+
+import "github.com/DanKo-code/TODO-list/internal/models"
+
+type SearchTasksQuery struct {
+	Query  string
+	Cursor string
+	Limit  int
+
+	// OwnerId is set by the use case from the authenticated user.
+	OwnerId string
+}
+
+type SearchResult struct {
+	Task           *models.Task `json:"task"`
+	Rank           float64      `json:"rank"`
+	TitleHighlight string       `json:"title_highlight"`
+	Snippet        string       `json:"snippet"`
+}
+
+type SearchPage struct {
+	Results    []*SearchResult `json:"results"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (q *SearchTasksQuery) Validate() error {
+	if q.Query == "" {
+		return SearchQueryIsRequired
+	}
+	if len(q.Query) > 255 {
+		return SearchQueryMaxLenExceeded
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultTasksLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxTasksLimit {
+		return NotValidLimit
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package dtos
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"strings"
+)
+
+// SubscribeCommand selects the tasks whose events a socket subscription
+// receives; empty fields match every task.
+type SubscribeCommand struct {
+	ProjectId  string   `json:"project_id"`
+	Tags       []string `json:"tags"`
+	TagMode    string   `json:"tag_mode"`
+	Priorities []string `json:"priorities"`
+	Completed  *bool    `json:"completed"`
+}
+
+func (cmd *SubscribeCommand) Validate() error {
+	switch cmd.TagMode {
+	case "":
+		cmd.TagMode = TagModeAny
+	case TagModeAny, TagModeAll:
+	default:
+		return NotValidTagMode
+	}
+
+	for _, priority := range cmd.Priorities {
+		if _, ok := models.PriorityLevel(priority); !ok {
+			return NotValidPriority
+		}
+	}
+
+	for _, tag := range cmd.Tags {
+		if err := ValidateTagName(tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasTag(task *models.Task, name string) bool {
+	for _, tag := range task.Tags {
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches reports whether the task passes the filter, the same way
+// GetTasksQuery filters the task list.
+func (cmd *SubscribeCommand) Matches(task *models.Task) bool {
+	if cmd.ProjectId != "" && task.ProjectId != cmd.ProjectId {
+		return false
+	}
+
+	if cmd.Completed != nil && task.Completed != *cmd.Completed {
+		return false
+	}
+
+	if len(cmd.Priorities) > 0 {
+		found := false
+		for _, priority := range cmd.Priorities {
+			found = found || task.Priority == priority
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(cmd.Tags) > 0 {
+		matched := 0
+		for _, tag := range cmd.Tags {
+			if hasTag(task, tag) {
+				matched++
+			}
+		}
+
+		if matched == 0 || (cmd.TagMode == TagModeAll && matched < len(cmd.Tags)) {
+			return false
+		}
+	}
+
+	return true
+}
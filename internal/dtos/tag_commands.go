@@ -0,0 +1,26 @@
+package dtos
+
+import "regexp"
+
+var tagNameRegex = regexp.MustCompile(`^[\p{L}\p{N}_.:-]{1,50}$`)
+
+// TagCommand creates or renames a tag and attaches a tag to a task by name.
+type TagCommand struct {
+	Name string `json:"name"`
+}
+
+func (cmd *TagCommand) Validate() error {
+	if cmd.Name == "" {
+		return TagNameIsRequired
+	}
+
+	return ValidateTagName(cmd.Name)
+}
+
+func ValidateTagName(name string) error {
+	if !tagNameRegex.MatchString(name) {
+		return NotValidTagName
+	}
+
+	return nil
+}
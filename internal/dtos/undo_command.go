@@ -0,0 +1,49 @@
+package dtos
+
+import "github.com/DanKo-code/TODO-list/internal/models"
+
+// MaxUndoCount is how many changes one request can undo or redo.
+const MaxUndoCount = 20
+
+// UndoCommand undoes or redoes the latest Count changes of the user, one by
+// default.
+type UndoCommand struct {
+	Count int `json:"count"`
+}
+
+func (cmd *UndoCommand) Validate() error {
+	if cmd.Count == 0 {
+		cmd.Count = 1
+	}
+	if cmd.Count < 0 || cmd.Count > MaxUndoCount {
+		return NotValidUndoCount
+	}
+
+	return nil
+}
+
+// UndoResult lists the history entries of the reverted changes in the order
+// they were reverted and the tasks as they are afterwards.
+type UndoResult struct {
+	Entries []*models.HistoryEntry `json:"entries"`
+	Tasks   []*models.Task         `json:"tasks"`
+}
+
+// RevertTaskCommand sets Fields of the task to their values in Task, if the
+// task still has the version of Task. Fields are JSON names of task fields;
+// due_date sets all_day and the due time with it, completed sets
+// completed_at.
+type RevertTaskCommand struct {
+	Task   *models.Task
+	Fields []string
+}
+
+// Sets reports whether the command sets the field.
+func (cmd *RevertTaskCommand) Sets(field string) bool {
+	for _, f := range cmd.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,31 @@
 package dtos
 
+import "github.com/DanKo-code/TODO-list/internal/models"
+
 type UpdateTaskCommand struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	DueDate     string `json:"due_date"`
+	AllDay      *bool  `json:"all_day"`
+	Priority    string `json:"priority"`
+	// Recurrence replaces the rule when set, an empty string stops the series.
+	Recurrence *string `json:"recurrence"`
+	Version    *int64  `json:"version"`
+
+	// DueAt is set by the use case when DueDate changes.
+	DueAt string `json:"-"`
 }
 
 func (cmd *UpdateTaskCommand) Validate() error {
 
-	if cmd.Title == "" && cmd.Description == "" && cmd.DueDate == "" {
+	if cmd.Title == "" && cmd.Description == "" && cmd.DueDate == "" && cmd.Priority == "" && cmd.Recurrence == nil {
 		return NoParamsToUpdate
 	}
 
+	if cmd.Version == nil {
+		return VersionIsRequired
+	}
+
 	if cmd.Title != "" {
 		if len(cmd.Title) > 255 {
 			return TitleMaxLenExceeded
@@ -25,10 +39,26 @@ func (cmd *UpdateTaskCommand) Validate() error {
 	}
 
 	if cmd.DueDate != "" {
-		if !isValidDate(cmd.DueDate) {
+		if !isValidDueDate(cmd.DueDate) {
 			return NotValidDateFormat
 		}
 	}
 
+	if cmd.AllDay != nil && cmd.DueDate == "" {
+		return AllDayRequiresDueDate
+	}
+
+	if cmd.Priority != "" {
+		if _, ok := models.PriorityLevel(cmd.Priority); !ok {
+			return NotValidPriority
+		}
+	}
+
+	if cmd.Recurrence != nil && *cmd.Recurrence != "" {
+		if _, ok := models.ParseRecurrence(*cmd.Recurrence); !ok {
+			return NotValidRecurrence
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,94 @@
+package dtos
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"net/url"
+)
+
+type CreateWebhookCommand struct {
+	URL string `json:"url"`
+	// Secret signs the deliveries; one is generated when it is empty.
+	Secret string `json:"secret"`
+	// Events lists the event types to send, all of them when empty.
+	Events []string `json:"events"`
+}
+
+type UpdateWebhookCommand struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+	// Events replaces the event filter when set, an empty list sends all events.
+	Events *[]string `json:"events"`
+}
+
+func validateWebhookURL(raw string) error {
+	if len(raw) > 2048 {
+		return NotValidWebhookURL
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NotValidWebhookURL
+	}
+
+	return nil
+}
+
+func validateWebhookSecret(secret string) error {
+	if len(secret) < 16 || len(secret) > 256 {
+		return NotValidWebhookSecret
+	}
+
+	return nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !events.IsValidType(eventType) {
+			return NotValidEventType
+		}
+	}
+
+	return nil
+}
+
+func (cmd *CreateWebhookCommand) Validate() error {
+	if cmd.URL == "" {
+		return WebhookURLIsRequired
+	}
+
+	if err := validateWebhookURL(cmd.URL); err != nil {
+		return err
+	}
+
+	if cmd.Secret != "" {
+		if err := validateWebhookSecret(cmd.Secret); err != nil {
+			return err
+		}
+	}
+
+	return validateEventTypes(cmd.Events)
+}
+
+func (cmd *UpdateWebhookCommand) Validate() error {
+	if cmd.URL == "" && cmd.Secret == "" && cmd.Events == nil {
+		return NoParamsToUpdate
+	}
+
+	if cmd.URL != "" {
+		if err := validateWebhookURL(cmd.URL); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Secret != "" {
+		if err := validateWebhookSecret(cmd.Secret); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Events != nil {
+		return validateEventTypes(*cmd.Events)
+	}
+
+	return nil
+}
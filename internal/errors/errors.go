@@ -3,5 +3,31 @@ package errors
 import "errors"
 
 var (
-	TaskNotFound = errors.New("task not found")
+	TaskNotFound         = errors.New("task not found")
+	InvalidCursor        = errors.New("cursor is invalid or does not match the requested sort")
+	SearchUnavailable    = errors.New("full-text search is not available in this build")
+	ProjectNotFound      = errors.New("project not found")
+	ProjectArchived      = errors.New("project is archived")
+	ProjectHasTasks      = errors.New("project still has tasks, delete them with cascade=true or move them first")
+	SubtaskDepthExceeded = errors.New("subtasks cannot be nested deeper")
+	ParentTaskCompleted  = errors.New("cannot add a subtask to a completed task, reopen it first")
+	ParentTaskDeleted    = errors.New("parent task is in the trash, restore it first")
+	BlockerNotFound      = errors.New("blocker task not found")
+	DependencyCycle      = errors.New("dependency would create a cycle")
+	TaskBlocked          = errors.New("task is blocked by open tasks, complete them first or set force")
+	DueDateInPast        = errors.New("due date is already over")
+	ReminderNotFound     = errors.New("reminder not found")
+	ReminderInPast       = errors.New("reminder time is already over")
+	TaskHasNoDueDate     = errors.New("task has no due date to remind before")
+	WebhookNotFound      = errors.New("webhook not found")
+	TagNotFound          = errors.New("tag not found")
+	TagAlreadyExists     = errors.New("tag with this name already exists")
+	UserNotFound         = errors.New("user not found")
+	UserAlreadyExists    = errors.New("user with this username already exists")
+	InvalidCredentials   = errors.New("invalid username or password")
+	Unauthorized         = errors.New("authentication token is missing, invalid or expired")
+	VersionConflict      = errors.New("task was modified concurrently, expected version does not match")
+	NothingToUndo        = errors.New("there are no changes to undo")
+	NothingToRedo        = errors.New("there are no undone changes to redo")
+	UndoConflict         = errors.New("task was changed since the change to revert")
 )
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is an event as delivered by a Bus. Id increases with every event
+// and is only meaningful to the bus that assigned it.
+type Message struct {
+	Id    string
+	Event *Event
+
+	seq uint64
+}
+
+// Subscription receives the events of one owner from a Bus.
+type Subscription struct {
+	// C receives the events published after Subscribe. It is closed when the
+	// subscription or the bus is closed, or when the subscriber fell so far
+	// behind that the bus dropped it; it should then subscribe again with
+	// the id of the last message it handled.
+	C <-chan Message
+	// Missed holds the kept events of the owner published after the id
+	// passed to Subscribe.
+	Missed []Message
+	// Lost reports that events after that id are no longer kept, e.g.
+	// because the server restarted, so the subscriber should reload what it
+	// shows. LastId is then the id to resume from later.
+	Lost   bool
+	LastId string
+
+	c       chan Message
+	ownerId string
+	bus     *Bus
+}
+
+// Close stops the subscription; it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.drop(s)
+}
+
+// Bus fans events out to the subscribers in this process. It keeps the last
+// events so that a subscriber that reconnects can resume where it stopped.
+type Bus struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	history     []Message
+	historySize int
+	bufferSize  int
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+// NewBus returns a bus that keeps the last historySize events and drops
+// subscribers with more than bufferSize events waiting.
+func NewBus(historySize, bufferSize int) *Bus {
+	return &Bus{
+		// The epoch makes ids of an earlier run of the server unknown
+		// instead of pointing at unrelated events.
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+func (b *Bus) id(seq uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// Publish sends the event to the subscribers of its owner without waiting for
+// them.
+func (b *Bus) Publish(ctx context.Context, event *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	message := Message{Id: b.id(b.seq), Event: event, seq: b.seq}
+
+	b.history = append(b.history, message)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		if sub.ownerId != event.OwnerId {
+			continue
+		}
+
+		select {
+		case sub.c <- message:
+		default:
+			b.drop(sub)
+		}
+	}
+}
+
+// Subscribe starts receiving the events of ownerId. With a lastId from an
+// earlier subscription the events published since then are put into Missed.
+func (b *Bus) Subscribe(ownerId, lastId string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Message, b.bufferSize)
+	sub := &Subscription{C: c, c: c, ownerId: ownerId, bus: b, LastId: b.id(b.seq)}
+
+	if b.closed {
+		close(c)
+		return sub
+	}
+	b.subscribers[sub] = true
+
+	if lastId == "" {
+		return sub
+	}
+
+	seq, ok := b.parseId(lastId)
+	if !ok || seq > b.seq || (len(b.history) > 0 && seq+1 < b.history[0].seq) {
+		sub.Lost = true
+		return sub
+	}
+
+	for _, message := range b.history {
+		if message.seq > seq && message.Event.OwnerId == ownerId {
+			sub.Missed = append(sub.Missed, message)
+		}
+	}
+
+	return sub
+}
+
+func (b *Bus) parseId(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// drop removes the subscriber and closes its channel; b.mu must be held.
+func (b *Bus) drop(sub *Subscription) {
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.c)
+	}
+}
+
+// Close ends all subscriptions, e.g. on shutdown; later events are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.drop(sub)
+	}
+}
+
+// Fanout returns a publisher that passes every event to all publishers in
+// order.
+func Fanout(publishers ...Publisher) Publisher {
+	return fanout(publishers)
+}
+
+type fanout []Publisher
+
+func (f fanout) Publish(ctx context.Context, event *Event) {
+	for _, publisher := range f {
+		publisher.Publish(ctx, event)
+	}
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func publish(bus *Bus, ownerId, taskId string) {
+	bus.Publish(context.Background(), &Event{Id: taskId, Type: TaskUpdated, OwnerId: ownerId})
+}
+
+func received(sub *Subscription) []string {
+	ids := []string{}
+	for {
+		select {
+		case message, ok := <-sub.C:
+			if !ok {
+				return append(ids, "closed")
+			}
+			ids = append(ids, message.Event.Id)
+		default:
+			return ids
+		}
+	}
+}
+
+func eventIds(messages []Message) []string {
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.Event.Id)
+	}
+	return ids
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus(3, 2)
+
+	alice := bus.Subscribe("alice", "")
+	bob := bus.Subscribe("bob", "")
+
+	publish(bus, "alice", "1")
+	publish(bus, "bob", "2")
+
+	if got := received(alice); !equal(got, []string{"1"}) {
+		t.Errorf("expected alice to receive only her events, got %v", got)
+	}
+	if got := received(bob); !equal(got, []string{"2"}) {
+		t.Errorf("expected bob to receive only his events, got %v", got)
+	}
+
+	// A subscriber that does not keep up is dropped instead of blocking.
+	publish(bus, "alice", "3")
+	publish(bus, "alice", "4")
+	publish(bus, "alice", "5")
+	if got := received(alice); !equal(got, []string{"3", "4", "closed"}) {
+		t.Errorf("expected a slow subscriber to be dropped, got %v", got)
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		sub := bus.Subscribe("alice", bus.id(3))
+		defer sub.Close()
+
+		if sub.Lost || !equal(eventIds(sub.Missed), []string{"4", "5"}) {
+			t.Errorf("expected the events after the id, got %v, lost: %v", eventIds(sub.Missed), sub.Lost)
+		}
+	})
+
+	t.Run("resume from the current id", func(t *testing.T) {
+		sub := bus.Subscribe("alice", bus.id(5))
+		defer sub.Close()
+
+		if sub.Lost || len(sub.Missed) != 0 {
+			t.Errorf("expected nothing to be missed, got %v, lost: %v", eventIds(sub.Missed), sub.Lost)
+		}
+	})
+
+	for name, id := range map[string]string{
+		"no longer kept":    bus.id(1),
+		"from another run":  "abc-4",
+		"from the future":   bus.id(9),
+		"not an id of ours": "42",
+	} {
+		t.Run(name, func(t *testing.T) {
+			sub := bus.Subscribe("alice", id)
+			defer sub.Close()
+
+			if !sub.Lost || len(sub.Missed) != 0 || sub.LastId != bus.id(5) {
+				t.Errorf("expected events to be lost since %s, got %+v", id, sub)
+			}
+		})
+	}
+
+	t.Run("close", func(t *testing.T) {
+		sub := bus.Subscribe("bob", "")
+		sub.Close()
+		sub.Close()
+
+		bus.Close()
+		publish(bus, "bob", "6")
+
+		if got := received(bob); !equal(got, []string{"closed"}) {
+			t.Errorf("expected closing the bus to end subscriptions, got %v", got)
+		}
+		if got := received(bus.Subscribe("bob", "")); !equal(got, []string{"closed"}) {
+			t.Errorf("expected subscriptions to a closed bus to be closed, got %v", got)
+		}
+	})
+}
+
+func TestFanout(t *testing.T) {
+	first, second := NewBus(1, 1), NewBus(1, 1)
+	a, b := first.Subscribe("alice", ""), second.Subscribe("alice", "")
+
+	Fanout(first, second).Publish(context.Background(), &Event{Id: "1", OwnerId: "alice"})
+
+	if !equal(received(a), []string{"1"}) || !equal(received(b), []string{"1"}) {
+		t.Error("expected the event to reach every publisher")
+	}
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+const (
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"
+	TaskCompleted = "task.completed"
+	TaskDeleted   = "task.deleted"
+	TaskOverdue   = "task.overdue"
+	TaskRestored  = "task.restored"
+)
+
+// Types lists every event type in the order they are documented.
+var Types = []string{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskOverdue, TaskRestored}
+
+func IsValidType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event describes a change of a task. Task is the state after the change, or
+// the last state for deleted tasks.
+type Event struct {
+	Id         string       `json:"id"`
+	Type       string       `json:"type"`
+	OccurredAt string       `json:"occurred_at"`
+	OwnerId    string       `json:"owner_id"`
+	Task       *models.Task `json:"task"`
+}
+
+// Publisher receives events after the change is saved. Publishing must not
+// fail the change, so publishers handle their errors themselves.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event)
+}
+
+// Discard drops all events; it is the publisher until another one is set.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(ctx context.Context, event *Event) {}
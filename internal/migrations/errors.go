@@ -0,0 +1,12 @@
+package migrations
+
+import "errors"
+
+var (
+	InvalidFileName   = errors.New("migration file name must match NNNN_name.up.sql or NNNN_name.down.sql")
+	DuplicateVersion  = errors.New("migration version is used by more than one migration")
+	MissingDirection  = errors.New("migration must have both up and down files")
+	UnknownMigration  = errors.New("database has an applied migration that is not known to this build")
+	ChecksumMismatch  = errors.New("applied migration was modified after it had been applied")
+	DisabledMigration = errors.New("database has an applied migration that this build cannot run")
+)
@@ -0,0 +1,342 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	disabled   map[int]string
+	dryRun     bool
+}
+
+// Load reads migrations from the root of fsys. Every migration is a pair of
+// files named NNNN_name.up.sql and NNNN_name.down.sql; versions must be unique
+// and are applied in ascending order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", InvalidFileName, entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: %d", DuplicateVersion, version)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	var result []Migration
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s", MissingDirection, m.Version, m.Name)
+		}
+
+		m.Checksum = checksum(m.Up, m.Down)
+
+		result = append(result, *m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// checksum covers both directions of a migration, so editing either one of
+// them after it has been applied is detected.
+func checksum(up, down string) string {
+	sum := sha256.Sum256([]byte(up + "\x00" + down))
+	return hex.EncodeToString(sum[:])
+}
+
+// upChecksum is the checksum older versions recorded, which covered only the
+// up migration.
+func upChecksum(up string) string {
+	sum := sha256.Sum256([]byte(up))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		disabled:   make(map[int]string),
+	}, nil
+}
+
+// SetDryRun makes Up and Down only log the migrations they would run without
+// touching the database.
+func (m *Migrator) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
+// Disable keeps a migration that needs something this build lacks from being
+// applied. It stays known, so a database another build applied it to is
+// reported with the reason rather than as having an unknown migration.
+func (m *Migrator) Disable(version int, reason string) {
+	m.disabled[version] = reason
+}
+
+// Up applies all pending migrations, each in its own transaction.
+func (m *Migrator) Up(ctx context.Context) error {
+	applied, err := m.verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if _, ok := m.disabled[migration.Version]; ok {
+			continue
+		}
+
+		if m.dryRun {
+			logger.InfoLogger.Printf("[dry-run] would apply migration %04d_%s:\n%s", migration.Version, migration.Name, migration.Up)
+			continue
+		}
+
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)`,
+				migration.Version, migration.Name, migration.Checksum, time.Now().UTC().Format(time.RFC3339),
+			)
+			return err
+		})
+		if err != nil {
+			logger.ErrorLogger.Printf("Failed to apply migration %04d_%s: %v", migration.Version, migration.Name, err)
+			return fmt.Errorf("apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		logger.InfoLogger.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+// Down reverts the last steps applied migrations in reverse order.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	applied, err := m.verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := m.migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		steps--
+
+		if m.dryRun {
+			logger.InfoLogger.Printf("[dry-run] would revert migration %04d_%s:\n%s", migration.Version, migration.Name, migration.Down)
+			continue
+		}
+
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err
+		})
+		if err != nil {
+			logger.ErrorLogger.Printf("Failed to revert migration %04d_%s: %v", migration.Version, migration.Name, err)
+			return fmt.Errorf("revert migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		logger.InfoLogger.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.verify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Status
+	for _, migration := range m.migrations {
+		if _, ok := m.disabled[migration.Version]; ok {
+			continue
+		}
+
+		appliedAt, ok := applied[migration.Version]
+
+		result = append(result, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// verify makes sure the schema_migrations table exists and that every applied
+// migration is still known, enabled and unchanged. It returns applied_at by version.
+// Checksums recorded by older versions are brought up to date on the way. In
+// dry-run mode all of it is rolled back, so the database is left untouched.
+func (m *Migrator) verify(ctx context.Context) (map[int]string, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to create schema_migrations table: %v", err)
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to read schema_migrations: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]Migration)
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	applied := make(map[int]string)
+	var outdated []Migration
+	for rows.Next() {
+		var version int
+		var checksum, appliedAt string
+
+		if err := rows.Scan(&version, &checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", UnknownMigration, version)
+		}
+		if reason, ok := m.disabled[version]; ok {
+			return nil, fmt.Errorf("%w: %04d_%s: %s", DisabledMigration, version, migration.Name, reason)
+		}
+		if migration.Checksum != checksum {
+			if upChecksum(migration.Up) != checksum {
+				return nil, fmt.Errorf("%w: %04d_%s", ChecksumMismatch, version, migration.Name)
+			}
+			outdated = append(outdated, migration)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, migration := range outdated {
+		_, err := tx.ExecContext(ctx, `UPDATE schema_migrations SET checksum = $1 WHERE version = $2`, migration.Checksum, migration.Version)
+		if err != nil {
+			logger.ErrorLogger.Printf("Failed to update checksum of migration %04d_%s: %v", migration.Version, migration.Name, err)
+			return nil, err
+		}
+	}
+
+	if m.dryRun {
+		return applied, nil
+	}
+
+	return applied, tx.Commit()
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
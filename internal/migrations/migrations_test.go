@@ -0,0 +1,327 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	_ "github.com/mattn/go-sqlite3"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func testFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_items.up.sql":   {Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)},
+		"0001_create_items.down.sql": {Data: []byte(`DROP TABLE items;`)},
+		"0002_add_name.up.sql":       {Data: []byte(`ALTER TABLE items ADD COLUMN name TEXT;`)},
+		"0002_add_name.down.sql":     {Data: []byte(`ALTER TABLE items DROP COLUMN name;`)},
+	}
+}
+
+func appliedVersions(t *testing.T, m *Migrator) []int {
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []int
+	for _, status := range statuses {
+		if status.Applied {
+			versions = append(versions, status.Version)
+		}
+	}
+
+	return versions
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       fstest.MapFS
+		expectedErr error
+	}{
+		{
+			name:  "success",
+			files: testFiles(),
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"create_items.sql": {Data: []byte(`SELECT 1;`)},
+			},
+			expectedErr: InvalidFileName,
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"0001_create_items.up.sql": {Data: []byte(`SELECT 1;`)},
+			},
+			expectedErr: MissingDirection,
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"0001_a.up.sql":   {Data: []byte(`SELECT 1;`)},
+				"0001_a.down.sql": {Data: []byte(`SELECT 1;`)},
+				"0001_b.up.sql":   {Data: []byte(`SELECT 1;`)},
+				"0001_b.down.sql": {Data: []byte(`SELECT 1;`)},
+			},
+			expectedErr: DuplicateVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	m, err := NewMigrator(db, testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 2 {
+		t.Fatalf("expected 2 applied migrations, got %v", versions)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id, name) VALUES (1, 'a')`); err != nil {
+		t.Fatalf("expected schema to be migrated: %v", err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("expected repeated up to be a no-op: %v", err)
+	}
+
+	if err := m.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 1 || versions[0] != 1 {
+		t.Fatalf("expected only migration 1 applied, got %v", versions)
+	}
+
+	if err := m.Down(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 0 {
+		t.Fatalf("expected no applied migrations, got %v", versions)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	m, err := NewMigrator(db, testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetDryRun(true)
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name IN ('items', 'schema_migrations')`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected dry-run not to create tables, found %d", count)
+	}
+}
+
+func TestChecksumVerification(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	m, err := NewMigrator(db, testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := testFiles()
+	changed["0002_add_name.up.sql"] = &fstest.MapFile{Data: []byte(`ALTER TABLE items ADD COLUMN title TEXT;`)}
+
+	m, err = NewMigrator(db, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); !errors.Is(err, ChecksumMismatch) {
+		t.Errorf("expected %v, got %v", ChecksumMismatch, err)
+	}
+
+	changed = testFiles()
+	changed["0002_add_name.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE items;`)}
+
+	m, err = NewMigrator(db, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); !errors.Is(err, ChecksumMismatch) {
+		t.Errorf("expected %v for a changed down migration, got %v", ChecksumMismatch, err)
+	}
+
+	removed := testFiles()
+	delete(removed, "0002_add_name.up.sql")
+	delete(removed, "0002_add_name.down.sql")
+
+	m, err = NewMigrator(db, removed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); !errors.Is(err, UnknownMigration) {
+		t.Errorf("expected %v, got %v", UnknownMigration, err)
+	}
+}
+
+func TestOlderChecksumIsUpdated(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	m, err := NewMigrator(db, testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older versions recorded a checksum of the up migration only.
+	first := m.migrations[0]
+	if _, err := db.Exec(`UPDATE schema_migrations SET checksum = $1 WHERE version = $2`, upChecksum(first.Up), first.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	if versions := appliedVersions(t, m); len(versions) != 2 {
+		t.Fatalf("expected the migrations to stay applied, got %v", versions)
+	}
+
+	var checksum string
+	if err := db.QueryRow(`SELECT checksum FROM schema_migrations WHERE version = $1`, first.Version).Scan(&checksum); err != nil {
+		t.Fatal(err)
+	}
+	if checksum != first.Checksum {
+		t.Errorf("expected the checksum to be updated to %s, got %s", first.Checksum, checksum)
+	}
+}
+
+func TestDryRunReportsUnreadableHistory(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(db, testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetDryRun(true)
+
+	if err := m.Up(ctx); err == nil {
+		t.Error("expected the schema_migrations query error")
+	}
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	files := testFiles()
+	files["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE other (id INTEGER); INSERT INTO missing VALUES (1);`)}
+	files["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE other;`)}
+
+	m, err := NewMigrator(db, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err == nil {
+		t.Fatal("expected broken migration to fail")
+	}
+
+	if versions := appliedVersions(t, m); len(versions) != 2 {
+		t.Errorf("expected migrations before the broken one to stay applied, got %v", versions)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'other'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Error("expected partial changes of the broken migration to be rolled back")
+	}
+}
+
+func TestDisabledMigrationAcrossBuilds(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	files := testFiles()
+	files["0003_create_index.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE item_index (id INTEGER);`)}
+	files["0003_create_index.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE item_index;`)}
+
+	// A build that lacks what the migration needs leaves it out.
+	m, err := NewMigrator(db, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Disable(3, "built without the index")
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); !reflect.DeepEqual(versions, []int{1, 2}) {
+		t.Errorf("expected the disabled migration to be left out, got %v", versions)
+	}
+
+	// A build that has it catches up.
+	m, err = NewMigrator(db, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); !reflect.DeepEqual(versions, []int{1, 2, 3}) {
+		t.Errorf("expected the migration to be applied, got %v", versions)
+	}
+
+	// The first build then knows why it cannot use the database.
+	m, err = NewMigrator(db, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Disable(3, "built without the index")
+	err = m.Up(ctx)
+	if !errors.Is(err, DisabledMigration) || !strings.Contains(err.Error(), "0003_create_index: built without the index") {
+		t.Errorf("expected %v with the reason, got %v", DisabledMigration, err)
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Actions of history entries.
+const (
+	HistoryCreated   = "created"
+	HistoryUpdated   = "updated"
+	HistoryCompleted = "completed"
+	HistoryReopened  = "reopened"
+	HistoryDeleted   = "deleted"
+	HistoryRestored  = "restored"
+	HistoryOverdue   = "overdue"
+	HistoryUndone    = "undone"
+	HistoryRedone    = "redone"
+)
+
+// ActorSystem is the actor of changes the server makes on its own, such as
+// flagging overdue tasks.
+const ActorSystem = "system"
+
+// HistoryEntry records one change of a task: who made it, when, and the
+// fields it changed. Version is the version of the task after the change.
+// Entries of undone and redone changes refer to the entry they revert, and
+// entries of the other tasks a change reached refer to the entry of the task
+// it was made to as their cause.
+type HistoryEntry struct {
+	Id        string        `json:"id"`
+	TaskId    string        `json:"task_id"`
+	Action    string        `json:"action"`
+	ActorId   string        `json:"actor_id"`
+	Changes   []FieldChange `json:"changes"`
+	Version   int64         `json:"version"`
+	Reverts   string        `json:"reverts,omitempty"`
+	Cause     string        `json:"cause,omitempty"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// FieldChange holds the JSON values of a task field before and after a
+// change; Before is null for created tasks.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// historyFields are the fields of a task the history tracks, by their JSON
+// names. Versions, timestamps of the row and values derived from other
+// tasks are left out.
+var historyFields = []struct {
+	name  string
+	value func(task *Task) interface{}
+}{
+	{"title", func(task *Task) interface{} { return task.Title }},
+	{"description", func(task *Task) interface{} { return task.Description }},
+	{"due_date", func(task *Task) interface{} { return task.DueDate }},
+	{"all_day", func(task *Task) interface{} { return task.AllDay }},
+	{"overdue", func(task *Task) interface{} { return task.Overdue }},
+	{"completed", func(task *Task) interface{} { return task.Completed }},
+	{"completed_at", func(task *Task) interface{} { return task.CompletedAt }},
+	{"priority", func(task *Task) interface{} { return task.Priority }},
+	{"project_id", func(task *Task) interface{} { return task.ProjectId }},
+	{"parent_id", func(task *Task) interface{} { return task.ParentId }},
+	{"recurrence", func(task *Task) interface{} { return task.Recurrence }},
+	{"tags", func(task *Task) interface{} { return append([]string{}, task.Tags...) }},
+	{"blocked_by", func(task *Task) interface{} { return append([]string{}, task.BlockedBy...) }},
+	{"deleted_at", func(task *Task) interface{} { return task.DeletedAt }},
+}
+
+// DiffTasks returns the tracked fields that differ between the two states of
+// a task. With before nil it returns the fields a new task has set.
+func DiffTasks(before, after *Task) []FieldChange {
+	changes := []FieldChange{}
+
+	for _, field := range historyFields {
+		afterValue, _ := json.Marshal(field.value(after))
+
+		if before == nil {
+			empty, _ := json.Marshal(field.value(&Task{}))
+			if !bytes.Equal(afterValue, empty) {
+				changes = append(changes, FieldChange{Field: field.name, Before: json.RawMessage("null"), After: afterValue})
+			}
+			continue
+		}
+
+		beforeValue, _ := json.Marshal(field.value(before))
+		if !bytes.Equal(beforeValue, afterValue) {
+			changes = append(changes, FieldChange{Field: field.name, Before: beforeValue, After: afterValue})
+		}
+	}
+
+	return changes
+}
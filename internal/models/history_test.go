@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffTasks(t *testing.T) {
+	before := &Task{Id: "1", Title: "Task", DueDate: "2024-11-22", Priority: PriorityNone, Tags: []string{"bug"}, Version: 1}
+
+	tests := []struct {
+		name     string
+		before   *Task
+		after    *Task
+		expected string
+	}{
+		{
+			name:     "created",
+			after:    before,
+			expected: `[{"field":"title","before":null,"after":"Task"},{"field":"due_date","before":null,"after":"2024-11-22"},{"field":"priority","before":null,"after":"none"},{"field":"tags","before":null,"after":["bug"]}]`,
+		},
+		{
+			name:     "changed fields only",
+			before:   before,
+			after:    &Task{Id: "1", Title: "Renamed", DueDate: "2024-11-22", Priority: PriorityNone, Completed: true, Version: 2},
+			expected: `[{"field":"title","before":"Task","after":"Renamed"},{"field":"completed","before":false,"after":true},{"field":"tags","before":["bug"],"after":[]}]`,
+		},
+		{
+			name:     "untracked fields",
+			before:   before,
+			after:    &Task{Id: "1", Title: "Task", DueDate: "2024-11-22", Priority: PriorityNone, Tags: []string{"bug"}, Version: 5, UpdatedAt: "2024-11-22T10:00:00Z"},
+			expected: `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, _ := json.Marshal(DiffTasks(tt.before, tt.after))
+			if string(changes) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, changes)
+			}
+		})
+	}
+}
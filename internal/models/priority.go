@@ -0,0 +1,33 @@
+package models
+
+const (
+	PriorityNone   = "none"
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// priorities are ordered by level, the index is what gets stored.
+var priorities = []string{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// PriorityLevel returns the numeric level of a priority name, higher is more
+// important.
+func PriorityLevel(name string) (int, bool) {
+	for level, priority := range priorities {
+		if priority == name {
+			return level, true
+		}
+	}
+
+	return 0, false
+}
+
+// PriorityName returns the name of a level, unknown levels are treated as none.
+func PriorityName(level int) string {
+	if level < 0 || level >= len(priorities) {
+		return PriorityNone
+	}
+
+	return priorities[level]
+}
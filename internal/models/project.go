@@ -0,0 +1,11 @@
+package models
+
+type Project struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Archived    bool   `json:"archived"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	OwnerId     string `json:"owner_id,omitempty"`
+}
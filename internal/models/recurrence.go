@@ -0,0 +1,208 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+)
+
+// weekdays are the BYDAY names in time.Weekday order.
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is the subset of an iCalendar RRULE that tasks support, e.g.
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10". Until is a YYYY-MM-DD date.
+type Recurrence struct {
+	Frequency string
+	Interval  int
+	Weekdays  []time.Weekday
+	MonthDay  int
+	Until     string
+	Count     int
+}
+
+// ParseRecurrence parses an RRULE string. BYDAY is only allowed with WEEKLY,
+// BYMONTHDAY only with MONTHLY, and UNTIL and COUNT exclude each other.
+func ParseRecurrence(rule string) (*Recurrence, bool) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+
+	r := &Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" || seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Frequency = value
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if r.Interval < 1 || r.Interval > 366 {
+				return nil, false
+			}
+		case "BYDAY":
+			for _, name := range strings.Split(value, ",") {
+				day, ok := parseWeekday(name)
+				if !ok {
+					return nil, false
+				}
+				r.Weekdays = append(r.Weekdays, day)
+			}
+		case "BYMONTHDAY":
+			r.MonthDay, err = strconv.Atoi(value)
+			if r.MonthDay < 1 || r.MonthDay > 31 {
+				return nil, false
+			}
+		case "UNTIL":
+			until, parseErr := time.Parse("20060102", strings.ReplaceAll(value, "-", ""))
+			if parseErr != nil {
+				return nil, false
+			}
+			r.Until = until.Format("2006-01-02")
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if r.Count < 1 {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		if len(r.Weekdays) > 0 || r.MonthDay > 0 {
+			return nil, false
+		}
+	case FrequencyWeekly:
+		if r.MonthDay > 0 {
+			return nil, false
+		}
+	case FrequencyMonthly:
+		if len(r.Weekdays) > 0 {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	if r.Until != "" && r.Count > 0 {
+		return nil, false
+	}
+
+	return r, true
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day, weekday := range weekdays {
+		if weekday == name {
+			return time.Weekday(day), true
+		}
+	}
+
+	return 0, false
+}
+
+// String formats the rule in a canonical form, so equal rules compare equal.
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Frequency}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.Weekdays) > 0 {
+		var names []string
+		// Monday first, as the weeks are counted.
+		for i := 1; i <= 7; i++ {
+			if r.hasWeekday(time.Weekday(i % 7)) {
+				names = append(names, weekdays[i%7])
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if r.MonthDay > 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.MonthDay))
+	}
+	if r.Until != "" {
+		parts = append(parts, "UNTIL="+strings.ReplaceAll(r.Until, "-", ""))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func (r *Recurrence) hasWeekday(day time.Weekday) bool {
+	for _, weekday := range r.Weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the first date of the series after date. Weeks start on
+// Monday; a monthly rule without BYMONTHDAY keeps the day of date, and days
+// past the end of a short month fall on its last day.
+func (r *Recurrence) Next(date time.Time) time.Time {
+	switch r.Frequency {
+	case FrequencyWeekly:
+		if len(r.Weekdays) == 0 {
+			return date.AddDate(0, 0, 7*r.Interval)
+		}
+
+		offset := (int(date.Weekday()) + 6) % 7
+		for d := offset + 1; d < 7; d++ {
+			if r.hasWeekday(time.Weekday((d + 1) % 7)) {
+				return date.AddDate(0, 0, d-offset)
+			}
+		}
+
+		monday := date.AddDate(0, 0, 7*r.Interval-offset)
+		for d := 0; d < 7; d++ {
+			if r.hasWeekday(time.Weekday((d + 1) % 7)) {
+				return monday.AddDate(0, 0, d)
+			}
+		}
+
+		return monday
+	case FrequencyMonthly:
+		day := r.MonthDay
+		if day == 0 {
+			day = date.Day()
+		}
+
+		if next := monthDay(date.Year(), date.Month(), day, date.Location()); next.After(date) {
+			return next
+		}
+
+		return monthDay(date.Year(), date.Month()+time.Month(r.Interval), day, date.Location())
+	default:
+		return date.AddDate(0, 0, r.Interval)
+	}
+}
+
+func monthDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
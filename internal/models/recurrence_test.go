@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+		ok       bool
+	}{
+		{rule: "FREQ=DAILY", expected: "FREQ=DAILY", ok: true},
+		{rule: "rrule:freq=daily;interval=3", expected: "FREQ=DAILY;INTERVAL=3", ok: true},
+		{rule: "FREQ=WEEKLY;BYDAY=FR,MO;COUNT=4", expected: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=4", ok: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=2025-06-30", expected: "FREQ=MONTHLY;BYMONTHDAY=31;UNTIL=20250630", ok: true},
+		{rule: ""},
+		{rule: "FREQ=YEARLY"},
+		{rule: "FREQ=DAILY;INTERVAL=0"},
+		{rule: "FREQ=DAILY;BYDAY=MO"},
+		{rule: "FREQ=WEEKLY;BYDAY=XX"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20250101"},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			recurrence, ok := ParseRecurrence(tt.rule)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if ok && recurrence.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, recurrence.String())
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rule     string
+		date     string
+		expected string
+	}{
+		{rule: "FREQ=DAILY", date: "2024-12-31", expected: "2025-01-01"},
+		{rule: "FREQ=DAILY;INTERVAL=10", date: "2024-11-25", expected: "2024-12-05"},
+		{rule: "FREQ=WEEKLY", date: "2024-11-25", expected: "2024-12-02"},
+		// 2024-11-25 is a Monday.
+		{rule: "FREQ=WEEKLY;BYDAY=MO,WE", date: "2024-11-25", expected: "2024-11-27"},
+		{rule: "FREQ=WEEKLY;BYDAY=MO,WE", date: "2024-11-27", expected: "2024-12-02"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", date: "2024-12-01", expected: "2024-12-09"},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", date: "2024-11-25", expected: "2024-12-01"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=15", date: "2024-11-05", expected: "2024-11-15"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", date: "2025-01-31", expected: "2025-02-28"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", date: "2025-02-28", expected: "2025-03-31"},
+		{rule: "FREQ=MONTHLY;INTERVAL=3", date: "2024-11-20", expected: "2025-02-20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" after "+tt.date, func(t *testing.T) {
+			recurrence, ok := ParseRecurrence(tt.rule)
+			if !ok {
+				t.Fatalf("expected %s to be valid", tt.rule)
+			}
+
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := recurrence.Next(date).Format("2006-01-02"); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	// ReminderSkipped reminders were due when their task was already
+	// completed or there was nobody to notify.
+	ReminderSkipped = "skipped"
+	ReminderFailed  = "failed"
+)
+
+// Reminder notifies the owner of a task once, either at RemindAt or Before
+// the task is due. FireAt is the resolved UTC time it is sent at.
+type Reminder struct {
+	Id        string `json:"id"`
+	TaskId    string `json:"task_id"`
+	RemindAt  string `json:"remind_at,omitempty"`
+	Before    string `json:"before,omitempty"`
+	FireAt    string `json:"fire_at"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	SentAt    string `json:"sent_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+	OwnerId   string `json:"owner_id"`
+}
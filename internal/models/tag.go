@@ -0,0 +1,8 @@
+package models
+
+type Tag struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at,omitempty"`
+	OwnerId   string `json:"owner_id,omitempty"`
+}
@@ -1,10 +1,28 @@
 package models
 
 type Task struct {
-	Id          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date"`
-	Overdue     bool   `json:"overdue"`
-	Completed   bool   `json:"completed"`
+	Id          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	DueDate     string   `json:"due_date"`
+	AllDay      bool     `json:"all_day,omitempty"`
+	DueAt       string   `json:"-"`
+	Overdue     bool     `json:"overdue"`
+	Completed   bool     `json:"completed"`
+	Priority    string   `json:"priority,omitempty"`
+	Version     int64    `json:"version"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	CompletedAt string   `json:"completed_at,omitempty"`
+	ProjectId   string   `json:"project_id,omitempty"`
+	ParentId    string   `json:"parent_id,omitempty"`
+	Progress    *int     `json:"progress,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+	SeriesId    string   `json:"series_id,omitempty"`
+	Occurrence  int      `json:"occurrence,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	BlockedBy   []string `json:"blocked_by,omitempty"`
+	Blocked     bool     `json:"blocked,omitempty"`
+	OwnerId     string   `json:"owner_id,omitempty"`
+	DeletedAt   string   `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,11 @@
+package models
+
+type User struct {
+	Id           string `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	CreatedAt    string `json:"created_at"`
+	TimeZone     string `json:"time_zone"`
+	// Email receives reminders when they are sent by mail.
+	Email string `json:"email,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "encoding/json"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook subscribes URL to the task events of its owner. Events lists the
+// event types to send, all of them when empty. Secret signs the deliveries;
+// it is only shown when it is set.
+type Webhook struct {
+	Id        string   `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	OwnerId   string   `json:"owner_id"`
+}
+
+// Subscribes reports whether the webhook wants events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a webhook.
+type WebhookDelivery struct {
+	Id             string          `json:"id"`
+	WebhookId      string          `json:"webhook_id"`
+	EventId        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package notifier
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+// LogNotifier writes notifications to the info log. It is the default when
+// no other notifier is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	logger.InfoLogger.Printf("Reminder %s for user %s: %s", notification.Reminder.Id, notification.User.Username, notification.Text())
+	return nil
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+// ErrNoRecipient is returned when the user has no address the notifier can
+// deliver to; such reminders are not retried.
+var ErrNoRecipient = errors.New("user has no address to notify")
+
+// Notification is delivered when a reminder fires.
+type Notification struct {
+	Reminder *models.Reminder `json:"reminder"`
+	Task     *models.Task     `json:"task"`
+	User     *models.User     `json:"user"`
+}
+
+// Notifier delivers notifications to users. Notify returns an error when the
+// notification may not have been delivered, so that it is retried.
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+func (n *Notification) Subject() string {
+	return "Reminder: " + n.Task.Title
+}
+
+func (n *Notification) Text() string {
+	return fmt.Sprintf("Task %q is due %s.", n.Task.Title, n.Task.DueDate)
+}
@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testNotification(email string) *Notification {
+	return &Notification{
+		Reminder: &models.Reminder{Id: "r1", TaskId: "t1", FireAt: "2024-11-22T09:00:00Z", Status: models.ReminderPending},
+		Task:     &models.Task{Id: "t1", Title: "Купить молоко", DueDate: "2024-11-22"},
+		User:     &models.User{Id: "u1", Username: "alice", Email: email},
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCode  int
+		expectError bool
+	}{
+		{name: "success", statusCode: http.StatusNoContent},
+		{name: "error status", statusCode: http.StatusInternalServerError, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received Notification
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("expected JSON body, got %s", r.Header.Get("Content-Type"))
+				}
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			err := NewWebhookNotifier(server.URL).Notify(context.Background(), testNotification(""))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got %v", tt.expectError, err)
+			}
+
+			if received.Reminder == nil || received.Reminder.Id != "r1" || received.Task.Title != "Купить молоко" {
+				t.Errorf("expected the notification to be posted, got %+v", received)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer accepts one connection, speaks enough SMTP to receive a
+// mail and sends it to the returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var mail strings.Builder
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(command, "MAIL FROM:"), strings.HasPrefix(command, "RCPT TO:"):
+				mail.WriteString(strings.TrimSpace(line) + "\n")
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					mail.WriteString(line)
+				}
+				reply("250 OK")
+				mails <- mail.String()
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), mails
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, mails := fakeSMTPServer(t)
+
+	n, err := NewSMTPNotifier(addr, "todo@example.com", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.Notify(context.Background(), testNotification("alice@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	mail := <-mails
+	for _, expected := range []string{
+		"MAIL FROM:<todo@example.com>",
+		"RCPT TO:<alice@example.com>",
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Task \"Купить молоко\" is due 2024-11-22.",
+	} {
+		if !strings.Contains(mail, expected) {
+			t.Errorf("expected mail to contain %q, got:\n%s", expected, mail)
+		}
+	}
+
+	err = n.Notify(context.Background(), testNotification(""))
+	if !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("expected %v for a user without email, got %v", ErrNoRecipient, err)
+	}
+}
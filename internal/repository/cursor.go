@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+)
+
+type cursor struct {
+	Sort   string        `json:"s"`
+	Order  string        `json:"o"`
+	Values []interface{} `json:"v"`
+}
+
+// EncodeCursor returns an opaque cursor holding the sort key values of the
+// last row of a page, bound to the sort and order that produced it.
+func EncodeCursor(sort, order string, values []interface{}) string {
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+
+	data, _ := json.Marshal(cursor{Sort: sort, Order: order, Values: values})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the sort key values of a cursor made by EncodeCursor,
+// or InvalidCursor if it was made for another sort or is damaged. Numbers
+// come back as int64 where possible and float64 otherwise.
+func DecodeCursor(raw, sort, order string, keysCount int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, internalErrors.InvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	c := cursor{}
+	if err := decoder.Decode(&c); err != nil {
+		return nil, internalErrors.InvalidCursor
+	}
+
+	if c.Sort != sort || c.Order != order || len(c.Values) != keysCount {
+		return nil, internalErrors.InvalidCursor
+	}
+
+	for i, v := range c.Values {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if iv, err := n.Int64(); err == nil {
+			c.Values[i] = iv
+		} else if fv, err := n.Float64(); err == nil {
+			c.Values[i] = fv
+		} else {
+			return nil, internalErrors.InvalidCursor
+		}
+	}
+
+	return c.Values, nil
+}
@@ -0,0 +1,19 @@
+package memory
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/repository/repositorytest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Repositories {
+		store := NewStore()
+
+		return repositorytest.Repositories{
+			Tasks:    NewTaskRepository(store),
+			Projects: NewProjectRepository(store),
+			Tags:     NewTagRepository(store),
+			History:  NewHistoryRepository(store),
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+)
+
+// AddBlocker checks for a cycle and records the dependency under one lock,
+// so two concurrent requests cannot close a cycle between them.
+func (s *TaskRepository) AddBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	if taskId == blockerId {
+		return internalErrors.DependencyCycle
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	// The new edge closes a cycle if the blocker already waits for the task,
+	// directly or through other tasks.
+	visited := map[string]bool{blockerId: true}
+	queue := []string{blockerId}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for next := range s.store.dependencies[id] {
+			if next == taskId {
+				return internalErrors.DependencyCycle
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return s.store.changeRelations(taskId, record, func() bool {
+		if s.store.dependencies[taskId][blockerId] {
+			return false
+		}
+
+		link(s.store.dependencies, taskId, blockerId)
+		return true
+	})
+}
+
+// RemoveBlocker is a no-op when the task does not wait for blockerId.
+func (s *TaskRepository) RemoveBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	return s.store.changeRelations(taskId, record, func() bool {
+		if !s.store.dependencies[taskId][blockerId] {
+			return false
+		}
+
+		unlink(s.store.dependencies, taskId, blockerId)
+		return true
+	})
+}
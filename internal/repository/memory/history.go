@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"sort"
+)
+
+type historyRow struct {
+	entry models.HistoryEntry
+	seq   int64
+}
+
+type HistoryRepository struct {
+	store *Store
+}
+
+func NewHistoryRepository(store *Store) *HistoryRepository {
+	return &HistoryRepository{store: store}
+}
+
+func historyCopy(entry models.HistoryEntry) *models.HistoryEntry {
+	changes := []models.FieldChange{}
+	for _, change := range entry.Changes {
+		changes = append(changes, models.FieldChange{
+			Field:  change.Field,
+			Before: append(json.RawMessage{}, change.Before...),
+			After:  append(json.RawMessage{}, change.After...),
+		})
+	}
+	entry.Changes = changes
+	return &entry
+}
+
+// record saves the entry record makes of the change of a task along with the
+// change. Callers hold the lock.
+func (s *Store) record(record repository.Recorder, before, after *models.Task) {
+	if record == nil {
+		return
+	}
+
+	if entry := record(before, after); entry != nil {
+		s.saveEntry(entry)
+	}
+}
+
+// saveEntry appends the entry to the history. Callers hold the lock.
+func (s *Store) saveEntry(entry *models.HistoryEntry) {
+	s.history[entry.Id] = &historyRow{entry: *historyCopy(*entry), seq: s.nextSeq()}
+}
+
+func (s *HistoryRepository) Save(ctx context.Context, entry *models.HistoryEntry) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.history[entry.Id]; ok {
+		return DuplicateKey
+	}
+
+	s.store.saveEntry(entry)
+
+	return nil
+}
+
+func (s *HistoryRepository) GetByTask(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return s.getPage(query.TaskId, "history", query, func(entry *models.HistoryEntry) bool { return entry.TaskId == query.TaskId })
+}
+
+func (s *HistoryRepository) GetByActor(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return s.getPage(query.ActorId, "actor_history", query, func(entry *models.HistoryEntry) bool { return entry.ActorId == query.ActorId })
+}
+
+// getPage returns the entries accepted by the filter, newest first. The
+// cursor is bound to value under cursorKind instead of a sort order.
+func (s *HistoryRepository) getPage(value, cursorKind string, query *dtos.GetHistoryQuery, filter func(entry *models.HistoryEntry) bool) (*dtos.HistoryPage, error) {
+	keys := []sortKey{{desc: true}, {desc: true}}
+
+	var cursorValues []interface{}
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, cursorKind, value, len(keys))
+		if err != nil {
+			return nil, err
+		}
+		cursorValues = values
+	}
+
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var rows []*historyRow
+	for _, row := range s.store.history {
+		if filter(&row.entry) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].entry.CreatedAt != rows[j].entry.CreatedAt {
+			return rows[i].entry.CreatedAt > rows[j].entry.CreatedAt
+		}
+		return rows[i].seq > rows[j].seq
+	})
+
+	page := &dtos.HistoryPage{Entries: []*models.HistoryEntry{}}
+	var lastValues []interface{}
+
+	for _, row := range rows {
+		values := []interface{}{row.entry.CreatedAt, row.seq}
+
+		if cursorValues != nil {
+			c, err := compareKeys(keys, values, cursorValues)
+			if err != nil {
+				return nil, err
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+
+		if len(page.Entries) == query.Limit {
+			page.NextCursor = repository.EncodeCursor(cursorKind, value, lastValues)
+			break
+		}
+
+		page.Entries = append(page.Entries, historyCopy(row.entry))
+		lastValues = values
+	}
+
+	return page, nil
+}
@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"sort"
+)
+
+type projectRow struct {
+	project models.Project
+	seq     int64
+}
+
+type ProjectRepository struct {
+	store *Store
+}
+
+func NewProjectRepository(store *Store) *ProjectRepository {
+	return &ProjectRepository{store: store}
+}
+
+func (s *ProjectRepository) Save(ctx context.Context, project *models.Project) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.projects[project.Id]; ok {
+		return DuplicateKey
+	}
+
+	s.store.projects[project.Id] = &projectRow{project: *project, seq: s.store.nextSeq()}
+
+	return nil
+}
+
+func (s *ProjectRepository) GetById(ctx context.Context, id string) (*models.Project, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	row, ok := s.store.projects[id]
+	if !ok {
+		return nil, internalErrors.ProjectNotFound
+	}
+
+	project := row.project
+	return &project, nil
+}
+
+func (s *ProjectRepository) GetAll(ctx context.Context, ownerId string, includeArchived bool) ([]*models.Project, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var rows []*projectRow
+	for _, row := range s.store.projects {
+		if row.project.OwnerId == ownerId && (includeArchived || !row.project.Archived) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].project.CreatedAt != rows[j].project.CreatedAt {
+			return rows[i].project.CreatedAt < rows[j].project.CreatedAt
+		}
+		return rows[i].seq < rows[j].seq
+	})
+
+	projects := []*models.Project{}
+	for _, row := range rows {
+		project := row.project
+		projects = append(projects, &project)
+	}
+
+	return projects, nil
+}
+
+func (s *ProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.projects[project.Id]
+	if !ok {
+		return internalErrors.ProjectNotFound
+	}
+
+	row.project.Name = project.Name
+	row.project.Description = project.Description
+	row.project.UpdatedAt = project.UpdatedAt
+
+	return nil
+}
+
+func (s *ProjectRepository) SetArchived(ctx context.Context, id string, archived bool, updatedAt string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.projects[id]
+	if !ok {
+		return internalErrors.ProjectNotFound
+	}
+
+	row.project.Archived = archived
+	row.project.UpdatedAt = updatedAt
+
+	return nil
+}
+
+// Delete moves the tasks of the project to the trash first when cascade is
+// set, and leaves the tasks in the trash without a project, as they cannot
+// be restored into a deleted project. Each of them moves to its next version
+// and is recorded.
+func (s *ProjectRepository) Delete(ctx context.Context, id string, cascade bool, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	// The tasks of the project outside the trash come with their subtasks,
+	// also those moved to another project.
+	touched := make(map[string]bool)
+	for taskId, row := range s.store.tasks {
+		if row.task.ProjectId != id {
+			continue
+		}
+
+		touched[taskId] = true
+		if row.task.DeletedAt != "" {
+			continue
+		}
+
+		if !cascade {
+			return nil, internalErrors.ProjectHasTasks
+		}
+		for _, subtaskId := range s.store.descendants(taskId) {
+			if s.store.tasks[subtaskId].task.DeletedAt == "" {
+				touched[subtaskId] = true
+			}
+		}
+	}
+
+	if _, ok := s.store.projects[id]; !ok {
+		return nil, internalErrors.ProjectNotFound
+	}
+
+	var taskIds []string
+	for taskId := range touched {
+		taskIds = append(taskIds, taskId)
+	}
+	sort.Slice(taskIds, func(i, j int) bool { return s.store.tasks[taskIds[i]].seq < s.store.tasks[taskIds[j]].seq })
+
+	var trashed []*taskRow
+	for _, taskId := range taskIds {
+		row := s.store.tasks[taskId]
+		before := s.store.taskCopy(row)
+
+		if row.task.DeletedAt == "" {
+			row.task.DeletedAt = deletedAt
+			trashed = append(trashed, row)
+		}
+		if row.task.ProjectId == id {
+			row.task.ProjectId = ""
+		}
+		row.task.Version++
+
+		s.store.record(record, before, s.store.taskCopy(row))
+	}
+
+	delete(s.store.projects, id)
+
+	result := []*models.Task{}
+	for _, row := range trashed {
+		result = append(result, s.store.taskCopy(row))
+	}
+
+	return result, nil
+}
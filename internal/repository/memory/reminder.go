@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"sort"
+	"time"
+)
+
+type reminderRow struct {
+	reminder     models.Reminder
+	claimedUntil string
+	seq          int64
+}
+
+type ReminderRepository struct {
+	store *Store
+}
+
+func NewReminderRepository(store *Store) *ReminderRepository {
+	return &ReminderRepository{store: store}
+}
+
+// normalizeDuration keeps whole seconds of a duration, the way SQLite stores
+// it, and drops anything that is not a duration.
+func normalizeDuration(s string) string {
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return ""
+	}
+	return (duration / time.Second * time.Second).String()
+}
+
+func (s *ReminderRepository) Save(ctx context.Context, reminder *models.Reminder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.reminders[reminder.Id]; ok {
+		return DuplicateKey
+	}
+
+	row := &reminderRow{reminder: *reminder, seq: s.store.nextSeq()}
+	row.reminder.Before = normalizeDuration(reminder.Before)
+	s.store.reminders[reminder.Id] = row
+
+	return nil
+}
+
+func (s *ReminderRepository) GetById(ctx context.Context, id string) (*models.Reminder, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	row, ok := s.store.reminders[id]
+	if !ok {
+		return nil, internalErrors.ReminderNotFound
+	}
+
+	reminder := row.reminder
+	return &reminder, nil
+}
+
+func (s *ReminderRepository) GetByTask(ctx context.Context, taskId string) ([]*models.Reminder, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	return s.store.collectReminders(func(row *reminderRow) bool { return row.reminder.TaskId == taskId }, -1), nil
+}
+
+// collectReminders returns copies of up to limit reminders accepted by the
+// filter, earliest first; a negative limit returns all. Callers hold the lock.
+func (s *Store) collectReminders(filter func(row *reminderRow) bool, limit int) []*models.Reminder {
+	var rows []*reminderRow
+	for _, row := range s.reminders {
+		if filter(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].reminder.FireAt != rows[j].reminder.FireAt {
+			return rows[i].reminder.FireAt < rows[j].reminder.FireAt
+		}
+		return rows[i].seq < rows[j].seq
+	})
+
+	if limit >= 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	reminders := []*models.Reminder{}
+	for _, row := range rows {
+		reminder := row.reminder
+		reminders = append(reminders, &reminder)
+	}
+
+	return reminders
+}
+
+func (s *ReminderRepository) Delete(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.reminders[id]; !ok {
+		return internalErrors.ReminderNotFound
+	}
+
+	delete(s.store.reminders, id)
+
+	return nil
+}
+
+// ClaimDue marks up to limit pending reminders that are due at now as taken
+// until claimedUntil and returns them. A claim that expires without Finish or
+// Retry is handed out again.
+func (s *ReminderRepository) ClaimDue(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	due := func(row *reminderRow) bool {
+		return row.reminder.Status == models.ReminderPending && row.reminder.FireAt <= now && (row.claimedUntil == "" || row.claimedUntil <= now)
+	}
+
+	claimed := s.store.collectReminders(due, limit)
+	for _, reminder := range claimed {
+		row := s.store.reminders[reminder.Id]
+		row.claimedUntil = claimedUntil
+		row.reminder.Attempts++
+		reminder.Attempts = row.reminder.Attempts
+	}
+
+	return claimed, nil
+}
+
+// Finish records the outcome of a claimed reminder, so it is not claimed again.
+func (s *ReminderRepository) Finish(ctx context.Context, id, status, sentAt string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if row, ok := s.store.reminders[id]; ok {
+		row.reminder.Status = status
+		row.reminder.SentAt = sentAt
+		row.claimedUntil = ""
+	}
+
+	return nil
+}
+
+// Retry keeps a claimed reminder pending but not claimable before retryAt.
+func (s *ReminderRepository) Retry(ctx context.Context, id, retryAt string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if row, ok := s.store.reminders[id]; ok {
+		row.claimedUntil = retryAt
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package memory
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightStart = `<mark>`
+	highlightEnd   = `</mark>`
+	// snippetTokens is the size of the description window a snippet shows,
+	// the same as in the SQLite snippet().
+	snippetTokens = 12
+	// titleWeight makes a match in the title count ten times more than one
+	// in the description, like the bm25 weights of the SQLite index.
+	titleWeight = 10
+)
+
+// searchPart is one part of a search query: a phrase of one or more tokens
+// that must follow each other, the last one matching as a prefix if set.
+type searchPart struct {
+	tokens []string
+	prefix bool
+}
+
+// token is a word of a text with its position in the text.
+type token struct {
+	text       string
+	start, end int
+}
+
+// tokenize splits text into lowercase words of letters and digits, like the
+// unicode61 tokenizer of the SQLite index.
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+
+	return tokens
+}
+
+// parseSearchQuery splits user input with the rules of the SQLite search:
+// double-quoted parts are phrases, words ending with * are prefixes and
+// everything else is a plain term. Operators are treated as text.
+func parseSearchQuery(input string) []searchPart {
+	var parts []searchPart
+
+	addPart := func(text string, prefix bool) {
+		var tokens []string
+		for _, t := range tokenize(text) {
+			tokens = append(tokens, t.text)
+		}
+		if len(tokens) > 0 {
+			parts = append(parts, searchPart{tokens: tokens, prefix: prefix})
+		}
+	}
+
+	for input != "" {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		if input == "" {
+			break
+		}
+
+		if input[0] == '"' {
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				addPart(input[1:], false)
+				break
+			}
+			addPart(input[1:end+1], false)
+			input = input[end+2:]
+			continue
+		}
+
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		if end < 0 {
+			end = len(input)
+		}
+		word := input[:end]
+		input = input[end:]
+
+		prefix := strings.HasSuffix(word, "*")
+		addPart(strings.TrimRight(word, "*"), prefix)
+	}
+
+	return parts
+}
+
+// matchKey identifies the parsed query a search cursor belongs to.
+func matchKey(parts []searchPart) string {
+	var keys []string
+	for _, part := range parts {
+		key := `"` + strings.Join(part.tokens, " ") + `"`
+		if part.prefix {
+			key += "*"
+		}
+		keys = append(keys, key)
+	}
+	return strings.Join(keys, " ")
+}
+
+// find returns the spans of the tokens matched by the part.
+func (p searchPart) find(tokens []token) [][2]int {
+	var spans [][2]int
+
+	for i := 0; i+len(p.tokens) <= len(tokens); i++ {
+		matched := true
+		for j, want := range p.tokens {
+			got := tokens[i+j].text
+			last := j == len(p.tokens)-1
+			if got != want && !(last && p.prefix && strings.HasPrefix(got, want)) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			for j := range p.tokens {
+				spans = append(spans, [2]int{tokens[i+j].start, tokens[i+j].end})
+			}
+		}
+	}
+
+	return spans
+}
+
+// mark wraps the spans of text in highlight tags.
+func mark(text string, spans [][2]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span[0] < last {
+			continue
+		}
+		b.WriteString(text[last:span[0]])
+		b.WriteString(highlightStart + text[span[0]:span[1]] + highlightEnd)
+		last = span[1]
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// snippet returns the highlighted window of the description around its
+// first match, with an ellipsis where text was cut.
+func snippet(text string, tokens []token, spans [][2]int) string {
+	if len(tokens) <= snippetTokens {
+		return mark(text, spans)
+	}
+
+	first := 0
+	for i, t := range tokens {
+		if isSpanStart(spans, t.start) {
+			first = i
+			break
+		}
+	}
+	if first+snippetTokens > len(tokens) {
+		first = len(tokens) - snippetTokens
+	}
+	lastToken := first + snippetTokens - 1
+
+	start, end := tokens[first].start, tokens[lastToken].end
+
+	var inWindow [][2]int
+	for _, span := range spans {
+		if span[0] >= start && span[1] <= end {
+			inWindow = append(inWindow, [2]int{span[0] - start, span[1] - start})
+		}
+	}
+
+	result := mark(text[start:end], inWindow)
+	if first > 0 {
+		result = "…" + result
+	}
+	if lastToken < len(tokens)-1 {
+		result += "…"
+	}
+
+	return result
+}
+
+func isSpanStart(spans [][2]int, start int) bool {
+	for _, span := range spans {
+		if span[0] == start {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TaskRepository) Search(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	parts := parseSearchQuery(query.Query)
+	if len(parts) == 0 {
+		return &dtos.SearchPage{}, nil
+	}
+	match := matchKey(parts)
+
+	// Results are ordered by rank and then by seq, both ascending; the keys
+	// only carry the directions since the values are computed below.
+	keys := []sortKey{{desc: false}, {desc: false}}
+
+	var cursorValues []interface{}
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, "search", match, len(keys))
+		if err != nil {
+			return nil, err
+		}
+		cursorValues = values
+	}
+
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	type hit struct {
+		row    *taskRow
+		result *dtos.SearchResult
+		values []interface{}
+	}
+	var hits []hit
+
+	for _, row := range s.store.tasks {
+		if row.task.OwnerId != query.OwnerId || row.task.DeletedAt != "" {
+			continue
+		}
+
+		titleTokens := tokenize(row.task.Title)
+		descriptionTokens := tokenize(row.task.Description)
+
+		var titleSpans, descriptionSpans [][2]int
+		matched := true
+		for _, part := range parts {
+			inTitle := part.find(titleTokens)
+			inDescription := part.find(descriptionTokens)
+			if len(inTitle) == 0 && len(inDescription) == 0 {
+				matched = false
+				break
+			}
+			titleSpans = append(titleSpans, inTitle...)
+			descriptionSpans = append(descriptionSpans, inDescription...)
+		}
+		if !matched {
+			continue
+		}
+
+		// Like bm25, the rank is negative and lower is better.
+		rank := -float64(titleWeight*len(titleSpans) + len(descriptionSpans))
+
+		hits = append(hits, hit{
+			row: row,
+			result: &dtos.SearchResult{
+				TitleHighlight: mark(row.task.Title, titleSpans),
+				Snippet:        snippet(row.task.Description, descriptionTokens, descriptionSpans),
+				Rank:           rank,
+			},
+			values: []interface{}{rank, row.seq},
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		c, _ := compareKeys(keys, hits[i].values, hits[j].values)
+		return c < 0
+	})
+
+	page := &dtos.SearchPage{}
+	var lastValues []interface{}
+
+	for _, h := range hits {
+		if cursorValues != nil {
+			c, err := compareKeys(keys, h.values, cursorValues)
+			if err != nil {
+				return nil, err
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+
+		if len(page.Results) == query.Limit {
+			page.NextCursor = repository.EncodeCursor("search", match, lastValues)
+			break
+		}
+
+		h.result.Task = s.store.taskCopy(h.row)
+		page.Results = append(page.Results, h.result)
+		lastValues = h.values
+	}
+
+	return page, nil
+}
@@ -0,0 +1,167 @@
+// Package memory implements the repositories in process memory with the
+// semantics of the SQLite ones. Nothing outlives the process, which suits
+// demos (DB_DRIVER=memory) and tests that need real repositories without a
+// database.
+package memory
+
+import (
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	DuplicateKey = errors.New("a row with the same key already exists")
+)
+
+// Store is the data of one in-memory database, shared by the repositories
+// built on it the way the SQLite repositories share one *sql.DB. A single
+// lock guards all of it, so every repository call is atomic like a statement
+// or a transaction in SQLite.
+type Store struct {
+	mu sync.RWMutex
+	// seq numbers inserted rows, standing in for the rowid SQLite orders by.
+	seq int64
+
+	users    map[string]models.User
+	tokens   map[string]authToken
+	projects map[string]*projectRow
+	tasks    map[string]*taskRow
+	tags     map[string]models.Tag
+	// taskTags and dependencies map a task id to the set of its tag ids and
+	// blocker ids.
+	taskTags     map[string]map[string]bool
+	dependencies map[string]map[string]bool
+	reminders    map[string]*reminderRow
+	webhooks     map[string]*webhookRow
+	deliveries   map[string]*deliveryRow
+	history      map[string]*historyRow
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:        make(map[string]models.User),
+		tokens:       make(map[string]authToken),
+		projects:     make(map[string]*projectRow),
+		tasks:        make(map[string]*taskRow),
+		tags:         make(map[string]models.Tag),
+		taskTags:     make(map[string]map[string]bool),
+		dependencies: make(map[string]map[string]bool),
+		reminders:    make(map[string]*reminderRow),
+		webhooks:     make(map[string]*webhookRow),
+		deliveries:   make(map[string]*deliveryRow),
+		history:      make(map[string]*historyRow),
+	}
+}
+
+func (s *Store) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+// link adds value to the set stored under key.
+func link(sets map[string]map[string]bool, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]bool)
+	}
+	sets[key][value] = true
+}
+
+// unlink removes value from the set stored under key.
+func unlink(sets map[string]map[string]bool, key, value string) {
+	delete(sets[key], value)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+// changeRelations changes the tags or blockers of the task outside the trash
+// with change, which reports whether anything changed. If so, the task moves
+// to its next version, so the version covers them too, and what record makes
+// of the change is saved. Callers hold the lock.
+func (s *Store) changeRelations(taskId string, record repository.Recorder, change func() bool) error {
+	row, ok := s.tasks[taskId]
+	if !ok || row.task.DeletedAt != "" {
+		return internalErrors.TaskNotFound
+	}
+
+	before := s.taskCopy(row)
+	if !change() {
+		return nil
+	}
+
+	row.task.Version++
+	s.record(record, before, s.taskCopy(row))
+
+	return nil
+}
+
+// deleteTasks removes the tasks together with their tags, dependencies and
+// reminders, like the delete triggers of the SQLite schema.
+func (s *Store) deleteTasks(ids []string) {
+	for _, id := range ids {
+		delete(s.tasks, id)
+		delete(s.taskTags, id)
+		delete(s.dependencies, id)
+
+		for taskId := range s.dependencies {
+			unlink(s.dependencies, taskId, id)
+		}
+
+		for reminderId, row := range s.reminders {
+			if row.reminder.TaskId == id {
+				delete(s.reminders, reminderId)
+			}
+		}
+	}
+}
+
+// descendants returns the ids of all subtasks of the task, at any depth,
+// oldest first, so that parents come before their subtasks.
+func (s *Store) descendants(id string) []string {
+	var ids []string
+
+	parents := map[string]bool{id: true}
+	for len(parents) > 0 {
+		next := make(map[string]bool)
+		for childId, row := range s.tasks {
+			if parents[row.task.ParentId] {
+				ids = append(ids, childId)
+				next[childId] = true
+			}
+		}
+		parents = next
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return s.tasks[ids[i]].seq < s.tasks[ids[j]].seq })
+
+	return ids
+}
+
+// followDue moves the pending reminders relative to the due time of the task
+// along with it.
+func (s *Store) followDue(taskId, dueAt string) {
+	due, err := time.Parse(time.RFC3339, dueAt)
+	if err != nil {
+		return
+	}
+
+	for _, row := range s.reminders {
+		if row.reminder.TaskId != taskId || row.reminder.Status != models.ReminderPending || row.reminder.Before == "" {
+			continue
+		}
+
+		before, _ := time.ParseDuration(row.reminder.Before)
+		row.reminder.FireAt = due.Add(-before).UTC().Format(time.RFC3339)
+	}
+}
+
+// foldedLess orders names ignoring case, like the NOCASE collation.
+func foldedLess(a, b string) bool {
+	return strings.ToLower(a) < strings.ToLower(b)
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"sort"
+	"strings"
+)
+
+type TagRepository struct {
+	store *Store
+}
+
+func NewTagRepository(store *Store) *TagRepository {
+	return &TagRepository{store: store}
+}
+
+// nameTaken reports whether the owner has another tag with the name in any
+// case. Callers hold the lock.
+func (s *Store) nameTaken(ownerId, name, exceptId string) bool {
+	for id, tag := range s.tags {
+		if id != exceptId && tag.OwnerId == ownerId && strings.EqualFold(tag.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TagRepository) Save(ctx context.Context, tag *models.Tag) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.tags[tag.Id]; ok {
+		return DuplicateKey
+	}
+
+	if s.store.nameTaken(tag.OwnerId, tag.Name, "") {
+		return internalErrors.TagAlreadyExists
+	}
+
+	s.store.tags[tag.Id] = *tag
+
+	return nil
+}
+
+func (s *TagRepository) GetById(ctx context.Context, id string) (*models.Tag, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	tag, ok := s.store.tags[id]
+	if !ok {
+		return nil, internalErrors.TagNotFound
+	}
+
+	return &tag, nil
+}
+
+func (s *TagRepository) GetByName(ctx context.Context, ownerId, name string) (*models.Tag, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	for _, tag := range s.store.tags {
+		if tag.OwnerId == ownerId && strings.EqualFold(tag.Name, name) {
+			return &tag, nil
+		}
+	}
+
+	return nil, internalErrors.TagNotFound
+}
+
+func (s *TagRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Tag, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	tags := []*models.Tag{}
+	for _, tag := range s.store.tags {
+		if tag.OwnerId == ownerId {
+			tag := tag
+			tags = append(tags, &tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return foldedLess(tags[i].Name, tags[j].Name) })
+
+	return tags, nil
+}
+
+func (s *TagRepository) Rename(ctx context.Context, id, name string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	tag, ok := s.store.tags[id]
+	if !ok {
+		return internalErrors.TagNotFound
+	}
+
+	if s.store.nameTaken(tag.OwnerId, name, id) {
+		return internalErrors.TagAlreadyExists
+	}
+
+	tag.Name = name
+	s.store.tags[id] = tag
+
+	return nil
+}
+
+func (s *TagRepository) Delete(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.tags[id]; !ok {
+		return internalErrors.TagNotFound
+	}
+
+	delete(s.store.tags, id)
+	for taskId := range s.store.taskTags {
+		unlink(s.store.taskTags, taskId, id)
+	}
+
+	return nil
+}
+
+// Attach is a no-op when the tag is already attached to the task; otherwise
+// the task moves to its next version.
+func (s *TagRepository) Attach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	return s.store.changeRelations(taskId, record, func() bool {
+		if s.store.taskTags[taskId][tagId] {
+			return false
+		}
+
+		link(s.store.taskTags, taskId, tagId)
+		return true
+	})
+}
+
+// Detach is a no-op when the tag is not attached to the task.
+func (s *TagRepository) Detach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	return s.store.changeRelations(taskId, record, func() bool {
+		if !s.store.taskTags[taskId][tagId] {
+			return false
+		}
+
+		unlink(s.store.taskTags, taskId, tagId)
+		return true
+	})
+}
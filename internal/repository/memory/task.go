@@ -0,0 +1,636 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"sort"
+	"time"
+)
+
+type taskRow struct {
+	task models.Task
+	seq  int64
+}
+
+type TaskRepository struct {
+	store *Store
+}
+
+func NewTaskRepository(store *Store) *TaskRepository {
+	return &TaskRepository{store: store}
+}
+
+// Close does nothing, the data lives as long as the store.
+func (s *TaskRepository) Close() {}
+
+// taskCopy returns the stored task with its tags and blockers outside the
+// trash, the way the SQLite repository loads them. Callers hold the lock.
+func (s *Store) taskCopy(row *taskRow) *models.Task {
+	task := row.task
+
+	for tagId := range s.taskTags[task.Id] {
+		if tag, ok := s.tags[tagId]; ok {
+			task.Tags = append(task.Tags, tag.Name)
+		}
+	}
+	sort.Slice(task.Tags, func(i, j int) bool { return foldedLess(task.Tags[i], task.Tags[j]) })
+
+	var blockers []*taskRow
+	for blockerId := range s.dependencies[task.Id] {
+		if blocker, ok := s.tasks[blockerId]; ok && blocker.task.DeletedAt == "" {
+			blockers = append(blockers, blocker)
+		}
+	}
+	sort.Slice(blockers, func(i, j int) bool { return blockers[i].seq < blockers[j].seq })
+
+	for _, blocker := range blockers {
+		task.BlockedBy = append(task.BlockedBy, blocker.task.Id)
+		task.Blocked = task.Blocked || !blocker.task.Completed
+	}
+
+	return &task
+}
+
+func (s *TaskRepository) Save(ctx context.Context, task *models.Task, record repository.Recorder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.tasks[task.Id]; ok {
+		logger.ErrorLogger.Printf("failed to save task: %v", DuplicateKey)
+		return DuplicateKey
+	}
+
+	// Only the columns of the tasks table are kept; the priority is stored
+	// as a level, so unknown names read back as none.
+	row := &taskRow{task: *task, seq: s.store.nextSeq()}
+	row.task.Priority = models.PriorityName(priorityLevel(task.Priority))
+	row.task.Progress = nil
+	row.task.Tags = nil
+	row.task.BlockedBy = nil
+	row.task.Blocked = false
+
+	s.store.tasks[task.Id] = row
+	s.store.record(record, nil, task)
+
+	return nil
+}
+
+func priorityLevel(name string) int {
+	level, _ := models.PriorityLevel(name)
+	return level
+}
+
+func (s *TaskRepository) GetAll(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	keys := sortKeys(query.Sort, query.Order)
+
+	var cursorValues []interface{}
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, query.Sort, query.Order, len(keys))
+		if err != nil {
+			return nil, err
+		}
+		cursorValues = values
+	}
+
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var rows []*taskRow
+	for _, row := range s.store.tasks {
+		if s.store.matches(row, query) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		c, _ := compareKeys(keys, keyValues(keys, rows[i]), keyValues(keys, rows[j]))
+		return c < 0
+	})
+
+	page := &dtos.TaskPage{}
+	var lastValues []interface{}
+
+	for _, row := range rows {
+		values := keyValues(keys, row)
+
+		if cursorValues != nil {
+			c, err := compareKeys(keys, values, cursorValues)
+			if err != nil {
+				return nil, err
+			}
+			if c <= 0 {
+				continue
+			}
+		}
+
+		if len(page.Tasks) == query.Limit {
+			page.NextCursor = repository.EncodeCursor(query.Sort, query.Order, lastValues)
+			break
+		}
+
+		page.Tasks = append(page.Tasks, s.store.taskCopy(row))
+		lastValues = values
+	}
+
+	return page, nil
+}
+
+func (s *TaskRepository) GetById(ctx context.Context, id string) (*models.Task, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	row, ok := s.store.tasks[id]
+	if !ok || row.task.DeletedAt != "" {
+		logger.ErrorLogger.Printf("task with id %s not found", id)
+		return nil, internalErrors.TaskNotFound
+	}
+
+	return s.store.taskCopy(row), nil
+}
+
+func (s *TaskRepository) GetByIds(ctx context.Context, ids []string) ([]*models.Task, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return s.store.collect(func(row *taskRow) bool { return wanted[row.task.Id] && row.task.DeletedAt == "" }, bySeq), nil
+}
+
+func (s *TaskRepository) GetByParentIds(ctx context.Context, parentIds []string) ([]*models.Task, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	parents := make(map[string]bool, len(parentIds))
+	for _, id := range parentIds {
+		parents[id] = true
+	}
+
+	byCreatedAt := func(a, b *taskRow) bool {
+		if a.task.CreatedAt != b.task.CreatedAt {
+			return a.task.CreatedAt < b.task.CreatedAt
+		}
+		return a.seq < b.seq
+	}
+
+	isSubtask := func(row *taskRow) bool {
+		return row.task.ParentId != "" && parents[row.task.ParentId] && row.task.DeletedAt == ""
+	}
+
+	return s.store.collect(isSubtask, byCreatedAt), nil
+}
+
+func bySeq(a, b *taskRow) bool {
+	return a.seq < b.seq
+}
+
+// collect returns copies of the tasks accepted by the filter in the given
+// order. Callers hold the lock.
+func (s *Store) collect(filter func(row *taskRow) bool, less func(a, b *taskRow) bool) []*models.Task {
+	var rows []*taskRow
+	for _, row := range s.tasks {
+		if filter(row) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+
+	tasks := []*models.Task{}
+	for _, row := range rows {
+		tasks = append(tasks, s.taskCopy(row))
+	}
+
+	return tasks
+}
+
+// casRow returns the task to change if its stored version still equals
+// version, and TaskNotFound or VersionConflict otherwise; tasks in the trash
+// are not found. Callers hold the lock.
+func (s *Store) casRow(id string, version int64) (*taskRow, error) {
+	row, ok := s.tasks[id]
+	if !ok || row.task.DeletedAt != "" {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	if row.task.Version != version {
+		logger.ErrorLogger.Printf("version conflict on task %s", id)
+		return nil, internalErrors.VersionConflict
+	}
+
+	return row, nil
+}
+
+// Update applies the command only if the stored version still equals
+// updateTaskCommand.Version and bumps the version on success.
+func (s *TaskRepository) Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error {
+	cmd := updateTaskCommand
+
+	if cmd.Title == "" && cmd.Description == "" && cmd.DueDate == "" && cmd.Priority == "" && cmd.Recurrence == nil {
+		logger.ErrorLogger.Println("no fields to update")
+		return fmt.Errorf("no fields to update")
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, err := s.store.casRow(id, *cmd.Version)
+	if err != nil {
+		return err
+	}
+	before := s.store.taskCopy(row)
+	task := &row.task
+
+	if cmd.Title != "" {
+		task.Title = cmd.Title
+	}
+	if cmd.Description != "" {
+		task.Description = cmd.Description
+	}
+	if cmd.DueDate != "" {
+		dueChanged := task.DueAt != cmd.DueAt
+
+		task.DueDate = cmd.DueDate
+		task.DueAt = cmd.DueAt
+		task.AllDay = cmd.AllDay == nil || *cmd.AllDay
+		task.Overdue = false
+
+		if dueChanged {
+			s.store.followDue(id, task.DueAt)
+		}
+	}
+	if cmd.Priority != "" {
+		task.Priority = models.PriorityName(priorityLevel(cmd.Priority))
+	}
+
+	// An empty rule stops the series, a task that starts recurring becomes the
+	// first occurrence of its own series.
+	if cmd.Recurrence != nil {
+		task.Recurrence = *cmd.Recurrence
+
+		if *cmd.Recurrence != "" {
+			if task.SeriesId == "" {
+				task.SeriesId = task.Id
+			}
+			if task.Occurrence < 1 {
+				task.Occurrence = 1
+			}
+		}
+	}
+
+	task.UpdatedAt = updatedAt
+	task.Version++
+
+	s.store.record(record, before, s.store.taskCopy(row))
+
+	return nil
+}
+
+// DeleteById stamps the task and its subtasks with deletedAt. Subtasks that
+// are already in the trash keep their own time, so restoring the task leaves
+// them there.
+func (s *TaskRepository) DeleteById(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, err := s.store.casRow(id, version); err != nil {
+		return nil, err
+	}
+
+	trashed := []*models.Task{}
+	for _, taskId := range append([]string{id}, s.store.descendants(id)...) {
+		row := s.store.tasks[taskId]
+		if row.task.DeletedAt != "" {
+			continue
+		}
+
+		before := s.store.taskCopy(row)
+
+		row.task.DeletedAt = deletedAt
+		row.task.Version++
+
+		after := s.store.taskCopy(row)
+		s.store.record(record, before, after)
+
+		trashed = append(trashed, after)
+	}
+
+	return trashed, nil
+}
+
+func (s *TaskRepository) GetTrash(ctx context.Context, ownerId string) ([]*models.Task, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	// Subtasks that went to the trash with their parent share its time.
+	deletedAlone := func(row *taskRow) bool {
+		if row.task.OwnerId != ownerId || row.task.DeletedAt == "" {
+			return false
+		}
+		parent, ok := s.store.tasks[row.task.ParentId]
+		return !ok || parent.task.DeletedAt != row.task.DeletedAt
+	}
+
+	latestFirst := func(a, b *taskRow) bool {
+		if a.task.DeletedAt != b.task.DeletedAt {
+			return a.task.DeletedAt > b.task.DeletedAt
+		}
+		return a.seq > b.seq
+	}
+
+	return s.store.collect(deletedAlone, latestFirst), nil
+}
+
+func (s *TaskRepository) GetTrashedById(ctx context.Context, id string) (*models.Task, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	row, ok := s.store.tasks[id]
+	if !ok || row.task.DeletedAt == "" {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	return s.store.taskCopy(row), nil
+}
+
+func (s *TaskRepository) Restore(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.tasks[id]
+	if !ok || row.task.DeletedAt == "" {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	deletedAt := row.task.DeletedAt
+	restored := []*models.Task{}
+	for _, taskId := range append([]string{id}, s.store.descendants(id)...) {
+		row := s.store.tasks[taskId]
+		if row.task.DeletedAt != deletedAt {
+			continue
+		}
+
+		before := s.store.taskCopy(row)
+
+		row.task.DeletedAt = ""
+		row.task.UpdatedAt = updatedAt
+		row.task.Version++
+
+		after := s.store.taskCopy(row)
+		s.store.record(record, before, after)
+
+		restored = append(restored, after)
+	}
+
+	return restored, nil
+}
+
+func (s *TaskRepository) Purge(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.tasks[id]
+	if !ok || row.task.DeletedAt == "" {
+		return internalErrors.TaskNotFound
+	}
+
+	s.store.deleteTasks(append(s.store.descendants(id), id))
+
+	return nil
+}
+
+// PurgeDeletedBefore relies on subtasks never being trashed later than their
+// parent, so a purged task takes all its subtasks with it.
+func (s *TaskRepository) PurgeDeletedBefore(ctx context.Context, before string) (int64, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	var ids []string
+	for id, row := range s.store.tasks {
+		if row.task.DeletedAt != "" && row.task.DeletedAt < before {
+			ids = append(ids, id)
+		}
+	}
+
+	s.store.deleteTasks(ids)
+
+	return int64(len(ids)), nil
+}
+
+func (s *TaskRepository) LastOccurrence(ctx context.Context, seriesId string) (int, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	last := 0
+	for _, row := range s.store.tasks {
+		if row.task.SeriesId == seriesId && row.task.Occurrence > last {
+			last = row.task.Occurrence
+		}
+	}
+
+	return last, nil
+}
+
+func (s *TaskRepository) GetSubtaskCounts(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	parents := make(map[string]bool, len(parentIds))
+	for _, id := range parentIds {
+		parents[id] = true
+	}
+
+	counts := make(map[string]dtos.SubtaskCounts)
+	for _, row := range s.store.tasks {
+		if row.task.ParentId == "" || !parents[row.task.ParentId] || row.task.DeletedAt != "" {
+			continue
+		}
+
+		c := counts[row.task.ParentId]
+		c.Total++
+		if row.task.Completed {
+			c.Completed++
+		}
+		counts[row.task.ParentId] = c
+	}
+
+	return counts, nil
+}
+
+func (s *TaskRepository) ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, err := s.store.casRow(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// A completed task has no open subtasks, a reopened one no completed
+	// ancestors.
+	var others []string
+	if completionStatus {
+		for _, taskId := range s.store.descendants(id) {
+			if task := s.store.tasks[taskId].task; !task.Completed && task.DeletedAt == "" {
+				others = append(others, taskId)
+			}
+		}
+	} else {
+		for parentId := row.task.ParentId; parentId != ""; parentId = s.store.tasks[parentId].task.ParentId {
+			if s.store.tasks[parentId].task.Completed {
+				others = append(others, parentId)
+			}
+		}
+		sort.Slice(others, func(i, j int) bool { return s.store.tasks[others[i]].seq < s.store.tasks[others[j]].seq })
+	}
+
+	changed := []*models.Task{}
+	for _, taskId := range append([]string{id}, others...) {
+		row := s.store.tasks[taskId]
+		before := s.store.taskCopy(row)
+
+		row.task.Completed = completionStatus
+		row.task.CompletedAt = completedAt
+		row.task.UpdatedAt = updatedAt
+		row.task.Version++
+
+		after := s.store.taskCopy(row)
+		s.store.record(record, before, after)
+
+		if taskId != id {
+			changed = append(changed, after)
+		}
+	}
+
+	return changed, nil
+}
+
+// ChangeProject moves the task into projectId, or out of any project when
+// projectId is empty.
+func (s *TaskRepository) ChangeProject(ctx context.Context, id string, projectId string, version int64, updatedAt string, record repository.Recorder) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, err := s.store.casRow(id, version)
+	if err != nil {
+		return err
+	}
+	before := s.store.taskCopy(row)
+
+	row.task.ProjectId = projectId
+	row.task.UpdatedAt = updatedAt
+	row.task.Version++
+
+	s.store.record(record, before, s.store.taskCopy(row))
+
+	return nil
+}
+
+// Revert checks every version before it changes anything, as reverting a
+// task can move subtasks that come later in cmds.
+func (s *TaskRepository) Revert(ctx context.Context, cmds []*dtos.RevertTaskCommand, updatedAt string, entries []*models.HistoryEntry) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	for _, cmd := range cmds {
+		row, ok := s.store.tasks[cmd.Task.Id]
+		if !ok {
+			return internalErrors.TaskNotFound
+		}
+
+		if row.task.Version != cmd.Task.Version {
+			logger.ErrorLogger.Printf("version conflict on task %s", cmd.Task.Id)
+			return internalErrors.VersionConflict
+		}
+	}
+
+	for _, cmd := range cmds {
+		task := &s.store.tasks[cmd.Task.Id].task
+		state := cmd.Task
+
+		for _, field := range cmd.Fields {
+			switch field {
+			case "title":
+				task.Title = state.Title
+			case "description":
+				task.Description = state.Description
+			case "due_date":
+				dueChanged := task.DueAt != state.DueAt
+
+				task.DueDate = state.DueDate
+				task.DueAt = state.DueAt
+				task.AllDay = state.AllDay
+				task.Overdue = state.Overdue
+
+				if dueChanged {
+					s.store.followDue(task.Id, task.DueAt)
+				}
+			case "completed":
+				task.Completed = state.Completed
+				task.CompletedAt = state.CompletedAt
+			case "priority":
+				task.Priority = models.PriorityName(priorityLevel(state.Priority))
+			case "project_id":
+				task.ProjectId = state.ProjectId
+			case "recurrence":
+				task.Recurrence = state.Recurrence
+
+				if state.Recurrence != "" {
+					if task.SeriesId == "" {
+						task.SeriesId = task.Id
+					}
+					if task.Occurrence < 1 {
+						task.Occurrence = 1
+					}
+				}
+			case "deleted_at":
+				task.DeletedAt = state.DeletedAt
+			}
+		}
+
+		task.UpdatedAt = updatedAt
+		task.Version++
+	}
+
+	for _, entry := range entries {
+		s.store.saveEntry(entry)
+	}
+
+	return nil
+}
+
+// UpdateOverdueTasks flags the tasks whose due time has passed by now and
+// returns the tasks it flagged, each at its new version.
+func (s *TaskRepository) UpdateOverdueTasks(ctx context.Context, now time.Time, record repository.Recorder) ([]*models.Task, error) {
+	dueBy := now.UTC().Format(time.RFC3339)
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	flagged := make(map[string]bool)
+	for id, row := range s.store.tasks {
+		if row.task.DueAt != "" && row.task.DueAt <= dueBy && !row.task.Overdue && row.task.DeletedAt == "" {
+			row.task.Overdue = true
+			row.task.Version++
+			flagged[id] = true
+		}
+	}
+
+	tasks := s.store.collect(func(row *taskRow) bool { return flagged[row.task.Id] }, bySeq)
+	for _, after := range tasks {
+		before := *after
+		before.Overdue = false
+		before.Version--
+
+		s.store.record(record, &before, after)
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"strings"
+)
+
+type sortKey struct {
+	value func(row *taskRow) interface{}
+	desc  bool
+}
+
+func boolValue(b bool) interface{} {
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+var (
+	completedKey   = func(row *taskRow) interface{} { return boolValue(row.task.Completed) }
+	overdueKey     = func(row *taskRow) interface{} { return boolValue(row.task.Overdue) }
+	priorityKey    = func(row *taskRow) interface{} { return int64(priorityLevel(row.task.Priority)) }
+	dueAtKey       = func(row *taskRow) interface{} { return row.task.DueAt }
+	titleKey       = func(row *taskRow) interface{} { return strings.ToLower(row.task.Title) }
+	createdAtKey   = func(row *taskRow) interface{} { return row.task.CreatedAt }
+	updatedAtKey   = func(row *taskRow) interface{} { return row.task.UpdatedAt }
+	completedAtKey = func(row *taskRow) interface{} { return row.task.CompletedAt }
+	idKey          = func(row *taskRow) interface{} { return row.task.Id }
+)
+
+// sortKeys returns the keys of the requested sort, the same ones the SQLite
+// repository orders by. The id is always the last key so that every task
+// has a unique position, which keyset pagination relies on.
+func sortKeys(sort, order string) []sortKey {
+	desc := order == dtos.OrderDesc
+
+	switch sort {
+	case dtos.SortBySmart:
+		return []sortKey{
+			{completedKey, desc},
+			{overdueKey, !desc},
+			{priorityKey, !desc},
+			{dueAtKey, desc},
+			{idKey, desc},
+		}
+	case dtos.SortByPriority:
+		return []sortKey{{priorityKey, desc}, {idKey, desc}}
+	case dtos.SortByDueDate:
+		return []sortKey{{dueAtKey, desc}, {idKey, desc}}
+	case dtos.SortByTitle:
+		return []sortKey{{titleKey, desc}, {idKey, desc}}
+	case dtos.SortByUpdatedAt:
+		return []sortKey{{updatedAtKey, desc}, {idKey, desc}}
+	case dtos.SortByCompletedAt:
+		return []sortKey{{completedAtKey, desc}, {idKey, desc}}
+	default:
+		// "created" is the older name of created_at.
+		return []sortKey{{createdAtKey, desc}, {idKey, desc}}
+	}
+}
+
+func keyValues(keys []sortKey, row *taskRow) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		values[i] = key.value(row)
+	}
+	return values
+}
+
+// compareKeys compares two positions in the order of the keys. Values come
+// from keyValues or a decoded cursor, so a value of another type means the
+// cursor is damaged.
+func compareKeys(keys []sortKey, a, b []interface{}) (int, error) {
+	for i, key := range keys {
+		c, err := compareValues(a[i], b[i])
+		if err != nil {
+			return 0, err
+		}
+
+		if c != 0 {
+			if key.desc {
+				return -c, nil
+			}
+			return c, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func compareValues(a, b interface{}) (int, error) {
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, internalErrors.InvalidCursor
+		}
+		return strings.Compare(av, bv), nil
+	case int64, float64:
+		af, aok := number(a)
+		bf, bok := number(b)
+		if !aok || !bok {
+			return 0, internalErrors.InvalidCursor
+		}
+
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	return 0, internalErrors.InvalidCursor
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// matches applies the filters of the query to the task. Callers hold the
+// lock.
+func (s *Store) matches(row *taskRow, query *dtos.GetTasksQuery) bool {
+	task := &row.task
+
+	if task.OwnerId != query.OwnerId || task.DeletedAt != "" {
+		return false
+	}
+	if query.ParentId != "" && task.ParentId != query.ParentId {
+		return false
+	}
+	if query.SeriesId != "" && task.SeriesId != query.SeriesId {
+		return false
+	}
+	if query.ProjectId != "" && task.ProjectId != query.ProjectId {
+		return false
+	}
+	if len(query.Tags) > 0 && !s.matchesTags(task.Id, query.Tags, query.TagMode == dtos.TagModeAll) {
+		return false
+	}
+	if len(query.Priorities) > 0 {
+		found := false
+		for _, priority := range query.Priorities {
+			level, _ := models.PriorityLevel(priority)
+			found = found || level == priorityLevel(task.Priority)
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.Completed != nil && task.Completed != *query.Completed {
+		return false
+	}
+	if query.Overdue != nil && task.Overdue != *query.Overdue {
+		return false
+	}
+	if query.DueAfter != "" && (task.DueAt == "" || task.DueAt <= query.DueAfter) {
+		return false
+	}
+	if query.DueUntil != "" && (task.DueAt == "" || task.DueAt > query.DueUntil) {
+		return false
+	}
+	if query.Title != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(query.Title)) {
+		return false
+	}
+
+	return true
+}
+
+// matchesTags reports whether the task has any, or with all every one, of the
+// tag names, which are compared ignoring case.
+func (s *Store) matchesTags(taskId string, names []string, all bool) bool {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	found := 0
+	for tagId := range s.taskTags[taskId] {
+		if tag, ok := s.tags[tagId]; ok && wanted[strings.ToLower(tag.Name)] {
+			found++
+		}
+	}
+
+	if all {
+		return found == len(wanted)
+	}
+	return found > 0
+}
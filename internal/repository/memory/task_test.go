@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepository(NewStore())
+
+	for _, id := range []string{"1", "2"} {
+		if err := repo.Save(ctx, &models.Task{Id: id, Title: "Task " + id, Version: 1}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	updates := make(chan error, 20)
+	blockers := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			version := int64(1)
+			updates <- repo.Update(ctx, "1", &dtos.UpdateTaskCommand{Title: "Renamed", Version: &version}, "2024-11-20T10:00:00Z", nil)
+		}()
+
+		// Half of the calls add each direction; only one can win without a
+		// cycle.
+		taskId, blockerId := "1", "2"
+		if i%2 == 1 {
+			taskId, blockerId = "2", "1"
+		}
+		go func() {
+			defer wg.Done()
+			blockers <- repo.AddBlocker(ctx, taskId, blockerId, nil)
+		}()
+	}
+
+	wg.Wait()
+	close(updates)
+	close(blockers)
+
+	applied := 0
+	for err := range updates {
+		switch {
+		case err == nil:
+			applied++
+		case !errors.Is(err, internalErrors.VersionConflict):
+			t.Errorf("expected VersionConflict, got %v", err)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("expected exactly one update to apply, got %d", applied)
+	}
+
+	cycles := 0
+	for err := range blockers {
+		if errors.Is(err, internalErrors.DependencyCycle) {
+			cycles++
+		} else if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if cycles != 10 {
+		t.Errorf("expected one direction to be refused, got %d refusals", cycles)
+	}
+
+	task, err := repo.GetById(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Version != 2 || task.Title != "Renamed" {
+		t.Errorf("expected a single update, got %+v", task)
+	}
+}
+
+func TestSaveCopiesTask(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTaskRepository(NewStore())
+
+	task := &models.Task{Id: "1", Title: "Buy milk", Version: 1, Tags: []string{"shop"}}
+	if err := repo.Save(ctx, task, nil); err != nil {
+		t.Fatal(err)
+	}
+	task.Title = "Changed"
+
+	got, err := repo.GetById(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Buy milk" || got.Tags != nil || got.Priority != models.PriorityNone {
+		t.Errorf("expected the stored row only, got %+v", got)
+	}
+
+	got.Title = "Changed again"
+	if again, _ := repo.GetById(ctx, "1"); again.Title != "Buy milk" {
+		t.Errorf("expected returned tasks not to alias the store, got %+v", again)
+	}
+
+	if err := repo.Save(ctx, task, nil); !errors.Is(err, DuplicateKey) {
+		t.Errorf("expected DuplicateKey, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"strings"
+)
+
+type authToken struct {
+	userId    string
+	createdAt string
+	expiresAt string
+}
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+// Save refuses a username that differs from a taken one only in case.
+func (s *UserRepository) Save(ctx context.Context, user *models.User) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.users[user.Id]; ok {
+		return DuplicateKey
+	}
+
+	for _, existing := range s.store.users {
+		if strings.EqualFold(existing.Username, user.Username) {
+			return internalErrors.UserAlreadyExists
+		}
+	}
+
+	s.store.users[user.Id] = *user
+
+	return nil
+}
+
+func (s *UserRepository) GetById(ctx context.Context, id string) (*models.User, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	user, ok := s.store.users[id]
+	if !ok {
+		return nil, internalErrors.UserNotFound
+	}
+
+	return &user, nil
+}
+
+func (s *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	for _, user := range s.store.users {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+
+	return nil, internalErrors.UserNotFound
+}
+
+// Update saves the settings of the user: the time zone and the email.
+func (s *UserRepository) Update(ctx context.Context, user *models.User) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	stored, ok := s.store.users[user.Id]
+	if !ok {
+		return internalErrors.UserNotFound
+	}
+
+	stored.TimeZone = user.TimeZone
+	stored.Email = user.Email
+	s.store.users[user.Id] = stored
+
+	return nil
+}
+
+func (s *UserRepository) SaveToken(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.tokens[tokenHash]; ok {
+		return DuplicateKey
+	}
+
+	s.store.tokens[tokenHash] = authToken{userId: userId, createdAt: createdAt, expiresAt: expiresAt}
+
+	return nil
+}
+
+func (s *UserRepository) GetUserByToken(ctx context.Context, tokenHash, now string) (*models.User, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	token, ok := s.store.tokens[tokenHash]
+	if !ok || token.expiresAt <= now {
+		return nil, internalErrors.UserNotFound
+	}
+
+	user, ok := s.store.users[token.userId]
+	if !ok {
+		return nil, internalErrors.UserNotFound
+	}
+
+	return &user, nil
+}
+
+func (s *UserRepository) DeleteToken(ctx context.Context, tokenHash string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	delete(s.store.tokens, tokenHash)
+
+	return nil
+}
+
+func (s *UserRepository) DeleteExpiredTokens(ctx context.Context, now string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	for tokenHash, token := range s.store.tokens {
+		if token.expiresAt <= now {
+			delete(s.store.tokens, tokenHash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"sort"
+)
+
+type webhookRow struct {
+	webhook models.Webhook
+	seq     int64
+}
+
+type deliveryRow struct {
+	delivery     models.WebhookDelivery
+	claimedUntil string
+	seq          int64
+}
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{store: store}
+}
+
+// webhookCopy returns the webhook with its own slice of events, which are
+// never nil, as read from SQLite.
+func webhookCopy(webhook models.Webhook) *models.Webhook {
+	webhook.Events = append([]string{}, webhook.Events...)
+	return &webhook
+}
+
+func deliveryCopy(delivery models.WebhookDelivery) *models.WebhookDelivery {
+	delivery.Payload = append(json.RawMessage{}, delivery.Payload...)
+	return &delivery
+}
+
+func (s *WebhookRepository) Save(ctx context.Context, webhook *models.Webhook) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.webhooks[webhook.Id]; ok {
+		return DuplicateKey
+	}
+
+	s.store.webhooks[webhook.Id] = &webhookRow{webhook: *webhookCopy(*webhook), seq: s.store.nextSeq()}
+
+	return nil
+}
+
+func (s *WebhookRepository) GetById(ctx context.Context, id string) (*models.Webhook, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	row, ok := s.store.webhooks[id]
+	if !ok {
+		return nil, internalErrors.WebhookNotFound
+	}
+
+	return webhookCopy(row.webhook), nil
+}
+
+func (s *WebhookRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Webhook, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var rows []*webhookRow
+	for _, row := range s.store.webhooks {
+		if row.webhook.OwnerId == ownerId {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].webhook.CreatedAt != rows[j].webhook.CreatedAt {
+			return rows[i].webhook.CreatedAt < rows[j].webhook.CreatedAt
+		}
+		return rows[i].seq < rows[j].seq
+	})
+
+	webhooks := []*models.Webhook{}
+	for _, row := range rows {
+		webhooks = append(webhooks, webhookCopy(row.webhook))
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.webhooks[webhook.Id]
+	if !ok {
+		return internalErrors.WebhookNotFound
+	}
+
+	row.webhook.URL = webhook.URL
+	row.webhook.Secret = webhook.Secret
+	row.webhook.Events = append([]string{}, webhook.Events...)
+	row.webhook.UpdatedAt = webhook.UpdatedAt
+
+	return nil
+}
+
+// Delete removes the webhook together with its deliveries.
+func (s *WebhookRepository) Delete(ctx context.Context, id string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.webhooks[id]; !ok {
+		return internalErrors.WebhookNotFound
+	}
+
+	delete(s.store.webhooks, id)
+	for deliveryId, row := range s.store.deliveries {
+		if row.delivery.WebhookId == id {
+			delete(s.store.deliveries, deliveryId)
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.deliveries[delivery.Id]; ok {
+		return DuplicateKey
+	}
+
+	s.store.deliveries[delivery.Id] = &deliveryRow{delivery: *deliveryCopy(*delivery), seq: s.store.nextSeq()}
+
+	return nil
+}
+
+// ClaimDeliveries takes up to limit pending deliveries whose next attempt is
+// due at now, the same way ReminderRepository.ClaimDue takes reminders.
+func (s *WebhookRepository) ClaimDeliveries(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	var rows []*deliveryRow
+	for _, row := range s.store.deliveries {
+		if row.delivery.Status == models.DeliveryPending && row.delivery.NextAttemptAt <= now && (row.claimedUntil == "" || row.claimedUntil <= now) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].delivery.NextAttemptAt != rows[j].delivery.NextAttemptAt {
+			return rows[i].delivery.NextAttemptAt < rows[j].delivery.NextAttemptAt
+		}
+		return rows[i].seq < rows[j].seq
+	})
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, row := range rows {
+		row.claimedUntil = claimedUntil
+		row.delivery.Attempts++
+		deliveries = append(deliveries, deliveryCopy(row.delivery))
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery records the outcome of an attempt and releases the claim.
+func (s *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	row, ok := s.store.deliveries[delivery.Id]
+	if !ok {
+		return nil
+	}
+
+	row.delivery.Status = delivery.Status
+	row.delivery.NextAttemptAt = delivery.NextAttemptAt
+	row.delivery.LastStatusCode = delivery.LastStatusCode
+	row.delivery.LastError = delivery.LastError
+	row.delivery.DeliveredAt = delivery.DeliveredAt
+	row.claimedUntil = ""
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the webhook, newest first.
+func (s *WebhookRepository) GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	var rows []*deliveryRow
+	for _, row := range s.store.deliveries {
+		if row.delivery.WebhookId == webhookId {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].delivery.CreatedAt != rows[j].delivery.CreatedAt {
+			return rows[i].delivery.CreatedAt > rows[j].delivery.CreatedAt
+		}
+		return rows[i].seq > rows[j].seq
+	})
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	deliveries := []*models.WebhookDelivery{}
+	for _, row := range rows {
+		deliveries = append(deliveries, deliveryCopy(row.delivery))
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/repository/repositorytest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Repositories {
+		db := newTestDB(t)
+
+		return repositorytest.Repositories{
+			Tasks:    NewTaskRepository(db),
+			Projects: NewProjectRepository(db),
+			Tags:     NewTagRepository(db),
+			History:  NewHistoryRepository(db),
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+// dependencyLock is the key of the advisory lock AddBlocker holds.
+const dependencyLock = 7243001
+
+// AddBlocker checks for a cycle and inserts the dependency in one transaction.
+// Unlike SQLite, PostgreSQL runs writers concurrently, so the transaction
+// takes an advisory lock to keep two requests from closing a cycle between
+// them.
+func (s *TaskRepository) AddBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	if taskId == blockerId {
+		return internalErrors.DependencyCycle
+	}
+
+	if !isId(taskId) || !isId(blockerId) {
+		return internalErrors.TaskNotFound
+	}
+
+	return changeTask(ctx, s.db, taskId, nil, record, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, dependencyLock); err != nil {
+			logger.ErrorLogger.Printf("failed to lock dependencies: %v", err)
+			return err
+		}
+
+		// The new edge closes a cycle if the blocker already waits for the
+		// task, directly or through other tasks.
+		q := `WITH RECURSIVE blockers (id) AS (
+				SELECT blocker_id FROM task_dependencies WHERE task_id = $1
+				UNION
+				SELECT task_dependencies.blocker_id FROM task_dependencies JOIN blockers ON task_dependencies.task_id = blockers.id
+			  ) SELECT EXISTS(SELECT 1 FROM blockers WHERE id = $2)`
+
+		var cycle bool
+		if err := tx.QueryRowContext(ctx, q, blockerId, taskId).Scan(&cycle); err != nil {
+			logger.ErrorLogger.Printf("failed to check dependency cycle: %v", err)
+			return err
+		}
+
+		if cycle {
+			return internalErrors.DependencyCycle
+		}
+
+		res, err := tx.ExecContext(ctx, `INSERT INTO task_dependencies (task_id, blocker_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, taskId, blockerId)
+		if err != nil {
+			logger.ErrorLogger.Printf("failed to add blocker: %v", err)
+			return err
+		}
+
+		return bumpVersion(ctx, tx, res, taskId)
+	})
+}
+
+// RemoveBlocker is a no-op when the task does not wait for blockerId;
+// otherwise the task moves to its next version in the same transaction.
+func (s *TaskRepository) RemoveBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	if !isId(taskId) {
+		return internalErrors.TaskNotFound
+	}
+
+	return changeTask(ctx, s.db, taskId, nil, record, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM task_dependencies WHERE task_id = $1 AND blocker_id = $2`, taskId, blockerId)
+		if err != nil {
+			logger.ErrorLogger.Printf("failed to remove blocker: %v", err)
+			return err
+		}
+
+		return bumpVersion(ctx, tx, res, taskId)
+	})
+}
+
+// loadBlockers fills BlockedBy of the given tasks and marks the ones with an
+// open blocker as Blocked. Blockers in the trash are left out.
+func loadBlockers(ctx context.Context, db queryer, tasks ...*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids, byId := taskIds(tasks)
+
+	q := `SELECT task_dependencies.task_id, task_dependencies.blocker_id, tasks.completed
+		  FROM task_dependencies
+		  JOIN tasks ON tasks.id = task_dependencies.blocker_id AND tasks.deleted_at IS NULL
+		  WHERE task_dependencies.task_id = ANY($1::uuid[])
+		  ORDER BY tasks.seq`
+
+	rows, err := db.QueryContext(ctx, q, uuids(ids))
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch task blockers: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId, blockerId string
+		var completed bool
+		if err := rows.Scan(&taskId, &blockerId, &completed); err != nil {
+			logger.ErrorLogger.Printf("failed to scan task blocker: %v", err)
+			return err
+		}
+
+		task := byId[taskId]
+		task.BlockedBy = append(task.BlockedBy, blockerId)
+		task.Blocked = task.Blocked || !completed
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+const historyColumns = `id, task_id, action, actor_id, changes, version, reverts, cause, created_at`
+
+type HistoryRepository struct {
+	db *sql.DB
+}
+
+func NewHistoryRepository(db *sql.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+// changesField scans the JSON list of changed fields.
+type changesField struct {
+	changes *[]models.FieldChange
+}
+
+func (c changesField) Scan(value interface{}) error {
+	ns := sql.NullString{}
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+
+	*c.changes = []models.FieldChange{}
+	if ns.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(ns.String), c.changes)
+}
+
+func historyFields(entry *models.HistoryEntry) []interface{} {
+	return []interface{}{
+		&entry.Id,
+		&entry.TaskId,
+		&entry.Action,
+		&entry.ActorId,
+		changesField{&entry.Changes},
+		&entry.Version,
+		nullableString{&entry.Reverts},
+		timeField{&entry.CreatedAt},
+	}
+}
+
+func (s *HistoryRepository) Save(ctx context.Context, entry *models.HistoryEntry) error {
+	return saveEntry(ctx, s.db, entry)
+}
+
+// execer runs statements on a *sql.DB or within a *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func saveEntry(ctx context.Context, db execer, entry *models.HistoryEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	q := `INSERT INTO task_history (` + historyColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = db.ExecContext(ctx, q, entry.Id, entry.TaskId, entry.Action, entry.ActorId, string(changes), entry.Version, nullIfEmpty(entry.Reverts), nullIfEmpty(entry.Cause), entry.CreatedAt)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save history entry: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// recordChange saves the entry record makes of the change of a task in the
+// transaction of the change.
+func recordChange(ctx context.Context, tx *sql.Tx, record repository.Recorder, before, after *models.Task) error {
+	if record == nil {
+		return nil
+	}
+
+	entry := record(before, after)
+	if entry == nil {
+		return nil
+	}
+
+	return saveEntry(ctx, tx, entry)
+}
+
+func (s *HistoryRepository) GetByTask(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return s.getPage(ctx, "task_id", query.TaskId, "history", query)
+}
+
+func (s *HistoryRepository) GetByActor(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return s.getPage(ctx, "actor_id", query.ActorId, "actor_history", query)
+}
+
+// getPage returns the entries whose column equals value, newest first. The
+// cursor is bound to the value under cursorKind instead of a sort order.
+func (s *HistoryRepository) getPage(ctx context.Context, column, value, cursorKind string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	keys := []sortKey{{"created_at", true}, {"seq", true}}
+
+	var args params
+	q := `SELECT ` + historyColumns + `, seq FROM task_history WHERE ` + column + ` = ` + args.add(value)
+
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, cursorKind, value, len(keys))
+		if err != nil {
+			return nil, err
+		}
+
+		q += " AND " + keysetCondition(keys, values, &args)
+	}
+
+	q += ` ORDER BY created_at DESC, seq DESC LIMIT ` + args.add(query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch task history: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &dtos.HistoryPage{Entries: []*models.HistoryEntry{}}
+	var lastValues []interface{}
+
+	for rows.Next() {
+		entry := &models.HistoryEntry{}
+		var seq int64
+
+		if err := rows.Scan(append(historyFields(entry), &seq)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan history entry: %v", err)
+			return nil, err
+		}
+
+		if len(page.Entries) == query.Limit {
+			page.NextCursor = repository.EncodeCursor(cursorKind, value, lastValues)
+			break
+		}
+
+		page.Entries = append(page.Entries, entry)
+		lastValues = []interface{}{entry.CreatedAt, seq}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return page, nil
+}
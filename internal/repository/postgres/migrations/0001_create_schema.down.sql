@@ -0,0 +1,12 @@
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TRIGGER reminders_task_due ON tasks;
+DROP FUNCTION reminders_follow_due();
+DROP TABLE reminders;
+DROP TABLE task_dependencies;
+DROP TABLE task_tags;
+DROP TABLE tags;
+DROP TABLE tasks;
+DROP TABLE projects;
+DROP TABLE auth_tokens;
+DROP TABLE users;
@@ -0,0 +1,182 @@
+-- The schema matches the one the SQLite migrations build up, with native
+-- types: ids are uuid, due dates are date and instants are timestamptz.
+-- seq keeps the insertion order where SQLite uses the rowid.
+CREATE TABLE users
+(
+    id            UUID PRIMARY KEY,
+    username      TEXT        NOT NULL,
+    password_hash TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    time_zone     TEXT        NOT NULL DEFAULT 'UTC',
+    email         TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX idx_users_username ON users (lower(username));
+
+CREATE TABLE auth_tokens
+(
+    token_hash TEXT PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_auth_tokens_expires_at ON auth_tokens (expires_at);
+
+CREATE TABLE projects
+(
+    id          UUID PRIMARY KEY,
+    seq         BIGSERIAL   NOT NULL,
+    name        TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    archived    BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    owner_id    UUID        NOT NULL
+);
+
+CREATE INDEX idx_projects_owner_id ON projects (owner_id, archived);
+
+-- search is the full-text document of the task: a match in the title is
+-- weighted A, one in the description B.
+CREATE TABLE tasks
+(
+    id           UUID PRIMARY KEY,
+    seq          BIGSERIAL   NOT NULL UNIQUE,
+    title        TEXT        NOT NULL DEFAULT '',
+    description  TEXT        NOT NULL DEFAULT '',
+    due_date     DATE,
+    all_day      BOOLEAN     NOT NULL DEFAULT TRUE,
+    due_at       TIMESTAMPTZ,
+    overdue      BOOLEAN     NOT NULL DEFAULT FALSE,
+    completed    BOOLEAN     NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMPTZ,
+    version      BIGINT      NOT NULL DEFAULT 1,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    owner_id     UUID        NOT NULL,
+    project_id   UUID REFERENCES projects (id),
+    priority     SMALLINT    NOT NULL DEFAULT 0,
+    parent_id    UUID REFERENCES tasks (id),
+    recurrence   TEXT        NOT NULL DEFAULT '',
+    series_id    UUID,
+    occurrence   INTEGER     NOT NULL DEFAULT 0,
+    search       TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple'::regconfig, title), 'A') ||
+        setweight(to_tsvector('simple'::regconfig, description), 'B')
+    ) STORED
+);
+
+CREATE INDEX idx_tasks_owner_id ON tasks (owner_id, priority);
+CREATE INDEX idx_tasks_created_at ON tasks (created_at);
+CREATE INDEX idx_tasks_due_at ON tasks (due_at);
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
+CREATE INDEX idx_tasks_parent_id ON tasks (parent_id);
+CREATE INDEX idx_tasks_series_id ON tasks (series_id);
+CREATE INDEX idx_tasks_search ON tasks USING GIN (search);
+
+CREATE TABLE tags
+(
+    id         UUID PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    owner_id   UUID        NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_tags_owner_id_name ON tags (owner_id, lower(name));
+
+CREATE TABLE task_tags
+(
+    task_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    tag_id  UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX idx_task_tags_tag_id ON task_tags (tag_id);
+
+CREATE TABLE task_dependencies
+(
+    task_id    UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    blocker_id UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX idx_task_dependencies_blocker_id ON task_dependencies (blocker_id);
+
+-- A reminder fires at remind_at, or before_seconds before the task is due.
+-- claimed_until is set while a scheduler sends it, so it is sent only once.
+CREATE TABLE reminders
+(
+    id             UUID PRIMARY KEY,
+    seq            BIGSERIAL   NOT NULL,
+    task_id        UUID        NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    remind_at      TIMESTAMPTZ,
+    before_seconds BIGINT,
+    fire_at        TIMESTAMPTZ NOT NULL,
+    status         TEXT        NOT NULL DEFAULT 'pending',
+    attempts       INTEGER     NOT NULL DEFAULT 0,
+    claimed_until  TIMESTAMPTZ,
+    sent_at        TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL,
+    owner_id       UUID        NOT NULL
+);
+
+CREATE INDEX idx_reminders_task_id ON reminders (task_id);
+
+CREATE INDEX idx_reminders_pending ON reminders (fire_at) WHERE status = 'pending';
+
+-- Pending reminders relative to the due date follow it when it changes.
+CREATE FUNCTION reminders_follow_due() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE reminders
+    SET fire_at = NEW.due_at - before_seconds * INTERVAL '1 second'
+    WHERE task_id = NEW.id AND before_seconds IS NOT NULL AND status = 'pending';
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reminders_task_due
+    AFTER UPDATE OF due_at ON tasks
+    FOR EACH ROW
+    WHEN (NEW.due_at IS DISTINCT FROM OLD.due_at AND NEW.due_at IS NOT NULL)
+EXECUTE FUNCTION reminders_follow_due();
+
+CREATE TABLE webhooks
+(
+    id         UUID PRIMARY KEY,
+    seq        BIGSERIAL   NOT NULL,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    owner_id   UUID        NOT NULL
+);
+
+CREATE INDEX idx_webhooks_owner_id ON webhooks (owner_id);
+
+-- webhook_deliveries is the queue of events to send and the log of sent ones.
+-- The payload is json rather than jsonb so it is sent byte for byte as it
+-- was queued.
+CREATE TABLE webhook_deliveries
+(
+    id               UUID PRIMARY KEY,
+    seq              BIGSERIAL   NOT NULL,
+    webhook_id       UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         UUID        NOT NULL,
+    event_type       TEXT        NOT NULL,
+    payload          JSON        NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL,
+    claimed_until    TIMESTAMPTZ,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP TRIGGER task_history_append_only ON task_history;
+DROP FUNCTION task_history_append_only();
+DROP TABLE task_history;
@@ -0,0 +1,28 @@
+-- task_history is an append-only log of task changes. It has no foreign key
+-- to tasks, so the history outlives purged tasks. actor_id is text because
+-- changes made by the server itself have the actor "system".
+CREATE TABLE task_history
+(
+    id         UUID PRIMARY KEY,
+    seq        BIGSERIAL   NOT NULL,
+    task_id    UUID        NOT NULL,
+    action     TEXT        NOT NULL,
+    actor_id   TEXT        NOT NULL,
+    changes    JSON        NOT NULL,
+    version    BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_task_history_task_id ON task_history (task_id, created_at);
+
+CREATE FUNCTION task_history_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'task history is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER task_history_append_only
+    BEFORE UPDATE OR DELETE ON task_history
+    FOR EACH ROW
+EXECUTE FUNCTION task_history_append_only();
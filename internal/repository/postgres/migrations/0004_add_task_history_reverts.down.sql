@@ -0,0 +1,3 @@
+DROP INDEX idx_task_history_actor_id;
+
+ALTER TABLE task_history DROP COLUMN reverts;
@@ -0,0 +1,5 @@
+-- Entries of undone and redone changes refer to the entry they revert. The
+-- history of an actor is read newest first to find what to undo or redo.
+ALTER TABLE task_history ADD COLUMN reverts UUID;
+
+CREATE INDEX idx_task_history_actor_id ON task_history (actor_id, created_at);
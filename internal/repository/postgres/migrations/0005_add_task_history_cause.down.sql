@@ -0,0 +1 @@
+ALTER TABLE task_history DROP COLUMN cause;
@@ -0,0 +1 @@
+ALTER TABLE tasks ALTER COLUMN due_date TYPE DATE USING substr(due_date, 1, 10)::DATE;
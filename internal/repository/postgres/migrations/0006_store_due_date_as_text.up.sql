@@ -0,0 +1,9 @@
+-- due_date holds what the user sees: a date for all-day tasks, an RFC 3339
+-- time with the offset of the owner's time zone for timed ones. As a DATE the
+-- time of timed tasks was lost, so it is restored in UTC from due_at.
+ALTER TABLE tasks ALTER COLUMN due_date TYPE TEXT USING to_char(due_date, 'YYYY-MM-DD');
+
+UPDATE tasks
+SET due_date = to_char(due_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+WHERE NOT all_day
+  AND due_at IS NOT NULL;
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"time"
+)
+
+const reminderColumns = `id, task_id, remind_at, before_seconds, fire_at, status, attempts, sent_at, created_at, owner_id`
+
+type ReminderRepository struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// durationField scans a number of seconds into a duration string.
+type durationField struct {
+	s *string
+}
+
+func (d durationField) Scan(value interface{}) error {
+	seconds := sql.NullInt64{}
+	if err := seconds.Scan(value); err != nil {
+		return err
+	}
+
+	*d.s = ""
+	if seconds.Valid {
+		*d.s = (time.Duration(seconds.Int64) * time.Second).String()
+	}
+	return nil
+}
+
+func nullIfNoDuration(s string) interface{} {
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return nil
+	}
+	return int64(duration / time.Second)
+}
+
+// reminderFields returns scan destinations in the order of reminderColumns.
+func reminderFields(reminder *models.Reminder) []interface{} {
+	return []interface{}{
+		&reminder.Id,
+		&reminder.TaskId,
+		timeField{&reminder.RemindAt},
+		durationField{&reminder.Before},
+		timeField{&reminder.FireAt},
+		&reminder.Status,
+		&reminder.Attempts,
+		timeField{&reminder.SentAt},
+		timeField{&reminder.CreatedAt},
+		&reminder.OwnerId,
+	}
+}
+
+func (s *ReminderRepository) Save(ctx context.Context, reminder *models.Reminder) error {
+	q := `INSERT INTO reminders (` + reminderColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		reminder.Id,
+		reminder.TaskId,
+		nullIfEmpty(reminder.RemindAt),
+		nullIfNoDuration(reminder.Before),
+		reminder.FireAt,
+		reminder.Status,
+		reminder.Attempts,
+		nullIfEmpty(reminder.SentAt),
+		reminder.CreatedAt,
+		reminder.OwnerId,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save reminder: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ReminderRepository) GetById(ctx context.Context, id string) (*models.Reminder, error) {
+	if !isId(id) {
+		return nil, internalErrors.ReminderNotFound
+	}
+
+	q := `SELECT ` + reminderColumns + ` FROM reminders WHERE id = $1`
+
+	reminder := &models.Reminder{}
+
+	err := s.db.QueryRowContext(ctx, q, id).Scan(reminderFields(reminder)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internalErrors.ReminderNotFound
+		}
+
+		logger.ErrorLogger.Printf("failed to fetch reminder: %v", err)
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *ReminderRepository) GetByTask(ctx context.Context, taskId string) ([]*models.Reminder, error) {
+	q := `SELECT ` + reminderColumns + ` FROM reminders WHERE task_id = $1 ORDER BY fire_at, seq`
+
+	return s.getMany(ctx, q, taskId)
+}
+
+func (s *ReminderRepository) getMany(ctx context.Context, q string, args ...interface{}) ([]*models.Reminder, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch reminders: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*models.Reminder{}
+
+	for rows.Next() {
+		reminder := &models.Reminder{}
+		if err := rows.Scan(reminderFields(reminder)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan reminder: %v", err)
+			return nil, err
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (s *ReminderRepository) Delete(ctx context.Context, id string) error {
+	if !isId(id) {
+		return internalErrors.ReminderNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM reminders WHERE id = $1`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete reminder: %v", err)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return internalErrors.ReminderNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue marks up to limit pending reminders that are due at now as taken
+// until claimedUntil and returns them. Rows locked by the claim of another
+// scheduler are skipped, so a reminder is handed out once even with several
+// schedulers; a claim that expires without Finish or Retry, e.g. after a
+// crash, is handed out again.
+func (s *ReminderRepository) ClaimDue(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error) {
+	q := `UPDATE reminders
+		  SET claimed_until = $1, attempts = attempts + 1
+		  WHERE id IN (
+			  SELECT id FROM reminders
+			  WHERE status = 'pending' AND fire_at <= $2 AND (claimed_until IS NULL OR claimed_until <= $2)
+			  ORDER BY fire_at
+			  LIMIT $3
+			  FOR UPDATE SKIP LOCKED
+		  )
+		  RETURNING ` + reminderColumns
+
+	return s.getMany(ctx, q, claimedUntil, now, limit)
+}
+
+// Finish records the outcome of a claimed reminder, so it is not claimed again.
+func (s *ReminderRepository) Finish(ctx context.Context, id, status, sentAt string) error {
+	q := `UPDATE reminders SET status = $1, sent_at = $2, claimed_until = NULL WHERE id = $3`
+
+	_, err := s.db.ExecContext(ctx, q, status, nullIfEmpty(sentAt), id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to finish reminder: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Retry keeps a claimed reminder pending but not claimable before retryAt.
+func (s *ReminderRepository) Retry(ctx context.Context, id, retryAt string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE reminders SET claimed_until = $1 WHERE id = $2`, retryAt, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to reschedule reminder: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ts_rank weights for D, C, B and A: a match in the title (A) counts ten
+	// times more than one in the description (B). The rank is negated so
+	// that, like bm25 in SQLite, lower is better.
+	searchRank     = `(-ts_rank('{0, 0, 0.1, 1.0}', tasks.search, search_query))::float8`
+	highlightStart = `<mark>`
+	highlightEnd   = `</mark>`
+	highlight      = `ts_headline('simple', tasks.title, search_query, 'StartSel=` + highlightStart + `, StopSel=` + highlightEnd + `, HighlightAll=true')`
+	snippet        = `ts_headline('simple', tasks.description, search_query, 'StartSel=` + highlightStart + `, StopSel=` + highlightEnd + `, MaxWords=12, MinWords=4')`
+)
+
+// buildTSQuery turns user input into a tsquery with the same rules as the
+// SQLite search: double-quoted parts are phrases, words ending with * are
+// prefixes and everything else is a plain term; all parts must match. Every
+// part is quoted so tsquery operators typed by the user are treated as text.
+func buildTSQuery(input string) string {
+	var parts []string
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `''`)
+
+	addPart := func(text string, prefix bool) {
+		text = strings.TrimSpace(text)
+		if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return
+		}
+
+		part := `'` + quote.Replace(text) + `'`
+		if prefix {
+			part += ":*"
+		}
+		parts = append(parts, part)
+	}
+
+	for input != "" {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		if input == "" {
+			break
+		}
+
+		if input[0] == '"' {
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				addPart(input[1:], false)
+				break
+			}
+			addPart(input[1:end+1], false)
+			input = input[end+2:]
+			continue
+		}
+
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		if end < 0 {
+			end = len(input)
+		}
+		word := input[:end]
+		input = input[end:]
+
+		prefix := strings.HasSuffix(word, "*")
+		addPart(strings.TrimRight(word, "*"), prefix)
+	}
+
+	return strings.Join(parts, " & ")
+}
+
+func (s *TaskRepository) Search(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	match := buildTSQuery(query.Query)
+	if match == "" {
+		return &dtos.SearchPage{}, nil
+	}
+
+	keys := []sortKey{{searchRank, false}, {"tasks.seq", false}}
+
+	var args params
+	q := `SELECT ` + prefixColumns("tasks", taskColumns) + `,
+			  ` + highlight + `,
+			  ` + snippet + `,
+			  ` + searchRank + `, tasks.seq
+		  FROM tasks, to_tsquery('simple', ` + args.add(match) + `) search_query
+		  WHERE tasks.search @@ search_query AND tasks.deleted_at IS NULL AND tasks.owner_id = ` + args.add(query.OwnerId)
+
+	// The cursor is bound to the match expression instead of a sort order.
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, "search", match, len(keys))
+		if err != nil {
+			return nil, err
+		}
+
+		q += " AND " + keysetCondition(keys, values, &args)
+	}
+
+	q += ` ORDER BY ` + searchRank + `, tasks.seq LIMIT ` + args.add(query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to search tasks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &dtos.SearchPage{}
+	var lastValues []interface{}
+
+	for rows.Next() {
+		result := &dtos.SearchResult{Task: &models.Task{}}
+		var seq int64
+
+		dest := append(taskFields(result.Task), &result.TitleHighlight, &result.Snippet, &result.Rank, &seq)
+		if err := rows.Scan(dest...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan search result: %v", err)
+			return nil, err
+		}
+
+		if len(page.Results) == query.Limit {
+			page.NextCursor = repository.EncodeCursor("search", match, lastValues)
+			break
+		}
+
+		page.Results = append(page.Results, result)
+		lastValues = []interface{}{result.Rank, seq}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	// The loop may stop before reading all rows, release the connection
+	// before loading tags and blockers.
+	rows.Close()
+
+	tasks := make([]*models.Task, len(page.Results))
+	for i, result := range page.Results {
+		tasks[i] = result.Task
+	}
+	if err := loadRelations(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func prefixColumns(table, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, part := range parts {
+		parts[i] = table + "." + part
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "terms", input: "buy milk", expected: `'buy' & 'milk'`},
+		{name: "phrase", input: `"buy milk" bread`, expected: `'buy milk' & 'bread'`},
+		{name: "prefix", input: "mil* bread", expected: `'mil':* & 'bread'`},
+		{name: "unterminated phrase", input: `"buy milk`, expected: `'buy milk'`},
+		{name: "operators are text", input: `milk | !bread <-> (a)`, expected: `'milk' & '!bread' & '(a)'`},
+		{name: "quotes are escaped", input: `it's a\b`, expected: `'it''s' & 'a\\b'`},
+		{name: "punctuation only", input: `* - ""`, expected: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTSQuery(tt.input); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+const tagColumns = `id, name, created_at, owner_id`
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+func tagFields(tag *models.Tag) []interface{} {
+	return []interface{}{&tag.Id, &tag.Name, timeField{&tag.CreatedAt}, &tag.OwnerId}
+}
+
+func (s *TagRepository) Save(ctx context.Context, tag *models.Tag) error {
+	q := `INSERT INTO tags (` + tagColumns + `) VALUES ($1, $2, $3, $4)`
+
+	_, err := s.db.ExecContext(ctx, q, tag.Id, tag.Name, tag.CreatedAt, tag.OwnerId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return internalErrors.TagAlreadyExists
+		}
+
+		logger.ErrorLogger.Printf("failed to save tag: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *TagRepository) GetById(ctx context.Context, id string) (*models.Tag, error) {
+	if !isId(id) {
+		return nil, internalErrors.TagNotFound
+	}
+
+	q := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1`
+
+	return s.getOne(ctx, q, id)
+}
+
+func (s *TagRepository) GetByName(ctx context.Context, ownerId, name string) (*models.Tag, error) {
+	q := `SELECT ` + tagColumns + ` FROM tags WHERE owner_id = $1 AND lower(name) = lower($2)`
+
+	return s.getOne(ctx, q, ownerId, name)
+}
+
+func (s *TagRepository) getOne(ctx context.Context, q string, args ...interface{}) (*models.Tag, error) {
+	tag := &models.Tag{}
+
+	err := s.db.QueryRowContext(ctx, q, args...).Scan(tagFields(tag)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internalErrors.TagNotFound
+		}
+
+		logger.ErrorLogger.Printf("failed to fetch tag: %v", err)
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (s *TagRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Tag, error) {
+	q := `SELECT ` + tagColumns + ` FROM tags WHERE owner_id = $1 ORDER BY lower(name)`
+
+	rows, err := s.db.QueryContext(ctx, q, ownerId)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch tags: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.Tag{}
+
+	for rows.Next() {
+		tag := &models.Tag{}
+		if err := rows.Scan(tagFields(tag)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan tag: %v", err)
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (s *TagRepository) Rename(ctx context.Context, id, name string) error {
+	if !isId(id) {
+		return internalErrors.TagNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE tags SET name = $1 WHERE id = $2`, name, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return internalErrors.TagAlreadyExists
+		}
+
+		logger.ErrorLogger.Printf("failed to rename tag: %v", err)
+		return err
+	}
+
+	return checkTagAffected(res)
+}
+
+func (s *TagRepository) Delete(ctx context.Context, id string) error {
+	if !isId(id) {
+		return internalErrors.TagNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete tag: %v", err)
+		return err
+	}
+
+	return checkTagAffected(res)
+}
+
+// Attach is a no-op when the tag is already attached to the task; otherwise
+// the task moves to its next version in the same transaction.
+func (s *TagRepository) Attach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	return s.changeTags(ctx, `INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, taskId, tagId, record)
+}
+
+// Detach is a no-op when the tag is not attached to the task.
+func (s *TagRepository) Detach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	return s.changeTags(ctx, `DELETE FROM task_tags WHERE task_id = $1 AND tag_id = $2`, taskId, tagId, record)
+}
+
+func (s *TagRepository) changeTags(ctx context.Context, q, taskId, tagId string, record repository.Recorder) error {
+	if !isId(taskId) {
+		return internalErrors.TaskNotFound
+	}
+	if !isId(tagId) {
+		return internalErrors.TagNotFound
+	}
+
+	return changeTask(ctx, s.db, taskId, nil, record, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, q, taskId, tagId)
+		if err != nil {
+			logger.ErrorLogger.Printf("failed to change tags of task %s: %v", taskId, err)
+			return err
+		}
+
+		return bumpVersion(ctx, tx, res, taskId)
+	})
+}
+
+func checkTagAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return internalErrors.TagNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/lib/pq"
+	"strings"
+)
+
+type sortKey struct {
+	expr string
+	desc bool
+}
+
+// sortKeys returns the ORDER BY expressions for the requested sort. The id
+// is always the last key so that every row has a unique position, which
+// keyset pagination relies on, and ties are broken the way SQLite breaks
+// them. Missing timestamps sort as -infinity, the way SQLite sorts them as
+// empty strings, before any real value.
+func sortKeys(sort, order string) []sortKey {
+	desc := order == dtos.OrderDesc
+
+	switch sort {
+	case dtos.SortBySmart:
+		// Open tasks first, overdue ones on top, then the most important and
+		// the most urgent; desc reverses the whole ranking.
+		return []sortKey{
+			{"completed", desc},
+			{"overdue", !desc},
+			{"priority", !desc},
+			{"COALESCE(due_at, '-infinity')", desc},
+			{"id", desc},
+		}
+	case dtos.SortByPriority:
+		return []sortKey{{"priority", desc}, {"id", desc}}
+	case dtos.SortByDueDate:
+		return []sortKey{{"COALESCE(due_at, '-infinity')", desc}, {"id", desc}}
+	case dtos.SortByTitle:
+		return []sortKey{{"lower(title)", desc}, {"id", desc}}
+	case dtos.SortByUpdatedAt:
+		return []sortKey{{"updated_at", desc}, {"id", desc}}
+	case dtos.SortByCompletedAt:
+		return []sortKey{{"COALESCE(completed_at, '-infinity')", desc}, {"id", desc}}
+	default:
+		// "created" is the older name of created_at.
+		return []sortKey{{"created_at", desc}, {"id", desc}}
+	}
+}
+
+// keysetCondition builds "(k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..." so the
+// next page starts right after the last row of the previous one.
+func keysetCondition(keys []sortKey, values []interface{}, args *params) string {
+	var ors []string
+
+	for i, key := range keys {
+		var ands []string
+
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].expr+" = "+args.add(values[j]))
+		}
+
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s %s", key.expr, op, args.add(values[i])))
+
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func escapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
+
+func buildGetAllQuery(query *dtos.GetTasksQuery) (string, []interface{}, []sortKey, error) {
+	keys := sortKeys(query.Sort, query.Order)
+
+	var args params
+	where := []string{"owner_id = " + args.add(query.OwnerId), "deleted_at IS NULL"}
+
+	if query.ParentId != "" {
+		where = append(where, "parent_id = "+args.add(query.ParentId))
+	}
+	if query.SeriesId != "" {
+		where = append(where, "series_id = "+args.add(query.SeriesId))
+	}
+	if query.ProjectId != "" {
+		where = append(where, "project_id = "+args.add(query.ProjectId))
+	}
+	if len(query.Tags) > 0 {
+		// Tag names are unique regardless of case, so they are matched the
+		// same way.
+		distinct := make(map[string]bool)
+		var names []string
+		for _, tag := range query.Tags {
+			name := strings.ToLower(tag)
+			if !distinct[name] {
+				distinct[name] = true
+				names = append(names, name)
+			}
+		}
+
+		condition := `id IN (SELECT task_tags.task_id FROM task_tags
+				JOIN tags ON tags.id = task_tags.tag_id
+				WHERE lower(tags.name) = ANY(` + args.add(pq.Array(names)) + `)`
+
+		// With "all" the task needs as many distinct matching tags as were
+		// requested.
+		if query.TagMode == dtos.TagModeAll {
+			condition += ` GROUP BY task_tags.task_id HAVING COUNT(DISTINCT tags.id) = ` + args.add(len(names))
+		}
+		where = append(where, condition+")")
+	}
+	if len(query.Priorities) > 0 {
+		levels := make([]int64, len(query.Priorities))
+		for i, priority := range query.Priorities {
+			level, _ := models.PriorityLevel(priority)
+			levels[i] = int64(level)
+		}
+		where = append(where, "priority = ANY("+args.add(pq.Array(levels))+")")
+	}
+	if query.Completed != nil {
+		where = append(where, "completed = "+args.add(*query.Completed))
+	}
+	if query.Overdue != nil {
+		where = append(where, "overdue = "+args.add(*query.Overdue))
+	}
+	if query.DueAfter != "" {
+		where = append(where, "due_at > "+args.add(query.DueAfter))
+	}
+	if query.DueUntil != "" {
+		where = append(where, "due_at <= "+args.add(query.DueUntil))
+	}
+	if query.Title != "" {
+		where = append(where, `title ILIKE `+args.add("%"+escapeLike(query.Title)+"%")+` ESCAPE '\'`)
+	}
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, query.Sort, query.Order, len(keys))
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		where = append(where, keysetCondition(keys, values, &args))
+	}
+
+	var selectKeys, orderBy []string
+	for _, key := range keys {
+		selectKeys = append(selectKeys, key.expr)
+
+		direction := "ASC"
+		if key.desc {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, key.expr+" "+direction)
+	}
+
+	q := `SELECT ` + taskColumns + `, ` + strings.Join(selectKeys, ", ") + ` FROM tasks`
+	q += " WHERE " + strings.Join(where, " AND ")
+	q += " ORDER BY " + strings.Join(orderBy, ", ")
+	q += " LIMIT " + args.add(query.Limit+1)
+
+	return q, args, keys, nil
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (s *UserRepository) Save(ctx context.Context, user *models.User) error {
+	q := `INSERT INTO users (id, username, password_hash, created_at, time_zone, email)
+			VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := s.db.ExecContext(ctx, q, user.Id, user.Username, user.PasswordHash, user.CreatedAt, user.TimeZone, user.Email)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return internalErrors.UserAlreadyExists
+		}
+
+		logger.ErrorLogger.Printf("failed to save user: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserRepository) GetById(ctx context.Context, id string) (*models.User, error) {
+	if !isId(id) {
+		return nil, internalErrors.UserNotFound
+	}
+
+	q := `SELECT id, username, password_hash, created_at, time_zone, email FROM users WHERE id = $1`
+
+	return s.getOne(ctx, q, id)
+}
+
+func (s *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	q := `SELECT id, username, password_hash, created_at, time_zone, email FROM users WHERE lower(username) = lower($1)`
+
+	return s.getOne(ctx, q, username)
+}
+
+func (s *UserRepository) getOne(ctx context.Context, q string, args ...interface{}) (*models.User, error) {
+	user := &models.User{}
+
+	err := s.db.QueryRowContext(ctx, q, args...).Scan(&user.Id, &user.Username, &user.PasswordHash, timeField{&user.CreatedAt}, &user.TimeZone, &user.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internalErrors.UserNotFound
+		}
+
+		logger.ErrorLogger.Printf("failed to fetch user: %v", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Update saves the settings of the user: the time zone and the email.
+func (s *UserRepository) Update(ctx context.Context, user *models.User) error {
+	if !isId(user.Id) {
+		return internalErrors.UserNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET time_zone = $1, email = $2 WHERE id = $3`, user.TimeZone, user.Email, user.Id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to update user: %v", err)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return internalErrors.UserNotFound
+	}
+
+	return nil
+}
+
+func (s *UserRepository) SaveToken(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error {
+	q := `INSERT INTO auth_tokens (token_hash, user_id, created_at, expires_at)
+			VALUES ($1, $2, $3, $4)`
+
+	_, err := s.db.ExecContext(ctx, q, tokenHash, userId, createdAt, expiresAt)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save auth token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserRepository) GetUserByToken(ctx context.Context, tokenHash, now string) (*models.User, error) {
+	q := `SELECT users.id, users.username, users.password_hash, users.created_at, users.time_zone, users.email
+		  FROM auth_tokens
+		  JOIN users ON users.id = auth_tokens.user_id
+		  WHERE auth_tokens.token_hash = $1 AND auth_tokens.expires_at > $2`
+
+	return s.getOne(ctx, q, tokenHash, now)
+}
+
+func (s *UserRepository) DeleteToken(ctx context.Context, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM auth_tokens WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete auth token: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserRepository) DeleteExpiredTokens(ctx context.Context, now string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM auth_tokens WHERE expires_at <= $1`, now)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete expired auth tokens: %v", err)
+		return err
+	}
+
+	return nil
+}
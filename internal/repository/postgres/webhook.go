@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"github.com/lib/pq"
+)
+
+const (
+	webhookColumns  = `id, url, secret, events, created_at, updated_at, owner_id`
+	deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created_at`
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// listField scans a text array into a slice that is never nil.
+type listField struct {
+	s *[]string
+}
+
+func (l listField) Scan(value interface{}) error {
+	var list pq.StringArray
+	if err := list.Scan(value); err != nil {
+		return err
+	}
+
+	*l.s = []string{}
+	*l.s = append(*l.s, list...)
+	return nil
+}
+
+// textArray binds a slice as a text array, empty rather than NULL when nil.
+func textArray(values []string) interface{} {
+	return pq.StringArray(append([]string{}, values...))
+}
+
+// jsonField scans a JSON document stored as text.
+type jsonField struct {
+	raw *json.RawMessage
+}
+
+func (j jsonField) Scan(value interface{}) error {
+	ns := sql.NullString{}
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+
+	*j.raw = json.RawMessage(ns.String)
+	return nil
+}
+
+// nullableInt scans NULL into zero.
+type nullableInt struct {
+	i *int
+}
+
+func (n nullableInt) Scan(value interface{}) error {
+	ni := sql.NullInt64{}
+	if err := ni.Scan(value); err != nil {
+		return err
+	}
+	*n.i = int(ni.Int64)
+	return nil
+}
+
+func nullIfZero(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
+
+func webhookFields(webhook *models.Webhook) []interface{} {
+	return []interface{}{
+		&webhook.Id,
+		&webhook.URL,
+		&webhook.Secret,
+		listField{&webhook.Events},
+		timeField{&webhook.CreatedAt},
+		timeField{&webhook.UpdatedAt},
+		&webhook.OwnerId,
+	}
+}
+
+func deliveryFields(delivery *models.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&delivery.Id,
+		&delivery.WebhookId,
+		&delivery.EventId,
+		&delivery.EventType,
+		jsonField{&delivery.Payload},
+		&delivery.Status,
+		&delivery.Attempts,
+		timeField{&delivery.NextAttemptAt},
+		nullableInt{&delivery.LastStatusCode},
+		nullableString{&delivery.LastError},
+		timeField{&delivery.DeliveredAt},
+		timeField{&delivery.CreatedAt},
+	}
+}
+
+func (s *WebhookRepository) Save(ctx context.Context, webhook *models.Webhook) error {
+	q := `INSERT INTO webhooks (` + webhookColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, q, webhook.Id, webhook.URL, webhook.Secret, textArray(webhook.Events), webhook.CreatedAt, webhook.UpdatedAt, webhook.OwnerId)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save webhook: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *WebhookRepository) GetById(ctx context.Context, id string) (*models.Webhook, error) {
+	if !isId(id) {
+		return nil, internalErrors.WebhookNotFound
+	}
+
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	webhook := &models.Webhook{}
+
+	err := s.db.QueryRowContext(ctx, q, id).Scan(webhookFields(webhook)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internalErrors.WebhookNotFound
+		}
+
+		logger.ErrorLogger.Printf("failed to fetch webhook: %v", err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE owner_id = $1 ORDER BY created_at, seq`
+
+	rows, err := s.db.QueryContext(ctx, q, ownerId)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch webhooks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+
+	for rows.Next() {
+		webhook := &models.Webhook{}
+		if err := rows.Scan(webhookFields(webhook)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan webhook: %v", err)
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	if !isId(webhook.Id) {
+		return internalErrors.WebhookNotFound
+	}
+
+	q := `UPDATE webhooks SET url = $1, secret = $2, events = $3, updated_at = $4 WHERE id = $5`
+
+	res, err := s.db.ExecContext(ctx, q, webhook.URL, webhook.Secret, textArray(webhook.Events), webhook.UpdatedAt, webhook.Id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to update webhook: %v", err)
+		return err
+	}
+
+	return checkWebhookAffected(res)
+}
+
+// Delete removes the webhook together with its deliveries.
+func (s *WebhookRepository) Delete(ctx context.Context, id string) error {
+	if !isId(id) {
+		return internalErrors.WebhookNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete webhook: %v", err)
+		return err
+	}
+
+	return checkWebhookAffected(res)
+}
+
+func checkWebhookAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return internalErrors.WebhookNotFound
+	}
+
+	return nil
+}
+
+func (s *WebhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	q := `INSERT INTO webhook_deliveries (` + deliveryColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		delivery.Id,
+		delivery.WebhookId,
+		delivery.EventId,
+		delivery.EventType,
+		string(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		nullIfZero(delivery.LastStatusCode),
+		nullIfEmpty(delivery.LastError),
+		nullIfEmpty(delivery.DeliveredAt),
+		delivery.CreatedAt,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save webhook delivery: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDeliveries takes up to limit pending deliveries whose next attempt is
+// due at now, the same way ReminderRepository.ClaimDue takes reminders.
+func (s *WebhookRepository) ClaimDeliveries(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+	q := `UPDATE webhook_deliveries
+		  SET claimed_until = $1, attempts = attempts + 1
+		  WHERE id IN (
+			  SELECT id FROM webhook_deliveries
+			  WHERE status = 'pending' AND next_attempt_at <= $2 AND (claimed_until IS NULL OR claimed_until <= $2)
+			  ORDER BY next_attempt_at
+			  LIMIT $3
+			  FOR UPDATE SKIP LOCKED
+		  )
+		  RETURNING ` + deliveryColumns
+
+	return s.getDeliveries(ctx, q, claimedUntil, now, limit)
+}
+
+// UpdateDelivery records the outcome of an attempt and releases the claim.
+func (s *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	q := `UPDATE webhook_deliveries
+		  SET status = $1, next_attempt_at = $2, last_status_code = $3, last_error = $4, delivered_at = $5, claimed_until = NULL
+		  WHERE id = $6`
+
+	_, err := s.db.ExecContext(ctx, q,
+		delivery.Status,
+		delivery.NextAttemptAt,
+		nullIfZero(delivery.LastStatusCode),
+		nullIfEmpty(delivery.LastError),
+		nullIfEmpty(delivery.DeliveredAt),
+		delivery.Id,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to update webhook delivery: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the webhook, newest first.
+func (s *WebhookRepository) GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error) {
+	q := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		  WHERE webhook_id = $1
+		  ORDER BY created_at DESC, seq DESC
+		  LIMIT $2`
+
+	return s.getDeliveries(ctx, q, webhookId, limit)
+}
+
+func (s *WebhookRepository) getDeliveries(ctx context.Context, q string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch webhook deliveries: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		if err := rows.Scan(deliveryFields(delivery)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan webhook delivery: %v", err)
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
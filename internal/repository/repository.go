@@ -4,15 +4,145 @@ import (
 	"context"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"time"
 )
 
+// Recorder describes the change of a task from before to after as a history
+// entry, or returns nil when the change is not recorded. Repositories call it
+// for each task a change reaches, the task the change was made to first, and
+// save the entries in the transaction of the change.
+type Recorder func(before, after *models.Task) *models.HistoryEntry
+
+type UserRepository interface {
+	Save(ctx context.Context, user *models.User) error
+	GetById(ctx context.Context, id string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	SaveToken(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error
+	GetUserByToken(ctx context.Context, tokenHash, now string) (*models.User, error)
+	DeleteToken(ctx context.Context, tokenHash string) error
+	DeleteExpiredTokens(ctx context.Context, now string) error
+}
+
+type ProjectRepository interface {
+	Save(ctx context.Context, project *models.Project) error
+	GetById(ctx context.Context, id string) (*models.Project, error)
+	GetAll(ctx context.Context, ownerId string, includeArchived bool) ([]*models.Project, error)
+	Update(ctx context.Context, project *models.Project) error
+	SetArchived(ctx context.Context, id string, archived bool, updatedAt string) error
+	// Delete removes the project, returning ProjectHasTasks while any task
+	// outside the trash refers to it unless cascade is set. With cascade
+	// those tasks go to the trash together with their subtasks and are
+	// returned, parents first. Tasks in the trash are left without a
+	// project. Every task it changes moves to its next version and is
+	// recorded in the transaction that removes the project.
+	Delete(ctx context.Context, id string, cascade bool, deletedAt string, record Recorder) ([]*models.Task, error)
+}
+
+type TagRepository interface {
+	Save(ctx context.Context, tag *models.Tag) error
+	GetById(ctx context.Context, id string) (*models.Tag, error)
+	GetByName(ctx context.Context, ownerId, name string) (*models.Tag, error)
+	GetAll(ctx context.Context, ownerId string) ([]*models.Tag, error)
+	Rename(ctx context.Context, id, name string) error
+	Delete(ctx context.Context, id string) error
+	// Attach and Detach change the tags of the task and save what record
+	// makes of the change in the same transaction.
+	Attach(ctx context.Context, taskId, tagId string, record Recorder) error
+	Detach(ctx context.Context, taskId, tagId string, record Recorder) error
+}
+
+type ReminderRepository interface {
+	Save(ctx context.Context, reminder *models.Reminder) error
+	GetById(ctx context.Context, id string) (*models.Reminder, error)
+	GetByTask(ctx context.Context, taskId string) ([]*models.Reminder, error)
+	Delete(ctx context.Context, id string) error
+	// ClaimDue takes pending reminders due at now for sending until
+	// claimedUntil; each claim increments the attempts of the reminder.
+	ClaimDue(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error)
+	Finish(ctx context.Context, id, status, sentAt string) error
+	Retry(ctx context.Context, id, retryAt string) error
+}
+
+type WebhookRepository interface {
+	Save(ctx context.Context, webhook *models.Webhook) error
+	GetById(ctx context.Context, id string) (*models.Webhook, error)
+	GetAll(ctx context.Context, ownerId string) ([]*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, id string) error
+	SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ClaimDeliveries takes pending deliveries due at now for sending until
+	// claimedUntil; each claim increments the attempts of the delivery.
+	ClaimDeliveries(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error)
+}
+
 type TaskRepository interface {
 	Close()
-	Save(ctx context.Context, task *models.Task) error
-	GetAll(ctx context.Context) ([]*models.Task, error)
+	// Save stores the new task and what record makes of its creation.
+	Save(ctx context.Context, task *models.Task, record Recorder) error
+	GetAll(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	Search(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error)
 	GetById(ctx context.Context, id string) (*models.Task, error)
-	Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error
-	DeleteById(ctx context.Context, id string) error
-	ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool) error
-	UpdateOverdueTasks(ctx context.Context) error
+	// GetByIds returns the tasks with the given ids that exist, in no
+	// particular order.
+	GetByIds(ctx context.Context, ids []string) ([]*models.Task, error)
+	// GetByParentIds returns the direct subtasks of the given tasks, oldest
+	// first.
+	GetByParentIds(ctx context.Context, parentIds []string) ([]*models.Task, error)
+	// Update, ChangeProject, AddBlocker and RemoveBlocker save what record
+	// makes of the change in its transaction.
+	Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record Recorder) error
+	// DeleteById moves the task together with all its subtasks to the trash
+	// and saves what record makes of each of them, the task first. It returns
+	// them as trashed, in the same order. Tasks in the trash are left out by
+	// every other method but the trash ones below.
+	DeleteById(ctx context.Context, id string, version int64, deletedAt string, record Recorder) ([]*models.Task, error)
+	// GetTrash returns the trashed tasks of the owner that were deleted on
+	// their own rather than with their parent, most recently deleted first.
+	GetTrash(ctx context.Context, ownerId string) ([]*models.Task, error)
+	// GetTrashedById returns the task only while it is in the trash.
+	GetTrashedById(ctx context.Context, id string) (*models.Task, error)
+	// Restore takes the trashed task out of the trash together with the
+	// subtasks that were deleted with it, recording and returning them like
+	// DeleteById.
+	Restore(ctx context.Context, id string, updatedAt string, record Recorder) ([]*models.Task, error)
+	// Purge removes the trashed task and all its subtasks for good.
+	Purge(ctx context.Context, id string) error
+	// PurgeDeletedBefore removes for good the tasks trashed before the time
+	// and returns how many there were.
+	PurgeDeletedBefore(ctx context.Context, before string) (int64, error)
+	// ChangeCompletionStatus completes or reopens the task if it still has the
+	// version. Completing a task completes its open subtasks at any depth and
+	// reopening it reopens its completed ancestors, in the same transaction
+	// that saves what record makes of each change. It returns the other tasks
+	// the change reached, at their new versions.
+	ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record Recorder) ([]*models.Task, error)
+	GetSubtaskCounts(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error)
+	// AddBlocker records that the task cannot be completed before blockerId,
+	// refusing dependencies that would form a cycle.
+	AddBlocker(ctx context.Context, taskId, blockerId string, record Recorder) error
+	RemoveBlocker(ctx context.Context, taskId, blockerId string, record Recorder) error
+	// LastOccurrence returns the highest occurrence number of the series.
+	LastOccurrence(ctx context.Context, seriesId string) (int, error)
+	ChangeProject(ctx context.Context, id string, projectId string, version int64, updatedAt string, record Recorder) error
+	// Revert applies the commands at once and saves the entries that record
+	// them: either every task still has the version of its command and is
+	// changed, or nothing is. Tasks in the trash can be reverted too. Only
+	// the tasks of the commands change; subtasks need commands of their own.
+	Revert(ctx context.Context, cmds []*dtos.RevertTaskCommand, updatedAt string, entries []*models.HistoryEntry) error
+	// UpdateOverdueTasks flags tasks whose due time has passed by now, bumping
+	// their version, records each of them and returns them.
+	UpdateOverdueTasks(ctx context.Context, now time.Time, record Recorder) ([]*models.Task, error)
+}
+
+// HistoryRepository is an append-only log of task changes.
+type HistoryRepository interface {
+	Save(ctx context.Context, entry *models.HistoryEntry) error
+	// GetByTask returns a page of the history of the task, newest first.
+	GetByTask(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
+	// GetByActor returns a page of the changes the actor made to any task,
+	// newest first.
+	GetByActor(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
 }
@@ -0,0 +1,1563 @@
+// Package repositorytest holds the conformance suite every repository backend
+// has to pass, so the use cases behave the same whichever one DB_DRIVER
+// selects. Backends call it from their own tests with a function that opens
+// empty repositories.
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Repositories are the repositories of one backend; they have to share
+// storage, since tasks refer to projects and tags.
+type Repositories struct {
+	Tasks    repository.TaskRepository
+	Projects repository.ProjectRepository
+	Tags     repository.TagRepository
+	History  repository.HistoryRepository
+}
+
+// Opener returns repositories over empty storage for one test.
+type Opener func(t *testing.T) Repositories
+
+var (
+	owner = id(900)
+	other = id(901)
+	start = time.Date(2024, time.November, 20, 10, 0, 0, 0, time.UTC)
+)
+
+// id returns the n-th test id; ids are UUIDs since some backends store them
+// as such.
+func id(n int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+}
+
+// at returns the test clock moved by the given number of minutes.
+func at(minutes int) string {
+	return start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)
+}
+
+func newTask(n int, title string) *models.Task {
+	return &models.Task{
+		Id:        id(n),
+		Title:     title,
+		Priority:  models.PriorityNone,
+		Version:   1,
+		CreatedAt: at(n),
+		UpdatedAt: at(n),
+		OwnerId:   owner,
+	}
+}
+
+func save(t *testing.T, r Repositories, tasks ...*models.Task) {
+	t.Helper()
+
+	for _, task := range tasks {
+		if err := r.Tasks.Save(context.Background(), task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func get(t *testing.T, r Repositories, taskId string) *models.Task {
+	t.Helper()
+
+	task, err := r.Tasks.GetById(context.Background(), taskId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// recorder returns a Recorder that records every change as action at the
+// given minute and collects the ids of the changed tasks in order.
+func recorder(action string, minutes int, taskIds *[]string) repository.Recorder {
+	return func(before, after *models.Task) *models.HistoryEntry {
+		*taskIds = append(*taskIds, after.Id)
+
+		entryId, _ := helper.GenerateUUID()
+
+		return &models.HistoryEntry{
+			Id:        entryId,
+			TaskId:    after.Id,
+			Action:    action,
+			ActorId:   owner,
+			Changes:   models.DiffTasks(before, after),
+			Version:   after.Version,
+			CreatedAt: at(minutes),
+		}
+	}
+}
+
+func ids(tasks []*models.Task) []string {
+	result := []string{}
+	for _, task := range tasks {
+		result = append(result, task.Id)
+	}
+	return result
+}
+
+// TestTaskRepository runs the TaskRepository suite against the backend.
+func TestTaskRepository(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r Repositories)
+	}{
+		{"SaveAndGet", testSaveAndGet},
+		{"MalformedIds", testMalformedIds},
+		{"Filters", testFilters},
+		{"Sorting", testSorting},
+		{"Pagination", testPagination},
+		{"PaginationTies", testPaginationTies},
+		{"Update", testUpdate},
+		{"Completion", testCompletion},
+		{"ChangeProject", testChangeProject},
+		{"Subtasks", testSubtasks},
+		{"Blockers", testBlockers},
+		{"Tags", testTags},
+		{"LastOccurrence", testLastOccurrence},
+		{"Overdue", testOverdue},
+		{"Trash", testTrash},
+		{"ProjectDelete", testProjectDelete},
+		{"Revert", testRevert},
+		{"History", testHistory},
+		{"Search", testSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+func testRevert(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	project := &models.Project{Id: id(50), Name: "Home", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner}
+	if err := r.Projects.Save(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+
+	task := newTask(1, "Buy oat milk")
+	task.Description = "two cartons"
+	task.DueDate, task.DueAt, task.Overdue = "2024-11-19", "2024-11-20T00:00:00Z", true
+	task.Completed, task.CompletedAt = true, at(30)
+	task.Priority = models.PriorityUrgent
+	task.ProjectId = project.Id
+	parent := newTask(2, "Move")
+	child := newTask(3, "Pack")
+	child.ParentId = parent.Id
+	save(t, r, task, parent, child)
+
+	if _, err := r.Tasks.DeleteById(ctx, parent.Id, 1, at(40), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	state := *task
+	state.Title, state.Description = "Buy milk", ""
+	state.DueDate, state.DueAt, state.AllDay, state.Overdue = "2024-11-30T15:30:00Z", "2024-11-30T15:30:00Z", false, false
+	state.Completed, state.CompletedAt = false, ""
+	state.Priority = models.PriorityLow
+	state.ProjectId = ""
+	state.Recurrence = "FREQ=DAILY"
+	restored := *parent
+	restored.Version = 2
+	restoredChild := *child
+	restoredChild.Version = 2
+	undone := &models.HistoryEntry{Id: id(90), TaskId: task.Id, Action: models.HistoryUndone, ActorId: owner, Changes: []models.FieldChange{}, Version: 2, CreatedAt: at(60)}
+
+	err := r.Tasks.Revert(ctx, []*dtos.RevertTaskCommand{
+		{Task: &state, Fields: []string{"title", "description", "due_date", "completed", "priority", "project_id", "recurrence"}},
+		{Task: &restored, Fields: []string{"deleted_at"}},
+		{Task: &restoredChild, Fields: []string{"deleted_at"}},
+	}, at(60), []*models.HistoryEntry{undone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: task.Id, Limit: 10}); err != nil || len(page.Entries) != 1 || page.Entries[0].Id != undone.Id {
+		t.Errorf("expected the entry to be saved with the change, got %+v, %v", page, err)
+	}
+
+	got := get(t, r, task.Id)
+	expected := state
+	expected.SeriesId, expected.Occurrence = task.Id, 1
+	expected.Version, expected.UpdatedAt = 2, at(60)
+	if !reflect.DeepEqual(got, &expected) {
+		t.Errorf("expected %+v, got %+v", &expected, got)
+	}
+	for _, subtask := range []*models.Task{parent, child} {
+		got := get(t, r, subtask.Id)
+		if got.DeletedAt != "" || got.UpdatedAt != at(60) || got.Version != 3 {
+			t.Errorf("expected %s to be restored, got %+v", subtask.Title, got)
+		}
+	}
+
+	// A stale version fails the whole batch.
+	renamed := expected
+	renamed.Title = "Lost"
+	trashed := *parent
+	trashed.Version, trashed.DeletedAt = 2, at(70)
+	redone := &models.HistoryEntry{Id: id(91), TaskId: task.Id, Action: models.HistoryRedone, ActorId: owner, Changes: []models.FieldChange{}, Version: 3, CreatedAt: at(70)}
+	err = r.Tasks.Revert(ctx, []*dtos.RevertTaskCommand{
+		{Task: &renamed, Fields: []string{"title"}},
+		{Task: &trashed, Fields: []string{"deleted_at"}},
+	}, at(70), []*models.HistoryEntry{redone})
+	if !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if got := get(t, r, task.Id); got.Title != "Buy milk" || got.Version != 2 {
+		t.Errorf("expected conflicting batch to change nothing, got %+v", got)
+	}
+	if page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: task.Id, Limit: 10}); err != nil || len(page.Entries) != 1 {
+		t.Errorf("expected conflicting batch to record nothing, got %+v, %v", page, err)
+	}
+
+	// Subtasks are reverted by commands of their own.
+	trashed.Version = 3
+	if err := r.Tasks.Revert(ctx, []*dtos.RevertTaskCommand{{Task: &trashed, Fields: []string{"deleted_at"}}}, at(70), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Tasks.GetTrashedById(ctx, parent.Id); err != nil || got.DeletedAt != at(70) || got.Version != 4 {
+		t.Errorf("expected the task to go back to the trash, got %+v, %v", got, err)
+	}
+	if got := get(t, r, child.Id); got.DeletedAt != "" || got.Version != 3 {
+		t.Errorf("expected subtask to stay out of the trash, got %+v", got)
+	}
+
+	lost := newTask(99, "Lost")
+	if err := r.Tasks.Revert(ctx, []*dtos.RevertTaskCommand{{Task: lost, Fields: []string{"title"}}}, at(80), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+}
+
+func testHistory(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	entry := func(n int, taskId, action string, minutes int, changes ...models.FieldChange) *models.HistoryEntry {
+		if changes == nil {
+			changes = []models.FieldChange{}
+		}
+		return &models.HistoryEntry{Id: id(n), TaskId: taskId, Action: action, ActorId: owner, Changes: changes, Version: int64(n), CreatedAt: at(minutes)}
+	}
+
+	created := entry(1, id(10), models.HistoryCreated, 1, models.FieldChange{Field: "title", Before: []byte("null"), After: []byte(`"Draft"`)})
+	renamed := entry(2, id(10), models.HistoryUpdated, 2, models.FieldChange{Field: "tags", Before: []byte(`[]`), After: []byte(`["home","work"]`)})
+	// Entries of the same second are ordered by when they were saved.
+	completed := entry(3, id(10), models.HistoryCompleted, 3, models.FieldChange{Field: "completed", Before: []byte("false"), After: []byte("true")})
+	overdue := entry(4, id(10), models.HistoryOverdue, 3)
+	overdue.ActorId = models.ActorSystem
+	// The completion reached another task, a subtask say.
+	foreign := entry(5, id(11), models.HistoryCompleted, 4, models.FieldChange{Field: "completed", Before: []byte("false"), After: []byte("true")})
+	foreign.Cause = completed.Id
+	undone := entry(6, id(10), models.HistoryUndone, 5, models.FieldChange{Field: "completed", Before: []byte("true"), After: []byte("false")})
+	undone.Reverts = completed.Id
+
+	for _, e := range []*models.HistoryEntry{created, renamed, completed, overdue, foreign, undone} {
+		if err := r.History.Save(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: id(10), Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*models.HistoryEntry{undone, overdue, completed, renamed, created}
+	if len(page.Entries) != len(expected) || page.NextCursor != "" {
+		t.Fatalf("expected %d entries on one page, got %+v", len(expected), page)
+	}
+	for i, e := range page.Entries {
+		got, _ := json.Marshal(e)
+		want, _ := json.Marshal(expected[i])
+		if string(got) != string(want) {
+			t.Errorf("expected entry %s, got %s", want, got)
+		}
+	}
+
+	var paged []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+
+		page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: id(10), Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Entries {
+			paged = append(paged, e.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if !reflect.DeepEqual(paged, []string{id(6), id(4), id(3), id(2), id(1)}) {
+		t.Errorf("expected pages to list the entries newest first, got %v", paged)
+	}
+
+	page, _ = r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: id(10), Limit: 1})
+	if _, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: id(11), Limit: 1, Cursor: page.NextCursor}); !errors.Is(err, internalErrors.InvalidCursor) {
+		t.Errorf("expected cursor of another task to be rejected, got %v", err)
+	}
+
+	if page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: id(12), Limit: 10}); err != nil || len(page.Entries) != 0 {
+		t.Errorf("expected no history, got %+v, %v", page, err)
+	}
+
+	page, err = r.History.GetByActor(ctx, &dtos.GetHistoryQuery{ActorId: owner, Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var byActor []string
+	for _, e := range page.Entries {
+		byActor = append(byActor, e.Id)
+	}
+	if !reflect.DeepEqual(byActor, []string{id(6), id(5), id(3), id(2)}) || page.NextCursor == "" {
+		t.Fatalf("expected the latest changes of the actor on all tasks, got %v, cursor %q", byActor, page.NextCursor)
+	}
+	if page.Entries[1].Cause != completed.Id {
+		t.Errorf("expected the entry to refer to its cause, got %+v", page.Entries[1])
+	}
+
+	page, err = r.History.GetByActor(ctx, &dtos.GetHistoryQuery{ActorId: owner, Limit: 4, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Id != created.Id || page.NextCursor != "" {
+		t.Errorf("expected the oldest change on the last page, got %+v", page)
+	}
+
+	page, _ = r.History.GetByActor(ctx, &dtos.GetHistoryQuery{ActorId: owner, Limit: 1})
+	if _, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: owner, Limit: 1, Cursor: page.NextCursor}); !errors.Is(err, internalErrors.InvalidCursor) {
+		t.Errorf("expected cursor of an actor to be rejected for a task, got %v", err)
+	}
+}
+
+func testSaveAndGet(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	project := &models.Project{Id: id(50), Name: "Home", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner}
+	if err := r.Projects.Save(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := newTask(1, "Renovate")
+	task := &models.Task{
+		Id:          id(2),
+		Title:       "Paint walls",
+		Description: "white, two layers",
+		DueDate:     "2024-11-25",
+		AllDay:      true,
+		DueAt:       "2024-11-26T00:00:00Z",
+		Overdue:     true,
+		Completed:   true,
+		Priority:    models.PriorityHigh,
+		Version:     3,
+		CreatedAt:   at(2),
+		UpdatedAt:   at(3),
+		CompletedAt: at(4),
+		ProjectId:   project.Id,
+		ParentId:    parent.Id,
+		Recurrence:  "FREQ=WEEKLY",
+		SeriesId:    id(2),
+		Occurrence:  1,
+		OwnerId:     owner,
+	}
+	// A timed task keeps the time and the offset of its due date.
+	timed := newTask(3, "Call the plumber")
+	timed.DueDate, timed.DueAt, timed.AllDay = "2024-11-25T18:30:00+03:00", "2024-11-25T15:30:00Z", false
+	save(t, r, parent, task, timed)
+
+	for _, expected := range []*models.Task{task, timed} {
+		if got := get(t, r, expected.Id); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %+v, got %+v", expected, got)
+		}
+	}
+
+	if got := get(t, r, parent.Id); !reflect.DeepEqual(got, parent) {
+		t.Errorf("expected %+v, got %+v", parent, got)
+	}
+
+	if _, err := r.Tasks.GetById(ctx, id(99)); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+
+	many, err := r.Tasks.GetByIds(ctx, []string{task.Id, id(99), parent.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotIds := ids(many)
+	sort.Strings(gotIds)
+	if !reflect.DeepEqual(gotIds, []string{parent.Id, task.Id}) {
+		t.Errorf("expected existing tasks only, got %v", gotIds)
+	}
+
+	none, err := r.Tasks.GetByIds(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected no tasks, got %v", ids(none))
+	}
+}
+
+// testMalformedIds checks that an id no task can have is not found, even by
+// backends that store ids as UUIDs.
+func testMalformedIds(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	const malformed = "not-an-id"
+
+	task := newTask(1, "Buy milk")
+	save(t, r, task)
+
+	if _, err := r.Tasks.GetById(ctx, malformed); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from GetById, got %v", err)
+	}
+	if _, err := r.Tasks.GetTrashedById(ctx, malformed); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from GetTrashedById, got %v", err)
+	}
+
+	version := int64(1)
+	if err := r.Tasks.Update(ctx, malformed, &dtos.UpdateTaskCommand{Title: "Buy bread", Version: &version}, at(10), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from Update, got %v", err)
+	}
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, malformed, true, 1, at(10), at(10), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from ChangeCompletionStatus, got %v", err)
+	}
+	if _, err := r.Tasks.DeleteById(ctx, malformed, 1, at(10), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from DeleteById, got %v", err)
+	}
+	if _, err := r.Tasks.Restore(ctx, malformed, at(10), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from Restore, got %v", err)
+	}
+	if err := r.Tasks.Purge(ctx, malformed); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound from Purge, got %v", err)
+	}
+
+	many, err := r.Tasks.GetByIds(ctx, []string{malformed, task.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids(many), []string{task.Id}) {
+		t.Errorf("expected existing tasks only, got %v", ids(many))
+	}
+
+	if _, err := r.Projects.GetById(ctx, malformed); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected ProjectNotFound, got %v", err)
+	}
+	if _, err := r.Tags.GetById(ctx, malformed); !errors.Is(err, internalErrors.TagNotFound) {
+		t.Errorf("expected TagNotFound, got %v", err)
+	}
+}
+
+func testFilters(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	project := &models.Project{Id: id(50), Name: "Family", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner}
+	if err := r.Projects.Save(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+
+	milk := newTask(1, "Buy milk")
+	milk.Priority = models.PriorityHigh
+	milk.DueDate, milk.DueAt = "2024-11-21", "2024-11-21T12:00:00Z"
+
+	report := newTask(2, "Write report 100%")
+	report.Priority = models.PriorityLow
+	report.Completed, report.CompletedAt = true, at(10)
+
+	call := newTask(3, "Call mom")
+	call.ProjectId = project.Id
+	call.DueDate, call.DueAt, call.Overdue = "2024-11-19", "2024-11-19T12:00:00Z", true
+
+	bottle := newTask(4, "Return the bottle")
+	bottle.ParentId = milk.Id
+	bottle.SeriesId, bottle.Recurrence, bottle.Occurrence = id(4), "FREQ=DAILY", 1
+
+	foreign := newTask(5, "Buy milk")
+	foreign.OwnerId = other
+
+	save(t, r, milk, report, call, bottle, foreign)
+
+	for n, name := range map[int]string{60: "home", 61: "urgent", 62: "work"} {
+		if err := r.Tags.Save(ctx, &models.Tag{Id: id(n), Name: name, CreatedAt: at(0), OwnerId: owner}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, link := range [][2]int{{1, 60}, {2, 61}, {2, 62}, {3, 60}, {3, 61}} {
+		if err := r.Tags.Attach(ctx, id(link[0]), id(link[1]), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		query    dtos.GetTasksQuery
+		expected []string
+	}{
+		{name: "owner", expected: []string{id(1), id(2), id(3), id(4)}},
+		{name: "parent", query: dtos.GetTasksQuery{ParentId: id(1)}, expected: []string{id(4)}},
+		{name: "series", query: dtos.GetTasksQuery{SeriesId: id(4)}, expected: []string{id(4)}},
+		{name: "project", query: dtos.GetTasksQuery{ProjectId: project.Id}, expected: []string{id(3)}},
+		{name: "any tag ignores case", query: dtos.GetTasksQuery{Tags: []string{"HOME"}, TagMode: dtos.TagModeAny}, expected: []string{id(1), id(3)}},
+		{name: "any of tags", query: dtos.GetTasksQuery{Tags: []string{"home", "work"}, TagMode: dtos.TagModeAny}, expected: []string{id(1), id(2), id(3)}},
+		{name: "all tags", query: dtos.GetTasksQuery{Tags: []string{"home", "urgent"}, TagMode: dtos.TagModeAll}, expected: []string{id(3)}},
+		{name: "repeated tag", query: dtos.GetTasksQuery{Tags: []string{"home", "Home"}, TagMode: dtos.TagModeAll}, expected: []string{id(1), id(3)}},
+		{name: "unknown tag", query: dtos.GetTasksQuery{Tags: []string{"garden"}, TagMode: dtos.TagModeAny}, expected: []string{}},
+		{name: "priorities", query: dtos.GetTasksQuery{Priorities: []string{models.PriorityHigh, models.PriorityLow}}, expected: []string{id(1), id(2)}},
+		{name: "no priority", query: dtos.GetTasksQuery{Priorities: []string{models.PriorityNone}}, expected: []string{id(3), id(4)}},
+		{name: "completed", query: dtos.GetTasksQuery{Completed: &yes}, expected: []string{id(2)}},
+		{name: "open", query: dtos.GetTasksQuery{Completed: &no}, expected: []string{id(1), id(3), id(4)}},
+		{name: "overdue", query: dtos.GetTasksQuery{Overdue: &yes}, expected: []string{id(3)}},
+		{name: "due window", query: dtos.GetTasksQuery{DueAfter: "2024-11-20T00:00:00Z", DueUntil: "2024-11-21T12:00:00Z"}, expected: []string{id(1)}},
+		{name: "due until", query: dtos.GetTasksQuery{DueUntil: "2024-11-20T00:00:00Z"}, expected: []string{id(3)}},
+		{name: "title ignores case", query: dtos.GetTasksQuery{Title: "MILK"}, expected: []string{id(1)}},
+		{name: "title wildcards are text", query: dtos.GetTasksQuery{Title: "%"}, expected: []string{id(2)}},
+		{name: "title underscore is text", query: dtos.GetTasksQuery{Title: "_"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.OwnerId = owner
+			query.Sort, query.Order, query.Limit = dtos.SortByCreated, dtos.OrderAsc, 10
+
+			page, err := r.Tasks.GetAll(ctx, &query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(page.Tasks); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+			if page.NextCursor != "" {
+				t.Errorf("expected no next cursor, got %q", page.NextCursor)
+			}
+		})
+	}
+
+	page, err := r.Tasks.GetAll(ctx, &dtos.GetTasksQuery{OwnerId: owner, Tags: []string{"home"}, Sort: dtos.SortByCreated, Order: dtos.OrderAsc, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 2 || !reflect.DeepEqual(page.Tasks[1].Tags, []string{"home", "urgent"}) {
+		t.Errorf("expected listed tasks to carry their tags, got %+v", page.Tasks)
+	}
+}
+
+// sortingTasks are saved in id order, which is also creation order, with
+// every sort key set so that no two sorts agree.
+func sortingTasks() []*models.Task {
+	apple := newTask(1, "apple")
+	apple.Priority = models.PriorityLow
+	apple.DueDate, apple.DueAt = "2024-11-23", "2024-11-23T00:00:00Z"
+	apple.UpdatedAt = at(40)
+
+	banana := newTask(2, "Banana")
+	banana.Priority = models.PriorityUrgent
+	banana.Completed, banana.CompletedAt = true, at(30)
+	banana.UpdatedAt = at(30)
+
+	cherry := newTask(3, "cherry")
+	cherry.Priority = models.PriorityLow
+	cherry.DueDate, cherry.DueAt, cherry.Overdue = "2024-11-19", "2024-11-19T00:00:00Z", true
+	cherry.UpdatedAt = at(20)
+
+	date := newTask(4, "Date")
+	date.Priority = models.PriorityHigh
+	date.DueDate, date.DueAt = "2024-11-21", "2024-11-21T00:00:00Z"
+	date.UpdatedAt = at(50)
+
+	elder := newTask(5, "elder")
+	elder.Priority = models.PriorityHigh
+	elder.Completed, elder.CompletedAt = true, at(10)
+	elder.UpdatedAt = at(10)
+
+	return []*models.Task{apple, banana, cherry, date, elder}
+}
+
+func testSorting(t *testing.T, r Repositories) {
+	save(t, r, sortingTasks()...)
+
+	tests := []struct {
+		sort     string
+		expected []int
+	}{
+		// Open tasks first: overdue, then by priority, then by due time.
+		{sort: dtos.SortBySmart, expected: []int{3, 4, 1, 2, 5}},
+		{sort: dtos.SortByPriority, expected: []int{1, 3, 4, 5, 2}},
+		// Tasks without a due date come first.
+		{sort: dtos.SortByDueDate, expected: []int{2, 5, 3, 4, 1}},
+		{sort: dtos.SortByTitle, expected: []int{1, 2, 3, 4, 5}},
+		{sort: dtos.SortByCreated, expected: []int{1, 2, 3, 4, 5}},
+		{sort: dtos.SortByCreatedAt, expected: []int{1, 2, 3, 4, 5}},
+		{sort: dtos.SortByUpdatedAt, expected: []int{5, 3, 2, 1, 4}},
+		{sort: dtos.SortByCompletedAt, expected: []int{1, 3, 4, 5, 2}},
+	}
+
+	for _, tt := range tests {
+		for _, order := range []string{dtos.OrderAsc, dtos.OrderDesc} {
+			t.Run(tt.sort+" "+order, func(t *testing.T) {
+				page, err := r.Tasks.GetAll(context.Background(), &dtos.GetTasksQuery{OwnerId: owner, Sort: tt.sort, Order: order, Limit: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var expected []string
+				for _, n := range tt.expected {
+					expected = append(expected, id(n))
+				}
+				if order == dtos.OrderDesc {
+					for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+						expected[i], expected[j] = expected[j], expected[i]
+					}
+				}
+
+				if got := ids(page.Tasks); !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected %v, got %v", expected, got)
+				}
+			})
+		}
+	}
+}
+
+func testPagination(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	tasks := sortingTasks()
+	// Ties on every sort key but the last one.
+	for n := 6; n <= 9; n++ {
+		task := newTask(n, "apple")
+		task.Priority = models.PriorityLow
+		task.DueDate, task.DueAt = "2024-11-23", "2024-11-23T00:00:00Z"
+		task.CreatedAt, task.UpdatedAt = at(1), at(40)
+		tasks = append(tasks, task)
+	}
+	save(t, r, tasks...)
+
+	sorts := []string{dtos.SortBySmart, dtos.SortByPriority, dtos.SortByDueDate, dtos.SortByTitle, dtos.SortByCreated, dtos.SortByCreatedAt, dtos.SortByUpdatedAt, dtos.SortByCompletedAt}
+
+	for _, sortBy := range sorts {
+		for _, order := range []string{dtos.OrderAsc, dtos.OrderDesc} {
+			t.Run(sortBy+" "+order, func(t *testing.T) {
+				query := &dtos.GetTasksQuery{OwnerId: owner, Sort: sortBy, Order: order, Limit: 20}
+				all, err := r.Tasks.GetAll(ctx, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var paged []*models.Task
+				query.Limit = 2
+				for pages := 0; ; pages++ {
+					if pages > len(tasks) {
+						t.Fatal("pagination does not end")
+					}
+
+					page, err := r.Tasks.GetAll(ctx, query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					paged = append(paged, page.Tasks...)
+
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+
+				if expected, got := ids(all.Tasks), ids(paged); !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected pages to list %v, got %v", expected, got)
+				}
+			})
+		}
+	}
+
+	page, err := r.Tasks.GetAll(ctx, &dtos.GetTasksQuery{OwnerId: owner, Sort: dtos.SortByTitle, Order: dtos.OrderAsc, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []struct {
+		name  string
+		query dtos.GetTasksQuery
+	}{
+		{name: "garbage", query: dtos.GetTasksQuery{Sort: dtos.SortByTitle, Order: dtos.OrderAsc, Cursor: "not a cursor"}},
+		{name: "other sort", query: dtos.GetTasksQuery{Sort: dtos.SortByPriority, Order: dtos.OrderAsc, Cursor: page.NextCursor}},
+		{name: "other order", query: dtos.GetTasksQuery{Sort: dtos.SortByTitle, Order: dtos.OrderDesc, Cursor: page.NextCursor}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.OwnerId, query.Limit = owner, 2
+
+			if _, err := r.Tasks.GetAll(ctx, &query); !errors.Is(err, internalErrors.InvalidCursor) {
+				t.Errorf("expected InvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+// testPaginationTies pages one task at a time through tasks that tie on
+// every sort key but the id and were saved in the reverse of id order.
+func testPaginationTies(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	for n := 5; n >= 1; n-- {
+		task := newTask(n, "same")
+		task.CreatedAt, task.UpdatedAt = at(0), at(0)
+		save(t, r, task)
+	}
+
+	sorts := []string{dtos.SortBySmart, dtos.SortByPriority, dtos.SortByDueDate, dtos.SortByTitle, dtos.SortByCreated, dtos.SortByCreatedAt, dtos.SortByUpdatedAt, dtos.SortByCompletedAt}
+
+	for _, sortBy := range sorts {
+		for _, order := range []string{dtos.OrderAsc, dtos.OrderDesc} {
+			t.Run(sortBy+" "+order, func(t *testing.T) {
+				expected := []string{id(1), id(2), id(3), id(4), id(5)}
+				if order == dtos.OrderDesc {
+					expected = []string{id(5), id(4), id(3), id(2), id(1)}
+				}
+
+				var paged []*models.Task
+				query := &dtos.GetTasksQuery{OwnerId: owner, Sort: sortBy, Order: order, Limit: 1}
+				for pages := 0; ; pages++ {
+					if pages > len(expected) {
+						t.Fatal("pagination does not end")
+					}
+
+					page, err := r.Tasks.GetAll(ctx, query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					paged = append(paged, page.Tasks...)
+
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+
+				if got := ids(paged); !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected pages to list %v, got %v", expected, got)
+				}
+			})
+		}
+	}
+}
+
+func testUpdate(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	task := newTask(1, "Buy milk")
+	task.Overdue = true
+	task.DueDate, task.DueAt = "2024-11-19", "2024-11-19T00:00:00Z"
+	save(t, r, task)
+
+	var recorded []string
+	version := int64(1)
+	allDay := false
+	err := r.Tasks.Update(ctx, task.Id, &dtos.UpdateTaskCommand{
+		Title:       "Buy oat milk",
+		Description: "two cartons",
+		DueDate:     "2024-11-30T18:30:00+03:00",
+		DueAt:       "2024-11-30T15:30:00Z",
+		AllDay:      &allDay,
+		Priority:    models.PriorityUrgent,
+		Version:     &version,
+	}, at(60), recorder(models.HistoryUpdated, 60, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := get(t, r, task.Id)
+	expected := *task
+	expected.Title, expected.Description = "Buy oat milk", "two cartons"
+	expected.DueDate, expected.DueAt, expected.AllDay = "2024-11-30T18:30:00+03:00", "2024-11-30T15:30:00Z", false
+	expected.Overdue = false
+	expected.Priority = models.PriorityUrgent
+	expected.Version, expected.UpdatedAt = 2, at(60)
+	if !reflect.DeepEqual(got, &expected) {
+		t.Errorf("expected %+v, got %+v", &expected, got)
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: task.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Version != 2 || len(page.Entries[0].Changes) != 5 {
+		t.Errorf("expected the update to be recorded with its five changed fields, got %+v", page.Entries)
+	}
+
+	version = 2
+	rule := "FREQ=DAILY"
+	if err := r.Tasks.Update(ctx, task.Id, &dtos.UpdateTaskCommand{Recurrence: &rule, Version: &version}, at(61), nil); err != nil {
+		t.Fatal(err)
+	}
+	got = get(t, r, task.Id)
+	if got.Recurrence != rule || got.SeriesId != task.Id || got.Occurrence != 1 {
+		t.Errorf("expected task to start its own series, got %+v", got)
+	}
+
+	version = 3
+	stop := ""
+	if err := r.Tasks.Update(ctx, task.Id, &dtos.UpdateTaskCommand{Recurrence: &stop, Version: &version}, at(62), nil); err != nil {
+		t.Fatal(err)
+	}
+	got = get(t, r, task.Id)
+	if got.Recurrence != "" || got.SeriesId != task.Id || got.Version != 4 {
+		t.Errorf("expected rule to be cleared and series kept, got %+v", got)
+	}
+
+	stale := int64(1)
+	err = r.Tasks.Update(ctx, task.Id, &dtos.UpdateTaskCommand{Title: "Lost", Version: &stale}, at(63), recorder(models.HistoryUpdated, 63, &recorded))
+	if !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if len(recorded) != 1 {
+		t.Errorf("expected the conflicting update to record nothing, got %v", recorded)
+	}
+	if got := get(t, r, task.Id); got.Title != "Buy oat milk" {
+		t.Errorf("expected conflicting update to change nothing, got %+v", got)
+	}
+
+	err = r.Tasks.Update(ctx, id(99), &dtos.UpdateTaskCommand{Title: "Lost", Version: &stale}, at(63), nil)
+	if !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+
+	version = 4
+	if err := r.Tasks.Update(ctx, task.Id, &dtos.UpdateTaskCommand{Version: &version}, at(64), nil); err == nil {
+		t.Error("expected an error for an empty update")
+	}
+}
+
+func testCompletion(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	task := newTask(1, "Buy milk")
+	save(t, r, task)
+
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, task.Id, true, 1, at(30), at(30), nil); err != nil {
+		t.Fatal(err)
+	}
+	got := get(t, r, task.Id)
+	if !got.Completed || got.CompletedAt != at(30) || got.UpdatedAt != at(30) || got.Version != 2 {
+		t.Errorf("expected completed task, got %+v", got)
+	}
+
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, task.Id, false, 2, "", at(31), nil); err != nil {
+		t.Fatal(err)
+	}
+	got = get(t, r, task.Id)
+	if got.Completed || got.CompletedAt != "" || got.Version != 3 {
+		t.Errorf("expected reopened task, got %+v", got)
+	}
+
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, task.Id, true, 2, at(32), at(32), nil); !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, id(99), true, 1, at(32), at(32), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+}
+
+func testChangeProject(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	project := &models.Project{Id: id(50), Name: "Home", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner}
+	if err := r.Projects.Save(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+
+	task := newTask(1, "Buy milk")
+	save(t, r, task)
+
+	var recorded []string
+	if err := r.Tasks.ChangeProject(ctx, task.Id, project.Id, 1, at(30), recorder(models.HistoryUpdated, 30, &recorded)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, task.Id); got.ProjectId != project.Id || got.Version != 2 || got.UpdatedAt != at(30) {
+		t.Errorf("expected task in project, got %+v", got)
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: task.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || len(page.Entries[0].Changes) != 1 || page.Entries[0].Changes[0].Field != "project_id" {
+		t.Errorf("expected the move to be recorded, got %+v", page.Entries)
+	}
+
+	if err := r.Tasks.ChangeProject(ctx, task.Id, "", 2, at(31), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, task.Id); got.ProjectId != "" || got.Version != 3 {
+		t.Errorf("expected task out of project, got %+v", got)
+	}
+
+	if err := r.Tasks.ChangeProject(ctx, task.Id, project.Id, 1, at(32), nil); !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if err := r.Tasks.ChangeProject(ctx, id(99), project.Id, 1, at(32), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+}
+
+func testSubtasks(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	root := newTask(1, "Move")
+	second := newTask(2, "Pack")
+	second.ParentId = root.Id
+	first := newTask(3, "Book a van")
+	first.ParentId = root.Id
+	first.CreatedAt = at(0)
+	nested := newTask(4, "Buy boxes")
+	nested.ParentId = second.Id
+	done := newTask(5, "Find flat")
+	done.ParentId = root.Id
+	done.Completed, done.CompletedAt, done.Version = true, at(5), 2
+	unrelated := newTask(6, "Buy milk")
+	save(t, r, root, second, first, nested, done, unrelated)
+
+	children, err := r.Tasks.GetByParentIds(ctx, []string{root.Id, second.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := []string{first.Id, second.Id, nested.Id, done.Id}, ids(children); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected oldest subtasks first %v, got %v", expected, got)
+	}
+
+	counts, err := r.Tasks.GetSubtaskCounts(ctx, []string{root.Id, second.Id, unrelated.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCounts := map[string]dtos.SubtaskCounts{
+		root.Id:   {Total: 3, Completed: 1},
+		second.Id: {Total: 1, Completed: 0},
+	}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("expected %v, got %v", expectedCounts, counts)
+	}
+
+	var recorded []string
+	changed, err := r.Tasks.ChangeCompletionStatus(ctx, root.Id, true, 1, at(30), at(31), recorder(models.HistoryCompleted, 31, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{second.Id, first.Id, nested.Id}; !reflect.DeepEqual(ids(changed), expected) {
+		t.Errorf("expected the open subtasks, parents first %v, got %v", expected, ids(changed))
+	}
+	if expected := []string{root.Id, second.Id, first.Id, nested.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected the task and then its subtasks to be recorded %v, got %v", expected, recorded)
+	}
+	for _, task := range []*models.Task{root, second, first, nested} {
+		got := get(t, r, task.Id)
+		if !got.Completed || got.CompletedAt != at(30) || got.UpdatedAt != at(31) || got.Version != 2 {
+			t.Errorf("expected %s to be completed, got %+v", task.Title, got)
+		}
+	}
+	if got := get(t, r, done.Id); got.CompletedAt != at(5) || got.Version != 2 {
+		t.Errorf("expected completed subtask to be left alone, got %+v", got)
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: nested.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Action != models.HistoryCompleted || page.Entries[0].Version != 2 {
+		t.Fatalf("expected the completion of the subtask to be recorded, got %+v", page.Entries)
+	}
+	changes, _ := json.Marshal(page.Entries[0].Changes)
+	if expected := `[{"field":"completed","before":false,"after":true},{"field":"completed_at","before":"","after":"` + at(30) + `"}]`; string(changes) != expected {
+		t.Errorf("expected changes %s, got %s", expected, changes)
+	}
+
+	recorded = nil
+	changed, err = r.Tasks.ChangeCompletionStatus(ctx, nested.Id, false, 2, "", at(32), recorder(models.HistoryReopened, 32, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{root.Id, second.Id}; !reflect.DeepEqual(ids(changed), expected) {
+		t.Errorf("expected the ancestors to be reopened %v, got %v", expected, ids(changed))
+	}
+	if expected := []string{nested.Id, root.Id, second.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected the task and then its ancestors to be recorded %v, got %v", expected, recorded)
+	}
+	for _, task := range changed {
+		if got := get(t, r, task.Id); got.Completed || got.CompletedAt != "" || got.Version != 3 {
+			t.Errorf("expected ancestor %s to be reopened, got %+v", task.Title, got)
+		}
+	}
+	if got := get(t, r, first.Id); !got.Completed || got.Version != 2 {
+		t.Errorf("expected the sibling to stay completed, got %+v", got)
+	}
+
+	// A completion that fails the version check leaves the subtasks open.
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, root.Id, true, 2, at(33), at(33), nil); !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if got := get(t, r, nested.Id); got.Completed {
+		t.Errorf("expected failed completion to leave subtasks open, got %+v", got)
+	}
+
+	if _, err := r.Tasks.DeleteById(ctx, root.Id, 5, at(40), nil); !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected VersionConflict, got %v", err)
+	}
+	if _, err := r.Tasks.GetById(ctx, nested.Id); err != nil {
+		t.Errorf("expected failed delete to keep subtasks, got %v", err)
+	}
+
+	if _, err := r.Tasks.DeleteById(ctx, root.Id, 3, at(40), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []*models.Task{root, second, first, nested, done} {
+		if _, err := r.Tasks.GetById(ctx, task.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+			t.Errorf("expected %s to be deleted, got %v", task.Title, err)
+		}
+	}
+	if _, err := r.Tasks.GetById(ctx, unrelated.Id); err != nil {
+		t.Errorf("expected unrelated task to stay, got %v", err)
+	}
+
+	if _, err := r.Tasks.DeleteById(ctx, root.Id, 2, at(41), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound, got %v", err)
+	}
+}
+
+func testBlockers(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	buy := newTask(1, "Buy paint")
+	paint := newTask(2, "Paint walls")
+	hang := newTask(3, "Hang pictures")
+	clean := newTask(4, "Clean up")
+	save(t, r, buy, paint, hang, clean)
+
+	var recorded []string
+	for _, dependency := range [][2]string{{paint.Id, buy.Id}, {hang.Id, paint.Id}, {hang.Id, clean.Id}, {hang.Id, paint.Id}} {
+		if err := r.Tasks.AddBlocker(ctx, dependency[0], dependency[1], recorder(models.HistoryUpdated, 20, &recorded)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Adding the same blocker twice moves the task to a new version and
+	// records it once.
+	got := get(t, r, hang.Id)
+	if !reflect.DeepEqual(got.BlockedBy, []string{paint.Id, clean.Id}) || !got.Blocked || got.Version != 3 {
+		t.Errorf("expected hanging to wait for painting and cleaning, got %+v", got)
+	}
+	if expected := []string{paint.Id, hang.Id, hang.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected each added blocker to be recorded %v, got %v", expected, recorded)
+	}
+
+	cycles := [][2]string{{buy.Id, buy.Id}, {buy.Id, paint.Id}, {buy.Id, hang.Id}, {clean.Id, hang.Id}}
+	for _, dependency := range cycles {
+		if err := r.Tasks.AddBlocker(ctx, dependency[0], dependency[1], nil); !errors.Is(err, internalErrors.DependencyCycle) {
+			t.Errorf("expected DependencyCycle for %v, got %v", dependency, err)
+		}
+	}
+
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, buy.Id, true, 1, at(30), at(30), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, paint.Id); got.Blocked || !reflect.DeepEqual(got.BlockedBy, []string{buy.Id}) {
+		t.Errorf("expected completed blocker to unblock, got %+v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Tasks.RemoveBlocker(ctx, hang.Id, paint.Id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.Tasks.DeleteById(ctx, clean.Id, 1, at(40), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, hang.Id); got.Blocked || len(got.BlockedBy) != 0 || got.Version != 4 {
+		t.Errorf("expected no blockers left, got %+v", got)
+	}
+
+	// The removed edge no longer closes a cycle.
+	if err := r.Tasks.AddBlocker(ctx, paint.Id, hang.Id, nil); err != nil {
+		t.Errorf("expected dependency to be allowed, got %v", err)
+	}
+}
+
+func testTags(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	task := newTask(1, "Buy milk")
+	save(t, r, task)
+
+	var recorded []string
+	for n, name := range map[int]string{60: "shop", 61: "Errand"} {
+		if err := r.Tags.Save(ctx, &models.Tag{Id: id(n), Name: name, CreatedAt: at(0), OwnerId: owner}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Tags.Attach(ctx, task.Id, id(n), recorder(models.HistoryUpdated, 10, &recorded)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Attaching an attached tag changes nothing.
+	if err := r.Tags.Attach(ctx, task.Id, id(60), recorder(models.HistoryUpdated, 10, &recorded)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, task.Id); !reflect.DeepEqual(got.Tags, []string{"Errand", "shop"}) || got.Version != 3 {
+		t.Errorf("expected tags sorted by name and a version per attached tag, got %+v", got)
+	}
+	if len(recorded) != 2 {
+		t.Errorf("expected a record per attached tag, got %v", recorded)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Tags.Detach(ctx, task.Id, id(61), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := get(t, r, task.Id); !reflect.DeepEqual(got.Tags, []string{"shop"}) || got.Version != 4 {
+		t.Errorf("expected detached tag to be gone at a new version, got %+v", got)
+	}
+
+	if err := r.Tags.Delete(ctx, id(60)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, task.Id); len(got.Tags) != 0 {
+		t.Errorf("expected deleted tag to leave the task, got %v", got.Tags)
+	}
+}
+
+func testLastOccurrence(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	var tasks []*models.Task
+	for n := 1; n <= 3; n++ {
+		task := newTask(n, "Water plants")
+		task.Recurrence, task.SeriesId, task.Occurrence = "FREQ=DAILY", id(1), n
+		tasks = append(tasks, task)
+	}
+	save(t, r, tasks...)
+
+	last, err := r.Tasks.LastOccurrence(ctx, id(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 3 {
+		t.Errorf("expected 3, got %d", last)
+	}
+
+	last, err = r.Tasks.LastOccurrence(ctx, id(99))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 0 {
+		t.Errorf("expected 0 for unknown series, got %d", last)
+	}
+}
+
+func testOverdue(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	now := start.Add(time.Hour)
+
+	past := newTask(1, "Pay rent")
+	past.DueDate, past.DueAt = now.AddDate(0, 0, -1).Format(dtos.DateLayout), now.Add(-time.Minute).Format(time.RFC3339)
+	future := newTask(2, "Pay taxes")
+	future.DueDate, future.DueAt = now.AddDate(0, 0, 1).Format(dtos.DateLayout), now.Add(time.Minute).Format(time.RFC3339)
+	flagged := newTask(3, "Call bank")
+	flagged.DueDate, flagged.DueAt, flagged.Overdue = past.DueDate, past.DueAt, true
+	undated := newTask(4, "Read book")
+	save(t, r, past, future, flagged, undated)
+
+	var recorded []string
+	updated, err := r.Tasks.UpdateOverdueTasks(ctx, now, recorder(models.HistoryOverdue, 60, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0].Id != past.Id || !updated[0].Overdue || updated[0].Version != 2 {
+		t.Fatalf("expected only the newly overdue task at a new version, got %+v", updated)
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: past.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Version != 2 || len(page.Entries[0].Changes) != 1 || page.Entries[0].Changes[0].Field != "overdue" {
+		t.Errorf("expected the flag to be recorded, got %+v", page.Entries)
+	}
+
+	if got := get(t, r, past.Id); !got.Overdue || got.Version != 2 {
+		t.Errorf("expected task to be flagged at a new version, got %+v", got)
+	}
+	if got := get(t, r, future.Id); got.Overdue || got.Version != 1 {
+		t.Errorf("expected future task to stay, got %+v", got)
+	}
+
+	updated, err = r.Tasks.UpdateOverdueTasks(ctx, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("expected nothing left to flag, got %+v", updated)
+	}
+
+	updated, err = r.Tasks.UpdateOverdueTasks(ctx, now.Add(2*time.Minute), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0].Id != future.Id {
+		t.Errorf("expected the task due by the later time, got %+v", updated)
+	}
+}
+
+func testTrash(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+
+	root := newTask(1, "Move")
+	child := newTask(2, "Pack")
+	child.ParentId = root.Id
+	late := newTask(3, "Buy boxes")
+	late.ParentId = child.Id
+	late.DueDate, late.DueAt = now.AddDate(0, 0, -1).Format(dtos.DateLayout), now.Add(-time.Hour).Format(time.RFC3339)
+	alone := newTask(4, "Book a van")
+	alone.ParentId = root.Id
+	kept := newTask(5, "Buy milk")
+	foreign := newTask(6, "Pack boxes")
+	foreign.OwnerId = other
+	save(t, r, root, child, late, alone, kept, foreign)
+
+	if _, err := r.Tasks.DeleteById(ctx, alone.Id, 1, at(40), nil); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := r.Tasks.GetSubtaskCounts(ctx, []string{root.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[root.Id].Total != 1 {
+		t.Errorf("expected trashed subtask not to be counted, got %v", counts)
+	}
+
+	var recorded []string
+	deleted, err := r.Tasks.DeleteById(ctx, root.Id, 1, at(50), recorder(models.HistoryDeleted, 50, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{root.Id, child.Id, late.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected the task and then its subtasks to be recorded %v, got %v", expected, recorded)
+	}
+	if expected := []string{root.Id, child.Id, late.Id}; !reflect.DeepEqual(ids(deleted), expected) || deleted[2].DeletedAt != at(50) {
+		t.Errorf("expected the trashed tasks to be returned %v, got %+v", expected, deleted)
+	}
+	for _, task := range []*models.Task{root, child, late} {
+		if _, err := r.Tasks.GetById(ctx, task.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+			t.Errorf("expected %s to be in the trash, got %v", task.Title, err)
+		}
+	}
+	trashed, err := r.Tasks.GetTrashedById(ctx, late.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trashed.DeletedAt != at(50) || trashed.Version != 2 {
+		t.Errorf("expected subtask to be trashed with its parent, got %+v", trashed)
+	}
+	if _, err := r.Tasks.GetTrashedById(ctx, kept.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound for a task outside the trash, got %v", err)
+	}
+
+	page, err := r.Tasks.GetAll(ctx, &dtos.GetTasksQuery{OwnerId: owner, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := []string{kept.Id}, ids(page.Tasks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected trashed tasks to be left out %v, got %v", expected, got)
+	}
+	if got, err := r.Tasks.GetByIds(ctx, []string{root.Id, kept.Id}); err != nil || len(got) != 1 {
+		t.Errorf("expected only the kept task, got %v, %v", ids(got), err)
+	}
+
+	trash, err := r.Tasks.GetTrash(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := []string{root.Id, alone.Id}, ids(trash); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected tasks deleted on their own, latest first %v, got %v", expected, got)
+	}
+
+	updated, err := r.Tasks.UpdateOverdueTasks(ctx, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("expected trashed task not to be flagged, got %+v", updated)
+	}
+	if _, err := r.Tasks.ChangeCompletionStatus(ctx, root.Id, true, 2, at(55), at(55), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound for a trashed task, got %v", err)
+	}
+	if _, err := r.Tasks.DeleteById(ctx, root.Id, 2, at(55), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound for a trashed task, got %v", err)
+	}
+
+	recorded = nil
+	restored, err := r.Tasks.Restore(ctx, root.Id, at(60), recorder(models.HistoryRestored, 60, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{root.Id, child.Id, late.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected the task and then the subtasks deleted with it to be recorded %v, got %v", expected, recorded)
+	}
+	if expected := []string{root.Id, child.Id, late.Id}; !reflect.DeepEqual(ids(restored), expected) || restored[0].DeletedAt != "" {
+		t.Errorf("expected the restored tasks to be returned %v, got %+v", expected, restored)
+	}
+
+	history, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: late.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, entry := range history.Entries {
+		actions = append(actions, entry.Action)
+	}
+	if expected := []string{models.HistoryRestored, models.HistoryDeleted}; !reflect.DeepEqual(actions, expected) {
+		t.Errorf("expected the subtask to be recorded as deleted and restored %v, got %v", expected, actions)
+	}
+	changes, _ := json.Marshal(history.Entries[1].Changes)
+	if expected := `[{"field":"deleted_at","before":"","after":"` + at(50) + `"}]`; string(changes) != expected {
+		t.Errorf("expected changes %s, got %s", expected, changes)
+	}
+	for _, task := range []*models.Task{root, child, late} {
+		got := get(t, r, task.Id)
+		if got.DeletedAt != "" || got.UpdatedAt != at(60) || got.Version != 3 {
+			t.Errorf("expected %s to be restored, got %+v", task.Title, got)
+		}
+	}
+	if _, err := r.Tasks.GetTrashedById(ctx, alone.Id); err != nil {
+		t.Errorf("expected subtask deleted on its own to stay in the trash, got %v", err)
+	}
+	if _, err := r.Tasks.Restore(ctx, root.Id, at(61), nil); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound for a task outside the trash, got %v", err)
+	}
+
+	if err := r.Tasks.Purge(ctx, kept.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected TaskNotFound for a task outside the trash, got %v", err)
+	}
+	if err := r.Tasks.Purge(ctx, alone.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Tasks.GetTrashedById(ctx, alone.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected purged task to be gone, got %v", err)
+	}
+
+	if _, err := r.Tasks.DeleteById(ctx, root.Id, 3, at(70), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Tasks.DeleteById(ctx, kept.Id, 1, at(80), nil); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := r.Tasks.PurgeDeletedBefore(ctx, at(75))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 3 {
+		t.Errorf("expected the task and its subtasks to be purged, got %d", purged)
+	}
+	if _, err := r.Tasks.GetTrashedById(ctx, late.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected purged subtask to be gone, got %v", err)
+	}
+	trash, err = r.Tasks.GetTrash(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := []string{kept.Id}, ids(trash); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected only the recently trashed task %v, got %v", expected, got)
+	}
+}
+
+func testProjectDelete(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	for _, project := range []*models.Project{
+		{Id: id(50), Name: "Move", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner},
+		{Id: id(51), Name: "Home", CreatedAt: at(0), UpdatedAt: at(0), OwnerId: owner},
+	} {
+		if err := r.Projects.Save(ctx, project); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := newTask(1, "Move")
+	root.ProjectId = id(50)
+	moved := newTask(2, "Pack")
+	moved.ParentId, moved.ProjectId = root.Id, id(51)
+	trashed := newTask(3, "Book a van")
+	trashed.ProjectId, trashed.DeletedAt = id(50), at(5)
+	other := newTask(4, "Buy milk")
+	other.ProjectId = id(51)
+	save(t, r, root, moved, trashed, other)
+
+	if _, err := r.Projects.Delete(ctx, id(50), false, at(40), nil); !errors.Is(err, internalErrors.ProjectHasTasks) {
+		t.Fatalf("expected ProjectHasTasks, got %v", err)
+	}
+	if got := get(t, r, root.Id); got.ProjectId != id(50) || got.Version != 1 {
+		t.Errorf("expected the refused delete to change nothing, got %+v", got)
+	}
+
+	var recorded []string
+	deleted, err := r.Projects.Delete(ctx, id(50), true, at(40), recorder(models.HistoryDeleted, 40, &recorded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Subtasks moved to another project go along with their parents, and the
+	// task already in the trash changes only by losing the project.
+	if expected := []string{root.Id, moved.Id}; !reflect.DeepEqual(ids(deleted), expected) {
+		t.Errorf("expected the tasks of the project, parents first %v, got %v", expected, ids(deleted))
+	}
+	if expected := []string{root.Id, moved.Id, trashed.Id}; !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("expected every changed task to be recorded %v, got %v", expected, recorded)
+	}
+	for _, task := range deleted {
+		if task.DeletedAt != at(40) || task.Version != 2 {
+			t.Errorf("expected %s to be trashed, got %+v", task.Title, task)
+		}
+	}
+	if got := get(t, r, other.Id); got.DeletedAt != "" {
+		t.Errorf("expected the task of another project to stay, got %+v", got)
+	}
+	if _, err := r.Projects.GetById(ctx, id(50)); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected ProjectNotFound, got %v", err)
+	}
+
+	page, err := r.History.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: trashed.Id, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Version != 2 || len(page.Entries[0].Changes) != 1 || page.Entries[0].Changes[0].Field != "project_id" {
+		t.Errorf("expected the lost project to be recorded, got %+v", page.Entries)
+	}
+
+	// The tasks stay in the trash until restored or purged, without the
+	// deleted project.
+	for _, task := range []*models.Task{root, trashed} {
+		got, err := r.Tasks.GetTrashedById(ctx, task.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ProjectId != "" || got.Version != 2 {
+			t.Errorf("expected %s to lose the deleted project at a new version, got %+v", task.Title, got)
+		}
+	}
+
+	if _, err := r.Tasks.Restore(ctx, root.Id, at(50), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, r, root.Id); got.DeletedAt != "" || got.ProjectId != "" || got.Version != 3 {
+		t.Errorf("expected the task to be restored without a project, got %+v", got)
+	}
+	if got := get(t, r, moved.Id); got.DeletedAt != "" || got.ProjectId != id(51) {
+		t.Errorf("expected the subtask to be restored into its own project, got %+v", got)
+	}
+
+	if _, err := r.Projects.Delete(ctx, id(50), true, at(60), nil); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected ProjectNotFound, got %v", err)
+	}
+}
+
+func testSearch(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	title := newTask(1, "Quarterly report")
+	description := newTask(2, "Misc")
+	description.Description = "send the report draft to Anna"
+	longer := newTask(3, "Reporting tool")
+	foreign := newTask(4, "Annual report")
+	foreign.OwnerId = other
+	save(t, r, title, description, longer, foreign)
+
+	search := func(input string, limit int, cursor string) *dtos.SearchPage {
+		t.Helper()
+
+		page, err := r.Tasks.Search(ctx, &dtos.SearchTasksQuery{Query: input, Limit: limit, Cursor: cursor, OwnerId: owner})
+		if errors.Is(err, internalErrors.SearchUnavailable) {
+			t.Skip("full-text search is not available in this build")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	page := search("report", 10, "")
+	if len(page.Results) != 2 || page.Results[0].Task.Id != title.Id || page.Results[1].Task.Id != description.Id {
+		t.Fatalf("expected title match before description match, got %+v", page.Results)
+	}
+	if page.Results[0].TitleHighlight != "Quarterly <mark>report</mark>" {
+		t.Errorf("unexpected highlight %q", page.Results[0].TitleHighlight)
+	}
+	if !strings.Contains(page.Results[1].Snippet, "<mark>report</mark>") {
+		t.Errorf("expected snippet to mark the match, got %q", page.Results[1].Snippet)
+	}
+
+	if page := search("repo*", 10, ""); len(page.Results) != 3 {
+		t.Errorf("expected prefix to match all own tasks, got %+v", page.Results)
+	}
+	if page := search(`"report draft"`, 10, ""); len(page.Results) != 1 || page.Results[0].Task.Id != description.Id {
+		t.Errorf("expected phrase to match the description, got %+v", page.Results)
+	}
+	if page := search(`report OR tool`, 10, ""); len(page.Results) != 0 {
+		t.Errorf("expected operators to be plain terms, got %+v", page.Results)
+	}
+	if page := search(`* ""`, 10, ""); len(page.Results) != 0 || page.NextCursor != "" {
+		t.Errorf("expected empty page for a query without terms, got %+v", page)
+	}
+
+	var paged []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+
+		page := search("repo*", 1, cursor)
+		for _, result := range page.Results {
+			paged = append(paged, result.Task.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	var expected []string
+	for _, result := range search("repo*", 10, "").Results {
+		expected = append(expected, result.Task.Id)
+	}
+	if !reflect.DeepEqual(paged, expected) {
+		t.Errorf("expected pages to list %v, got %v", expected, paged)
+	}
+
+	cursor = search("repo*", 1, "").NextCursor
+	if _, err := r.Tasks.Search(ctx, &dtos.SearchTasksQuery{Query: "report", Limit: 1, Cursor: cursor, OwnerId: owner}); !errors.Is(err, internalErrors.InvalidCursor) {
+		t.Errorf("expected cursor of another query to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/repository/repositorytest"
+	"testing"
+)
+
+// Search cases skip themselves unless the tests run with -tags sqlite_fts5.
+func TestConformance(t *testing.T) {
+	repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Repositories {
+		db := newTestDB(t)
+
+		tasks := NewTaskRepository(db)
+		if err := tasks.InitSearch(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		return repositorytest.Repositories{
+			Tasks:    tasks,
+			Projects: NewProjectRepository(db),
+			Tags:     NewTagRepository(db),
+			History:  NewHistoryRepository(db),
+		}
+	})
+}
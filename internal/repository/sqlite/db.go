@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"github.com/DanKo-code/TODO-list/internal/migrations"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	_ "github.com/mattn/go-sqlite3"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+func NewDB(driver string, dsn string) (*sql.DB, error) {
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to connect database: %v", err)
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to verify database: %v", err)
+		return nil, err
+	}
+
+	logger.InfoLogger.Println("Database connected")
+
+	return db, nil
+}
+
+// params collects the arguments of a statement built piece by piece. SQLite
+// numbers $n parameters in the order they first appear, so the pieces have
+// to be added in the order they end up in the statement.
+type params []interface{}
+
+func (p *params) add(value interface{}) string {
+	*p = append(*p, value)
+	return "$" + strconv.Itoa(len(*p))
+}
+
+// addList binds every value and returns their placeholders for an IN list.
+func (p *params) addList(values []interface{}) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = p.add(value)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// searchIndexMigration creates the full-text index, which needs FTS5.
+const searchIndexMigration = 18
+
+// NewMigrator returns the migrator of the schema. When the linked SQLite is
+// built without FTS5 the full-text index migration is disabled, so such a
+// build still runs, only without search. A database that already has the
+// index is refused: its triggers make every write to tasks need FTS5.
+func NewMigrator(db *sql.DB) (*migrations.Migrator, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrations.NewMigrator(db, files)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := fts5Available(context.TODO(), db)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		logger.InfoLogger.Println("SQLite is built without FTS5, full-text search is disabled")
+		migrator.Disable(searchIndexMigration, "SQLite is built without FTS5, build with -tags sqlite_fts5")
+	}
+
+	return migrator, nil
+}
+
+// fts5Available reports whether FTS5 is compiled into the linked SQLite,
+// which go-sqlite3 does with the sqlite_fts5 build tag.
+func fts5Available(ctx context.Context, db *sql.DB) (bool, error) {
+	var available bool
+	err := db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&available)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to detect FTS5 support: %v", err)
+		return false, err
+	}
+
+	return available, nil
+}
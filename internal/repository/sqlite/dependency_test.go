@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestBlockers(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+
+	for _, task := range []*models.Task{
+		{Id: "1", Title: "design", Version: 1, OwnerId: "u1"},
+		{Id: "2", Title: "build", Version: 1, OwnerId: "u1"},
+		{Id: "3", Title: "ship", Version: 1, OwnerId: "u1"},
+	} {
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 3 waits for 2, 2 waits for 1.
+	if err := tasks.AddBlocker(ctx, "3", "2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.AddBlocker(ctx, "2", "1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.AddBlocker(ctx, "2", "1", nil); err != nil {
+		t.Errorf("expected adding a blocker twice to succeed, got %v", err)
+	}
+
+	for _, edge := range [][2]string{{"1", "1"}, {"1", "2"}, {"1", "3"}} {
+		if err := tasks.AddBlocker(ctx, edge[0], edge[1], nil); !errors.Is(err, internalErrors.DependencyCycle) {
+			t.Errorf("expected %s blocked by %s to be a cycle, got %v", edge[0], edge[1], err)
+		}
+	}
+
+	task, err := tasks.GetById(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.Blocked || len(task.BlockedBy) != 1 || task.BlockedBy[0] != "1" {
+		t.Errorf("expected 2 to be blocked by 1, got %+v", task)
+	}
+
+	if _, err := tasks.ChangeCompletionStatus(ctx, "1", true, 1, "2024-11-22T10:30:00Z", "2024-11-22T10:30:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	task, err = tasks.GetById(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Blocked || len(task.BlockedBy) != 1 {
+		t.Errorf("expected 2 to be unblocked once 1 is completed, got %+v", task)
+	}
+
+	if _, err := tasks.DeleteById(ctx, "2", task.Version, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	task, err = tasks.GetById(ctx, "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Blocked || len(task.BlockedBy) != 0 {
+		t.Errorf("expected a trashed blocker to be left out, got %+v", task)
+	}
+
+	if _, err := tasks.Restore(ctx, "2", "2024-11-21T11:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	task, err = tasks.GetById(ctx, "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.Blocked || len(task.BlockedBy) != 1 {
+		t.Errorf("expected a restored blocker to block again, got %+v", task)
+	}
+
+	task, err = tasks.GetById(ctx, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.DeleteById(ctx, "2", task.Version, "2024-11-21T12:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.Purge(ctx, "2"); err != nil {
+		t.Fatal(err)
+	}
+	var dependencies int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM task_dependencies WHERE task_id = '2' OR blocker_id = '2'`).Scan(&dependencies); err != nil {
+		t.Fatal(err)
+	}
+	if dependencies != 0 {
+		t.Errorf("expected dependencies of a purged task to be removed, got %d", dependencies)
+	}
+
+	if err := tasks.AddBlocker(ctx, "1", "3", nil); err != nil {
+		t.Errorf("expected the cycle to be gone with the deleted task, got %v", err)
+	}
+}
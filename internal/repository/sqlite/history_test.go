@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestHistoryIsAppendOnly(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+	history := NewHistoryRepository(db)
+
+	entry := &models.HistoryEntry{Id: "h1", TaskId: "1", Action: models.HistoryCreated, ActorId: "u1", Changes: []models.FieldChange{}, Version: 1, CreatedAt: "2024-11-20T10:00:00Z"}
+	if err := history.Save(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE task_history SET action = 'updated'`); err == nil {
+		t.Error("expected history entries not to be updated")
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM task_history`); err == nil {
+		t.Error("expected history entries not to be deleted")
+	}
+
+	page, err := history.GetByTask(ctx, &dtos.GetHistoryQuery{TaskId: "1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Action != models.HistoryCreated {
+		t.Errorf("expected the entry to stay as saved, got %+v", page.Entries)
+	}
+}
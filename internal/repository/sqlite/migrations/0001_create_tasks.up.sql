@@ -0,0 +1,4 @@
+-- Adopts databases created before migrations existed, which only ever ran
+-- this statement on startup.
+CREATE TABLE IF NOT EXISTS tasks
+    (id uuid, title TEXT, description TEXT, due_date TEXT, overdue INTEGER, completed INTEGER);
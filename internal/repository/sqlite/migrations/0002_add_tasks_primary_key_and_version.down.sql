@@ -0,0 +1,11 @@
+CREATE TABLE tasks_old
+    (id uuid, title TEXT, description TEXT, due_date TEXT, overdue INTEGER, completed INTEGER);
+
+INSERT INTO tasks_old (id, title, description, due_date, overdue, completed)
+SELECT id, title, description, due_date, overdue, completed
+FROM tasks
+ORDER BY rowid;
+
+DROP TABLE tasks;
+
+ALTER TABLE tasks_old RENAME TO tasks;
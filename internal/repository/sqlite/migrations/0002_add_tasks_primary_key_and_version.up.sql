@@ -0,0 +1,30 @@
+-- SQLite cannot add a primary key to an existing table, so the table is
+-- rebuilt. Rows are copied in rowid order to keep the creation order.
+CREATE TABLE tasks_new
+(
+    id          TEXT PRIMARY KEY NOT NULL,
+    title       TEXT             NOT NULL DEFAULT '',
+    description TEXT             NOT NULL DEFAULT '',
+    due_date    TEXT             NOT NULL DEFAULT '',
+    overdue     INTEGER          NOT NULL DEFAULT 0,
+    completed   INTEGER          NOT NULL DEFAULT 0,
+    version     INTEGER          NOT NULL DEFAULT 1
+);
+
+INSERT OR IGNORE INTO tasks_new (id, title, description, due_date, overdue, completed)
+SELECT id,
+       COALESCE(title, ''),
+       COALESCE(description, ''),
+       COALESCE(due_date, ''),
+       COALESCE(overdue, 0),
+       COALESCE(completed, 0)
+FROM tasks
+WHERE id IS NOT NULL
+ORDER BY rowid;
+
+DROP TABLE tasks;
+
+ALTER TABLE tasks_new RENAME TO tasks;
+
+CREATE INDEX idx_tasks_due_date ON tasks (due_date);
+CREATE INDEX idx_tasks_overdue_completed ON tasks (overdue, completed);
@@ -0,0 +1,5 @@
+DROP INDEX idx_tasks_created_at;
+
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN updated_at;
+ALTER TABLE tasks DROP COLUMN created_at;
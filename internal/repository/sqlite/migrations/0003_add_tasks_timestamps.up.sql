@@ -0,0 +1,12 @@
+ALTER TABLE tasks ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN completed_at TEXT;
+
+-- The real creation time of existing tasks is unknown, the migration time is
+-- the closest honest value.
+UPDATE tasks
+SET created_at   = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    updated_at   = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+    completed_at = CASE WHEN completed THEN strftime('%Y-%m-%dT%H:%M:%SZ', 'now') END;
+
+CREATE INDEX idx_tasks_created_at ON tasks (created_at);
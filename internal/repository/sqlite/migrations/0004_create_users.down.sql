@@ -0,0 +1,6 @@
+DROP INDEX idx_tasks_owner_id;
+
+ALTER TABLE tasks DROP COLUMN owner_id;
+
+DROP TABLE auth_tokens;
+DROP TABLE users;
@@ -0,0 +1,23 @@
+CREATE TABLE users
+(
+    id            TEXT PRIMARY KEY NOT NULL,
+    username      TEXT             NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT             NOT NULL,
+    created_at    TEXT             NOT NULL
+);
+
+CREATE TABLE auth_tokens
+(
+    token_hash TEXT PRIMARY KEY NOT NULL,
+    user_id    TEXT             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TEXT             NOT NULL,
+    expires_at TEXT             NOT NULL
+);
+
+CREATE INDEX idx_auth_tokens_expires_at ON auth_tokens (expires_at);
+
+-- Tasks created before accounts existed get no owner and are not visible to
+-- any user.
+ALTER TABLE tasks ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tasks_owner_id ON tasks (owner_id);
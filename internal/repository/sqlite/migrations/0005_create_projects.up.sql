@@ -0,0 +1,17 @@
+CREATE TABLE projects
+(
+    id          TEXT PRIMARY KEY NOT NULL,
+    name        TEXT             NOT NULL,
+    description TEXT             NOT NULL DEFAULT '',
+    archived    BOOLEAN          NOT NULL DEFAULT FALSE,
+    created_at  TEXT             NOT NULL,
+    updated_at  TEXT             NOT NULL,
+    owner_id    TEXT             NOT NULL
+);
+
+CREATE INDEX idx_projects_owner_id ON projects (owner_id, archived);
+
+-- Tasks without a project keep project_id NULL.
+ALTER TABLE tasks ADD COLUMN project_id TEXT REFERENCES projects (id);
+
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
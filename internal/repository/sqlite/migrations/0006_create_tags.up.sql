@@ -0,0 +1,27 @@
+CREATE TABLE tags
+(
+    id         TEXT PRIMARY KEY NOT NULL,
+    name       TEXT             NOT NULL COLLATE NOCASE,
+    created_at TEXT             NOT NULL,
+    owner_id   TEXT             NOT NULL,
+    UNIQUE (owner_id, name)
+);
+
+CREATE TABLE task_tags
+(
+    task_id TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    tag_id  TEXT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX idx_task_tags_tag_id ON task_tags (tag_id);
+
+-- Foreign keys are not enforced on the connection, so the join table is
+-- cleaned up by triggers.
+CREATE TRIGGER task_tags_task_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM task_tags WHERE task_id = old.id;
+END;
+
+CREATE TRIGGER task_tags_tag_delete AFTER DELETE ON tags BEGIN
+    DELETE FROM task_tags WHERE tag_id = old.id;
+END;
@@ -0,0 +1,5 @@
+-- Priority is stored as a level from 0 (none) to 4 (urgent) so it can be
+-- ordered by directly.
+ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_tasks_priority ON tasks (owner_id, priority);
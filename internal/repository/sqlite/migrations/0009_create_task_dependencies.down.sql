@@ -0,0 +1,3 @@
+DROP TRIGGER task_dependencies_task_delete;
+
+DROP TABLE task_dependencies;
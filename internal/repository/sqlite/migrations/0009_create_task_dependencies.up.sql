@@ -0,0 +1,14 @@
+CREATE TABLE task_dependencies
+(
+    task_id    TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    blocker_id TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX idx_task_dependencies_blocker_id ON task_dependencies (blocker_id);
+
+-- Foreign keys are not enforced on the connection, so dependencies of a
+-- deleted task are cleaned up by a trigger.
+CREATE TRIGGER task_dependencies_task_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM task_dependencies WHERE task_id = old.id OR blocker_id = old.id;
+END;
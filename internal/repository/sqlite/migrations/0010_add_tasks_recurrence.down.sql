@@ -0,0 +1,7 @@
+DROP INDEX idx_tasks_series_id;
+
+ALTER TABLE tasks DROP COLUMN occurrence;
+
+ALTER TABLE tasks DROP COLUMN series_id;
+
+ALTER TABLE tasks DROP COLUMN recurrence;
@@ -0,0 +1,7 @@
+ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN series_id TEXT;
+
+ALTER TABLE tasks ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_tasks_series_id ON tasks (series_id);
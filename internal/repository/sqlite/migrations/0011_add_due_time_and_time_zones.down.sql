@@ -0,0 +1,7 @@
+DROP INDEX idx_tasks_due_at;
+
+ALTER TABLE tasks DROP COLUMN due_at;
+
+ALTER TABLE tasks DROP COLUMN all_day;
+
+ALTER TABLE users DROP COLUMN time_zone;
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+-- due_at is the UTC instant a task becomes overdue: the due time itself, or
+-- the end of the due day in the time zone of the owner for all-day tasks.
+ALTER TABLE tasks ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE tasks ADD COLUMN due_at TEXT;
+
+UPDATE tasks SET due_at = strftime('%Y-%m-%dT%H:%M:%SZ', due_date, '+1 day') WHERE due_date <> '';
+
+CREATE INDEX idx_tasks_due_at ON tasks (due_at);
@@ -0,0 +1,7 @@
+DROP TRIGGER reminders_task_due;
+
+DROP TRIGGER reminders_task_delete;
+
+DROP TABLE reminders;
+
+ALTER TABLE users DROP COLUMN email;
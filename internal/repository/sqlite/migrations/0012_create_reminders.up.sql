@@ -0,0 +1,33 @@
+ALTER TABLE users ADD COLUMN email TEXT NOT NULL DEFAULT '';
+
+-- A reminder fires at remind_at, or before_seconds before the task is due.
+-- claimed_until is set while a scheduler sends it, so it is sent only once.
+CREATE TABLE reminders
+(
+    id             TEXT PRIMARY KEY,
+    task_id        TEXT    NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    remind_at      TEXT,
+    before_seconds INTEGER,
+    fire_at        TEXT    NOT NULL,
+    status         TEXT    NOT NULL DEFAULT 'pending',
+    attempts       INTEGER NOT NULL DEFAULT 0,
+    claimed_until  TEXT,
+    sent_at        TEXT,
+    created_at     TEXT    NOT NULL,
+    owner_id       TEXT    NOT NULL
+);
+
+CREATE INDEX idx_reminders_task_id ON reminders (task_id);
+
+CREATE INDEX idx_reminders_pending ON reminders (fire_at) WHERE status = 'pending';
+
+CREATE TRIGGER reminders_task_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM reminders WHERE task_id = old.id;
+END;
+
+-- Pending reminders relative to the due date follow it when it changes.
+CREATE TRIGGER reminders_task_due AFTER UPDATE OF due_at ON tasks WHEN new.due_at IS NOT old.due_at BEGIN
+    UPDATE reminders
+    SET fire_at = strftime('%Y-%m-%dT%H:%M:%SZ', new.due_at, '-' || before_seconds || ' seconds')
+    WHERE task_id = new.id AND before_seconds IS NOT NULL AND status = 'pending' AND new.due_at IS NOT NULL;
+END;
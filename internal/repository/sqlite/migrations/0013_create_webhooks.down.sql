@@ -0,0 +1,5 @@
+DROP TRIGGER webhook_deliveries_webhook_delete;
+
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
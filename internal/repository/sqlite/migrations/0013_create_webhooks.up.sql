@@ -0,0 +1,39 @@
+-- events is a comma-separated list of event types, empty for all events.
+CREATE TABLE webhooks
+(
+    id         TEXT PRIMARY KEY,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    events     TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    owner_id   TEXT NOT NULL
+);
+
+CREATE INDEX idx_webhooks_owner_id ON webhooks (owner_id);
+
+-- webhook_deliveries is the queue of events to send and the log of sent ones.
+CREATE TABLE webhook_deliveries
+(
+    id               TEXT PRIMARY KEY,
+    webhook_id       TEXT    NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         TEXT    NOT NULL,
+    event_type       TEXT    NOT NULL,
+    payload          TEXT    NOT NULL,
+    status           TEXT    NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TEXT    NOT NULL,
+    claimed_until    TEXT,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    delivered_at     TEXT,
+    created_at       TEXT    NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TRIGGER webhook_deliveries_webhook_delete AFTER DELETE ON webhooks BEGIN
+    DELETE FROM webhook_deliveries WHERE webhook_id = old.id;
+END;
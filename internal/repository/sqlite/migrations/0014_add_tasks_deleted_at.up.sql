@@ -0,0 +1,4 @@
+-- A task with deleted_at is in the trash until it is restored or purged.
+ALTER TABLE tasks ADD COLUMN deleted_at TEXT;
+
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TRIGGER task_history_no_delete;
+
+DROP TRIGGER task_history_no_update;
+
+DROP TABLE task_history;
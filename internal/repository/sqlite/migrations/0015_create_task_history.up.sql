@@ -0,0 +1,22 @@
+-- task_history is an append-only log of task changes. It has no foreign key
+-- to tasks, so the history outlives purged tasks.
+CREATE TABLE task_history
+(
+    id         TEXT PRIMARY KEY,
+    task_id    TEXT    NOT NULL,
+    action     TEXT    NOT NULL,
+    actor_id   TEXT    NOT NULL,
+    changes    TEXT    NOT NULL,
+    version    INTEGER NOT NULL,
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX idx_task_history_task_id ON task_history (task_id, created_at);
+
+CREATE TRIGGER task_history_no_update BEFORE UPDATE ON task_history BEGIN
+    SELECT RAISE(ABORT, 'task history is append-only');
+END;
+
+CREATE TRIGGER task_history_no_delete BEFORE DELETE ON task_history BEGIN
+    SELECT RAISE(ABORT, 'task history is append-only');
+END;
@@ -0,0 +1,3 @@
+DROP INDEX idx_tasks_search_id;
+
+ALTER TABLE tasks DROP COLUMN search_id;
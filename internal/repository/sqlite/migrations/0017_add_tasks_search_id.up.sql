@@ -0,0 +1,7 @@
+-- The full-text index refers to tasks by search_id. The implicit rowid of a
+-- table with a TEXT primary key may change on VACUUM, so it can not be used.
+ALTER TABLE tasks ADD COLUMN search_id INTEGER;
+
+UPDATE tasks SET search_id = rowid;
+
+CREATE UNIQUE INDEX idx_tasks_search_id ON tasks (search_id);
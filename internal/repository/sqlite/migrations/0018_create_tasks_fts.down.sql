@@ -0,0 +1,5 @@
+DROP TRIGGER tasks_fts_update;
+DROP TRIGGER tasks_fts_delete;
+DROP TRIGGER tasks_fts_insert;
+
+DROP TABLE tasks_fts;
@@ -0,0 +1,28 @@
+-- Needs FTS5, which go-sqlite3 compiles in only with the sqlite_fts5 build
+-- tag; builds without it skip this migration. Older versions created the
+-- index at startup, so it is replaced here and filled from the tasks.
+DROP TRIGGER IF EXISTS tasks_fts_insert;
+DROP TRIGGER IF EXISTS tasks_fts_delete;
+DROP TRIGGER IF EXISTS tasks_fts_update;
+DROP TABLE IF EXISTS tasks_fts;
+
+CREATE VIRTUAL TABLE tasks_fts USING fts5(
+    title, description,
+    content = 'tasks', content_rowid = 'search_id',
+    tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3'
+);
+
+CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.search_id, new.title, new.description);
+END;
+
+CREATE TRIGGER tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.search_id, old.title, old.description);
+END;
+
+CREATE TRIGGER tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+    INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.search_id, old.title, old.description);
+    INSERT INTO tasks_fts (rowid, title, description) VALUES (new.search_id, new.title, new.description);
+END;
+
+INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild');
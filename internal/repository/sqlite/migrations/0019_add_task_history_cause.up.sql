@@ -0,0 +1,4 @@
+-- Entries of the tasks a change reaches besides the one it was made to, such
+-- as the subtasks of a deleted task, refer to the entry of that task, so the
+-- change is undone and redone as a whole.
+ALTER TABLE task_history ADD COLUMN cause TEXT;
@@ -4,46 +4,341 @@ import (
 	"context"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"time"
 )
 
 type MockTaskRepository struct {
 	CloseFunc                  func()
-	SaveFunc                   func(ctx context.Context, task *models.Task) error
-	GetAllFunc                 func(ctx context.Context) ([]*models.Task, error)
+	SaveFunc                   func(ctx context.Context, task *models.Task, record repository.Recorder) error
+	GetAllFunc                 func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	SearchFunc                 func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error)
 	GetByIdFunc                func(ctx context.Context, id string) (*models.Task, error)
-	UpdateFunc                 func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error
-	DeleteByIdFunc             func(ctx context.Context, id string) error
-	ChangeCompletionStatusFunc func(ctx context.Context, id string, completionStatus bool) error
-	UpdateOverdueTasksFunc     func(ctx context.Context) error
+	GetByIdsFunc               func(ctx context.Context, ids []string) ([]*models.Task, error)
+	GetByParentIdsFunc         func(ctx context.Context, parentIds []string) ([]*models.Task, error)
+	UpdateFunc                 func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error
+	DeleteByIdFunc             func(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error)
+	GetTrashFunc               func(ctx context.Context, ownerId string) ([]*models.Task, error)
+	GetTrashedByIdFunc         func(ctx context.Context, id string) (*models.Task, error)
+	RestoreFunc                func(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error)
+	PurgeFunc                  func(ctx context.Context, id string) error
+	PurgeDeletedBeforeFunc     func(ctx context.Context, before string) (int64, error)
+	ChangeCompletionStatusFunc func(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error)
+	GetSubtaskCountsFunc       func(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error)
+	AddBlockerFunc             func(ctx context.Context, taskId, blockerId string, record repository.Recorder) error
+	RemoveBlockerFunc          func(ctx context.Context, taskId, blockerId string, record repository.Recorder) error
+	LastOccurrenceFunc         func(ctx context.Context, seriesId string) (int, error)
+	ChangeProjectFunc          func(ctx context.Context, id string, projectId string, version int64, updatedAt string, record repository.Recorder) error
+	RevertFunc                 func(ctx context.Context, cmds []*dtos.RevertTaskCommand, updatedAt string, entries []*models.HistoryEntry) error
+	UpdateOverdueTasksFunc     func(ctx context.Context, now time.Time, record repository.Recorder) ([]*models.Task, error)
 }
 
 func (m MockTaskRepository) Close() {
 }
 
-func (m MockTaskRepository) Save(ctx context.Context, task *models.Task) error {
-	return m.SaveFunc(ctx, task)
+func (m MockTaskRepository) Save(ctx context.Context, task *models.Task, record repository.Recorder) error {
+	return m.SaveFunc(ctx, task, record)
 }
 
-func (m MockTaskRepository) GetAll(ctx context.Context) ([]*models.Task, error) {
-	return m.GetAllFunc(ctx)
+func (m MockTaskRepository) GetAll(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	return m.GetAllFunc(ctx, query)
+}
+
+func (m MockTaskRepository) Search(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	return m.SearchFunc(ctx, query)
 }
 
 func (m MockTaskRepository) GetById(ctx context.Context, id string) (*models.Task, error) {
 	return m.GetByIdFunc(ctx, id)
 }
 
-func (m MockTaskRepository) Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error {
-	return m.UpdateFunc(ctx, id, updateTaskCommand)
+func (m MockTaskRepository) GetByIds(ctx context.Context, ids []string) ([]*models.Task, error) {
+	return m.GetByIdsFunc(ctx, ids)
+}
+
+func (m MockTaskRepository) GetByParentIds(ctx context.Context, parentIds []string) ([]*models.Task, error) {
+	return m.GetByParentIdsFunc(ctx, parentIds)
+}
+
+func (m MockTaskRepository) Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error {
+	return m.UpdateFunc(ctx, id, updateTaskCommand, updatedAt, record)
+}
+
+func (m MockTaskRepository) DeleteById(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	return m.DeleteByIdFunc(ctx, id, version, deletedAt, record)
+}
+
+func (m MockTaskRepository) GetTrash(ctx context.Context, ownerId string) ([]*models.Task, error) {
+	return m.GetTrashFunc(ctx, ownerId)
+}
+
+func (m MockTaskRepository) GetTrashedById(ctx context.Context, id string) (*models.Task, error) {
+	return m.GetTrashedByIdFunc(ctx, id)
+}
+
+func (m MockTaskRepository) Restore(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	return m.RestoreFunc(ctx, id, updatedAt, record)
+}
+
+func (m MockTaskRepository) Purge(ctx context.Context, id string) error {
+	return m.PurgeFunc(ctx, id)
+}
+
+func (m MockTaskRepository) PurgeDeletedBefore(ctx context.Context, before string) (int64, error) {
+	return m.PurgeDeletedBeforeFunc(ctx, before)
+}
+
+func (m MockTaskRepository) ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	return m.ChangeCompletionStatusFunc(ctx, id, completionStatus, version, completedAt, updatedAt, record)
+}
+
+func (m MockTaskRepository) GetSubtaskCounts(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error) {
+	return m.GetSubtaskCountsFunc(ctx, parentIds)
+}
+
+func (m MockTaskRepository) AddBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	return m.AddBlockerFunc(ctx, taskId, blockerId, record)
+}
+
+func (m MockTaskRepository) RemoveBlocker(ctx context.Context, taskId, blockerId string, record repository.Recorder) error {
+	return m.RemoveBlockerFunc(ctx, taskId, blockerId, record)
+}
+
+func (m MockTaskRepository) LastOccurrence(ctx context.Context, seriesId string) (int, error) {
+	return m.LastOccurrenceFunc(ctx, seriesId)
+}
+
+func (m MockTaskRepository) ChangeProject(ctx context.Context, id string, projectId string, version int64, updatedAt string, record repository.Recorder) error {
+	return m.ChangeProjectFunc(ctx, id, projectId, version, updatedAt, record)
+}
+
+func (m MockTaskRepository) Revert(ctx context.Context, cmds []*dtos.RevertTaskCommand, updatedAt string, entries []*models.HistoryEntry) error {
+	return m.RevertFunc(ctx, cmds, updatedAt, entries)
+}
+
+func (m MockTaskRepository) UpdateOverdueTasks(ctx context.Context, now time.Time, record repository.Recorder) ([]*models.Task, error) {
+	return m.UpdateOverdueTasksFunc(ctx, now, record)
+}
+
+type MockUserRepository struct {
+	SaveFunc                func(ctx context.Context, user *models.User) error
+	GetByIdFunc             func(ctx context.Context, id string) (*models.User, error)
+	GetByUsernameFunc       func(ctx context.Context, username string) (*models.User, error)
+	UpdateFunc              func(ctx context.Context, user *models.User) error
+	SaveTokenFunc           func(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error
+	GetUserByTokenFunc      func(ctx context.Context, tokenHash, now string) (*models.User, error)
+	DeleteTokenFunc         func(ctx context.Context, tokenHash string) error
+	DeleteExpiredTokensFunc func(ctx context.Context, now string) error
+}
+
+func (m MockUserRepository) Save(ctx context.Context, user *models.User) error {
+	return m.SaveFunc(ctx, user)
+}
+
+func (m MockUserRepository) GetById(ctx context.Context, id string) (*models.User, error) {
+	return m.GetByIdFunc(ctx, id)
+}
+
+func (m MockUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return m.GetByUsernameFunc(ctx, username)
+}
+
+func (m MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	return m.UpdateFunc(ctx, user)
+}
+
+func (m MockUserRepository) SaveToken(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error {
+	return m.SaveTokenFunc(ctx, tokenHash, userId, createdAt, expiresAt)
+}
+
+func (m MockUserRepository) GetUserByToken(ctx context.Context, tokenHash, now string) (*models.User, error) {
+	return m.GetUserByTokenFunc(ctx, tokenHash, now)
+}
+
+func (m MockUserRepository) DeleteToken(ctx context.Context, tokenHash string) error {
+	return m.DeleteTokenFunc(ctx, tokenHash)
+}
+
+func (m MockUserRepository) DeleteExpiredTokens(ctx context.Context, now string) error {
+	return m.DeleteExpiredTokensFunc(ctx, now)
+}
+
+type MockProjectRepository struct {
+	SaveFunc        func(ctx context.Context, project *models.Project) error
+	GetByIdFunc     func(ctx context.Context, id string) (*models.Project, error)
+	GetAllFunc      func(ctx context.Context, ownerId string, includeArchived bool) ([]*models.Project, error)
+	UpdateFunc      func(ctx context.Context, project *models.Project) error
+	SetArchivedFunc func(ctx context.Context, id string, archived bool, updatedAt string) error
+	DeleteFunc      func(ctx context.Context, id string, cascade bool, deletedAt string, record repository.Recorder) ([]*models.Task, error)
+}
+
+func (m MockProjectRepository) Save(ctx context.Context, project *models.Project) error {
+	return m.SaveFunc(ctx, project)
+}
+
+func (m MockProjectRepository) GetById(ctx context.Context, id string) (*models.Project, error) {
+	return m.GetByIdFunc(ctx, id)
+}
+
+func (m MockProjectRepository) GetAll(ctx context.Context, ownerId string, includeArchived bool) ([]*models.Project, error) {
+	return m.GetAllFunc(ctx, ownerId, includeArchived)
+}
+
+func (m MockProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	return m.UpdateFunc(ctx, project)
+}
+
+func (m MockProjectRepository) SetArchived(ctx context.Context, id string, archived bool, updatedAt string) error {
+	return m.SetArchivedFunc(ctx, id, archived, updatedAt)
+}
+
+func (m MockProjectRepository) Delete(ctx context.Context, id string, cascade bool, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	return m.DeleteFunc(ctx, id, cascade, deletedAt, record)
+}
+
+type MockTagRepository struct {
+	SaveFunc      func(ctx context.Context, tag *models.Tag) error
+	GetByIdFunc   func(ctx context.Context, id string) (*models.Tag, error)
+	GetByNameFunc func(ctx context.Context, ownerId, name string) (*models.Tag, error)
+	GetAllFunc    func(ctx context.Context, ownerId string) ([]*models.Tag, error)
+	RenameFunc    func(ctx context.Context, id, name string) error
+	DeleteFunc    func(ctx context.Context, id string) error
+	AttachFunc    func(ctx context.Context, taskId, tagId string, record repository.Recorder) error
+	DetachFunc    func(ctx context.Context, taskId, tagId string, record repository.Recorder) error
+}
+
+func (m MockTagRepository) Save(ctx context.Context, tag *models.Tag) error {
+	return m.SaveFunc(ctx, tag)
+}
+
+func (m MockTagRepository) GetById(ctx context.Context, id string) (*models.Tag, error) {
+	return m.GetByIdFunc(ctx, id)
+}
+
+func (m MockTagRepository) GetByName(ctx context.Context, ownerId, name string) (*models.Tag, error) {
+	return m.GetByNameFunc(ctx, ownerId, name)
+}
+
+func (m MockTagRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Tag, error) {
+	return m.GetAllFunc(ctx, ownerId)
+}
+
+func (m MockTagRepository) Rename(ctx context.Context, id, name string) error {
+	return m.RenameFunc(ctx, id, name)
+}
+
+func (m MockTagRepository) Delete(ctx context.Context, id string) error {
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m MockTagRepository) Attach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	return m.AttachFunc(ctx, taskId, tagId, record)
+}
+
+func (m MockTagRepository) Detach(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+	return m.DetachFunc(ctx, taskId, tagId, record)
+}
+
+type MockReminderRepository struct {
+	SaveFunc      func(ctx context.Context, reminder *models.Reminder) error
+	GetByIdFunc   func(ctx context.Context, id string) (*models.Reminder, error)
+	GetByTaskFunc func(ctx context.Context, taskId string) ([]*models.Reminder, error)
+	DeleteFunc    func(ctx context.Context, id string) error
+	ClaimDueFunc  func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error)
+	FinishFunc    func(ctx context.Context, id, status, sentAt string) error
+	RetryFunc     func(ctx context.Context, id, retryAt string) error
+}
+
+func (m MockReminderRepository) Save(ctx context.Context, reminder *models.Reminder) error {
+	return m.SaveFunc(ctx, reminder)
+}
+
+func (m MockReminderRepository) GetById(ctx context.Context, id string) (*models.Reminder, error) {
+	return m.GetByIdFunc(ctx, id)
+}
+
+func (m MockReminderRepository) GetByTask(ctx context.Context, taskId string) ([]*models.Reminder, error) {
+	return m.GetByTaskFunc(ctx, taskId)
+}
+
+func (m MockReminderRepository) Delete(ctx context.Context, id string) error {
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m MockReminderRepository) ClaimDue(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error) {
+	return m.ClaimDueFunc(ctx, now, claimedUntil, limit)
+}
+
+func (m MockReminderRepository) Finish(ctx context.Context, id, status, sentAt string) error {
+	return m.FinishFunc(ctx, id, status, sentAt)
+}
+
+func (m MockReminderRepository) Retry(ctx context.Context, id, retryAt string) error {
+	return m.RetryFunc(ctx, id, retryAt)
+}
+
+type MockWebhookRepository struct {
+	SaveFunc            func(ctx context.Context, webhook *models.Webhook) error
+	GetByIdFunc         func(ctx context.Context, id string) (*models.Webhook, error)
+	GetAllFunc          func(ctx context.Context, ownerId string) ([]*models.Webhook, error)
+	UpdateFunc          func(ctx context.Context, webhook *models.Webhook) error
+	DeleteFunc          func(ctx context.Context, id string) error
+	SaveDeliveryFunc    func(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDeliveriesFunc func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDeliveryFunc  func(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveriesFunc   func(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error)
+}
+
+func (m MockWebhookRepository) Save(ctx context.Context, webhook *models.Webhook) error {
+	return m.SaveFunc(ctx, webhook)
+}
+
+func (m MockWebhookRepository) GetById(ctx context.Context, id string) (*models.Webhook, error) {
+	return m.GetByIdFunc(ctx, id)
+}
+
+func (m MockWebhookRepository) GetAll(ctx context.Context, ownerId string) ([]*models.Webhook, error) {
+	return m.GetAllFunc(ctx, ownerId)
+}
+
+func (m MockWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return m.UpdateFunc(ctx, webhook)
+}
+
+func (m MockWebhookRepository) Delete(ctx context.Context, id string) error {
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m MockWebhookRepository) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return m.SaveDeliveryFunc(ctx, delivery)
+}
+
+func (m MockWebhookRepository) ClaimDeliveries(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+	return m.ClaimDeliveriesFunc(ctx, now, claimedUntil, limit)
+}
+
+func (m MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return m.UpdateDeliveryFunc(ctx, delivery)
+}
+
+func (m MockWebhookRepository) GetDeliveries(ctx context.Context, webhookId string, limit int) ([]*models.WebhookDelivery, error) {
+	return m.GetDeliveriesFunc(ctx, webhookId, limit)
+}
+
+type MockHistoryRepository struct {
+	SaveFunc       func(ctx context.Context, entry *models.HistoryEntry) error
+	GetByTaskFunc  func(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
+	GetByActorFunc func(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
 }
 
-func (m MockTaskRepository) DeleteById(ctx context.Context, id string) error {
-	return m.DeleteByIdFunc(ctx, id)
+func (m MockHistoryRepository) Save(ctx context.Context, entry *models.HistoryEntry) error {
+	return m.SaveFunc(ctx, entry)
 }
 
-func (m MockTaskRepository) ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool) error {
-	return m.ChangeCompletionStatusFunc(ctx, id, completionStatus)
+func (m MockHistoryRepository) GetByTask(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return m.GetByTaskFunc(ctx, query)
 }
 
-func (m MockTaskRepository) UpdateOverdueTasks(ctx context.Context) error {
-	return m.UpdateOverdueTasksFunc(ctx)
+func (m MockHistoryRepository) GetByActor(ctx context.Context, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return m.GetByActorFunc(ctx, query)
 }
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+)
+
+const projectColumns = `id, name, description, archived, created_at, updated_at, owner_id`
+
+type ProjectRepository struct {
+	db *sql.DB
+}
+
+func NewProjectRepository(db *sql.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+func projectFields(project *models.Project) []interface{} {
+	return []interface{}{
+		&project.Id,
+		&project.Name,
+		&project.Description,
+		&project.Archived,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+		&project.OwnerId,
+	}
+}
+
+func (s *ProjectRepository) Save(ctx context.Context, project *models.Project) error {
+	q := `INSERT INTO projects (` + projectColumns + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, q,
+		project.Id,
+		project.Name,
+		project.Description,
+		project.Archived,
+		project.CreatedAt,
+		project.UpdatedAt,
+		project.OwnerId,
+	)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to save project: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ProjectRepository) GetById(ctx context.Context, id string) (*models.Project, error) {
+	q := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	project := &models.Project{}
+
+	err := s.db.QueryRowContext(ctx, q, id).Scan(projectFields(project)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internalErrors.ProjectNotFound
+		}
+
+		logger.ErrorLogger.Printf("failed to fetch project: %v", err)
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *ProjectRepository) GetAll(ctx context.Context, ownerId string, includeArchived bool) ([]*models.Project, error) {
+	q := `SELECT ` + projectColumns + ` FROM projects WHERE owner_id = $1`
+	if !includeArchived {
+		q += ` AND archived = FALSE`
+	}
+	q += ` ORDER BY created_at, rowid`
+
+	rows, err := s.db.QueryContext(ctx, q, ownerId)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch projects: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*models.Project{}
+
+	for rows.Next() {
+		project := &models.Project{}
+		if err := rows.Scan(projectFields(project)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan project: %v", err)
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (s *ProjectRepository) Update(ctx context.Context, project *models.Project) error {
+	q := `UPDATE projects SET name = $1, description = $2, updated_at = $3 WHERE id = $4`
+
+	res, err := s.db.ExecContext(ctx, q, project.Name, project.Description, project.UpdatedAt, project.Id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to update project: %v", err)
+		return err
+	}
+
+	return checkProjectAffected(res)
+}
+
+func (s *ProjectRepository) SetArchived(ctx context.Context, id string, archived bool, updatedAt string) error {
+	q := `UPDATE projects SET archived = $1, updated_at = $2 WHERE id = $3`
+
+	res, err := s.db.ExecContext(ctx, q, archived, updatedAt, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to archive project: %v", err)
+		return err
+	}
+
+	return checkProjectAffected(res)
+}
+
+// Delete moves the tasks of the project to the trash first when cascade is
+// set, and leaves the tasks in the trash without a project, as they cannot
+// be restored into a deleted project. Each of them moves to its next version
+// and is recorded in the transaction that deletes the project.
+func (s *ProjectRepository) Delete(ctx context.Context, id string, cascade bool, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if !cascade {
+		var hasTasks bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM tasks WHERE project_id = $1 AND deleted_at IS NULL)`, id).Scan(&hasTasks)
+		if err != nil {
+			logger.ErrorLogger.Printf("failed to check project tasks: %v", err)
+			return nil, err
+		}
+
+		if hasTasks {
+			return nil, internalErrors.ProjectHasTasks
+		}
+	}
+
+	// The tasks of the project outside the trash come with their subtasks,
+	// also those moved to another project.
+	q := `WITH RECURSIVE project_tasks (id) AS (
+			SELECT id FROM tasks WHERE project_id = $1 AND deleted_at IS NULL
+			UNION
+			SELECT tasks.id FROM tasks JOIN project_tasks ON tasks.parent_id = project_tasks.id
+		) SELECT ` + taskColumns + ` FROM tasks
+		  WHERE (id IN (SELECT id FROM project_tasks) AND deleted_at IS NULL) OR project_id = $1
+		  ORDER BY rowid`
+
+	tasks, err := queryTasks(ctx, tx, q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := []*models.Task{}
+	for _, before := range tasks {
+		after := *before
+		if after.DeletedAt == "" {
+			after.DeletedAt = deletedAt
+			trashed = append(trashed, &after)
+		}
+		if after.ProjectId == id {
+			after.ProjectId = ""
+		}
+		after.Version++
+
+		q := `UPDATE tasks SET deleted_at = $1, project_id = $2, version = version + 1 WHERE id = $3`
+
+		if _, err := tx.ExecContext(ctx, q, after.DeletedAt, nullIfEmpty(after.ProjectId), after.Id); err != nil {
+			logger.ErrorLogger.Printf("failed to detach project task: %v", err)
+			return nil, err
+		}
+
+		if err := recordChange(ctx, tx, record, before, &after); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to delete project: %v", err)
+		return nil, err
+	}
+
+	if err := checkProjectAffected(res); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, s.db, trashed...); err != nil {
+		return nil, err
+	}
+
+	return trashed, nil
+}
+
+func checkProjectAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return internalErrors.ProjectNotFound
+	}
+
+	return nil
+}
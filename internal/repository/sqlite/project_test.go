@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestProjectDelete(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+
+	projects := NewProjectRepository(db)
+	tasks := NewTaskRepository(db)
+
+	for _, id := range []string{"p1", "p2", "p3"} {
+		if err := projects.Save(ctx, &models.Project{Id: id, Name: id, OwnerId: "u1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, task := range []*models.Task{
+		{Id: "1", Title: "in p1", Version: 1, OwnerId: "u1", ProjectId: "p1"},
+		{Id: "2", Title: "in p2", Version: 1, OwnerId: "u1", ProjectId: "p2"},
+		{Id: "3", Title: "no project", Version: 1, OwnerId: "u1"},
+		{Id: "4", Title: "subtask of 1 in p2", Version: 1, OwnerId: "u1", ProjectId: "p2", ParentId: "1"},
+		{Id: "5", Title: "trashed in p3", Version: 1, OwnerId: "u1", ProjectId: "p3", DeletedAt: "2024-11-20T10:00:00Z"},
+	} {
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := tasks.GetAll(ctx, &dtos.GetTasksQuery{OwnerId: "u1", ProjectId: "p1", Sort: dtos.SortByCreated, Order: dtos.OrderAsc, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].Id != "1" {
+		t.Fatalf("expected only the task of p1, got %+v", page.Tasks)
+	}
+
+	if _, err := projects.Delete(ctx, "p1", false, "2024-11-21T10:00:00Z", nil); !errors.Is(err, internalErrors.ProjectHasTasks) {
+		t.Fatalf("expected %v, got %v", internalErrors.ProjectHasTasks, err)
+	}
+
+	deleted, err := projects.Delete(ctx, "p1", true, "2024-11-21T10:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || deleted[0].Id != "1" || deleted[1].Id != "4" {
+		t.Errorf("expected the task of p1 and its subtask to be trashed, got %+v", deleted)
+	}
+	if _, err := tasks.GetById(ctx, "1"); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected task of the deleted project to be in the trash, got %v", err)
+	}
+	if _, err := tasks.GetById(ctx, "4"); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected subtask of a deleted task to be in the trash, got %v", err)
+	}
+
+	if err := tasks.ChangeProject(ctx, "2", "", 1, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projects.Delete(ctx, "p2", false, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatalf("expected project with only trashed tasks to be deleted, got %v", err)
+	}
+
+	if _, err := tasks.GetById(ctx, "2"); err != nil {
+		t.Errorf("expected moved task to survive, got %v", err)
+	}
+	if _, err := projects.Delete(ctx, "p2", false, "2024-11-21T10:00:00Z", nil); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.ProjectNotFound, err)
+	}
+
+	if _, err := projects.Delete(ctx, "p3", false, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatalf("expected project with only trashed tasks to be deleted, got %v", err)
+	}
+	for _, id := range []string{"1", "4", "5"} {
+		task, err := tasks.GetTrashedById(ctx, id)
+		if err != nil {
+			t.Fatalf("expected trashed task %s to stay in the trash, got %v", id, err)
+		}
+		if task.ProjectId != "" {
+			t.Errorf("expected trashed task %s to lose the deleted project, got %+v", id, task)
+		}
+	}
+}
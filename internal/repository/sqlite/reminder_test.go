@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+	reminders := NewReminderRepository(db)
+
+	for _, task := range []*models.Task{
+		{Id: "1", Title: "report", DueDate: "2024-11-22", DueAt: "2024-11-23T00:00:00Z", Version: 1, OwnerId: "u1"},
+		{Id: "2", Title: "call", DueDate: "2024-11-25", DueAt: "2024-11-26T00:00:00Z", Version: 1, OwnerId: "u1"},
+	} {
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, reminder := range []*models.Reminder{
+		{Id: "at", TaskId: "1", RemindAt: "2024-11-22T09:00:00Z", FireAt: "2024-11-22T09:00:00Z"},
+		{Id: "before", TaskId: "1", Before: "1h0m0s", FireAt: "2024-11-22T23:00:00Z"},
+		{Id: "other", TaskId: "2", Before: "24h0m0s", FireAt: "2024-11-25T00:00:00Z"},
+	} {
+		reminder.Status, reminder.CreatedAt, reminder.OwnerId = models.ReminderPending, "2024-11-20T10:00:00Z", "u1"
+		if err := reminders.Save(ctx, reminder); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := reminders.ClaimDue(ctx, "2024-11-22T09:00:00Z", "2024-11-22T09:05:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].Id != "at" || claimed[0].Attempts != 1 {
+		t.Fatalf("expected only the due reminder to be claimed, got %+v", claimed)
+	}
+
+	// A claimed reminder is not handed out again until the claim expires.
+	claimed, err = reminders.ClaimDue(ctx, "2024-11-22T09:01:00Z", "2024-11-22T09:06:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("expected a claimed reminder not to be claimed twice, got %+v", claimed)
+	}
+
+	claimed, err = reminders.ClaimDue(ctx, "2024-11-22T09:05:00Z", "2024-11-22T09:10:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].Attempts != 2 {
+		t.Fatalf("expected an expired claim to be claimed again, got %+v", claimed)
+	}
+
+	if err := reminders.Retry(ctx, "at", "2024-11-22T09:30:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = reminders.ClaimDue(ctx, "2024-11-22T09:20:00Z", "2024-11-22T09:25:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("expected a retried reminder to wait, got %+v", claimed)
+	}
+
+	if err := reminders.Finish(ctx, "at", models.ReminderSent, "2024-11-22T09:30:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err = reminders.ClaimDue(ctx, "2024-11-22T10:00:00Z", "2024-11-22T10:05:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("expected a sent reminder not to be claimed, got %+v", claimed)
+	}
+
+	sent, err := reminders.GetById(ctx, "at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Status != models.ReminderSent || sent.SentAt != "2024-11-22T09:30:00Z" || sent.Before != "" {
+		t.Errorf("expected the reminder to be sent, got %+v", sent)
+	}
+
+	// Moving the due date moves the pending reminders relative to it.
+	version := int64(1)
+	err = tasks.Update(ctx, "1", &dtos.UpdateTaskCommand{DueDate: "2024-11-24", DueAt: "2024-11-25T00:00:00Z", Version: &version}, "2024-11-22T10:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := reminders.GetByTask(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Id != "at" || list[0].FireAt != "2024-11-22T09:00:00Z" {
+		t.Fatalf("expected the sent reminder to keep its time, got %+v", list)
+	}
+	if list[1].Id != "before" || list[1].Before != "1h0m0s" || list[1].FireAt != "2024-11-24T23:00:00Z" {
+		t.Errorf("expected the relative reminder to follow the due date, got %+v", list[1])
+	}
+
+	if _, err := tasks.DeleteById(ctx, "2", 1, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reminders.GetById(ctx, "other"); err != nil {
+		t.Errorf("expected reminders of a trashed task to be kept, got %v", err)
+	}
+	if err := tasks.Purge(ctx, "2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reminders.GetById(ctx, "other"); !errors.Is(err, internalErrors.ReminderNotFound) {
+		t.Errorf("expected reminders of a purged task to be deleted, got %v", err)
+	}
+
+	if err := reminders.Delete(ctx, "before"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reminders.Delete(ctx, "before"); !errors.Is(err, internalErrors.ReminderNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.ReminderNotFound, err)
+	}
+}
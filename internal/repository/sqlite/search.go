@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"strings"
+	"unicode"
+)
+
+const (
+	// bm25 weights: a match in the title counts ten times more than one in the description.
+	searchRank     = `bm25(tasks_fts, 10.0, 1.0)`
+	highlightStart = `<mark>`
+	highlightEnd   = `</mark>`
+)
+
+// InitSearch enables full-text search when the migrations have created the
+// index, which they do only if the linked SQLite supports FTS5.
+func (s *TaskRepository) InitSearch(ctx context.Context) error {
+	available, err := fts5Available(ctx, s.db)
+	if err != nil || !available {
+		return err
+	}
+
+	var existing int
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'tasks_fts'`).Scan(&existing)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to inspect search index: %v", err)
+		return err
+	}
+
+	s.searchEnabled = existing == 1
+	return nil
+}
+
+// buildMatchQuery turns user input into an FTS5 query. Double-quoted parts are
+// phrases, words ending with * are prefixes and everything else is a plain
+// term; all parts must match. Every part is quoted so FTS5 operators and
+// column filters typed by the user are treated as text.
+func buildMatchQuery(input string) string {
+	var parts []string
+
+	addPart := func(text string, prefix bool) {
+		text = strings.TrimSpace(text)
+		if strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return
+		}
+
+		part := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		if prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+
+	for input != "" {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		if input == "" {
+			break
+		}
+
+		if input[0] == '"' {
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				addPart(input[1:], false)
+				break
+			}
+			addPart(input[1:end+1], false)
+			input = input[end+2:]
+			continue
+		}
+
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		if end < 0 {
+			end = len(input)
+		}
+		word := input[:end]
+		input = input[end:]
+
+		prefix := strings.HasSuffix(word, "*")
+		addPart(strings.TrimRight(word, "*"), prefix)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (s *TaskRepository) Search(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	if !s.searchEnabled {
+		return nil, internalErrors.SearchUnavailable
+	}
+
+	match := buildMatchQuery(query.Query)
+	if match == "" {
+		return &dtos.SearchPage{}, nil
+	}
+
+	keys := []sortKey{{searchRank, false}, {"tasks.search_id", false}}
+
+	q := `SELECT ` + prefixColumns("tasks", taskColumns) + `,
+			  highlight(tasks_fts, 0, '` + highlightStart + `', '` + highlightEnd + `'),
+			  snippet(tasks_fts, 1, '` + highlightStart + `', '` + highlightEnd + `', '…', 12),
+			  ` + searchRank + `, tasks.search_id
+		  FROM tasks_fts
+		  JOIN tasks ON tasks.search_id = tasks_fts.rowid
+		  WHERE tasks_fts MATCH $1 AND tasks.owner_id = $2 AND tasks.deleted_at IS NULL`
+	args := params{match, query.OwnerId}
+
+	// The cursor is bound to the match expression instead of a sort order.
+	if query.Cursor != "" {
+		values, err := repository.DecodeCursor(query.Cursor, "search", match, len(keys))
+		if err != nil {
+			return nil, err
+		}
+
+		q += " AND " + keysetCondition(keys, values, &args)
+	}
+
+	q += ` ORDER BY ` + searchRank + `, tasks.search_id LIMIT ` + args.add(query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to search tasks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &dtos.SearchPage{}
+	var lastValues []interface{}
+
+	for rows.Next() {
+		result := &dtos.SearchResult{Task: &models.Task{}}
+		var searchId int64
+
+		dest := append(taskFields(result.Task), &result.TitleHighlight, &result.Snippet, &result.Rank, &searchId)
+		if err := rows.Scan(dest...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan search result: %v", err)
+			return nil, err
+		}
+
+		if len(page.Results) == query.Limit {
+			page.NextCursor = repository.EncodeCursor("search", match, lastValues)
+			break
+		}
+
+		page.Results = append(page.Results, result)
+		lastValues = []interface{}{result.Rank, searchId}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	// The loop may stop before reading all rows, release the connection
+	// before loading tags and blockers.
+	rows.Close()
+
+	tasks := make([]*models.Task, len(page.Results))
+	for i, result := range page.Results {
+		tasks[i] = result.Task
+	}
+	if err := loadRelations(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func prefixColumns(table, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, part := range parts {
+		parts[i] = table + "." + part
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/migrations"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "terms", input: "buy milk", expected: `"buy" "milk"`},
+		{name: "phrase", input: `"buy milk" bread`, expected: `"buy milk" "bread"`},
+		{name: "prefix", input: "mil* bread", expected: `"mil"* "bread"`},
+		{name: "unterminated phrase", input: `"buy milk`, expected: `"buy milk"`},
+		{name: "operators are text", input: `title:milk OR NEAR(a)`, expected: `"title:milk" "OR" "NEAR(a)"`},
+		{name: "quotes are escaped", input: `a"b`, expected: `"a""b"`},
+		{name: "punctuation only", input: `* - ""`, expected: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildMatchQuery(tt.input); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+
+	repo := NewTaskRepository(db)
+	if err := repo.InitSearch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.searchEnabled {
+		t.Skip("SQLite is built without FTS5, run with -tags sqlite_fts5")
+	}
+
+	tasks := []*models.Task{
+		{Id: "1", Title: "Buy milk", Description: "and bread from the corner store", Version: 1},
+		{Id: "2", Title: "Repair car", Description: "buy oil and milk filters", Version: 1},
+		{Id: "3", Title: "Call mom", Description: "", Version: 1},
+	}
+	for _, task := range tasks {
+		if err := repo.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	version := int64(1)
+	err := repo.Update(ctx, "3", &dtos.UpdateTaskCommand{Description: "ask about milk", Version: &version}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: "milk", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 2 || page.Results[0].Task.Id != "1" {
+		t.Fatalf("expected title match to rank first, got %+v", page.Results)
+	}
+	if page.Results[0].TitleHighlight != "Buy <mark>milk</mark>" {
+		t.Errorf("unexpected highlight %q", page.Results[0].TitleHighlight)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected next cursor")
+	}
+
+	next, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: "milk", Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Results) != 1 || next.NextCursor != "" {
+		t.Fatalf("expected last result on second page, got %+v", next.Results)
+	}
+
+	phrase, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: `"corner store"`, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(phrase.Results) != 1 || phrase.Results[0].Task.Id != "1" {
+		t.Errorf("expected phrase to match task 1, got %+v", phrase.Results)
+	}
+
+	prefix, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: "rep*", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefix.Results) != 1 || prefix.Results[0].Task.Id != "2" {
+		t.Errorf("expected prefix to match task 2, got %+v", prefix.Results)
+	}
+
+	if _, err := repo.DeleteById(ctx, "1", 1, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: "corner", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted.Results) != 0 {
+		t.Errorf("expected trashed task to be left out, got %+v", deleted.Results)
+	}
+
+	if err := repo.Purge(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	var indexed int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tasks_fts WHERE tasks_fts MATCH 'corner'`).Scan(&indexed); err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 0 {
+		t.Errorf("expected purged task to leave the index, got %d rows", indexed)
+	}
+}
+
+func TestSearchIndexMigration(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := NewDB("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if available, err := fts5Available(ctx, db); err != nil || !available {
+		t.Skip("SQLite is built without FTS5, run with -tags sqlite_fts5")
+	}
+
+	// A database migrated by a build without FTS5 has tasks but no index.
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator.Disable(searchIndexMigration, "no FTS5")
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewTaskRepository(db)
+	for _, task := range []*models.Task{
+		{Id: "b", Title: "Buy milk", Version: 1},
+		{Id: "a", Title: "Call mom", Version: 1},
+		{Id: "c", Title: "Repair car", Version: 1},
+	} {
+		if err := repo.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrator, err = NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.InitSearch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once indexed, the database is refused by a build without FTS5.
+	refused, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused.Disable(searchIndexMigration, "no FTS5")
+	if err := refused.Up(ctx); !errors.Is(err, migrations.DisabledMigration) {
+		t.Errorf("expected %v, got %v", migrations.DisabledMigration, err)
+	}
+
+	// VACUUM may renumber the implicit rowids, the index must not care.
+	if _, err := repo.DeleteById(ctx, "b", 1, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Purge(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		t.Fatal(err)
+	}
+
+	for query, id := range map[string]string{"mom": "a", "car": "c"} {
+		page, err := repo.Search(ctx, &dtos.SearchTasksQuery{Query: query, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Results) != 1 || page.Results[0].Task.Id != id {
+			t.Errorf("expected %q to find task %s, got %+v", query, id, page.Results)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"testing"
+)
+
+func TestSubtasks(t *testing.T) {
+	ctx := context.Background()
+
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+
+	for _, task := range []*models.Task{
+		{Id: "1", Title: "parent", Version: 1, OwnerId: "u1"},
+		{Id: "2", Title: "child", Version: 1, OwnerId: "u1", ParentId: "1"},
+		{Id: "3", Title: "done child", Version: 1, OwnerId: "u1", ParentId: "1", Completed: true, CompletedAt: "2024-11-20T10:00:00Z"},
+		{Id: "4", Title: "grandchild", Version: 1, OwnerId: "u1", ParentId: "2"},
+		{Id: "5", Title: "other", Version: 1, OwnerId: "u1"},
+	} {
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := tasks.GetAll(ctx, &dtos.GetTasksQuery{OwnerId: "u1", ParentId: "1", Sort: dtos.SortByCreated, Order: dtos.OrderAsc, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 2 || page.Tasks[0].Id != "2" || page.Tasks[0].ParentId != "1" {
+		t.Fatalf("expected direct subtasks of 1, got %+v", page.Tasks)
+	}
+
+	counts, err := tasks.GetSubtaskCounts(ctx, []string{"1", "2", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["1"] != (dtos.SubtaskCounts{Total: 2, Completed: 1}) || counts["2"] != (dtos.SubtaskCounts{Total: 1}) {
+		t.Errorf("unexpected subtask counts %+v", counts)
+	}
+	if _, ok := counts["5"]; ok {
+		t.Errorf("expected no counts for a task without subtasks, got %+v", counts["5"])
+	}
+
+	byIds, err := tasks.GetByIds(ctx, []string{"4", "1", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byIds) != 2 {
+		t.Errorf("expected the two existing tasks, got %+v", byIds)
+	}
+
+	children, err := tasks.GetByParentIds(ctx, []string{"1", "2", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 3 || children[0].Id != "2" || children[1].Id != "3" || children[2].Id != "4" {
+		t.Errorf("expected direct subtasks of 1 and 2 in creation order, got %+v", children)
+	}
+
+	changed, err := tasks.ChangeCompletionStatus(ctx, "1", true, 1, "2024-11-22T10:30:00Z", "2024-11-22T10:30:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || changed[0].Id != "2" || changed[1].Id != "4" {
+		t.Errorf("expected the open subtasks to be completed, got %+v", changed)
+	}
+	grandchild, err := tasks.GetById(ctx, "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grandchild.Completed || grandchild.Version != 2 {
+		t.Errorf("expected nested subtask to be completed, got %+v", grandchild)
+	}
+	doneChild, err := tasks.GetById(ctx, "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doneChild.CompletedAt != "2024-11-20T10:00:00Z" || doneChild.Version != 1 {
+		t.Errorf("expected completed subtask to stay untouched, got %+v", doneChild)
+	}
+
+	if _, err := tasks.DeleteById(ctx, "1", 1, "2024-11-21T10:00:00Z", nil); !errors.Is(err, internalErrors.VersionConflict) {
+		t.Fatalf("expected %v, got %v", internalErrors.VersionConflict, err)
+	}
+	if _, err := tasks.GetById(ctx, "4"); err != nil {
+		t.Errorf("expected subtasks to survive a failed delete, got %v", err)
+	}
+
+	if _, err := tasks.DeleteById(ctx, "1", 2, "2024-11-21T10:00:00Z", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"2", "3", "4"} {
+		if _, err := tasks.GetById(ctx, id); !errors.Is(err, internalErrors.TaskNotFound) {
+			t.Errorf("expected subtask %s to be deleted, got %v", id, err)
+		}
+	}
+	if _, err := tasks.GetById(ctx, "5"); err != nil {
+		t.Errorf("expected unrelated task to survive, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestTagFilters(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+	tags := NewTagRepository(db)
+
+	for _, task := range []*models.Task{
+		{Id: "1", Title: "bug at home", Version: 1, OwnerId: "u1"},
+		{Id: "2", Title: "bug", Version: 1, OwnerId: "u1"},
+		{Id: "3", Title: "untagged", Version: 1, OwnerId: "u1"},
+	} {
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tag := range []*models.Tag{{Id: "bug", Name: "bug", OwnerId: "u1"}, {Id: "home", Name: "home", OwnerId: "u1"}} {
+		if err := tags.Save(ctx, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pair := range [][2]string{{"1", "bug"}, {"1", "home"}, {"2", "bug"}, {"2", "bug"}} {
+		if err := tags.Attach(ctx, pair[0], pair[1], nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		mode     string
+		expected []string
+	}{
+		{name: "any", tags: []string{"home", "bug"}, mode: dtos.TagModeAny, expected: []string{"1", "2"}},
+		{name: "all", tags: []string{"home", "bug"}, mode: dtos.TagModeAll, expected: []string{"1"}},
+		{name: "all with duplicates", tags: []string{"bug", "BUG"}, mode: dtos.TagModeAll, expected: []string{"1", "2"}},
+		{name: "unknown tag", tags: []string{"q3"}, mode: dtos.TagModeAny, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tasks.GetAll(ctx, &dtos.GetTasksQuery{
+				OwnerId: "u1",
+				Tags:    tt.tags,
+				TagMode: tt.mode,
+				Sort:    dtos.SortByCreated,
+				Order:   dtos.OrderAsc,
+				Limit:   10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, task := range page.Tasks {
+				ids = append(ids, task.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	task, err := tasks.GetById(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(task.Tags, []string{"bug", "home"}) {
+		t.Errorf("expected tags [bug home], got %v", task.Tags)
+	}
+
+	if err := tags.Delete(ctx, "home"); err != nil {
+		t.Fatal(err)
+	}
+	task, err = tasks.GetById(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(task.Tags, []string{"bug"}) {
+		t.Errorf("expected deleted tag to be detached, got %v", task.Tags)
+	}
+}
@@ -8,46 +8,87 @@ import (
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
 	"github.com/DanKo-code/TODO-list/pkg/logger"
 	"strings"
+	"time"
 )
 
+const taskColumns = `id, title, description, due_date, overdue, completed, version, created_at, updated_at, completed_at, owner_id, project_id, priority, parent_id, recurrence, series_id, occurrence, all_day, due_at, deleted_at`
+
 type TaskRepository struct {
-	db *sql.DB
+	db            *sql.DB
+	searchEnabled bool
 }
 
-func NewTaskRepository(driver string, dsn string) (*TaskRepository, error) {
-
-	db, err := sql.Open(driver, dsn)
-	if err != nil {
-		logger.ErrorLogger.Printf("Failed to connect database: %v", err)
-		return nil, err
-	}
+// nullableString scans NULL into an empty string.
+type nullableString struct {
+	s *string
+}
 
-	err = db.Ping()
-	if err != nil {
-		logger.ErrorLogger.Printf("Failed to verify database: %v", err)
-		return nil, err
+func (n nullableString) Scan(value interface{}) error {
+	ns := sql.NullString{}
+	if err := ns.Scan(value); err != nil {
+		return err
 	}
+	*n.s = ns.String
+	return nil
+}
 
-	logger.InfoLogger.Println("Database connected")
+// priorityField scans a priority level into its name.
+type priorityField struct {
+	s *string
+}
 
-	return &TaskRepository{db: db}, nil
+func (p priorityField) Scan(value interface{}) error {
+	level := sql.NullInt64{}
+	if err := level.Scan(value); err != nil {
+		return err
+	}
+	*p.s = models.PriorityName(int(level.Int64))
+	return nil
 }
 
-func (s *TaskRepository) Init(ctx context.Context) error {
-	q := `CREATE TABLE IF NOT EXISTS tasks 
-			(id uuid, title TEXT, description TEXT, due_date TEXT, overdue INTEGER, completed INTEGER)`
+func priorityLevel(name string) int {
+	level, _ := models.PriorityLevel(name)
+	return level
+}
 
-	_, err := s.db.ExecContext(ctx, q)
-	if err != nil {
-		logger.ErrorLogger.Printf("Failed to init db: %v", err)
-		return err
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
 	}
+	return s
+}
 
-	logger.InfoLogger.Println("Database initialized")
+// taskFields returns scan destinations in the order of taskColumns.
+func taskFields(task *models.Task) []interface{} {
+	return []interface{}{
+		&task.Id,
+		&task.Title,
+		&task.Description,
+		&task.DueDate,
+		&task.Overdue,
+		&task.Completed,
+		&task.Version,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		nullableString{&task.CompletedAt},
+		&task.OwnerId,
+		nullableString{&task.ProjectId},
+		priorityField{&task.Priority},
+		nullableString{&task.ParentId},
+		&task.Recurrence,
+		nullableString{&task.SeriesId},
+		&task.Occurrence,
+		&task.AllDay,
+		nullableString{&task.DueAt},
+		nullableString{&task.DeletedAt},
+	}
+}
 
-	return nil
+func NewTaskRepository(db *sql.DB) *TaskRepository {
+	return &TaskRepository{db: db}
 }
 
 func (s *TaskRepository) Close() {
@@ -58,53 +99,90 @@ func (s *TaskRepository) Close() {
 	}
 }
 
-func (s *TaskRepository) Save(ctx context.Context, task *models.Task) error {
-	q := `INSERT INTO tasks (id, title, description, due_date, overdue, completed)
-			VALUES ($1, $2, $3, $4, $5, $6);`
+func (s *TaskRepository) Save(ctx context.Context, task *models.Task, record repository.Recorder) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `INSERT INTO tasks (` + taskColumns + `, search_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+				(SELECT COALESCE(MAX(search_id), 0) + 1 FROM tasks));`
 
-	_, err := s.db.ExecContext(ctx, q,
+	_, err = tx.ExecContext(ctx, q,
 		task.Id,
 		task.Title,
 		task.Description,
 		task.DueDate,
 		task.Overdue,
 		task.Completed,
+		task.Version,
+		task.CreatedAt,
+		task.UpdatedAt,
+		nullIfEmpty(task.CompletedAt),
+		task.OwnerId,
+		nullIfEmpty(task.ProjectId),
+		priorityLevel(task.Priority),
+		nullIfEmpty(task.ParentId),
+		task.Recurrence,
+		nullIfEmpty(task.SeriesId),
+		task.Occurrence,
+		task.AllDay,
+		nullIfEmpty(task.DueAt),
+		nullIfEmpty(task.DeletedAt),
 	)
 	if err != nil {
 		logger.ErrorLogger.Printf("failed to save task: %v", err)
 		return err
 	}
-	return nil
+
+	if err := recordChange(ctx, tx, record, nil, task); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *TaskRepository) GetAll(ctx context.Context) ([]*models.Task, error) {
-	q := `SELECT id, title, description, due_date, overdue, completed FROM tasks`
+func (s *TaskRepository) GetAll(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	q, args, keys, err := buildGetAllQuery(query)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := s.db.QueryContext(ctx, q)
+	rows, err := s.db.QueryContext(ctx, q, args...)
 	if err != nil {
 		logger.ErrorLogger.Printf("failed to fetch tasks: %v", err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var tasks []*models.Task
+	page := &dtos.TaskPage{}
+	var lastValues []interface{}
 
 	for rows.Next() {
 		task := &models.Task{}
+		values := make([]interface{}, len(keys))
 
-		err := rows.Scan(
-			&task.Id,
-			&task.Title,
-			&task.Description,
-			&task.DueDate,
-			&task.Overdue,
-			&task.Completed,
-		)
+		dest := taskFields(task)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
 			logger.ErrorLogger.Printf("failed to scan task: %v", err)
 			return nil, err
 		}
 
-		tasks = append(tasks, task)
+		if len(page.Tasks) == query.Limit {
+			page.NextCursor = repository.EncodeCursor(query.Sort, query.Order, lastValues)
+			break
+		}
+
+		page.Tasks = append(page.Tasks, task)
+		lastValues = values
 	}
 
 	if err = rows.Err(); err != nil {
@@ -112,25 +190,94 @@ func (s *TaskRepository) GetAll(ctx context.Context) ([]*models.Task, error) {
 		return nil, err
 	}
 
-	return tasks, nil
+	// The loop may stop before reading all rows, release the connection
+	// before loading tags and blockers.
+	rows.Close()
+
+	if err := loadRelations(ctx, s.db, page.Tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// loadRelations fills the data of the given tasks that lives outside the
+// tasks table, reading it with db, which may be a transaction.
+func loadRelations(ctx context.Context, db queryer, tasks ...*models.Task) error {
+	if err := loadTags(ctx, db, tasks...); err != nil {
+		return err
+	}
+
+	return loadBlockers(ctx, db, tasks...)
+}
+
+// loadTags fills Tags of the given tasks with one query.
+func loadTags(ctx context.Context, db queryer, tasks ...*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	byId := make(map[string]*models.Task, len(tasks))
+	ids := make([]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		byId[task.Id] = task
+		ids = append(ids, task.Id)
+	}
+
+	var args params
+	q := `SELECT task_tags.task_id, tags.name
+		  FROM task_tags
+		  JOIN tags ON tags.id = task_tags.tag_id
+		  WHERE task_tags.task_id IN (` + args.addList(ids) + `)
+		  ORDER BY tags.name`
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch task tags: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId, name string
+		if err := rows.Scan(&taskId, &name); err != nil {
+			logger.ErrorLogger.Printf("failed to scan task tag: %v", err)
+			return err
+		}
+
+		byId[taskId].Tags = append(byId[taskId].Tags, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return err
+	}
+
+	return nil
 }
 
 func (s *TaskRepository) GetById(ctx context.Context, id string) (*models.Task, error) {
-	q := `SELECT id, title, description, due_date, overdue, completed
+	q := `SELECT ` + taskColumns + `
 		  FROM tasks
-		  WHERE id = $1`
+		  WHERE id = $1 AND deleted_at IS NULL`
+
+	return s.getOne(ctx, q, id)
+}
 
+func (s *TaskRepository) GetTrashedById(ctx context.Context, id string) (*models.Task, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	return s.getOne(ctx, q, id)
+}
+
+// getOne runs the query for the task with the id bound to $1.
+func (s *TaskRepository) getOne(ctx context.Context, q string, id string) (*models.Task, error) {
 	task := &models.Task{}
 	row := s.db.QueryRowContext(ctx, q, id)
 
-	err := row.Scan(
-		&task.Id,
-		&task.Title,
-		&task.Description,
-		&task.DueDate,
-		&task.Overdue,
-		&task.Completed,
-	)
+	err := row.Scan(taskFields(task)...)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -142,28 +289,131 @@ func (s *TaskRepository) GetById(ctx context.Context, id string) (*models.Task,
 		return nil, err
 	}
 
+	if err := loadRelations(ctx, s.db, task); err != nil {
+		return nil, err
+	}
+
 	return task, nil
 }
 
-func (s *TaskRepository) Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error {
-	q := `UPDATE tasks SET `
-	var args []interface{}
+// GetByIds fetches the tasks with one query, e.g. the parents of a page of
+// tasks.
+func (s *TaskRepository) GetByIds(ctx context.Context, ids []string) ([]*models.Task, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE id IN (%s) AND deleted_at IS NULL`
+
+	return s.getIn(ctx, q, ids)
+}
+
+func (s *TaskRepository) GetByParentIds(ctx context.Context, parentIds []string) ([]*models.Task, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE parent_id IN (%s) AND deleted_at IS NULL
+		  ORDER BY created_at, rowid`
+
+	return s.getIn(ctx, q, parentIds)
+}
+
+// getIn runs the query with the values in place of the %s of its IN clause.
+func (s *TaskRepository) getIn(ctx context.Context, q string, values []string) ([]*models.Task, error) {
+	tasks := []*models.Task{}
+	if len(values) == 0 {
+		return tasks, nil
+	}
+
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+
+	var args params
+	q = fmt.Sprintf(q, args.addList(list))
+
+	return s.getMany(ctx, q, args...)
+}
+
+func (s *TaskRepository) getMany(ctx context.Context, q string, args ...interface{}) ([]*models.Task, error) {
+	tasks, err := queryTasks(ctx, s.db, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, s.db, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// queryer runs queries on a *sql.DB or within a *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryTasks runs a query selecting taskColumns without loading relations,
+// so it can run within a transaction.
+func queryTasks(ctx context.Context, db queryer, q string, args ...interface{}) ([]*models.Task, error) {
+	tasks := []*models.Task{}
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch tasks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task := &models.Task{}
+		if err := rows.Scan(taskFields(task)...); err != nil {
+			logger.ErrorLogger.Printf("failed to scan task: %v", err)
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Update applies the command only if the stored version still equals
+// updateTaskCommand.Version and bumps the version on success.
+func (s *TaskRepository) Update(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error {
+	var args params
 	var setClauses []string
 
 	if updateTaskCommand.Title != "" {
-		setClauses = append(setClauses, "title = ?")
-		args = append(args, updateTaskCommand.Title)
+		setClauses = append(setClauses, "title = "+args.add(updateTaskCommand.Title))
 	}
 	if updateTaskCommand.Description != "" {
-		setClauses = append(setClauses, "description = ?")
-		args = append(args, updateTaskCommand.Description)
+		setClauses = append(setClauses, "description = "+args.add(updateTaskCommand.Description))
 	}
 	if updateTaskCommand.DueDate != "" {
-		setClauses = append(setClauses, "due_date = ?")
-		args = append(args, updateTaskCommand.DueDate)
+		setClauses = append(setClauses,
+			"due_date = "+args.add(updateTaskCommand.DueDate),
+			"due_at = "+args.add(nullIfEmpty(updateTaskCommand.DueAt)),
+			"all_day = "+args.add(updateTaskCommand.AllDay == nil || *updateTaskCommand.AllDay),
+			"overdue = FALSE",
+		)
+	}
 
-		setClauses = append(setClauses, "overdue = ?")
-		args = append(args, false)
+	if updateTaskCommand.Priority != "" {
+		setClauses = append(setClauses, "priority = "+args.add(priorityLevel(updateTaskCommand.Priority)))
+	}
+
+	// An empty rule stops the series, a task that starts recurring becomes the
+	// first occurrence of its own series.
+	if updateTaskCommand.Recurrence != nil {
+		setClauses = append(setClauses, "recurrence = "+args.add(*updateTaskCommand.Recurrence))
+
+		if *updateTaskCommand.Recurrence != "" {
+			setClauses = append(setClauses, "series_id = COALESCE(series_id, id)", "occurrence = MAX(occurrence, 1)")
+		}
 	}
 
 	if len(setClauses) == 0 {
@@ -171,52 +421,607 @@ func (s *TaskRepository) Update(ctx context.Context, id string, updateTaskComman
 		return fmt.Errorf("no fields to update")
 	}
 
-	q += strings.Join(setClauses, ", ")
-	q += " WHERE id = ?"
-	args = append(args, id)
+	setClauses = append(setClauses, "updated_at = "+args.add(updatedAt), "version = version + 1")
 
-	_, err := s.db.ExecContext(ctx, q, args...)
+	q := `UPDATE tasks SET ` + strings.Join(setClauses, ", ") + ` WHERE id = ` + args.add(id)
+
+	return changeTask(ctx, s.db, id, updateTaskCommand.Version, record, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			logger.ErrorLogger.Printf("failed to update task: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// withDescendants prefixes a statement with the ids of all subtasks of the
+// task bound to $1, at any depth. It has to come first so $1 is the first
+// parameter SQLite sees.
+const withDescendants = `WITH RECURSIVE descendants (id) AS (
+		SELECT id FROM tasks WHERE parent_id = $1
+		UNION ALL
+		SELECT tasks.id FROM tasks JOIN descendants ON tasks.parent_id = descendants.id
+	) `
+
+// DeleteById stamps the task and its subtasks with deletedAt. Subtasks that
+// are already in the trash keep their own time, so restoring the task leaves
+// them there.
+func (s *TaskRepository) DeleteById(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		logger.ErrorLogger.Printf("failed to update task: %v", err)
-		return err
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	return nil
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND version = $2 AND deleted_at IS NULL`
+
+	tasks, err := queryTasks(ctx, tx, q, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		// Keep the subtasks and report why the task was not deleted.
+		tx.Rollback()
+		return nil, s.casError(ctx, id)
+	}
+
+	q = withDescendants + `SELECT ` + taskColumns + ` FROM tasks
+		  WHERE id IN (SELECT id FROM descendants) AND deleted_at IS NULL
+		  ORDER BY rowid`
+
+	subtasks, err := queryTasks(ctx, tx, q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := trash(ctx, tx, append(tasks, subtasks...), deletedAt, record)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, s.db, trashed...); err != nil {
+		return nil, err
+	}
+
+	return trashed, nil
+}
+
+// trash moves the tasks to the trash within tx, records each of them and
+// returns them as trashed.
+func trash(ctx context.Context, tx *sql.Tx, tasks []*models.Task, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+	trashed := []*models.Task{}
+	for _, before := range tasks {
+		q := `UPDATE tasks SET deleted_at = $1, version = version + 1 WHERE id = $2`
+
+		if _, err := tx.ExecContext(ctx, q, deletedAt, before.Id); err != nil {
+			logger.ErrorLogger.Printf("failed to delete task: %v", err)
+			return nil, err
+		}
+
+		after := *before
+		after.DeletedAt = deletedAt
+		after.Version++
+
+		if err := recordChange(ctx, tx, record, before, &after); err != nil {
+			return nil, err
+		}
+
+		trashed = append(trashed, &after)
+	}
+
+	return trashed, nil
+}
+
+func (s *TaskRepository) GetTrash(ctx context.Context, ownerId string) ([]*models.Task, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE owner_id = $1 AND deleted_at IS NOT NULL AND NOT EXISTS (
+			  SELECT 1 FROM tasks AS parents WHERE parents.id = tasks.parent_id AND parents.deleted_at = tasks.deleted_at
+		  )
+		  ORDER BY deleted_at DESC, rowid DESC`
+
+	return s.getMany(ctx, q, ownerId)
+}
+
+func (s *TaskRepository) Restore(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The subtasks deleted with the task share its deleted_at, and were
+	// created after it.
+	q := withDescendants + `SELECT ` + taskColumns + ` FROM tasks
+		  WHERE (id = $1 OR id IN (SELECT id FROM descendants))
+			  AND deleted_at = (SELECT deleted_at FROM tasks WHERE id = $1)
+		  ORDER BY rowid`
+
+	tasks, err := queryTasks(ctx, tx, q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	restored := []*models.Task{}
+	for _, before := range tasks {
+		q := `UPDATE tasks SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2`
+
+		if _, err := tx.ExecContext(ctx, q, updatedAt, before.Id); err != nil {
+			logger.ErrorLogger.Printf("failed to restore task: %v", err)
+			return nil, err
+		}
+
+		after := *before
+		after.DeletedAt = ""
+		after.UpdatedAt = updatedAt
+		after.Version++
+
+		if err := recordChange(ctx, tx, record, before, &after); err != nil {
+			return nil, err
+		}
+
+		restored = append(restored, &after)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, s.db, restored...); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
 }
 
-func (s *TaskRepository) DeleteById(ctx context.Context, id string) error {
-	q := `DELETE FROM tasks WHERE id = $1`
+func (s *TaskRepository) Purge(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
 
-	_, err := s.db.ExecContext(ctx, q, id)
+	_, err = tx.ExecContext(ctx, withDescendants+`DELETE FROM tasks WHERE id IN (SELECT id FROM descendants)`, id)
 	if err != nil {
-		logger.ErrorLogger.Printf("failed to delete task: %v", err)
+		logger.ErrorLogger.Printf("failed to purge subtasks: %v", err)
 		return err
 	}
 
-	return nil
+	res, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to purge task: %v", err)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	// The subtasks are kept when the task is not in the trash.
+	if affected == 0 {
+		return internalErrors.TaskNotFound
+	}
+
+	return tx.Commit()
+}
+
+// PurgeDeletedBefore relies on subtasks never being trashed later than their
+// parent, so a purged task takes all its subtasks with it.
+func (s *TaskRepository) PurgeDeletedBefore(ctx context.Context, before string) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at < $1`, before)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to purge trash: %v", err)
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func (s *TaskRepository) LastOccurrence(ctx context.Context, seriesId string) (int, error) {
+	var last int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(occurrence), 0) FROM tasks WHERE series_id = $1`, seriesId).Scan(&last)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to fetch last occurrence: %v", err)
+		return 0, err
+	}
+
+	return last, nil
+}
+
+func (s *TaskRepository) GetSubtaskCounts(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error) {
+	counts := make(map[string]dtos.SubtaskCounts)
+	if len(parentIds) == 0 {
+		return counts, nil
+	}
+
+	ids := make([]interface{}, len(parentIds))
+	for i, id := range parentIds {
+		ids[i] = id
+	}
+
+	var args params
+	q := `SELECT parent_id, COUNT(*), COALESCE(SUM(completed), 0)
+		  FROM tasks
+		  WHERE parent_id IN (` + args.addList(ids) + `) AND deleted_at IS NULL
+		  GROUP BY parent_id`
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to count subtasks: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentId string
+		var c dtos.SubtaskCounts
+		if err := rows.Scan(&parentId, &c.Total, &c.Completed); err != nil {
+			logger.ErrorLogger.Printf("failed to scan subtask counts: %v", err)
+			return nil, err
+		}
+
+		counts[parentId] = c
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.ErrorLogger.Printf("rows iteration error: %v", err)
+		return nil, err
+	}
+
+	return counts, nil
 }
 
-func (s *TaskRepository) ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool) error {
-	q := `UPDATE tasks SET completed = $1 WHERE id = $2`
+// withAncestors prefixes a statement with the ids of the parent of the task
+// bound to $1, its parent and so on.
+const withAncestors = `WITH RECURSIVE ancestors (id) AS (
+		SELECT parent_id FROM tasks WHERE id = $1 AND parent_id IS NOT NULL
+		UNION ALL
+		SELECT tasks.parent_id FROM tasks JOIN ancestors ON tasks.id = ancestors.id WHERE tasks.parent_id IS NOT NULL
+	) `
+
+// ChangeCompletionStatus stores completedAt as NULL when it is empty. The
+// tasks the change reaches are read and written in the transaction of the
+// version check, so they cannot drift from the task.
+func (s *TaskRepository) ChangeCompletionStatus(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND version = $2 AND deleted_at IS NULL`
+
+	tasks, err := queryTasks(ctx, tx, q, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		tx.Rollback()
+		return nil, s.casError(ctx, id)
+	}
+
+	// A completed task has no open subtasks, a reopened one no completed
+	// ancestors. Parents were created before their subtasks, so they come
+	// first.
+	q = withDescendants + `SELECT ` + taskColumns + ` FROM tasks
+		  WHERE id IN (SELECT id FROM descendants) AND completed = FALSE AND deleted_at IS NULL
+		  ORDER BY rowid`
+	if !completionStatus {
+		q = withAncestors + `SELECT ` + taskColumns + ` FROM tasks
+			  WHERE id IN (SELECT id FROM ancestors) AND completed = TRUE
+			  ORDER BY rowid`
+	}
 
-	_, err := s.db.ExecContext(ctx, q, completionStatus, id)
+	others, err := queryTasks(ctx, tx, q, id)
 	if err != nil {
-		logger.ErrorLogger.Printf("failed to change completion status: %v", err)
+		return nil, err
+	}
+
+	changed := []*models.Task{}
+	for _, before := range append(tasks, others...) {
+		q := `UPDATE tasks
+			  SET completed = $1, completed_at = $2, updated_at = $3, version = version + 1
+			  WHERE id = $4`
+
+		if _, err := tx.ExecContext(ctx, q, completionStatus, nullIfEmpty(completedAt), updatedAt, before.Id); err != nil {
+			logger.ErrorLogger.Printf("failed to change completion status: %v", err)
+			return nil, err
+		}
+
+		after := *before
+		after.Completed = completionStatus
+		after.CompletedAt = completedAt
+		after.UpdatedAt = updatedAt
+		after.Version++
+
+		if err := recordChange(ctx, tx, record, before, &after); err != nil {
+			return nil, err
+		}
+
+		if before.Id != id {
+			changed = append(changed, &after)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, s.db, changed...); err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// ChangeProject moves the task into projectId, or out of any project when
+// projectId is empty.
+func (s *TaskRepository) ChangeProject(ctx context.Context, id string, projectId string, version int64, updatedAt string, record repository.Recorder) error {
+	q := `UPDATE tasks SET project_id = $1, updated_at = $2, version = version + 1 WHERE id = $3`
+
+	return changeTask(ctx, s.db, id, &version, record, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, q, nullIfEmpty(projectId), updatedAt, id); err != nil {
+			logger.ErrorLogger.Printf("failed to change task project: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// revertAssignments returns the SET clauses and arguments for the fields of
+// the command.
+func revertAssignments(cmd *dtos.RevertTaskCommand, args *params) []string {
+	task := cmd.Task
+	var setClauses []string
+
+	for _, field := range cmd.Fields {
+		switch field {
+		case "title":
+			setClauses = append(setClauses, "title = "+args.add(task.Title))
+		case "description":
+			setClauses = append(setClauses, "description = "+args.add(task.Description))
+		case "due_date":
+			setClauses = append(setClauses,
+				"due_date = "+args.add(task.DueDate),
+				"due_at = "+args.add(nullIfEmpty(task.DueAt)),
+				"all_day = "+args.add(task.AllDay),
+				"overdue = "+args.add(task.Overdue),
+			)
+		case "completed":
+			setClauses = append(setClauses,
+				"completed = "+args.add(task.Completed),
+				"completed_at = "+args.add(nullIfEmpty(task.CompletedAt)),
+			)
+		case "priority":
+			setClauses = append(setClauses, "priority = "+args.add(priorityLevel(task.Priority)))
+		case "project_id":
+			setClauses = append(setClauses, "project_id = "+args.add(nullIfEmpty(task.ProjectId)))
+		case "recurrence":
+			setClauses = append(setClauses, "recurrence = "+args.add(task.Recurrence))
+
+			if task.Recurrence != "" {
+				setClauses = append(setClauses, "series_id = COALESCE(series_id, id)", "occurrence = MAX(occurrence, 1)")
+			}
+		case "deleted_at":
+			setClauses = append(setClauses, "deleted_at = "+args.add(nullIfEmpty(task.DeletedAt)))
+		}
+	}
+
+	return setClauses
+}
+
+// Revert checks every version before it writes anything, as reverting a task
+// can move subtasks that come later in cmds.
+func (s *TaskRepository) Revert(ctx context.Context, cmds []*dtos.RevertTaskCommand, updatedAt string, entries []*models.HistoryEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, cmd := range cmds {
+		var version int64
+
+		err := tx.QueryRowContext(ctx, `SELECT version FROM tasks WHERE id = $1`, cmd.Task.Id).Scan(&version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return internalErrors.TaskNotFound
+			}
+
+			logger.ErrorLogger.Printf("failed to fetch task: %v", err)
+			return err
+		}
+
+		if version != cmd.Task.Version {
+			logger.ErrorLogger.Printf("version conflict on task %s", cmd.Task.Id)
+			return internalErrors.VersionConflict
+		}
+	}
+
+	for _, cmd := range cmds {
+		var args params
+		setClauses := revertAssignments(cmd, &args)
+		setClauses = append(setClauses, "updated_at = "+args.add(updatedAt), "version = version + 1")
+
+		q := `UPDATE tasks SET ` + strings.Join(setClauses, ", ") + ` WHERE id = ` + args.add(cmd.Task.Id)
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			logger.ErrorLogger.Printf("failed to revert task: %v", err)
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if err := saveEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// bumpVersion moves the task to its next version when the statement that
+// changed one of its tags or blockers touched a row, so the version covers
+// them too.
+func bumpVersion(ctx context.Context, tx *sql.Tx, res sql.Result, taskId string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to read affected rows: %v", err)
+		return err
+	}
+
+	if affected == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET version = version + 1 WHERE id = $1`, taskId); err != nil {
+		logger.ErrorLogger.Printf("failed to bump version of task %s: %v", taskId, err)
 		return err
 	}
 
 	return nil
 }
 
-func (s *TaskRepository) UpdateOverdueTasks(ctx context.Context) error {
-	q := `UPDATE tasks 
-		  SET overdue = TRUE 
-		  WHERE due_date <= DATE('now') AND overdue = FALSE`
+// getForChange reads the task outside the trash within tx, together with its
+// relations, as a change finds or leaves it.
+func getForChange(ctx context.Context, tx *sql.Tx, id string) (*models.Task, error) {
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND deleted_at IS NULL`
 
-	_, err := s.db.ExecContext(ctx, q)
+	tasks, err := queryTasks(ctx, tx, q, id)
 	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	if err := loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return tasks[0], nil
+}
+
+// changeTask runs write in a transaction if the task still has the version,
+// when one is given, and saves what record makes of the change in the same
+// transaction. A write that leaves the version as it was changed nothing and
+// is not recorded.
+func changeTask(ctx context.Context, db *sql.DB, id string, version *int64, record repository.Recorder, write func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	before, err := getForChange(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if version != nil && before.Version != *version {
+		logger.ErrorLogger.Printf("version conflict on task %s", id)
+		return internalErrors.VersionConflict
+	}
+
+	if err := write(tx); err != nil {
+		return err
+	}
+
+	after, err := getForChange(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if after.Version == before.Version {
+		return tx.Commit()
+	}
+
+	if err := recordChange(ctx, tx, record, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// casError tells why a compare-and-swap on the task failed: TaskNotFound or
+// VersionConflict depending on whether the task still exists.
+func (s *TaskRepository) casError(ctx context.Context, id string) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to check task existence: %v", err)
+		return err
+	}
+
+	if !exists {
+		return internalErrors.TaskNotFound
+	}
+
+	logger.ErrorLogger.Printf("version conflict on task %s", id)
+	return internalErrors.VersionConflict
+}
+
+// UpdateOverdueTasks flags the tasks whose due time has passed by now and
+// returns the tasks it flagged, each at its new version.
+func (s *TaskRepository) UpdateOverdueTasks(ctx context.Context, now time.Time, record repository.Recorder) ([]*models.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.ErrorLogger.Printf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE tasks
+		  SET overdue = TRUE, version = version + 1
+		  WHERE due_at <= $1 AND overdue = FALSE AND deleted_at IS NULL
+		  RETURNING ` + taskColumns
+
+	tasks, err := queryTasks(ctx, tx, q, now.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadRelations(ctx, tx, tasks...); err != nil {
+		return nil, err
+	}
+
+	for _, after := range tasks {
+		before := *after
+		before.Overdue = false
+		before.Version--
+
+		if err := recordChange(ctx, tx, record, &before, after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
 }
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSmartOrdering(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+
+	for _, task := range []*models.Task{
+		{Id: "done", DueDate: "2024-01-01", Completed: true, Priority: models.PriorityUrgent},
+		{Id: "low-soon", DueDate: "2024-11-20", Priority: models.PriorityLow},
+		{Id: "high-late", DueDate: "2024-12-31", Priority: models.PriorityHigh},
+		{Id: "high-soon", DueDate: "2024-11-25", Priority: models.PriorityHigh},
+		{Id: "overdue", DueDate: "2024-10-01", Overdue: true, Priority: models.PriorityNone},
+	} {
+		task.Title, task.Version, task.OwnerId = task.Id, 1, "u1"
+		task.DueAt = task.DueDate + "T23:59:59Z"
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	query := &dtos.GetTasksQuery{OwnerId: "u1", Sort: dtos.SortBySmart, Order: dtos.OrderAsc, Limit: 2}
+	for {
+		page, err := tasks.GetAll(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range page.Tasks {
+			ids = append(ids, task.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	expected := []string{"overdue", "high-soon", "high-late", "low-soon", "done"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	page, err := tasks.GetAll(ctx, &dtos.GetTasksQuery{
+		OwnerId:    "u1",
+		Priorities: []string{models.PriorityHigh, models.PriorityUrgent},
+		Sort:       dtos.SortByPriority,
+		Order:      dtos.OrderDesc,
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 3 || page.Tasks[0].Priority != models.PriorityUrgent {
+		t.Errorf("expected urgent and high tasks with urgent first, got %+v", page.Tasks)
+	}
+}
+
+func TestUpdateOverdueTasks(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	tasks := NewTaskRepository(db)
+
+	current := time.Now().UTC()
+	for _, task := range []*models.Task{
+		// Due today in UTC, but the day is already over in the user's zone.
+		{Id: "day-over", DueDate: current.Format("2006-01-02"), AllDay: true, DueAt: current.Add(-time.Minute).Format(time.RFC3339)},
+		{Id: "time-passed", DueDate: current.Add(-time.Hour).Format(time.RFC3339), DueAt: current.Add(-time.Hour).Format(time.RFC3339)},
+		{Id: "time-ahead", DueDate: current.Add(time.Hour).Format(time.RFC3339), DueAt: current.Add(time.Hour).Format(time.RFC3339)},
+		{Id: "day-ahead", DueDate: current.Format("2006-01-02"), AllDay: true, DueAt: current.Add(time.Hour).Format(time.RFC3339)},
+	} {
+		task.Title, task.Version, task.OwnerId = task.Id, 1, "u1"
+		if err := tasks.Save(ctx, task, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flagged, err := tasks.UpdateOverdueTasks(ctx, current, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flagged) != 2 || flagged[0].Id != "day-over" || flagged[1].Id != "time-passed" || !flagged[0].Overdue {
+		t.Errorf("expected the flagged tasks to be returned, got %+v", flagged)
+	}
+
+	expected := map[string]bool{"day-over": true, "time-passed": true, "time-ahead": false, "day-ahead": false}
+	for id, overdue := range expected {
+		task, err := tasks.GetById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Overdue != overdue {
+			t.Errorf("expected %s to have overdue %v, got %v", id, overdue, task.Overdue)
+		}
+		if task.AllDay != (id == "day-over" || id == "day-ahead") {
+			t.Errorf("expected %s to keep all_day, got %v", id, task.AllDay)
+		}
+	}
+}
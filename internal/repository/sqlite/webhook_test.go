@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+
+	webhooks := NewWebhookRepository(newTestDB(t))
+
+	for _, webhook := range []*models.Webhook{
+		{Id: "w1", URL: "https://example.com/hook", Secret: "0123456789abcdef", Events: []string{"task.created", "task.deleted"}, OwnerId: "u1"},
+		{Id: "w2", URL: "https://example.com/all", Secret: "0123456789abcdef", Events: []string{}, OwnerId: "u1"},
+		{Id: "w3", URL: "https://example.org/hook", Secret: "0123456789abcdef", Events: []string{}, OwnerId: "u2"},
+	} {
+		webhook.CreatedAt, webhook.UpdatedAt = "2024-11-20T10:00:00Z", "2024-11-20T10:00:00Z"
+		if err := webhooks.Save(ctx, webhook); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	own, err := webhooks.GetAll(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(own) != 2 || own[0].Id != "w1" || own[1].Id != "w2" {
+		t.Fatalf("expected the webhooks of the owner, got %+v", own)
+	}
+	if !reflect.DeepEqual(own[0].Events, []string{"task.created", "task.deleted"}) || len(own[1].Events) != 0 {
+		t.Errorf("expected the event filters to round-trip, got %v and %v", own[0].Events, own[1].Events)
+	}
+
+	own[0].URL, own[0].Events = "https://example.com/moved", []string{"task.overdue"}
+	if err := webhooks.Update(ctx, own[0]); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := webhooks.GetById(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.URL != "https://example.com/moved" || !reflect.DeepEqual(updated.Events, []string{"task.overdue"}) {
+		t.Errorf("expected the webhook to be updated, got %+v", updated)
+	}
+
+	for _, delivery := range []*models.WebhookDelivery{
+		{Id: "d1", WebhookId: "w1", CreatedAt: "2024-11-22T09:00:00Z", NextAttemptAt: "2024-11-22T09:00:00Z"},
+		{Id: "d2", WebhookId: "w1", CreatedAt: "2024-11-22T09:01:00Z", NextAttemptAt: "2024-11-22T09:30:00Z"},
+		{Id: "d3", WebhookId: "w2", CreatedAt: "2024-11-22T09:02:00Z", NextAttemptAt: "2024-11-22T09:00:00Z"},
+	} {
+		delivery.EventId, delivery.EventType, delivery.Status = "e-"+delivery.Id, "task.created", models.DeliveryPending
+		delivery.Payload = []byte(`{"type":"task.created"}`)
+		if err := webhooks.SaveDelivery(ctx, delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := webhooks.ClaimDeliveries(ctx, "2024-11-22T09:10:00Z", "2024-11-22T09:15:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].Attempts != 1 || string(claimed[0].Payload) != `{"type":"task.created"}` {
+		t.Fatalf("expected the due deliveries to be claimed, got %+v", claimed)
+	}
+
+	// A claimed delivery is not handed out again until the claim expires.
+	claimed, err = webhooks.ClaimDeliveries(ctx, "2024-11-22T09:11:00Z", "2024-11-22T09:16:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("expected claimed deliveries not to be claimed twice, got %+v", claimed)
+	}
+
+	failed := &models.WebhookDelivery{Id: "d1", Status: models.DeliveryPending, NextAttemptAt: "2024-11-22T10:00:00Z", LastStatusCode: 500, LastError: "webhook responded with status 500"}
+	if err := webhooks.UpdateDelivery(ctx, failed); err != nil {
+		t.Fatal(err)
+	}
+	delivered := &models.WebhookDelivery{Id: "d3", Status: models.DeliveryDelivered, NextAttemptAt: "2024-11-22T09:00:00Z", LastStatusCode: 204, DeliveredAt: "2024-11-22T09:10:01Z"}
+	if err := webhooks.UpdateDelivery(ctx, delivered); err != nil {
+		t.Fatal(err)
+	}
+
+	// The retried delivery waits for its next attempt, the delivered one is done.
+	claimed, err = webhooks.ClaimDeliveries(ctx, "2024-11-22T09:40:00Z", "2024-11-22T09:45:00Z", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].Id != "d2" {
+		t.Errorf("expected only the delivery that fell due to be claimed, got %+v", claimed)
+	}
+
+	log, err := webhooks.GetDeliveries(ctx, "w1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].Id != "d2" || log[1].Id != "d1" {
+		t.Fatalf("expected the deliveries of the webhook newest first, got %+v", log)
+	}
+	if log[1].LastStatusCode != 500 || log[1].LastError == "" || log[1].Attempts != 1 {
+		t.Errorf("expected the failed attempt to be recorded, got %+v", log[1])
+	}
+
+	if err := webhooks.Delete(ctx, "w1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webhooks.GetById(ctx, "w1"); !errors.Is(err, internalErrors.WebhookNotFound) {
+		t.Errorf("expected the webhook to be deleted, got %v", err)
+	}
+	log, err = webhooks.GetDeliveries(ctx, "w1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 0 {
+		t.Errorf("expected the deliveries to be deleted with the webhook, got %+v", log)
+	}
+
+	if err := webhooks.Delete(ctx, "w1"); !errors.Is(err, internalErrors.WebhookNotFound) {
+		t.Errorf("expected deleting a missing webhook to fail, got %v", err)
+	}
+}
@@ -3,59 +3,188 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/DanKo-code/TODO-list/internal/background"
+	"github.com/DanKo-code/TODO-list/internal/background/reminder_background"
 	"github.com/DanKo-code/TODO-list/internal/background/task_background"
+	"github.com/DanKo-code/TODO-list/internal/background/trash_background"
+	"github.com/DanKo-code/TODO-list/internal/background/webhook_background"
+	graphqlDelivery "github.com/DanKo-code/TODO-list/internal/delivery/graphql"
+	grpcDelivery "github.com/DanKo-code/TODO-list/internal/delivery/grpc"
 	"github.com/DanKo-code/TODO-list/internal/delivery/rest"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/notifier"
 	"github.com/DanKo-code/TODO-list/internal/repository"
-	sqliteRep "github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"github.com/DanKo-code/TODO-list/internal/usecase/auth_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/project_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/reminder_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/tag_usecase"
 	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"github.com/DanKo-code/TODO-list/internal/usecase/webhook_usecase"
 	"github.com/DanKo-code/TODO-list/pkg/logger"
-	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 )
 
 var (
-	interval = 1 * time.Minute / 3
+	interval         = 1 * time.Minute / 3
+	reminderInterval = 10 * time.Second
+	webhookInterval  = 5 * time.Second
+	trashInterval    = 1 * time.Hour
+	defaultTokenTTL  = 24 * time.Hour
+	// defaultTrashRetention is how long deleted tasks stay in the trash.
+	defaultTrashRetention = 30 * 24 * time.Hour
 )
 
+const (
+	// eventHistorySize is how many events are kept for streams that resume.
+	eventHistorySize = 1000
+	// eventBufferSize is how many events a stream may fall behind before it
+	// is dropped; the client then reconnects and resumes.
+	eventBufferSize = 64
+)
+
+// tokenTTL reads the lifetime of auth tokens from AUTH_TOKEN_TTL, e.g. "12h".
+func tokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("AUTH_TOKEN_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultTokenTTL
+	}
+
+	return ttl
+}
+
+// trashRetention reads how long deleted tasks can be restored from
+// TRASH_RETENTION, e.g. "168h".
+func trashRetention() time.Duration {
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil || retention <= 0 {
+		return defaultTrashRetention
+	}
+
+	return retention
+}
+
+// subtaskMaxDepth reads how deep subtasks can be nested from SUBTASK_MAX_DEPTH.
+func subtaskMaxDepth() int {
+	depth, err := strconv.Atoi(os.Getenv("SUBTASK_MAX_DEPTH"))
+	if err != nil || depth < 1 {
+		return task_usecase.DefaultMaxSubtaskDepth
+	}
+
+	return depth
+}
+
+// newNotifier builds the notifier for reminders selected by NOTIFIER: "log"
+// (the default), "webhook" posting to NOTIFIER_WEBHOOK_URL, or "smtp" sending
+// through SMTP_ADDR from SMTP_FROM, authenticated with SMTP_USERNAME and
+// SMTP_PASSWORD if set.
+func newNotifier() (notifier.Notifier, error) {
+	switch kind := os.Getenv("NOTIFIER"); kind {
+	case "", "log":
+		return notifier.NewLogNotifier(), nil
+	case "webhook":
+		url := os.Getenv("NOTIFIER_WEBHOOK_URL")
+		if url == "" {
+			return nil, errors.New("NOTIFIER_WEBHOOK_URL is required for the webhook notifier")
+		}
+		return notifier.NewWebhookNotifier(url), nil
+	case "smtp":
+		if os.Getenv("SMTP_ADDR") == "" || os.Getenv("SMTP_FROM") == "" {
+			return nil, errors.New("SMTP_ADDR and SMTP_FROM are required for the smtp notifier")
+		}
+		return notifier.NewSMTPNotifier(os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
 type App struct {
 	server *http.Server
-	tRep   repository.TaskRepository
-	tc     background.TaskChecker
+	// grpcServer serves the task service on grpcAddress; it is nil when no
+	// address is configured.
+	grpcServer  *grpc.Server
+	grpcAddress string
+	tRep        repository.TaskRepository
+	tc          background.TaskChecker
+	rs          background.ReminderScheduler
+	wd          background.WebhookDispatcher
+	tp          background.TrashPurger
 }
 
-func NewApp(appAddress, driver, dsn string) (*App, error) {
-	tRep, err := sqliteRep.NewTaskRepository(driver, dsn)
+func NewApp(appAddress, grpcAddress, driver, dsn string) (*App, error) {
+	stores, err := newStorage(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	err = tRep.Init(context.TODO())
+	tRep := stores.tasks
+	uRep := stores.users
+	pRep := stores.projects
+	tagRep := stores.tags
+	reminderRep := stores.reminders
+	webhookRep := stores.webhooks
+
+	reminderNotifier, err := newNotifier()
 	if err != nil {
 		return nil, err
 	}
 
-	taskUseCase := task_usecase.NewTaskUseCase(tRep)
+	taskUseCase := task_usecase.NewTaskUseCase(tRep, pRep, tagRep)
+	taskUseCase.SetMaxSubtaskDepth(subtaskMaxDepth())
+	taskUseCase.SetHistory(stores.history)
+	webhookUseCase := webhook_usecase.NewWebhookUseCase(webhookRep)
+	bus := events.NewBus(eventHistorySize, eventBufferSize)
+	taskUseCase.SetPublisher(events.Fanout(webhookUseCase, bus))
+	authUseCase := auth_usecase.NewAuthUseCase(uRep, tokenTTL())
+	projectUseCase := project_usecase.NewProjectUseCase(pRep, taskUseCase)
+	tagUseCase := tag_usecase.NewTagUseCase(tagRep)
+	reminderUseCase := reminder_usecase.NewReminderUseCase(reminderRep, tRep, uRep, reminderNotifier)
 
 	handlers := rest.NewHandlers(taskUseCase)
+	authHandlers := rest.NewAuthHandlers(authUseCase)
+	projectHandlers := rest.NewProjectHandlers(projectUseCase)
+	tagHandlers := rest.NewTagHandlers(tagUseCase)
+	reminderHandlers := rest.NewReminderHandlers(reminderUseCase)
+	webhookHandlers := rest.NewWebhookHandlers(webhookUseCase)
+	eventHandlers := rest.NewEventHandlers(bus)
+	socketHandlers := rest.NewSocketHandlers(taskUseCase, bus)
+	graphqlHandler := graphqlDelivery.NewHandler(taskUseCase, projectUseCase)
 
-	router := rest.NewRouter(handlers)
+	router := rest.NewRouter(handlers, authHandlers, projectHandlers, tagHandlers, reminderHandlers, webhookHandlers, eventHandlers, socketHandlers, graphqlHandler)
 
 	server := &http.Server{
 		Addr:    appAddress,
-		Handler: router,
+		Handler: rest.AuthMiddleware(authUseCase, router),
+	}
+	// Shutdown waits for open requests, so event streams are ended first.
+	server.RegisterOnShutdown(bus.Close)
+
+	var grpcServer *grpc.Server
+	if grpcAddress != "" {
+		grpcServer = grpcDelivery.NewGRPCServer(authUseCase, grpcDelivery.NewServer(taskUseCase, bus))
 	}
 
 	tc := task_background.NewTaskChecker(taskUseCase)
+	rs := reminder_background.NewReminderScheduler(reminderUseCase)
+	wd := webhook_background.NewWebhookDispatcher(webhookUseCase)
+	tp := trash_background.NewTrashPurger(taskUseCase)
 
 	return &App{
-		server: server,
-		tRep:   tRep,
-		tc:     tc,
+		server:      server,
+		grpcServer:  grpcServer,
+		grpcAddress: grpcAddress,
+		tRep:        tRep,
+		tc:          tc,
+		rs:          rs,
+		wd:          wd,
+		tp:          tp,
 	}, nil
 }
 
@@ -79,11 +208,51 @@ func (a *App) Run() error {
 
 	logger.InfoLogger.Printf("Server started on address %s", a.server.Addr)
 
+	if a.grpcServer != nil {
+		listener, err := net.Listen("tcp", a.grpcAddress)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := a.grpcServer.Serve(listener); err != nil {
+				logger.FatalLogger.Fatalf("Failed to serve gRPC: %v", err)
+			}
+		}()
+
+		logger.InfoLogger.Printf("gRPC server started on address %s", a.grpcAddress)
+	}
+
 	go a.tc.StartOverdueStatusChecker(context.TODO(), interval, stopChecker)
+	go a.rs.StartReminderScheduler(context.TODO(), reminderInterval, stopChecker)
+	go a.wd.StartWebhookDispatcher(context.TODO(), webhookInterval, stopChecker)
+	go a.tp.StartTrashPurger(context.TODO(), trashInterval, trashRetention(), stopChecker)
 
 	<-quit
 
 	close(stopChecker)
 
-	return a.server.Shutdown(ctx)
+	err := a.server.Shutdown(ctx)
+
+	if a.grpcServer != nil {
+		a.stopGRPC(ctx)
+	}
+
+	return err
+}
+
+// stopGRPC waits for open calls to finish and cancels the rest once ctx is
+// done. Watch streams end earlier, when Shutdown closes the event bus.
+func (a *App) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.grpcServer.Stop()
+	}
 }
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	UnknownMigrateCommand = errors.New("usage: migrate [-dry-run] up | down [steps] | status")
+)
+
+// Migrate runs the migrate subcommand: up applies pending migrations, down
+// reverts the given number of migrations (1 by default) and status prints
+// which migrations are applied.
+func Migrate(driver, dsn string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dryRun := flags.Bool("dry-run", false, "print migrations without applying them")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	command := flags.Arg(0)
+	if command != "up" && command != "down" && command != "status" {
+		return UnknownMigrateCommand
+	}
+
+	db, migrator, err := openDB(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator.SetDryRun(*dryRun)
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if flags.NArg() > 1 {
+			steps, err = strconv.Atoi(flags.Arg(1))
+			if err != nil || steps < 1 {
+				return UnknownMigrateCommand
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied at " + status.AppliedAt
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return UnknownMigrateCommand
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/migrations"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	memoryRep "github.com/DanKo-code/TODO-list/internal/repository/memory"
+	postgresRep "github.com/DanKo-code/TODO-list/internal/repository/postgres"
+	sqliteRep "github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+)
+
+// Values of DB_DRIVER.
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+	// DriverMemory keeps everything in memory until the app stops.
+	DriverMemory = "memory"
+)
+
+var (
+	UnknownDriver = errors.New("DB_DRIVER must be sqlite3, postgres or memory")
+	NoMigrations  = errors.New("the memory driver has no schema to migrate")
+)
+
+// storage holds the repositories the app runs on; they share one database.
+type storage struct {
+	tasks     repository.TaskRepository
+	users     repository.UserRepository
+	projects  repository.ProjectRepository
+	tags      repository.TagRepository
+	reminders repository.ReminderRepository
+	webhooks  repository.WebhookRepository
+	history   repository.HistoryRepository
+}
+
+// openDB connects to the database of the driver and returns the migrator
+// of its schema.
+func openDB(driver, dsn string) (*sql.DB, *migrations.Migrator, error) {
+	var db *sql.DB
+	var err error
+	newMigrator := sqliteRep.NewMigrator
+
+	switch driver {
+	case DriverSQLite:
+		db, err = sqliteRep.NewDB(driver, dsn)
+	case DriverPostgres:
+		db, err = postgresRep.NewDB(dsn)
+		newMigrator = postgresRep.NewMigrator
+	case DriverMemory:
+		return nil, nil, NoMigrations
+	default:
+		return nil, nil, fmt.Errorf("%w, got %q", UnknownDriver, driver)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, migrator, nil
+}
+
+// newStorage opens the database selected by driver, migrates it and builds
+// the repositories on it. The memory driver ignores dsn.
+func newStorage(driver, dsn string) (*storage, error) {
+	if driver == DriverMemory {
+		store := memoryRep.NewStore()
+
+		return &storage{
+			tasks:     memoryRep.NewTaskRepository(store),
+			users:     memoryRep.NewUserRepository(store),
+			projects:  memoryRep.NewProjectRepository(store),
+			tags:      memoryRep.NewTagRepository(store),
+			reminders: memoryRep.NewReminderRepository(store),
+			webhooks:  memoryRep.NewWebhookRepository(store),
+			history:   memoryRep.NewHistoryRepository(store),
+		}, nil
+	}
+
+	db, migrator, err := openDB(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrator.Up(context.TODO()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if driver == DriverPostgres {
+		return &storage{
+			tasks:     postgresRep.NewTaskRepository(db),
+			users:     postgresRep.NewUserRepository(db),
+			projects:  postgresRep.NewProjectRepository(db),
+			tags:      postgresRep.NewTagRepository(db),
+			reminders: postgresRep.NewReminderRepository(db),
+			webhooks:  postgresRep.NewWebhookRepository(db),
+			history:   postgresRep.NewHistoryRepository(db),
+		}, nil
+	}
+
+	tasks := sqliteRep.NewTaskRepository(db)
+	if err := tasks.InitSearch(context.TODO()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &storage{
+		tasks:     tasks,
+		users:     sqliteRep.NewUserRepository(db),
+		projects:  sqliteRep.NewProjectRepository(db),
+		tags:      sqliteRep.NewTagRepository(db),
+		reminders: sqliteRep.NewReminderRepository(db),
+		webhooks:  sqliteRep.NewWebhookRepository(db),
+		history:   sqliteRep.NewHistoryRepository(db),
+	}, nil
+}
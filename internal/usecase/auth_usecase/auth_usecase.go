@@ -0,0 +1,156 @@
+package auth_usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+// dummyHash is compared against when the user does not exist so that a login
+// with an unknown username takes as long as one with a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type AuthUseCase struct {
+	userRep  repository.UserRepository
+	tokenTTL time.Duration
+}
+
+func NewAuthUseCase(userRep repository.UserRepository, tokenTTL time.Duration) *AuthUseCase {
+	return &AuthUseCase{
+		userRep:  userRep,
+		tokenTTL: tokenTTL,
+	}
+}
+
+// hashToken returns the form in which tokens are stored, so a leaked database
+// does not contain usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (auc *AuthUseCase) Register(ctx context.Context, cmd *dtos.RegisterCommand) (*models.User, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(cmd.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, _ := helper.GenerateUUID()
+
+	if cmd.TimeZone == "" {
+		cmd.TimeZone = "UTC"
+	}
+
+	user := &models.User{
+		Id:           userId,
+		Username:     cmd.Username,
+		PasswordHash: string(passwordHash),
+		CreatedAt:    clock.Now().Format(time.RFC3339),
+		TimeZone:     cmd.TimeZone,
+	}
+
+	err = auc.userRep.Save(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (auc *AuthUseCase) Login(ctx context.Context, cmd *dtos.LoginCommand) (*dtos.AuthToken, error) {
+	user, err := auc.userRep.GetByUsername(ctx, cmd.Username)
+	if err != nil && !errors.Is(err, internalErrors.UserNotFound) {
+		return nil, err
+	}
+
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(cmd.Password))
+		return nil, internalErrors.InvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(cmd.Password)) != nil {
+		return nil, internalErrors.InvalidCredentials
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	createdAt := clock.Now()
+	expiresAt := createdAt.Add(auc.tokenTTL)
+
+	// Logins are rare enough to clean up expired tokens along the way.
+	err = auc.userRep.DeleteExpiredTokens(ctx, createdAt.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	err = auc.userRep.SaveToken(ctx, hashToken(token), user.Id, createdAt.Format(time.RFC3339), expiresAt.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.AuthToken{
+		Token:     token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+		User:      user,
+	}, nil
+}
+
+func (auc *AuthUseCase) Logout(ctx context.Context, token string) error {
+	return auc.userRep.DeleteToken(ctx, hashToken(token))
+}
+
+func (auc *AuthUseCase) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, internalErrors.Unauthorized
+	}
+
+	user, err := auc.userRep.GetUserByToken(ctx, hashToken(token), clock.Now().Format(time.RFC3339))
+	if err != nil {
+		if errors.Is(err, internalErrors.UserNotFound) {
+			return nil, internalErrors.Unauthorized
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (auc *AuthUseCase) GetUser(ctx context.Context, id string) (*models.User, error) {
+	return auc.userRep.GetById(ctx, id)
+}
+
+func (auc *AuthUseCase) UpdateUser(ctx context.Context, id string, cmd *dtos.UpdateUserCommand) (*models.User, error) {
+	user, err := auc.userRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.TimeZone != "" {
+		user.TimeZone = cmd.TimeZone
+	}
+	if cmd.Email != nil {
+		user.Email = *cmd.Email
+	}
+
+	err = auc.userRep.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
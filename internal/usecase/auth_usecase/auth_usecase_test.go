@@ -0,0 +1,97 @@
+package auth_usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+	"time"
+)
+
+func TestLoginUseCase(t *testing.T) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("correct password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		username    string
+		password    string
+		expectedErr error
+	}{
+		{name: "success", username: "alice", password: "correct password"},
+		{name: "wrong password", username: "alice", password: "wrong password", expectedErr: internalErrors.InvalidCredentials},
+		{name: "unknown user", username: "bob", password: "correct password", expectedErr: internalErrors.InvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var savedTokenHash string
+
+			mockRepository := &sqlite.MockUserRepository{
+				GetByUsernameFunc: func(ctx context.Context, username string) (*models.User, error) {
+					if username != "alice" {
+						return nil, internalErrors.UserNotFound
+					}
+					return &models.User{Id: "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90", Username: "alice", PasswordHash: string(passwordHash)}, nil
+				},
+				DeleteExpiredTokensFunc: func(ctx context.Context, now string) error {
+					return nil
+				},
+				SaveTokenFunc: func(ctx context.Context, tokenHash, userId, createdAt, expiresAt string) error {
+					savedTokenHash = tokenHash
+					return nil
+				},
+			}
+
+			auc := NewAuthUseCase(mockRepository, time.Hour)
+
+			token, err := auc.Login(ctx, &dtos.LoginCommand{Username: tt.username, Password: tt.password})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if token.Token == "" || savedTokenHash == token.Token {
+				t.Errorf("expected only the token hash to be stored, got token %q and hash %q", token.Token, savedTokenHash)
+			}
+			if savedTokenHash != hashToken(token.Token) {
+				t.Errorf("expected stored hash %q, got %q", hashToken(token.Token), savedTokenHash)
+			}
+		})
+	}
+}
+
+func TestAuthenticateUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	mockRepository := &sqlite.MockUserRepository{
+		GetUserByTokenFunc: func(ctx context.Context, tokenHash, now string) (*models.User, error) {
+			if tokenHash != hashToken("valid") {
+				return nil, internalErrors.UserNotFound
+			}
+			return &models.User{Id: "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"}, nil
+		},
+	}
+
+	auc := NewAuthUseCase(mockRepository, time.Hour)
+
+	if _, err := auc.Authenticate(ctx, "valid"); err != nil {
+		t.Errorf("expected valid token to authenticate, got %v", err)
+	}
+	if _, err := auc.Authenticate(ctx, "expired"); !errors.Is(err, internalErrors.Unauthorized) {
+		t.Errorf("expected %v, got %v", internalErrors.Unauthorized, err)
+	}
+	if _, err := auc.Authenticate(ctx, ""); !errors.Is(err, internalErrors.Unauthorized) {
+		t.Errorf("expected %v, got %v", internalErrors.Unauthorized, err)
+	}
+}
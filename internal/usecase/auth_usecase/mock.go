@@ -0,0 +1,40 @@
+package auth_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+type MockAuthUseCase struct {
+	RegisterFunc     func(ctx context.Context, cmd *dtos.RegisterCommand) (*models.User, error)
+	LoginFunc        func(ctx context.Context, cmd *dtos.LoginCommand) (*dtos.AuthToken, error)
+	LogoutFunc       func(ctx context.Context, token string) error
+	AuthenticateFunc func(ctx context.Context, token string) (*models.User, error)
+	GetUserFunc      func(ctx context.Context, id string) (*models.User, error)
+	UpdateUserFunc   func(ctx context.Context, id string, cmd *dtos.UpdateUserCommand) (*models.User, error)
+}
+
+func (m *MockAuthUseCase) Register(ctx context.Context, cmd *dtos.RegisterCommand) (*models.User, error) {
+	return m.RegisterFunc(ctx, cmd)
+}
+
+func (m *MockAuthUseCase) Login(ctx context.Context, cmd *dtos.LoginCommand) (*dtos.AuthToken, error) {
+	return m.LoginFunc(ctx, cmd)
+}
+
+func (m *MockAuthUseCase) Logout(ctx context.Context, token string) error {
+	return m.LogoutFunc(ctx, token)
+}
+
+func (m *MockAuthUseCase) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	return m.AuthenticateFunc(ctx, token)
+}
+
+func (m *MockAuthUseCase) GetUser(ctx context.Context, id string) (*models.User, error) {
+	return m.GetUserFunc(ctx, id)
+}
+
+func (m *MockAuthUseCase) UpdateUser(ctx context.Context, id string, cmd *dtos.UpdateUserCommand) (*models.User, error) {
+	return m.UpdateUserFunc(ctx, id, cmd)
+}
@@ -0,0 +1,45 @@
+package project_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+type MockProjectUseCase struct {
+	CreateProjectFunc   func(ctx context.Context, cmd *dtos.CreateProjectCommand) (*models.Project, error)
+	GetProjectFunc      func(ctx context.Context, id string) (*models.Project, error)
+	GetProjectsFunc     func(ctx context.Context, includeArchived bool) ([]*models.Project, error)
+	GetProjectTasksFunc func(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	UpdateProjectFunc   func(ctx context.Context, id string, cmd *dtos.UpdateProjectCommand) (*models.Project, error)
+	ArchiveProjectFunc  func(ctx context.Context, id string, cmd *dtos.ArchiveProjectCommand) (*models.Project, error)
+	DeleteProjectFunc   func(ctx context.Context, id string, cascade bool) error
+}
+
+func (m *MockProjectUseCase) CreateProject(ctx context.Context, cmd *dtos.CreateProjectCommand) (*models.Project, error) {
+	return m.CreateProjectFunc(ctx, cmd)
+}
+
+func (m *MockProjectUseCase) GetProject(ctx context.Context, id string) (*models.Project, error) {
+	return m.GetProjectFunc(ctx, id)
+}
+
+func (m *MockProjectUseCase) GetProjects(ctx context.Context, includeArchived bool) ([]*models.Project, error) {
+	return m.GetProjectsFunc(ctx, includeArchived)
+}
+
+func (m *MockProjectUseCase) GetProjectTasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	return m.GetProjectTasksFunc(ctx, id, query)
+}
+
+func (m *MockProjectUseCase) UpdateProject(ctx context.Context, id string, cmd *dtos.UpdateProjectCommand) (*models.Project, error) {
+	return m.UpdateProjectFunc(ctx, id, cmd)
+}
+
+func (m *MockProjectUseCase) ArchiveProject(ctx context.Context, id string, cmd *dtos.ArchiveProjectCommand) (*models.Project, error) {
+	return m.ArchiveProjectFunc(ctx, id, cmd)
+}
+
+func (m *MockProjectUseCase) DeleteProject(ctx context.Context, id string, cascade bool) error {
+	return m.DeleteProjectFunc(ctx, id, cascade)
+}
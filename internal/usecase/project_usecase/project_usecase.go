@@ -0,0 +1,144 @@
+package project_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/internal/usecase"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+)
+
+type ProjectUseCase struct {
+	projectRep  repository.ProjectRepository
+	taskUseCase usecase.TaskUseCase
+}
+
+func NewProjectUseCase(projectRep repository.ProjectRepository, taskUseCase usecase.TaskUseCase) *ProjectUseCase {
+	return &ProjectUseCase{
+		projectRep:  projectRep,
+		taskUseCase: taskUseCase,
+	}
+}
+
+// getOwnProject returns the project only if it belongs to the authenticated
+// user; projects of other users are reported as not found.
+func (puc *ProjectUseCase) getOwnProject(ctx context.Context, id string) (*models.Project, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	project, err := puc.projectRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.OwnerId != userId {
+		return nil, internalErrors.ProjectNotFound
+	}
+
+	return project, nil
+}
+
+func (puc *ProjectUseCase) CreateProject(ctx context.Context, cmd *dtos.CreateProjectCommand) (*models.Project, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	projectId, _ := helper.GenerateUUID()
+	createdAt := clock.Timestamp()
+
+	project := &models.Project{
+		Id:          projectId,
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+		OwnerId:     userId,
+	}
+
+	err := puc.projectRep.Save(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (puc *ProjectUseCase) GetProject(ctx context.Context, id string) (*models.Project, error) {
+	return puc.getOwnProject(ctx, id)
+}
+
+func (puc *ProjectUseCase) GetProjects(ctx context.Context, includeArchived bool) ([]*models.Project, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	return puc.projectRep.GetAll(ctx, userId, includeArchived)
+}
+
+func (puc *ProjectUseCase) GetProjectTasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	project, err := puc.getOwnProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query.ProjectId = project.Id
+
+	return puc.taskUseCase.GetTasks(ctx, query)
+}
+
+func (puc *ProjectUseCase) UpdateProject(ctx context.Context, id string, cmd *dtos.UpdateProjectCommand) (*models.Project, error) {
+	project, err := puc.getOwnProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Name != "" {
+		project.Name = cmd.Name
+	}
+	if cmd.Description != "" {
+		project.Description = cmd.Description
+	}
+	project.UpdatedAt = clock.Timestamp()
+
+	err = puc.projectRep.Update(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (puc *ProjectUseCase) ArchiveProject(ctx context.Context, id string, cmd *dtos.ArchiveProjectCommand) (*models.Project, error) {
+	project, err := puc.getOwnProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Archived = *cmd.Archived
+	project.UpdatedAt = clock.Timestamp()
+
+	err = puc.projectRep.SetArchived(ctx, id, project.Archived, project.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (puc *ProjectUseCase) DeleteProject(ctx context.Context, id string, cascade bool) error {
+	_, err := puc.getOwnProject(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// The tasks go to the trash, from where they can still be restored.
+	return puc.taskUseCase.DeleteProject(ctx, id, cascade)
+}
@@ -0,0 +1,176 @@
+package project_usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository/memory"
+	"github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"github.com/DanKo-code/TODO-list/internal/usecase/task_usecase"
+	"testing"
+)
+
+const testUserId = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+
+func TestProjectOwnership(t *testing.T) {
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	mockProjectRepository := &sqlite.MockProjectRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Project, error) {
+			owner := testUserId
+			if id == "foreign" {
+				owner = "another-user"
+			}
+			return &models.Project{Id: id, OwnerId: owner}, nil
+		},
+	}
+	mockTaskUseCase := &task_usecase.MockTaskUseCase{
+		GetTasksFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+			if query.ProjectId != "own" {
+				t.Errorf("expected tasks of project own, got %q", query.ProjectId)
+			}
+			return &dtos.TaskPage{}, nil
+		},
+		DeleteProjectFunc: func(ctx context.Context, projectId string, cascade bool) error {
+			if projectId == "foreign" {
+				t.Error("expected DeleteProject not to be called for a foreign project")
+			}
+			return nil
+		},
+	}
+
+	npuc := NewProjectUseCase(mockProjectRepository, mockTaskUseCase)
+
+	if _, err := npuc.GetProjectTasks(ctx, "own", &dtos.GetTasksQuery{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := npuc.GetProjectTasks(ctx, "foreign", &dtos.GetTasksQuery{}); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.ProjectNotFound, err)
+	}
+	if err := npuc.DeleteProject(ctx, "foreign", true); !errors.Is(err, internalErrors.ProjectNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.ProjectNotFound, err)
+	}
+	if err := npuc.DeleteProject(ctx, "own", false); err != nil {
+		t.Errorf("expected own project to be deleted, got %v", err)
+	}
+}
+
+func TestArchiveProjectUseCase(t *testing.T) {
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	var storedArchived bool
+
+	mockProjectRepository := &sqlite.MockProjectRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Project, error) {
+			return &models.Project{Id: id, Name: "Home", OwnerId: testUserId}, nil
+		},
+		SetArchivedFunc: func(ctx context.Context, id string, archived bool, updatedAt string) error {
+			storedArchived = archived
+			return nil
+		},
+	}
+
+	npuc := NewProjectUseCase(mockProjectRepository, &task_usecase.MockTaskUseCase{})
+
+	archived := true
+	project, err := npuc.ArchiveProject(ctx, "own", &dtos.ArchiveProjectCommand{Archived: &archived})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !storedArchived || !project.Archived || project.UpdatedAt == "" {
+		t.Errorf("expected project to be archived, stored %v, returned %+v", storedArchived, project)
+	}
+}
+
+func TestDeleteProjectCascadeUseCase(t *testing.T) {
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	store := memory.NewStore()
+	tuc := task_usecase.NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	tuc.SetHistory(memory.NewHistoryRepository(store))
+	npuc := NewProjectUseCase(memory.NewProjectRepository(store), tuc)
+
+	project, err := npuc.CreateProject(ctx, &dtos.CreateProjectCommand{Name: "Move"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Pack", ProjectId: project.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtask, err := tuc.CreateSubtask(ctx, task.Id, &dtos.CreateTaskCommand{Title: "Buy boxes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := npuc.DeleteProject(ctx, project.Id, false); !errors.Is(err, internalErrors.ProjectHasTasks) {
+		t.Fatalf("expected %v, got %v", internalErrors.ProjectHasTasks, err)
+	}
+	if err := npuc.DeleteProject(ctx, project.Id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	trash, err := tuc.GetTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].Id != task.Id {
+		t.Fatalf("expected the task of the project in the trash, got %+v", trash)
+	}
+
+	restored, err := tuc.RestoreTask(ctx, task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != "" || restored.ProjectId != "" {
+		t.Errorf("expected the task to be restored without the deleted project, got %+v", restored)
+	}
+	if got, err := tuc.GetTask(ctx, subtask.Id); err != nil || got.DeletedAt != "" {
+		t.Errorf("expected the subtask to be restored with its parent, got %+v, %v", got, err)
+	}
+}
+
+func TestUndoDeleteProjectUseCase(t *testing.T) {
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	store := memory.NewStore()
+	tuc := task_usecase.NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	tuc.SetHistory(memory.NewHistoryRepository(store))
+	npuc := NewProjectUseCase(memory.NewProjectRepository(store), tuc)
+
+	project, err := npuc.CreateProject(ctx, &dtos.CreateProjectCommand{Name: "Move"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Pack", ProjectId: project.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtask, err := tuc.CreateSubtask(ctx, task.Id, &dtos.CreateTaskCommand{Title: "Buy boxes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := npuc.DeleteProject(ctx, project.Id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The deletion is undone as one change, without the deleted project.
+	result, err := tuc.Undo(ctx, &dtos.UndoCommand{Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Tasks) != 2 {
+		t.Fatalf("expected the task and its subtask to come back, got %+v", result.Tasks)
+	}
+	for _, id := range []string{task.Id, subtask.Id} {
+		got, err := tuc.GetTask(ctx, id)
+		if err != nil || got.DeletedAt != "" || got.ProjectId != "" {
+			t.Errorf("expected %s out of the trash without the project, got %+v, %v", id, got, err)
+		}
+	}
+}
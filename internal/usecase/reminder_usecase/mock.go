@@ -0,0 +1,32 @@
+package reminder_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+type MockReminderUseCase struct {
+	CreateReminderFunc   func(ctx context.Context, taskId string, cmd *dtos.CreateReminderCommand) (*models.Reminder, error)
+	GetRemindersFunc     func(ctx context.Context, taskId string) ([]*models.Reminder, error)
+	DeleteReminderFunc   func(ctx context.Context, taskId, reminderId string) error
+	SendDueRemindersFunc func(ctx context.Context) error
+	Called               bool
+}
+
+func (m *MockReminderUseCase) CreateReminder(ctx context.Context, taskId string, cmd *dtos.CreateReminderCommand) (*models.Reminder, error) {
+	return m.CreateReminderFunc(ctx, taskId, cmd)
+}
+
+func (m *MockReminderUseCase) GetReminders(ctx context.Context, taskId string) ([]*models.Reminder, error) {
+	return m.GetRemindersFunc(ctx, taskId)
+}
+
+func (m *MockReminderUseCase) DeleteReminder(ctx context.Context, taskId, reminderId string) error {
+	return m.DeleteReminderFunc(ctx, taskId, reminderId)
+}
+
+func (m *MockReminderUseCase) SendDueReminders(ctx context.Context) error {
+	m.Called = true
+	return m.SendDueRemindersFunc(ctx)
+}
@@ -0,0 +1,205 @@
+package reminder_usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/notifier"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"time"
+)
+
+const (
+	// MaxAttempts is how often sending a reminder is tried before it is
+	// marked as failed.
+	MaxAttempts = 5
+
+	// claimLease is how long a claimed reminder is reserved for the scheduler
+	// that claimed it; it must be longer than sending a batch takes.
+	claimLease    = 5 * time.Minute
+	notifyTimeout = 30 * time.Second
+	batchSize     = 100
+)
+
+// retryDelay doubles the wait after every failed attempt, starting at a minute.
+func retryDelay(attempts int) time.Duration {
+	return time.Minute << (attempts - 1)
+}
+
+type ReminderUseCase struct {
+	reminderRep repository.ReminderRepository
+	taskRep     repository.TaskRepository
+	userRep     repository.UserRepository
+	notifier    notifier.Notifier
+}
+
+func NewReminderUseCase(reminderRep repository.ReminderRepository, taskRep repository.TaskRepository, userRep repository.UserRepository, notifier notifier.Notifier) *ReminderUseCase {
+	return &ReminderUseCase{
+		reminderRep: reminderRep,
+		taskRep:     taskRep,
+		userRep:     userRep,
+		notifier:    notifier,
+	}
+}
+
+// getOwnTask returns the task only if it belongs to the authenticated user;
+// tasks of other users are reported as not found.
+func (ruc *ReminderUseCase) getOwnTask(ctx context.Context, id string) (*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	task, err := ruc.taskRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.OwnerId != userId {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	return task, nil
+}
+
+func (ruc *ReminderUseCase) CreateReminder(ctx context.Context, taskId string, cmd *dtos.CreateReminderCommand) (*models.Reminder, error) {
+	task, err := ruc.getOwnTask(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderId, _ := helper.GenerateUUID()
+
+	reminder := &models.Reminder{
+		Id:        reminderId,
+		TaskId:    task.Id,
+		Status:    models.ReminderPending,
+		CreatedAt: clock.Timestamp(),
+		OwnerId:   task.OwnerId,
+	}
+
+	var fireAt time.Time
+	if cmd.RemindAt != "" {
+		fireAt, err = time.Parse(time.RFC3339, cmd.RemindAt)
+		if err != nil {
+			return nil, dtos.NotValidRemindAt
+		}
+
+		reminder.RemindAt = fireAt.UTC().Format(time.RFC3339)
+	} else {
+		before, err := time.ParseDuration(cmd.Before)
+		if err != nil {
+			return nil, dtos.NotValidBefore
+		}
+
+		dueAt, err := time.Parse(time.RFC3339, task.DueAt)
+		if err != nil {
+			return nil, internalErrors.TaskHasNoDueDate
+		}
+
+		fireAt = dueAt.Add(-before)
+		reminder.Before = before.String()
+	}
+
+	if !fireAt.After(clock.Now()) {
+		return nil, internalErrors.ReminderInPast
+	}
+	reminder.FireAt = fireAt.UTC().Format(time.RFC3339)
+
+	err = ruc.reminderRep.Save(ctx, reminder)
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (ruc *ReminderUseCase) GetReminders(ctx context.Context, taskId string) ([]*models.Reminder, error) {
+	task, err := ruc.getOwnTask(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	return ruc.reminderRep.GetByTask(ctx, task.Id)
+}
+
+func (ruc *ReminderUseCase) DeleteReminder(ctx context.Context, taskId, reminderId string) error {
+	task, err := ruc.getOwnTask(ctx, taskId)
+	if err != nil {
+		return err
+	}
+
+	reminder, err := ruc.reminderRep.GetById(ctx, reminderId)
+	if err != nil {
+		return err
+	}
+
+	if reminder.TaskId != task.Id {
+		return internalErrors.ReminderNotFound
+	}
+
+	return ruc.reminderRep.Delete(ctx, reminder.Id)
+}
+
+// SendDueReminders claims the reminders that are due and notifies their
+// owners. A reminder is marked as sent only after the notifier succeeded;
+// failed ones are retried with a growing delay up to MaxAttempts times.
+func (ruc *ReminderUseCase) SendDueReminders(ctx context.Context) error {
+	current := clock.Now()
+
+	reminders, err := ruc.reminderRep.ClaimDue(ctx, current.Format(time.RFC3339), current.Add(claimLease).Format(time.RFC3339), batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		if err := ruc.send(ctx, reminder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ruc *ReminderUseCase) send(ctx context.Context, reminder *models.Reminder) error {
+	task, err := ruc.taskRep.GetById(ctx, reminder.TaskId)
+	if errors.Is(err, internalErrors.TaskNotFound) {
+		return ruc.reminderRep.Finish(ctx, reminder.Id, models.ReminderSkipped, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	if task.Completed {
+		return ruc.reminderRep.Finish(ctx, reminder.Id, models.ReminderSkipped, "")
+	}
+
+	user, err := ruc.userRep.GetById(ctx, task.OwnerId)
+	if err != nil {
+		return err
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	err = ruc.notifier.Notify(notifyCtx, &notifier.Notification{Reminder: reminder, Task: task, User: user})
+	switch {
+	case err == nil:
+		return ruc.reminderRep.Finish(ctx, reminder.Id, models.ReminderSent, clock.Timestamp())
+	case errors.Is(err, notifier.ErrNoRecipient):
+		logger.InfoLogger.Printf("Skipping reminder %s: %v", reminder.Id, err)
+		return ruc.reminderRep.Finish(ctx, reminder.Id, models.ReminderSkipped, "")
+	case reminder.Attempts >= MaxAttempts:
+		logger.ErrorLogger.Printf("Giving up on reminder %s after %d attempts: %v", reminder.Id, reminder.Attempts, err)
+		return ruc.reminderRep.Finish(ctx, reminder.Id, models.ReminderFailed, "")
+	default:
+		logger.ErrorLogger.Printf("Failed to send reminder %s, will retry: %v", reminder.Id, err)
+		return ruc.reminderRep.Retry(ctx, reminder.Id, clock.Now().Add(retryDelay(reminder.Attempts)).Format(time.RFC3339))
+	}
+}
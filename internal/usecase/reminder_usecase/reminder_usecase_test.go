@@ -0,0 +1,157 @@
+package reminder_usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/notifier"
+	"github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"testing"
+	"time"
+)
+
+const testUserId = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+
+type notifierFunc func(ctx context.Context, notification *notifier.Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, notification *notifier.Notification) error {
+	return f(ctx, notification)
+}
+
+func freeze(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+}
+
+func TestCreateReminderUseCase(t *testing.T) {
+	freeze(t)
+
+	test := []struct {
+		name           string
+		cmd            dtos.CreateReminderCommand
+		ownerId        string
+		expectedFireAt string
+		expectedErr    error
+	}{
+		{name: "absolute", cmd: dtos.CreateReminderCommand{RemindAt: "2024-11-22T15:00:00+03:00"}, ownerId: testUserId, expectedFireAt: "2024-11-22T12:00:00Z"},
+		{name: "before due", cmd: dtos.CreateReminderCommand{Before: "90m"}, ownerId: testUserId, expectedFireAt: "2024-11-22T22:30:00Z"},
+		{name: "in the past", cmd: dtos.CreateReminderCommand{RemindAt: "2024-11-22T10:00:00Z"}, ownerId: testUserId, expectedErr: internalErrors.ReminderInPast},
+		{name: "before due in the past", cmd: dtos.CreateReminderCommand{Before: "24h"}, ownerId: testUserId, expectedErr: internalErrors.ReminderInPast},
+		{name: "task of another user", cmd: dtos.CreateReminderCommand{Before: "1h"}, ownerId: "another", expectedErr: internalErrors.TaskNotFound},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *models.Reminder
+
+			mockTaskRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return &models.Task{Id: id, DueDate: "2024-11-22", DueAt: "2024-11-23T00:00:00Z", AllDay: true, OwnerId: tt.ownerId}, nil
+				},
+			}
+			mockReminderRepository := &sqlite.MockReminderRepository{
+				SaveFunc: func(ctx context.Context, reminder *models.Reminder) error {
+					saved = reminder
+					return nil
+				},
+			}
+
+			ruc := NewReminderUseCase(mockReminderRepository, mockTaskRepository, &sqlite.MockUserRepository{}, notifier.NewLogNotifier())
+
+			reminder, err := ruc.CreateReminder(auth.WithUserId(context.Background(), testUserId), "task", &tt.cmd)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				if saved != nil {
+					t.Errorf("expected no reminder to be saved, got %+v", saved)
+				}
+				return
+			}
+
+			if saved != reminder || reminder.FireAt != tt.expectedFireAt || reminder.Status != models.ReminderPending || reminder.TaskId != "task" {
+				t.Errorf("expected a pending reminder at %s, got %+v", tt.expectedFireAt, reminder)
+			}
+		})
+	}
+}
+
+func TestSendDueRemindersUseCase(t *testing.T) {
+	freeze(t)
+
+	test := []struct {
+		name           string
+		completed      bool
+		attempts       int
+		notifyErr      error
+		expectedStatus string
+		expectedRetry  string
+	}{
+		{name: "sent", attempts: 1, expectedStatus: models.ReminderSent},
+		{name: "completed task is skipped", completed: true, attempts: 1, expectedStatus: models.ReminderSkipped},
+		{name: "no recipient is skipped", attempts: 1, notifyErr: notifier.ErrNoRecipient, expectedStatus: models.ReminderSkipped},
+		{name: "failure is retried", attempts: 3, notifyErr: errors.New("connection refused"), expectedRetry: "2024-11-22T10:34:00Z"},
+		{name: "last failure gives up", attempts: MaxAttempts, notifyErr: errors.New("connection refused"), expectedStatus: models.ReminderFailed},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var finished, retryAt string
+			notified := false
+
+			mockReminderRepository := &sqlite.MockReminderRepository{
+				ClaimDueFunc: func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.Reminder, error) {
+					if now != "2024-11-22T10:30:00Z" || claimedUntil <= now {
+						t.Errorf("unexpected claim from %s until %s", now, claimedUntil)
+					}
+					return []*models.Reminder{{Id: "r1", TaskId: "t1", Status: models.ReminderPending, Attempts: tt.attempts}}, nil
+				},
+				FinishFunc: func(ctx context.Context, id, status, sentAt string) error {
+					finished = status
+					if (status == models.ReminderSent) != (sentAt != "") {
+						t.Errorf("expected sent_at only for sent reminders, got %q for %s", sentAt, status)
+					}
+					return nil
+				},
+				RetryFunc: func(ctx context.Context, id, at string) error {
+					retryAt = at
+					return nil
+				},
+			}
+			mockTaskRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return &models.Task{Id: id, Title: "report", Completed: tt.completed, OwnerId: testUserId}, nil
+				},
+			}
+			mockUserRepository := &sqlite.MockUserRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.User, error) {
+					return &models.User{Id: id, Username: "alice"}, nil
+				},
+			}
+			notify := notifierFunc(func(ctx context.Context, notification *notifier.Notification) error {
+				notified = true
+				if notification.Task.Title != "report" || notification.User.Username != "alice" {
+					t.Errorf("unexpected notification %+v", notification)
+				}
+				return tt.notifyErr
+			})
+
+			ruc := NewReminderUseCase(mockReminderRepository, mockTaskRepository, mockUserRepository, notify)
+
+			if err := ruc.SendDueReminders(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if notified == tt.completed {
+				t.Errorf("expected notification only for open tasks, notified: %v", notified)
+			}
+			if finished != tt.expectedStatus || retryAt != tt.expectedRetry {
+				t.Errorf("expected status %q and retry at %q, got %q and %q", tt.expectedStatus, tt.expectedRetry, finished, retryAt)
+			}
+		})
+	}
+}
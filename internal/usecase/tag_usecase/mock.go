@@ -0,0 +1,30 @@
+package tag_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+type MockTagUseCase struct {
+	CreateTagFunc func(ctx context.Context, cmd *dtos.TagCommand) (*models.Tag, error)
+	GetTagsFunc   func(ctx context.Context) ([]*models.Tag, error)
+	RenameTagFunc func(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Tag, error)
+	DeleteTagFunc func(ctx context.Context, id string) error
+}
+
+func (m *MockTagUseCase) CreateTag(ctx context.Context, cmd *dtos.TagCommand) (*models.Tag, error) {
+	return m.CreateTagFunc(ctx, cmd)
+}
+
+func (m *MockTagUseCase) GetTags(ctx context.Context) ([]*models.Tag, error) {
+	return m.GetTagsFunc(ctx)
+}
+
+func (m *MockTagUseCase) RenameTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Tag, error) {
+	return m.RenameTagFunc(ctx, id, cmd)
+}
+
+func (m *MockTagUseCase) DeleteTag(ctx context.Context, id string) error {
+	return m.DeleteTagFunc(ctx, id)
+}
@@ -0,0 +1,99 @@
+package tag_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"time"
+)
+
+type TagUseCase struct {
+	tagRep repository.TagRepository
+}
+
+func NewTagUseCase(tagRep repository.TagRepository) *TagUseCase {
+	return &TagUseCase{
+		tagRep: tagRep,
+	}
+}
+
+// getOwnTag returns the tag only if it belongs to the authenticated user;
+// tags of other users are reported as not found.
+func (tuc *TagUseCase) getOwnTag(ctx context.Context, id string) (*models.Tag, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	tag, err := tuc.tagRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag.OwnerId != userId {
+		return nil, internalErrors.TagNotFound
+	}
+
+	return tag, nil
+}
+
+func (tuc *TagUseCase) CreateTag(ctx context.Context, cmd *dtos.TagCommand) (*models.Tag, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	tagId, _ := helper.GenerateUUID()
+
+	tag := &models.Tag{
+		Id:        tagId,
+		Name:      cmd.Name,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		OwnerId:   userId,
+	}
+
+	err := tuc.tagRep.Save(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (tuc *TagUseCase) GetTags(ctx context.Context) ([]*models.Tag, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	return tuc.tagRep.GetAll(ctx, userId)
+}
+
+func (tuc *TagUseCase) RenameTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Tag, error) {
+	tag, err := tuc.getOwnTag(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tuc.tagRep.Rename(ctx, id, cmd.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag.Name = cmd.Name
+
+	return tag, nil
+}
+
+func (tuc *TagUseCase) DeleteTag(ctx context.Context, id string) error {
+	_, err := tuc.getOwnTag(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return tuc.tagRep.Delete(ctx, id)
+}
@@ -0,0 +1,146 @@
+package task_usecase
+
+import (
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"time"
+)
+
+// due is a resolved due date: the value shown to the user, the calendar day
+// it falls on and the instant the task becomes overdue.
+type due struct {
+	value  string
+	day    string
+	at     time.Time
+	allDay bool
+}
+
+// dayDue makes date an all-day due date that is overdue once the day is over
+// in loc.
+func dayDue(date time.Time, loc *time.Location) due {
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, loc)
+	day := date.Format(dtos.DateLayout)
+
+	return due{value: day, day: day, at: end, allDay: true}
+}
+
+func timeDue(t time.Time, loc *time.Location) due {
+	t = t.In(loc)
+
+	return due{value: t.Format(time.RFC3339), day: t.Format(dtos.DateLayout), at: t}
+}
+
+func (d due) dueAt() string {
+	return d.at.UTC().Format(time.RFC3339)
+}
+
+func (d due) apply(task *models.Task) {
+	task.DueDate = d.value
+	task.DueAt = d.dueAt()
+	task.AllDay = d.allDay
+}
+
+// today returns the current date in loc as midnight UTC, the form dates are
+// parsed into.
+func today(loc *time.Location) time.Time {
+	current := clock.Now().In(loc)
+	return time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// defaultDue is used when a task is created without a due date.
+func defaultDue(loc *time.Location) due {
+	return dayDue(today(loc).AddDate(0, 0, 1), loc)
+}
+
+// resolveDueDate interprets the due date of a command in loc. A date without
+// time or allDay makes an all-day task; dates that are already over are
+// rejected.
+func resolveDueDate(value string, allDay *bool, loc *time.Location) (due, error) {
+	var d due
+
+	if date, err := time.Parse(dtos.DateLayout, value); err == nil {
+		d = dayDue(date, loc)
+	} else {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return due{}, dtos.NotValidDateFormat
+		}
+
+		if allDay != nil && *allDay {
+			t = t.In(loc)
+			d = dayDue(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), loc)
+		} else {
+			d = timeDue(t, loc)
+		}
+	}
+
+	if !d.at.After(clock.Now()) {
+		return due{}, internalErrors.DueDateInPast
+	}
+
+	return d, nil
+}
+
+// parseDue splits a stored due date into its day and, for timed tasks, the
+// time in loc. Unparsable values are treated as today.
+func parseDue(value string, loc *time.Location) (day time.Time, timeOfDay time.Time, timed bool) {
+	if date, err := time.Parse(dtos.DateLayout, value); err == nil {
+		return date, time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return today(loc), time.Time{}, false
+	}
+
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), t, true
+}
+
+// storedDue resolves a due date as it was stored before, whether it is over
+// or not.
+func storedDue(value string, allDay bool, loc *time.Location) due {
+	date, timeOfDay, timed := parseDue(value, loc)
+	if timed && !allDay {
+		return timeDue(timeOfDay, loc)
+	}
+
+	return dayDue(date, loc)
+}
+
+// nextDue returns the due date of the occurrence after the one due on value:
+// the first date of the series that is not over yet, so a task completed late
+// does not spawn overdue occurrences. Timed tasks keep their time of day.
+func nextDue(recurrence *models.Recurrence, value string, loc *time.Location) due {
+	date, timeOfDay, timed := parseDue(value, loc)
+	current := clock.Now()
+
+	for {
+		date = recurrence.Next(date)
+
+		d := dayDue(date, loc)
+		if timed {
+			d = timeDue(time.Date(date.Year(), date.Month(), date.Day(), timeOfDay.Hour(), timeOfDay.Minute(), timeOfDay.Second(), 0, loc), loc)
+		}
+
+		if d.at.After(current) {
+			return d
+		}
+	}
+}
+
+// dueRange converts the DueFrom and DueTo days of the query into bounds of
+// due_at in loc: a task is in range if it becomes overdue after the start of
+// DueFrom and no later than the end of DueTo.
+func dueRange(query *dtos.GetTasksQuery, loc *time.Location) {
+	if from, err := time.Parse(dtos.DateLayout, query.DueFrom); err == nil {
+		start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		query.DueAfter = start.UTC().Format(time.RFC3339)
+	}
+
+	if to, err := time.Parse(dtos.DateLayout, query.DueTo); err == nil {
+		query.DueUntil = dayDue(to, loc).dueAt()
+	}
+}
@@ -4,15 +4,35 @@ import (
 	"context"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"time"
 )
 
 type MockTaskUseCase struct {
 	CreateTaskFunc                 func(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error)
-	GetTaskFunc                    func(ctx context.Context) ([]*models.Task, error)
+	CreateSubtaskFunc              func(ctx context.Context, parentId string, cmd *dtos.CreateTaskCommand) (*models.Task, error)
+	GetTaskFunc                    func(ctx context.Context, id string) (*models.Task, error)
+	GetTasksByIdsFunc              func(ctx context.Context, ids []string) ([]*models.Task, error)
+	GetSubtasksOfFunc              func(ctx context.Context, parentIds []string) (map[string][]*models.Task, error)
+	GetTasksFunc                   func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	GetSubtasksFunc                func(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	SearchTasksFunc                func(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error)
 	UpdateTaskFunc                 func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error)
-	DeleteTaskFunc                 func(ctx context.Context, id string) error
-	ChangeTaskCompletionStatusFunc func(ctx context.Context, id string, completionStatus bool) (*models.Task, error)
+	DeleteTaskFunc                 func(ctx context.Context, id string, version int64) error
+	DeleteProjectFunc              func(ctx context.Context, projectId string, cascade bool) error
+	GetTrashFunc                   func(ctx context.Context) ([]*models.Task, error)
+	RestoreTaskFunc                func(ctx context.Context, id string) (*models.Task, error)
+	PurgeTaskFunc                  func(ctx context.Context, id string) error
+	PurgeTrashFunc                 func(ctx context.Context, retention time.Duration) (int64, error)
+	ChangeTaskCompletionStatusFunc func(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error)
+	MoveTaskFunc                   func(ctx context.Context, id string, cmd *dtos.MoveTaskCommand) (*models.Task, error)
+	AddTaskTagFunc                 func(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Task, error)
+	RemoveTaskTagFunc              func(ctx context.Context, id string, name string) (*models.Task, error)
+	AddTaskBlockerFunc             func(ctx context.Context, id string, cmd *dtos.BlockerCommand) (*models.Task, error)
+	RemoveTaskBlockerFunc          func(ctx context.Context, id string, blockerId string) (*models.Task, error)
 	UpdateOverdueTasksFunc         func(ctx context.Context) error
+	GetTaskHistoryFunc             func(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
+	UndoFunc                       func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)
+	RedoFunc                       func(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)
 	Called                         bool
 }
 
@@ -20,23 +40,99 @@ func (m *MockTaskUseCase) CreateTask(ctx context.Context, cmd *dtos.CreateTaskCo
 	return m.CreateTaskFunc(ctx, cmd)
 }
 
-func (m *MockTaskUseCase) GetTasks(ctx context.Context) ([]*models.Task, error) {
-	return m.GetTaskFunc(ctx)
+func (m *MockTaskUseCase) CreateSubtask(ctx context.Context, parentId string, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+	return m.CreateSubtaskFunc(ctx, parentId, cmd)
+}
+
+func (m *MockTaskUseCase) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	return m.GetTaskFunc(ctx, id)
+}
+
+func (m *MockTaskUseCase) GetTasksByIds(ctx context.Context, ids []string) ([]*models.Task, error) {
+	return m.GetTasksByIdsFunc(ctx, ids)
+}
+
+func (m *MockTaskUseCase) GetSubtasksOf(ctx context.Context, parentIds []string) (map[string][]*models.Task, error) {
+	return m.GetSubtasksOfFunc(ctx, parentIds)
+}
+
+func (m *MockTaskUseCase) GetTasks(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	return m.GetTasksFunc(ctx, query)
+}
+
+func (m *MockTaskUseCase) GetSubtasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	return m.GetSubtasksFunc(ctx, id, query)
+}
+
+func (m *MockTaskUseCase) SearchTasks(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	return m.SearchTasksFunc(ctx, query)
 }
 
 func (m *MockTaskUseCase) UpdateTask(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
 	return m.UpdateTaskFunc(ctx, id, updateTaskCommand)
 }
 
-func (m *MockTaskUseCase) DeleteTask(ctx context.Context, id string) error {
-	return m.DeleteTaskFunc(ctx, id)
+func (m *MockTaskUseCase) DeleteTask(ctx context.Context, id string, version int64) error {
+	return m.DeleteTaskFunc(ctx, id, version)
+}
+
+func (m *MockTaskUseCase) DeleteProject(ctx context.Context, projectId string, cascade bool) error {
+	return m.DeleteProjectFunc(ctx, projectId, cascade)
+}
+
+func (m *MockTaskUseCase) GetTrash(ctx context.Context) ([]*models.Task, error) {
+	return m.GetTrashFunc(ctx)
+}
+
+func (m *MockTaskUseCase) RestoreTask(ctx context.Context, id string) (*models.Task, error) {
+	return m.RestoreTaskFunc(ctx, id)
+}
+
+func (m *MockTaskUseCase) PurgeTask(ctx context.Context, id string) error {
+	return m.PurgeTaskFunc(ctx, id)
+}
+
+func (m *MockTaskUseCase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return m.PurgeTrashFunc(ctx, retention)
+}
+
+func (m *MockTaskUseCase) ChangeTaskCompletionStatus(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error) {
+	return m.ChangeTaskCompletionStatusFunc(ctx, id, cmd)
 }
 
-func (m *MockTaskUseCase) ChangeTaskCompletionStatus(ctx context.Context, id string, completionStatus bool) (*models.Task, error) {
-	return m.ChangeTaskCompletionStatusFunc(ctx, id, completionStatus)
+func (m *MockTaskUseCase) MoveTask(ctx context.Context, id string, cmd *dtos.MoveTaskCommand) (*models.Task, error) {
+	return m.MoveTaskFunc(ctx, id, cmd)
+}
+
+func (m *MockTaskUseCase) AddTaskTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Task, error) {
+	return m.AddTaskTagFunc(ctx, id, cmd)
+}
+
+func (m *MockTaskUseCase) RemoveTaskTag(ctx context.Context, id string, name string) (*models.Task, error) {
+	return m.RemoveTaskTagFunc(ctx, id, name)
+}
+
+func (m *MockTaskUseCase) AddTaskBlocker(ctx context.Context, id string, cmd *dtos.BlockerCommand) (*models.Task, error) {
+	return m.AddTaskBlockerFunc(ctx, id, cmd)
+}
+
+func (m *MockTaskUseCase) RemoveTaskBlocker(ctx context.Context, id string, blockerId string) (*models.Task, error) {
+	return m.RemoveTaskBlockerFunc(ctx, id, blockerId)
 }
 
 func (m *MockTaskUseCase) UpdateOverdueTasks(ctx context.Context) error {
 	m.Called = true
 	return m.UpdateOverdueTasksFunc(ctx)
 }
+
+func (m *MockTaskUseCase) GetTaskHistory(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	return m.GetTaskHistoryFunc(ctx, id, query)
+}
+
+func (m *MockTaskUseCase) Undo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+	return m.UndoFunc(ctx, cmd)
+}
+
+func (m *MockTaskUseCase) Redo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+	return m.RedoFunc(ctx, cmd)
+}
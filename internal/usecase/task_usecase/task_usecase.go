@@ -2,80 +2,503 @@ package task_usecase
 
 import (
 	"context"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
 	"github.com/DanKo-code/TODO-list/internal/models"
 	"github.com/DanKo-code/TODO-list/internal/repository"
 	"github.com/DanKo-code/TODO-list/pkg/helper"
 	"time"
 )
 
+// DefaultMaxSubtaskDepth is how many levels of subtasks a task can have.
+const DefaultMaxSubtaskDepth = 3
+
 type TaskUseCase struct {
-	taskRep repository.TaskRepository
+	taskRep         repository.TaskRepository
+	projectRep      repository.ProjectRepository
+	tagRep          repository.TagRepository
+	historyRep      repository.HistoryRepository
+	publisher       events.Publisher
+	maxSubtaskDepth int
 }
 
-func NewTaskUseCase(taskRep repository.TaskRepository) *TaskUseCase {
+func NewTaskUseCase(taskRep repository.TaskRepository, projectRep repository.ProjectRepository, tagRep repository.TagRepository) *TaskUseCase {
 	return &TaskUseCase{
-		taskRep: taskRep,
+		taskRep:         taskRep,
+		projectRep:      projectRep,
+		tagRep:          tagRep,
+		publisher:       events.Discard,
+		maxSubtaskDepth: DefaultMaxSubtaskDepth,
+	}
+}
+
+func (tuc *TaskUseCase) SetMaxSubtaskDepth(depth int) {
+	tuc.maxSubtaskDepth = depth
+}
+
+// SetPublisher sets where events about changed tasks are sent.
+func (tuc *TaskUseCase) SetPublisher(publisher events.Publisher) {
+	tuc.publisher = publisher
+}
+
+// SetHistory sets where changes of tasks are recorded; without it they are
+// not recorded.
+func (tuc *TaskUseCase) SetHistory(historyRep repository.HistoryRepository) {
+	tuc.historyRep = historyRep
+}
+
+// recorder lets a repository record each task it changes in one transaction,
+// naming every change with action. The entries after the first refer to it
+// as their cause, so undo reverts them together.
+func (tuc *TaskUseCase) recorder(ctx context.Context, action func(before, after *models.Task) string) repository.Recorder {
+	cause := ""
+
+	return func(before, after *models.Task) *models.HistoryEntry {
+		entry := tuc.newEntry(ctx, action(before, after), before, after)
+		if entry == nil {
+			return nil
+		}
+
+		if cause == "" {
+			cause = entry.Id
+		} else {
+			entry.Cause = cause
+		}
+
+		return entry
+	}
+}
+
+// separately lets a repository record each task it changes as a change of
+// its own, for tasks that change together without one causing the others.
+func (tuc *TaskUseCase) separately(ctx context.Context, action string) repository.Recorder {
+	return func(before, after *models.Task) *models.HistoryEntry {
+		return tuc.newEntry(ctx, action, before, after)
+	}
+}
+
+// newEntry describes the change of a task from before to after, made by the
+// authenticated user or else by the system. It returns nil when the history
+// is not recorded or nothing tracked has changed.
+func (tuc *TaskUseCase) newEntry(ctx context.Context, action string, before, after *models.Task) *models.HistoryEntry {
+	if tuc.historyRep == nil {
+		return nil
+	}
+
+	changes := models.DiffTasks(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	actorId, ok := auth.UserId(ctx)
+	if !ok {
+		actorId = models.ActorSystem
+	}
+
+	entryId, _ := helper.GenerateUUID()
+
+	return &models.HistoryEntry{
+		Id:        entryId,
+		TaskId:    after.Id,
+		Action:    action,
+		ActorId:   actorId,
+		Changes:   changes,
+		Version:   after.Version,
+		CreatedAt: clock.Timestamp(),
+	}
+}
+
+func (tuc *TaskUseCase) publish(ctx context.Context, eventType string, task *models.Task) {
+	eventId, _ := helper.GenerateUUID()
+
+	tuc.publisher.Publish(ctx, &events.Event{
+		Id:         eventId,
+		Type:       eventType,
+		OccurredAt: clock.Timestamp(),
+		OwnerId:    task.OwnerId,
+		Task:       task,
+	})
+}
+
+// fillProgress sets Progress of tasks that have subtasks to the percentage of
+// their completed direct subtasks.
+func (tuc *TaskUseCase) fillProgress(ctx context.Context, tasks ...*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+
+	counts, err := tuc.taskRep.GetSubtaskCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		task.Progress = nil
+
+		c, ok := counts[task.Id]
+		if !ok || c.Total == 0 {
+			continue
+		}
+
+		progress := c.Completed * 100 / c.Total
+		task.Progress = &progress
+	}
+
+	return nil
+}
+
+// getAncestors returns the parent of the task, its parent and so on.
+func (tuc *TaskUseCase) getAncestors(ctx context.Context, task *models.Task) ([]*models.Task, error) {
+	var ancestors []*models.Task
+
+	for task.ParentId != "" {
+		parent, err := tuc.taskRep.GetById(ctx, task.ParentId)
+		if err != nil {
+			return nil, err
+		}
+
+		ancestors = append(ancestors, parent)
+		task = parent
+	}
+
+	return ancestors, nil
+}
+
+// getOwnTask returns the task only if it belongs to the authenticated user;
+// tasks of other users are reported as not found.
+func (tuc *TaskUseCase) getOwnTask(ctx context.Context, id string) (*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
 	}
+
+	task, err := tuc.taskRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.OwnerId != userId {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	return task, nil
+}
+
+// checkTargetProject verifies that tasks of userId can be put into the project.
+func (tuc *TaskUseCase) checkTargetProject(ctx context.Context, userId, projectId string) error {
+	project, err := tuc.projectRep.GetById(ctx, projectId)
+	if err != nil {
+		return err
+	}
+
+	if project.OwnerId != userId {
+		return internalErrors.ProjectNotFound
+	}
+
+	if project.Archived {
+		return internalErrors.ProjectArchived
+	}
+
+	return nil
 }
 
 func (tuc *TaskUseCase) CreateTask(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	return tuc.createTask(ctx, userId, "", cmd)
+}
+
+// CreateSubtask creates a task under parentId in the project of the parent.
+func (tuc *TaskUseCase) CreateSubtask(ctx context.Context, parentId string, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+	parent, err := tuc.getOwnTask(ctx, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent.Completed {
+		return nil, internalErrors.ParentTaskCompleted
+	}
+
+	ancestors, err := tuc.getAncestors(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	// The parent is at depth len(ancestors), the new subtask one level below.
+	if len(ancestors)+1 > tuc.maxSubtaskDepth {
+		return nil, internalErrors.SubtaskDepthExceeded
+	}
+
+	cmd.ProjectId = parent.ProjectId
+
+	return tuc.createTask(ctx, parent.OwnerId, parent.Id, cmd)
+}
+
+func (tuc *TaskUseCase) createTask(ctx context.Context, userId, parentId string, cmd *dtos.CreateTaskCommand) (*models.Task, error) {
+	if cmd.ProjectId != "" {
+		if err := tuc.checkTargetProject(ctx, userId, cmd.ProjectId); err != nil {
+			return nil, err
+		}
+	}
 
 	taskId, _ := helper.GenerateUUID()
 
-	if cmd.DueDate == "" {
-		cmd.DueDate = time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	if cmd.Priority == "" {
+		cmd.Priority = models.PriorityNone
+	}
+
+	loc := auth.TimeZone(ctx)
+
+	dueDate := defaultDue(loc)
+	if cmd.DueDate != "" {
+		var err error
+		dueDate, err = resolveDueDate(cmd.DueDate, cmd.AllDay, loc)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	createdAt := clock.Timestamp()
+
 	task := &models.Task{
 		Id:          taskId,
 		Title:       cmd.Title,
 		Description: cmd.Description,
-		DueDate:     cmd.DueDate,
 		Overdue:     false,
 		Completed:   false,
+		Priority:    cmd.Priority,
+		Version:     1,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+		ProjectId:   cmd.ProjectId,
+		ParentId:    parentId,
+		OwnerId:     userId,
+	}
+	dueDate.apply(task)
+
+	if cmd.Recurrence != "" {
+		task.Recurrence = normalizeRecurrence(cmd.Recurrence, task.DueDate, loc)
+		task.SeriesId = taskId
+		task.Occurrence = 1
+	}
+
+	err := tuc.taskRep.Save(ctx, task, tuc.recorder(ctx, always(models.HistoryCreated)))
+	if err != nil {
+		return nil, err
 	}
 
-	err := tuc.taskRep.Save(ctx, task)
+	tuc.publish(ctx, events.TaskCreated, task)
+
+	return task, nil
+}
+
+func (tuc *TaskUseCase) GetTask(ctx context.Context, id string) (*models.Task, error) {
+
+	task, err := tuc.getOwnTask(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := tuc.fillProgress(ctx, task); err != nil {
+		return nil, err
+	}
+
 	return task, nil
 }
 
-func (tuc *TaskUseCase) GetTasks(ctx context.Context) ([]*models.Task, error) {
+func (tuc *TaskUseCase) GetTasksByIds(ctx context.Context, ids []string) ([]*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
 
-	tasks, err := tuc.taskRep.GetAll(ctx)
+	found, err := tuc.taskRep.GetByIds(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
+	tasks := []*models.Task{}
+	for _, task := range found {
+		if task.OwnerId == userId {
+			tasks = append(tasks, task)
+		}
+	}
+
+	if err := tuc.fillProgress(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
 	return tasks, nil
 }
 
+func (tuc *TaskUseCase) GetSubtasksOf(ctx context.Context, parentIds []string) (map[string][]*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	found, err := tuc.taskRep.GetByParentIds(ctx, parentIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*models.Task
+	for _, task := range found {
+		if task.OwnerId == userId {
+			tasks = append(tasks, task)
+		}
+	}
+
+	if err := tuc.fillProgress(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
+	subtasks := make(map[string][]*models.Task)
+	for _, task := range tasks {
+		subtasks[task.ParentId] = append(subtasks[task.ParentId], task)
+	}
+
+	return subtasks, nil
+}
+
+func (tuc *TaskUseCase) GetSubtasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query.ParentId = task.Id
+
+	return tuc.GetTasks(ctx, query)
+}
+
+func (tuc *TaskUseCase) GetTasks(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+	query.OwnerId = userId
+	dueRange(query, auth.TimeZone(ctx))
+
+	page, err := tuc.taskRep.GetAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tuc.fillProgress(ctx, page.Tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (tuc *TaskUseCase) SearchTasks(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+	query.OwnerId = userId
+
+	page, err := tuc.taskRep.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*models.Task, len(page.Results))
+	for i, result := range page.Results {
+		tasks[i] = result.Task
+	}
+	if err := tuc.fillProgress(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
 func (tuc *TaskUseCase) UpdateTask(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error) {
 
-	task, err := tuc.taskRep.GetById(ctx, id)
+	task, err := tuc.getOwnTask(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = tuc.taskRep.Update(ctx, id, updateTaskCommand)
+	if task.Version != *updateTaskCommand.Version {
+		return nil, internalErrors.VersionConflict
+	}
+
+	loc := auth.TimeZone(ctx)
+
+	if updateTaskCommand.DueDate != "" {
+		dueDate, err := resolveDueDate(updateTaskCommand.DueDate, updateTaskCommand.AllDay, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		updateTaskCommand.DueDate = dueDate.value
+		updateTaskCommand.DueAt = dueDate.dueAt()
+		updateTaskCommand.AllDay = &dueDate.allDay
+	}
+
+	if updateTaskCommand.Recurrence != nil && *updateTaskCommand.Recurrence != "" {
+		dueDate := updateTaskCommand.DueDate
+		if dueDate == "" {
+			dueDate = task.DueDate
+		}
+
+		rule := normalizeRecurrence(*updateTaskCommand.Recurrence, dueDate, loc)
+		updateTaskCommand.Recurrence = &rule
+	}
+
+	updatedAt := clock.Timestamp()
+
+	err = tuc.taskRep.Update(ctx, id, updateTaskCommand, updatedAt, tuc.recorder(ctx, always(models.HistoryUpdated)))
 	if err != nil {
 		return nil, err
 	}
 
 	updatedTask := createUpdateTaskRes(task, updateTaskCommand)
+	updatedTask.UpdatedAt = updatedAt
+
+	if err := tuc.fillProgress(ctx, updatedTask); err != nil {
+		return nil, err
+	}
+
+	tuc.publish(ctx, events.TaskUpdated, updatedTask)
 
 	return updatedTask, nil
 }
 
 func createUpdateTaskRes(task *models.Task, updateTaskCommand *dtos.UpdateTaskCommand) *models.Task {
 	updatedTask := &models.Task{
-		Id:        task.Id,
-		Overdue:   task.Overdue,
-		Completed: task.Completed,
+		Id:          task.Id,
+		Overdue:     task.Overdue,
+		Completed:   task.Completed,
+		AllDay:      task.AllDay,
+		DueAt:       task.DueAt,
+		Version:     task.Version + 1,
+		CreatedAt:   task.CreatedAt,
+		CompletedAt: task.CompletedAt,
+		ProjectId:   task.ProjectId,
+		ParentId:    task.ParentId,
+		Recurrence:  task.Recurrence,
+		SeriesId:    task.SeriesId,
+		Occurrence:  task.Occurrence,
+		Tags:        task.Tags,
+		BlockedBy:   task.BlockedBy,
+		Blocked:     task.Blocked,
+		OwnerId:     task.OwnerId,
 	}
 	if updateTaskCommand.Title == "" {
 		updatedTask.Title = task.Title
@@ -89,52 +512,525 @@ func createUpdateTaskRes(task *models.Task, updateTaskCommand *dtos.UpdateTaskCo
 		updatedTask.Description = updateTaskCommand.Description
 	}
 
+	if updateTaskCommand.Priority == "" {
+		updatedTask.Priority = task.Priority
+	} else {
+		updatedTask.Priority = updateTaskCommand.Priority
+	}
+
 	if updateTaskCommand.DueDate == "" {
 		updatedTask.DueDate = task.DueDate
 	} else {
 		updatedTask.DueDate = updateTaskCommand.DueDate
+		updatedTask.DueAt = updateTaskCommand.DueAt
+		updatedTask.AllDay = updateTaskCommand.AllDay == nil || *updateTaskCommand.AllDay
 		updatedTask.Overdue = false
 	}
 
+	if updateTaskCommand.Recurrence != nil {
+		updatedTask.Recurrence = *updateTaskCommand.Recurrence
+
+		if updatedTask.Recurrence != "" && updatedTask.SeriesId == "" {
+			updatedTask.SeriesId = task.Id
+		}
+		if updatedTask.Recurrence != "" && updatedTask.Occurrence < 1 {
+			updatedTask.Occurrence = 1
+		}
+	}
+
 	return updatedTask
 }
 
-func (tuc *TaskUseCase) DeleteTask(ctx context.Context, id string) error {
+func (tuc *TaskUseCase) DeleteTask(ctx context.Context, id string, version int64) error {
 
-	_, err := tuc.taskRep.GetById(ctx, id)
+	task, err := tuc.getOwnTask(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	err = tuc.taskRep.DeleteById(ctx, id)
+	if task.Version != version {
+		return internalErrors.VersionConflict
+	}
+
+	deletedAt := clock.Timestamp()
+
+	trashed, err := tuc.taskRep.DeleteById(ctx, id, version, deletedAt, tuc.recorder(ctx, always(models.HistoryDeleted)))
 	if err != nil {
 		return err
 	}
 
+	for _, task := range trashed {
+		tuc.publish(ctx, events.TaskDeleted, task)
+	}
+
 	return nil
 }
 
-func (tuc *TaskUseCase) ChangeTaskCompletionStatus(ctx context.Context, id string, completionStatus bool) (*models.Task, error) {
-	task, err := tuc.taskRep.GetById(ctx, id)
+// DeleteProject expects the caller to have checked that the project belongs
+// to the user.
+func (tuc *TaskUseCase) DeleteProject(ctx context.Context, projectId string, cascade bool) error {
+	trashed, err := tuc.projectRep.Delete(ctx, projectId, cascade, clock.Timestamp(), tuc.recorder(ctx, deletionAction))
 	if err != nil {
+		return err
+	}
+
+	for _, task := range trashed {
+		tuc.publish(ctx, events.TaskDeleted, task)
+	}
+
+	return nil
+}
+
+// deletionAction names the changes a deleted project makes to its tasks in
+// their history: the tasks it takes to the trash are deleted, the ones
+// already there only lose the project.
+func deletionAction(before, after *models.Task) string {
+	if before.DeletedAt == "" && after.DeletedAt != "" {
+		return models.HistoryDeleted
+	}
+	return models.HistoryUpdated
+}
+
+// getOwnTrashedTask is getOwnTask for tasks in the trash.
+func (tuc *TaskUseCase) getOwnTrashedTask(ctx context.Context, id string) (*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	task, err := tuc.taskRep.GetTrashedById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.OwnerId != userId {
+		return nil, internalErrors.TaskNotFound
+	}
+
+	return task, nil
+}
+
+// GetTrash lists the tasks the user deleted; subtasks deleted together with
+// their parent come back with it and are not listed.
+func (tuc *TaskUseCase) GetTrash(ctx context.Context) ([]*models.Task, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	return tuc.taskRep.GetTrash(ctx, userId)
+}
+
+// RestoreTask takes the task out of the trash with the subtasks deleted
+// together with it. A subtask whose parent is still in the trash cannot be
+// restored on its own.
+func (tuc *TaskUseCase) RestoreTask(ctx context.Context, id string) (*models.Task, error) {
+	task, err := tuc.getOwnTrashedTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.ParentId != "" {
+		_, err := tuc.taskRep.GetById(ctx, task.ParentId)
+		if errors.Is(err, internalErrors.TaskNotFound) {
+			return nil, internalErrors.ParentTaskDeleted
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	restored, err := tuc.taskRep.Restore(ctx, id, clock.Timestamp(), tuc.recorder(ctx, always(models.HistoryRestored)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tuc.fillProgress(ctx, restored...); err != nil {
 		return nil, err
 	}
 
-	err = tuc.taskRep.ChangeCompletionStatus(ctx, id, completionStatus)
+	for _, task := range restored {
+		tuc.publish(ctx, events.TaskRestored, task)
+	}
+
+	return restored[0], nil
+}
+
+func (tuc *TaskUseCase) PurgeTask(ctx context.Context, id string) error {
+	if _, err := tuc.getOwnTrashedTask(ctx, id); err != nil {
+		return err
+	}
+
+	return tuc.taskRep.Purge(ctx, id)
+}
+
+func (tuc *TaskUseCase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return tuc.taskRep.PurgeDeletedBefore(ctx, clock.Now().Add(-retention).Format(time.RFC3339))
+}
+
+func (tuc *TaskUseCase) ChangeTaskCompletionStatus(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	task.Completed = completionStatus
+	if task.Version != *cmd.Version {
+		return nil, internalErrors.VersionConflict
+	}
+
+	if *cmd.Completed && !task.Completed && task.Blocked && !cmd.Force {
+		return nil, internalErrors.TaskBlocked
+	}
+
+	updatedAt := clock.Timestamp()
+
+	// Completing an already completed task keeps the original completion time.
+	completedAt := ""
+	if *cmd.Completed {
+		completedAt = task.CompletedAt
+		if !task.Completed || completedAt == "" {
+			completedAt = updatedAt
+		}
+	}
+
+	// A completed task has no open subtasks, a reopened one no completed
+	// ancestors; the repository changes them along with the task.
+	changed, err := tuc.taskRep.ChangeCompletionStatus(ctx, id, *cmd.Completed, *cmd.Version, completedAt, updatedAt, tuc.recorder(ctx, completionAction))
+	if err != nil {
+		return nil, err
+	}
+
+	if *cmd.Completed && !task.Completed && task.Recurrence != "" {
+		if err := tuc.scheduleNextOccurrence(ctx, task, auth.TimeZone(ctx)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Completed subtasks go on with their series like the task itself.
+	for _, other := range changed {
+		if other.Completed && other.Recurrence != "" {
+			if err := tuc.scheduleNextOccurrence(ctx, other, auth.TimeZone(ctx)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	eventType := events.TaskUpdated
+	if *cmd.Completed && !task.Completed {
+		eventType = events.TaskCompleted
+	}
+
+	task.Completed = *cmd.Completed
+	task.CompletedAt = completedAt
+	task.UpdatedAt = updatedAt
+	task.Version++
+
+	if err := tuc.fillProgress(ctx, task); err != nil {
+		return nil, err
+	}
+
+	tuc.publish(ctx, eventType, task)
+
+	for _, other := range changed {
+		eventType := events.TaskUpdated
+		if other.Completed {
+			eventType = events.TaskCompleted
+		}
+		tuc.publish(ctx, eventType, other)
+	}
+
+	return task, nil
+}
+
+// always names every change in the history with action.
+func always(action string) func(before, after *models.Task) string {
+	return func(before, after *models.Task) string {
+		return action
+	}
+}
+
+// completionAction names the change of the completion status of a task in
+// its history.
+func completionAction(before, after *models.Task) string {
+	switch {
+	case after.Completed && !before.Completed:
+		return models.HistoryCompleted
+	case !after.Completed && before.Completed:
+		return models.HistoryReopened
+	default:
+		return models.HistoryUpdated
+	}
+}
+
+// creationAction names the creation of a task and the changes that follow
+// from it in its history.
+func creationAction(before, after *models.Task) string {
+	if before == nil {
+		return models.HistoryCreated
+	}
+	return models.HistoryUpdated
+}
+
+// scheduleNextOccurrence creates the task that follows the completed one in
+// its series, unless the series has ended or the next task already exists
+// because this one was reopened and completed again.
+func (tuc *TaskUseCase) scheduleNextOccurrence(ctx context.Context, task *models.Task, loc *time.Location) error {
+	recurrence, ok := models.ParseRecurrence(task.Recurrence)
+	if !ok {
+		return nil
+	}
+
+	if recurrence.Count > 0 && task.Occurrence >= recurrence.Count {
+		return nil
+	}
+
+	last, err := tuc.taskRep.LastOccurrence(ctx, task.SeriesId)
+	if err != nil {
+		return err
+	}
+	if last > task.Occurrence {
+		return nil
+	}
+
+	dueDate := nextDue(recurrence, task.DueDate, loc)
+	if recurrence.Until != "" && dueDate.day > recurrence.Until {
+		return nil
+	}
+
+	taskId, _ := helper.GenerateUUID()
+	createdAt := clock.Timestamp()
+
+	next := &models.Task{
+		Id:          taskId,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Version:     1,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+		ProjectId:   task.ProjectId,
+		ParentId:    task.ParentId,
+		Recurrence:  task.Recurrence,
+		SeriesId:    task.SeriesId,
+		Occurrence:  task.Occurrence + 1,
+		OwnerId:     task.OwnerId,
+	}
+	dueDate.apply(next)
+
+	// The tags copied to the next task are recorded as caused by its
+	// creation, so undoing the creation takes them along.
+	record := tuc.recorder(ctx, creationAction)
+
+	err = tuc.taskRep.Save(ctx, next, record)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range task.Tags {
+		tag, err := tuc.tagRep.GetByName(ctx, task.OwnerId, name)
+		if err != nil {
+			return err
+		}
+
+		if err := tuc.tagRep.Attach(ctx, next.Id, tag.Id, record); err != nil {
+			return err
+		}
+	}
+	next.Tags = task.Tags
+
+	tuc.publish(ctx, events.TaskCreated, next)
+
+	return nil
+}
+
+// normalizeRecurrence stores rules in canonical form and pins monthly rules
+// to the day of the due date, so short months do not shift the series.
+func normalizeRecurrence(rule, dueDate string, loc *time.Location) string {
+	recurrence, ok := models.ParseRecurrence(rule)
+	if !ok {
+		return rule
+	}
+
+	if recurrence.Frequency == models.FrequencyMonthly && recurrence.MonthDay == 0 {
+		date, _, _ := parseDue(dueDate, loc)
+		recurrence.MonthDay = date.Day()
+	}
+
+	return recurrence.String()
+}
+
+func (tuc *TaskUseCase) MoveTask(ctx context.Context, id string, cmd *dtos.MoveTaskCommand) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Version != *cmd.Version {
+		return nil, internalErrors.VersionConflict
+	}
+
+	projectId := ""
+	if cmd.ProjectId != nil {
+		projectId = *cmd.ProjectId
+	}
+
+	if projectId != "" {
+		if err := tuc.checkTargetProject(ctx, task.OwnerId, projectId); err != nil {
+			return nil, err
+		}
+	}
+
+	updatedAt := clock.Timestamp()
+
+	err = tuc.taskRep.ChangeProject(ctx, id, projectId, *cmd.Version, updatedAt, tuc.recorder(ctx, always(models.HistoryUpdated)))
+	if err != nil {
+		return nil, err
+	}
+
+	task.ProjectId = projectId
+	task.UpdatedAt = updatedAt
+	task.Version++
+
+	if err := tuc.fillProgress(ctx, task); err != nil {
+		return nil, err
+	}
+
+	tuc.publish(ctx, events.TaskUpdated, task)
+
+	return task, nil
+}
+
+// AddTaskTag attaches the tag with the given name to the task, creating the
+// tag first if the user does not have it yet.
+func (tuc *TaskUseCase) AddTaskTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tuc.tagRep.GetByName(ctx, task.OwnerId, cmd.Name)
+	if errors.Is(err, internalErrors.TagNotFound) {
+		tagId, _ := helper.GenerateUUID()
+		tag = &models.Tag{
+			Id:        tagId,
+			Name:      cmd.Name,
+			CreatedAt: clock.Timestamp(),
+			OwnerId:   task.OwnerId,
+		}
+		err = tuc.tagRep.Save(ctx, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tuc.tagRep.Attach(ctx, task.Id, tag.Id, tuc.recorder(ctx, always(models.HistoryUpdated)))
+	if err != nil {
+		return nil, err
+	}
+
+	return tuc.reload(ctx, task.Id)
+}
+
+func (tuc *TaskUseCase) RemoveTaskTag(ctx context.Context, id string, name string) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := tuc.tagRep.GetByName(ctx, task.OwnerId, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tuc.tagRep.Detach(ctx, task.Id, tag.Id, tuc.recorder(ctx, always(models.HistoryUpdated)))
+	if err != nil {
+		return nil, err
+	}
+
+	return tuc.reload(ctx, task.Id)
+}
+
+// AddTaskBlocker makes the task wait for another task of the same user.
+func (tuc *TaskUseCase) AddTaskBlocker(ctx context.Context, id string, cmd *dtos.BlockerCommand) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	blocker, err := tuc.getOwnTask(ctx, cmd.BlockerId)
+	if errors.Is(err, internalErrors.TaskNotFound) {
+		return nil, internalErrors.BlockerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tuc.taskRep.AddBlocker(ctx, task.Id, blocker.Id, tuc.recorder(ctx, always(models.HistoryUpdated)))
+	if err != nil {
+		return nil, err
+	}
+
+	return tuc.reload(ctx, task.Id)
+}
+
+func (tuc *TaskUseCase) RemoveTaskBlocker(ctx context.Context, id string, blockerId string) (*models.Task, error) {
+	task, err := tuc.getOwnTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tuc.taskRep.RemoveBlocker(ctx, task.Id, blockerId, tuc.recorder(ctx, always(models.HistoryUpdated)))
+	if err != nil {
+		return nil, err
+	}
+
+	return tuc.reload(ctx, task.Id)
+}
+
+// reload returns the task after a change of its tags or blockers and
+// publishes the change.
+func (tuc *TaskUseCase) reload(ctx context.Context, id string) (*models.Task, error) {
+	task, err := tuc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tuc.publish(ctx, events.TaskUpdated, task)
 
 	return task, nil
 }
 
 func (tuc *TaskUseCase) UpdateOverdueTasks(ctx context.Context) error {
-	err := tuc.taskRep.UpdateOverdueTasks(ctx)
+	tasks, err := tuc.taskRep.UpdateOverdueTasks(ctx, clock.Now(), tuc.separately(ctx, models.HistoryOverdue))
 	if err != nil {
 		return err
 	}
 
+	for _, task := range tasks {
+		if !task.Completed {
+			tuc.publish(ctx, events.TaskOverdue, task)
+		}
+	}
+
 	return nil
 }
+
+// GetTaskHistory returns a page of the history of a task of the user, newest
+// first; the history of a task in the trash can be read too.
+func (tuc *TaskUseCase) GetTaskHistory(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error) {
+	_, err := tuc.getOwnTask(ctx, id)
+	if errors.Is(err, internalErrors.TaskNotFound) {
+		_, err = tuc.getOwnTrashedTask(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tuc.historyRep == nil {
+		return &dtos.HistoryPage{Entries: []*models.HistoryEntry{}}, nil
+	}
+
+	query.TaskId = id
+
+	return tuc.historyRep.GetByTask(ctx, query)
+}
@@ -2,19 +2,41 @@ package task_usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/internal/repository/memory"
 	"github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"reflect"
 	"testing"
 	"time"
 )
 
+const testUserId = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+
+func userContext() context.Context {
+	return auth.WithUserId(context.Background(), testUserId)
+}
+
 func TestCreateTaskUseCase(t *testing.T) {
+	frozen := time.Date(2024, 11, 21, 22, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	allDay := true
+
 	test := []struct {
-		name         string
-		param        dtos.CreateTaskCommand
-		mockSaveFunc func(ctx context.Context, task *models.Task) error
-		result       models.Task
+		name   string
+		param  dtos.CreateTaskCommand
+		loc    *time.Location
+		result models.Task
+		err    error
 	}{
 		{
 			name: "success",
@@ -23,15 +45,13 @@ func TestCreateTaskUseCase(t *testing.T) {
 				Description: "Test Description",
 				DueDate:     "2024-11-22",
 			},
-			mockSaveFunc: func(ctx context.Context, task *models.Task) error {
-				return nil
-			},
+			loc: time.UTC,
 			result: models.Task{
 				Title:       "Test Task",
 				Description: "Test Description",
 				DueDate:     "2024-11-22",
-				Overdue:     false,
-				Completed:   false,
+				DueAt:       "2024-11-23T00:00:00Z",
+				AllDay:      true,
 			},
 		},
 		{
@@ -40,39 +60,94 @@ func TestCreateTaskUseCase(t *testing.T) {
 				Title:       "Test Task",
 				Description: "Test Description",
 			},
-			mockSaveFunc: func(ctx context.Context, task *models.Task) error {
-				return nil
-			},
+			loc: time.UTC,
 			result: models.Task{
 				Title:       "Test Task",
 				Description: "Test Description",
-				DueDate:     time.Now().Add(24 * time.Hour).Format("2006-01-02"),
-				Overdue:     false,
-				Completed:   false,
+				DueDate:     "2024-11-22",
+				DueAt:       "2024-11-23T00:00:00Z",
+				AllDay:      true,
+			},
+		},
+		{
+			name:  "void due date is tomorrow in the user's time zone",
+			param: dtos.CreateTaskCommand{Title: "Test Task"},
+			loc:   moscow,
+			result: models.Task{
+				Title:   "Test Task",
+				DueDate: "2024-11-23",
+				DueAt:   "2024-11-23T21:00:00Z",
+				AllDay:  true,
+			},
+		},
+		{
+			name:  "all-day task ends at midnight in the user's time zone",
+			param: dtos.CreateTaskCommand{Title: "Test Task", DueDate: "2024-11-21"},
+			loc:   moscow,
+			err:   internalErrors.DueDateInPast,
+		},
+		{
+			name:  "due time is kept in the user's time zone",
+			param: dtos.CreateTaskCommand{Title: "Test Task", DueDate: "2024-11-22T09:00:00Z"},
+			loc:   moscow,
+			result: models.Task{
+				Title:   "Test Task",
+				DueDate: "2024-11-22T12:00:00+03:00",
+				DueAt:   "2024-11-22T09:00:00Z",
 			},
 		},
+		{
+			name:  "due time as all-day takes the date in the user's time zone",
+			param: dtos.CreateTaskCommand{Title: "Test Task", DueDate: "2024-11-22T22:00:00Z", AllDay: &allDay},
+			loc:   moscow,
+			result: models.Task{
+				Title:   "Test Task",
+				DueDate: "2024-11-23",
+				DueAt:   "2024-11-23T21:00:00Z",
+				AllDay:  true,
+			},
+		},
+		{
+			name:  "due time in the past",
+			param: dtos.CreateTaskCommand{Title: "Test Task", DueDate: "2024-11-21T22:00:00Z"},
+			loc:   time.UTC,
+			err:   internalErrors.DueDateInPast,
+		},
 	}
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
+			ctx := auth.WithTimeZone(userContext(), tt.loc)
 
+			var saved *models.Task
 			mockRepository := &sqlite.MockTaskRepository{
-				SaveFunc: tt.mockSaveFunc,
+				SaveFunc: func(ctx context.Context, task *models.Task, record repository.Recorder) error {
+					saved = task
+					return nil
+				},
 			}
 
-			ntuc := NewTaskUseCase(mockRepository)
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
 
 			task, err := ntuc.CreateTask(ctx, &tt.param)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
 			if err != nil {
 				return
 			}
 
-			if !(task.Title == tt.param.Title &&
-				task.Description == tt.param.Description &&
-				task.DueDate == tt.param.DueDate) {
+			if !(task.Title == tt.result.Title &&
+				task.Description == tt.result.Description &&
+				task.DueDate == tt.result.DueDate &&
+				task.DueAt == tt.result.DueAt &&
+				task.AllDay == tt.result.AllDay) {
 				t.Errorf("expected the same fields from cmd: %v but on result model: %v", task, tt.result)
 			}
+
+			if saved != task {
+				t.Errorf("expected the created task to be saved")
+			}
 		})
 	}
 }
@@ -80,15 +155,15 @@ func TestCreateTaskUseCase(t *testing.T) {
 func TestGetTasksUseCase(t *testing.T) {
 	test := []struct {
 		name           string
-		mockGetAllFunc func(ctx context.Context) ([]*models.Task, error)
+		mockGetAllFunc func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
 		result         []*models.Task
 	}{
 		{
 			name: "success",
-			mockGetAllFunc: func(ctx context.Context) ([]*models.Task, error) {
-				return []*models.Task{
+			mockGetAllFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+				return &dtos.TaskPage{Tasks: []*models.Task{
 					{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false},
-				}, nil
+				}}, nil
 			},
 			result: []*models.Task{
 				{Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false},
@@ -97,21 +172,22 @@ func TestGetTasksUseCase(t *testing.T) {
 	}
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
+			ctx := userContext()
 
 			mockRepository := &sqlite.MockTaskRepository{
-				GetAllFunc: tt.mockGetAllFunc,
+				GetAllFunc:           tt.mockGetAllFunc,
+				GetSubtaskCountsFunc: noSubtasks,
 			}
 
-			ntuc := NewTaskUseCase(mockRepository)
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
 
-			tasks, err := ntuc.GetTasks(ctx)
+			page, err := ntuc.GetTasks(ctx, &dtos.GetTasksQuery{})
 			if err != nil {
 				return
 			}
 
-			if len(tasks) != len(tt.result) {
-				t.Errorf("expected: %v but got: %v", len(tt.result), len(tasks))
+			if len(page.Tasks) != len(tt.result) {
+				t.Errorf("expected: %v but got: %v", len(tt.result), len(page.Tasks))
 			}
 		})
 	}
@@ -123,7 +199,7 @@ func TestUpdateUseCase(t *testing.T) {
 		id              string
 		param           *dtos.CreateTaskCommand
 		mockGetByIdFunc func(ctx context.Context, id string) (*models.Task, error)
-		mockUpdate      func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error
+		mockUpdate      func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error
 		result          *models.Task
 	}{
 		{
@@ -135,10 +211,10 @@ func TestUpdateUseCase(t *testing.T) {
 			},
 			mockGetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
 				return &models.Task{
-					Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false,
+					Id: "a495465c-d177-48e1-8954-516bba76d541", Title: "Test Task", Description: "This is a test task", DueDate: "2024-11-22", Overdue: false, Completed: false, Version: 1, OwnerId: testUserId,
 				}, nil
 			},
-			mockUpdate: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) error {
+			mockUpdate: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error {
 				return nil
 			},
 			result: &models.Task{
@@ -149,18 +225,22 @@ func TestUpdateUseCase(t *testing.T) {
 
 	for _, tt := range test {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
+			ctx := userContext()
 
 			mockRepository := &sqlite.MockTaskRepository{
-				GetByIdFunc: tt.mockGetByIdFunc,
-				UpdateFunc:  tt.mockUpdate,
+				GetByIdFunc:          tt.mockGetByIdFunc,
+				UpdateFunc:           tt.mockUpdate,
+				GetSubtaskCountsFunc: noSubtasks,
 			}
 
-			ntuc := NewTaskUseCase(mockRepository)
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+			version := int64(1)
 
 			task, err := ntuc.UpdateTask(ctx, tt.id, &dtos.UpdateTaskCommand{
 				Title:       tt.param.Title,
 				Description: tt.param.Description,
+				Version:     &version,
 			})
 			if err != nil {
 				return
@@ -173,3 +253,1281 @@ func TestUpdateUseCase(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateUseCaseVersionConflict(t *testing.T) {
+	ctx := userContext()
+
+	mockRepository := &sqlite.MockTaskRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return &models.Task{Id: id, Title: "Test Task", Version: 3, OwnerId: testUserId}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand, updatedAt string, record repository.Recorder) error {
+			t.Error("expected Update not to be called on stale version")
+			return nil
+		},
+	}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+	version := int64(2)
+	_, err := ntuc.UpdateTask(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.UpdateTaskCommand{
+		Title:   "Test Task!",
+		Version: &version,
+	})
+	if !errors.Is(err, internalErrors.VersionConflict) {
+		t.Errorf("expected %v, got %v", internalErrors.VersionConflict, err)
+	}
+}
+
+func TestChangeTaskCompletionStatusUseCaseTimestamps(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	test := []struct {
+		name                string
+		stored              *models.Task
+		completed           bool
+		expectedCompletedAt string
+	}{
+		{
+			name:                "complete",
+			stored:              &models.Task{Completed: false, Version: 1, OwnerId: testUserId},
+			completed:           true,
+			expectedCompletedAt: "2024-11-22T10:30:00Z",
+		},
+		{
+			name:                "complete again keeps completion time",
+			stored:              &models.Task{Completed: true, CompletedAt: "2024-11-20T08:00:00Z", Version: 1, OwnerId: testUserId},
+			completed:           true,
+			expectedCompletedAt: "2024-11-20T08:00:00Z",
+		},
+		{
+			name:                "reopen clears completion time",
+			stored:              &models.Task{Completed: true, CompletedAt: "2024-11-20T08:00:00Z", Version: 1, OwnerId: testUserId},
+			completed:           false,
+			expectedCompletedAt: "",
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := userContext()
+
+			var storedCompletedAt, storedUpdatedAt string
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return tt.stored, nil
+				},
+				ChangeCompletionStatusFunc: func(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+					storedCompletedAt, storedUpdatedAt = completedAt, updatedAt
+					return nil, nil
+				},
+				GetSubtaskCountsFunc: noSubtasks,
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+			version := int64(1)
+			task, err := ntuc.ChangeTaskCompletionStatus(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.ChangeTaskCompletionStatusCommand{
+				Completed: &tt.completed,
+				Version:   &version,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if storedCompletedAt != tt.expectedCompletedAt || task.CompletedAt != tt.expectedCompletedAt {
+				t.Errorf("expected completed_at %q, stored %q, returned %q", tt.expectedCompletedAt, storedCompletedAt, task.CompletedAt)
+			}
+			if storedUpdatedAt != "2024-11-22T10:30:00Z" || task.UpdatedAt != storedUpdatedAt {
+				t.Errorf("expected updated_at to be set to the current time, stored %q, returned %q", storedUpdatedAt, task.UpdatedAt)
+			}
+			if task.Version != 2 {
+				t.Errorf("expected version 2, got %d", task.Version)
+			}
+		})
+	}
+}
+
+func TestTaskOwnership(t *testing.T) {
+	mockRepository := &sqlite.MockTaskRepository{
+		SaveFunc: func(ctx context.Context, task *models.Task, record repository.Recorder) error {
+			return nil
+		},
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return &models.Task{Id: id, Title: "Foreign Task", Version: 1, OwnerId: "another-user"}, nil
+		},
+		GetAllFunc: func(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error) {
+			if query.OwnerId != testUserId {
+				t.Errorf("expected tasks to be filtered by owner %s, got %q", testUserId, query.OwnerId)
+			}
+			return &dtos.TaskPage{}, nil
+		},
+		DeleteByIdFunc: func(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+			t.Error("expected DeleteById not to be called for a foreign task")
+			return nil, nil
+		},
+		GetByIdsFunc: func(ctx context.Context, ids []string) ([]*models.Task, error) {
+			return []*models.Task{{Id: "1", OwnerId: testUserId}, {Id: "2", OwnerId: "another-user"}}, nil
+		},
+		GetByParentIdsFunc: func(ctx context.Context, parentIds []string) ([]*models.Task, error) {
+			return []*models.Task{{Id: "3", ParentId: "1", OwnerId: testUserId}, {Id: "4", ParentId: "2", OwnerId: "another-user"}}, nil
+		},
+		GetSubtaskCountsFunc: func(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error) {
+			return map[string]dtos.SubtaskCounts{}, nil
+		},
+	}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+	task, err := ntuc.CreateTask(userContext(), &dtos.CreateTaskCommand{Title: "Test Task"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.OwnerId != testUserId {
+		t.Errorf("expected owner %s, got %q", testUserId, task.OwnerId)
+	}
+
+	if _, err := ntuc.GetTasks(userContext(), &dtos.GetTasksQuery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ntuc.GetTask(userContext(), "a495465c-d177-48e1-8954-516bba76d541"); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.TaskNotFound, err)
+	}
+	if err := ntuc.DeleteTask(userContext(), "a495465c-d177-48e1-8954-516bba76d541", 1); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected %v, got %v", internalErrors.TaskNotFound, err)
+	}
+
+	byIds, err := ntuc.GetTasksByIds(userContext(), []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byIds) != 1 || byIds[0].Id != "1" {
+		t.Errorf("expected foreign tasks to be left out, got %+v", byIds)
+	}
+
+	subtasks, err := ntuc.GetSubtasksOf(userContext(), []string{"1", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtasks) != 1 || len(subtasks["1"]) != 1 || subtasks["1"][0].Id != "3" {
+		t.Errorf("expected foreign subtasks to be left out, got %+v", subtasks)
+	}
+
+	if _, err := ntuc.CreateTask(context.Background(), &dtos.CreateTaskCommand{Title: "Test Task"}); !errors.Is(err, internalErrors.Unauthorized) {
+		t.Errorf("expected %v, got %v", internalErrors.Unauthorized, err)
+	}
+}
+
+func TestMoveTaskUseCase(t *testing.T) {
+	projects := map[string]*models.Project{
+		"active":   {Id: "active", OwnerId: testUserId},
+		"archived": {Id: "archived", OwnerId: testUserId, Archived: true},
+		"foreign":  {Id: "foreign", OwnerId: "another-user"},
+	}
+
+	test := []struct {
+		name        string
+		projectId   *string
+		expectedErr error
+	}{
+		{name: "move into project", projectId: stringPtr("active")},
+		{name: "remove from project", projectId: nil},
+		{name: "archived project", projectId: stringPtr("archived"), expectedErr: internalErrors.ProjectArchived},
+		{name: "foreign project", projectId: stringPtr("foreign"), expectedErr: internalErrors.ProjectNotFound},
+		{name: "missing project", projectId: stringPtr("missing"), expectedErr: internalErrors.ProjectNotFound},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := userContext()
+
+			var storedProjectId string
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return &models.Task{Id: id, Version: 1, OwnerId: testUserId, ProjectId: "active"}, nil
+				},
+				ChangeProjectFunc: func(ctx context.Context, id string, projectId string, version int64, updatedAt string, record repository.Recorder) error {
+					storedProjectId = projectId
+					return nil
+				},
+				GetSubtaskCountsFunc: noSubtasks,
+			}
+			mockProjectRepository := &sqlite.MockProjectRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Project, error) {
+					project, ok := projects[id]
+					if !ok {
+						return nil, internalErrors.ProjectNotFound
+					}
+					return project, nil
+				},
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, mockProjectRepository, &sqlite.MockTagRepository{})
+
+			version := int64(1)
+			task, err := ntuc.MoveTask(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.MoveTaskCommand{
+				ProjectId: tt.projectId,
+				Version:   &version,
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			expected := ""
+			if tt.projectId != nil {
+				expected = *tt.projectId
+			}
+			if storedProjectId != expected || task.ProjectId != expected {
+				t.Errorf("expected project %q, stored %q, returned %q", expected, storedProjectId, task.ProjectId)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func noSubtasks(ctx context.Context, parentIds []string) (map[string]dtos.SubtaskCounts, error) {
+	return map[string]dtos.SubtaskCounts{}, nil
+}
+
+func TestAddTaskTagUseCase(t *testing.T) {
+	ctx := userContext()
+
+	var savedTag *models.Tag
+	var attached [2]string
+
+	mockRepository := &sqlite.MockTaskRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return &models.Task{Id: id, Version: 1, OwnerId: testUserId}, nil
+		},
+		GetSubtaskCountsFunc: noSubtasks,
+	}
+	mockTagRepository := &sqlite.MockTagRepository{
+		GetByNameFunc: func(ctx context.Context, ownerId, name string) (*models.Tag, error) {
+			return nil, internalErrors.TagNotFound
+		},
+		SaveFunc: func(ctx context.Context, tag *models.Tag) error {
+			savedTag = tag
+			return nil
+		},
+		AttachFunc: func(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+			attached = [2]string{taskId, tagId}
+			return nil
+		},
+	}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, mockTagRepository)
+
+	_, err := ntuc.AddTaskTag(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.TagCommand{Name: "bug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if savedTag == nil || savedTag.Name != "bug" || savedTag.OwnerId != testUserId {
+		t.Fatalf("expected missing tag to be created for the user, got %+v", savedTag)
+	}
+	if attached != [2]string{"a495465c-d177-48e1-8954-516bba76d541", savedTag.Id} {
+		t.Errorf("expected created tag to be attached, got %v", attached)
+	}
+}
+
+func TestCreateSubtaskUseCase(t *testing.T) {
+	// 1 <- 2 <- 3 is a chain of subtasks, 4 is completed.
+	stored := map[string]*models.Task{
+		"1": {Id: "1", Version: 1, OwnerId: testUserId, ProjectId: "p1"},
+		"2": {Id: "2", Version: 1, OwnerId: testUserId, ProjectId: "p1", ParentId: "1"},
+		"3": {Id: "3", Version: 1, OwnerId: testUserId, ProjectId: "p1", ParentId: "2"},
+		"4": {Id: "4", Version: 1, OwnerId: testUserId, Completed: true},
+	}
+
+	test := []struct {
+		name        string
+		parentId    string
+		maxDepth    int
+		expectedErr error
+	}{
+		{name: "first level", parentId: "1", maxDepth: 3},
+		{name: "deepest level", parentId: "3", maxDepth: 3},
+		{name: "too deep", parentId: "3", maxDepth: 2, expectedErr: internalErrors.SubtaskDepthExceeded},
+		{name: "completed parent", parentId: "4", maxDepth: 3, expectedErr: internalErrors.ParentTaskCompleted},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *models.Task
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					task := *stored[id]
+					return &task, nil
+				},
+				SaveFunc: func(ctx context.Context, task *models.Task, record repository.Recorder) error {
+					saved = task
+					return nil
+				},
+			}
+			mockProjectRepository := &sqlite.MockProjectRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Project, error) {
+					return &models.Project{Id: id, OwnerId: testUserId}, nil
+				},
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, mockProjectRepository, &sqlite.MockTagRepository{})
+			ntuc.SetMaxSubtaskDepth(tt.maxDepth)
+
+			task, err := ntuc.CreateSubtask(userContext(), tt.parentId, &dtos.CreateTaskCommand{Title: "Step", ProjectId: "p2"})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				if saved != nil {
+					t.Errorf("expected no task to be saved, got %+v", saved)
+				}
+				return
+			}
+
+			if saved != task || task.ParentId != tt.parentId || task.ProjectId != stored[tt.parentId].ProjectId {
+				t.Errorf("expected subtask of %s in the project of the parent, got %+v", tt.parentId, task)
+			}
+		})
+	}
+}
+
+func TestChangeTaskCompletionStatusSubtasks(t *testing.T) {
+	clock.Freeze(t, time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	ctx := userContext()
+
+	store := memory.NewStore()
+	ntuc := NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	ntuc.SetHistory(memory.NewHistoryRepository(store))
+
+	published := &recorder{}
+	ntuc.SetPublisher(published)
+
+	parent, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Chores"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ntuc.CreateSubtask(ctx, parent.Id, &dtos.CreateTaskCommand{Title: "Water plants", DueDate: "2024-11-22", Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grandchild, err := ntuc.CreateSubtask(ctx, child.Id, &dtos.CreateTaskCommand{Title: "Fill the can"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	published.types = nil
+
+	completed := true
+	task, err := ntuc.ChangeTaskCompletionStatus(ctx, parent.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &parent.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The recurring subtask is completed like any other task, so its series
+	// goes on under the parent.
+	subtasks, err := ntuc.GetSubtasksOf(ctx, []string{parent.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtasks[parent.Id]) != 2 {
+		t.Fatalf("expected the subtask and its next occurrence, got %+v", subtasks[parent.Id])
+	}
+	if got := subtasks[parent.Id][0]; !got.Completed || got.Version != 2 {
+		t.Errorf("expected the subtask to be completed, got %+v", got)
+	}
+	if next := subtasks[parent.Id][1]; next.Completed || next.DueDate != "2024-11-23" || next.Occurrence != 2 {
+		t.Errorf("expected the next occurrence of the subtask, got %+v", next)
+	}
+	if task.Progress == nil || *task.Progress != 50 {
+		t.Errorf("expected progress 50, got %v", task.Progress)
+	}
+	if got, err := ntuc.GetTask(ctx, grandchild.Id); err != nil || !got.Completed {
+		t.Errorf("expected the nested subtask to be completed, got %+v, %v", got, err)
+	}
+
+	expected := []string{events.TaskCreated, events.TaskCompleted, events.TaskCompleted, events.TaskCompleted}
+	if !reflect.DeepEqual(published.types, expected) {
+		t.Errorf("expected events %v, got %v", expected, published.types)
+	}
+
+	page, err := ntuc.GetTaskHistory(ctx, child.Id, &dtos.GetHistoryQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Action != models.HistoryCompleted || page.Entries[0].Version != 2 {
+		t.Errorf("expected the completion of the subtask to be recorded, got %+v", page.Entries)
+	}
+
+	published.types = nil
+
+	reopen, version := false, int64(2)
+	if _, err := ntuc.ChangeTaskCompletionStatus(ctx, grandchild.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &reopen, Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{parent.Id, child.Id} {
+		got, err := ntuc.GetTask(ctx, id)
+		if err != nil || got.Completed || got.Version != 3 {
+			t.Errorf("expected ancestor %s to be reopened, got %+v, %v", id, got, err)
+		}
+
+		page, err := ntuc.GetTaskHistory(ctx, id, &dtos.GetHistoryQuery{Limit: 1})
+		if err != nil || page.Entries[0].Action != models.HistoryReopened {
+			t.Errorf("expected the reopening of %s to be recorded, got %+v, %v", id, page, err)
+		}
+	}
+	if expected := []string{events.TaskUpdated, events.TaskUpdated, events.TaskUpdated}; !reflect.DeepEqual(published.types, expected) {
+		t.Errorf("expected events %v, got %v", expected, published.types)
+	}
+}
+
+func TestCompleteBlockedTaskUseCase(t *testing.T) {
+	ctx := userContext()
+
+	test := []struct {
+		name        string
+		force       bool
+		expectedErr error
+	}{
+		{name: "open blockers", expectedErr: internalErrors.TaskBlocked},
+		{name: "forced", force: true},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := false
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return &models.Task{Id: id, Version: 1, OwnerId: testUserId, BlockedBy: []string{"blocker"}, Blocked: true}, nil
+				},
+				ChangeCompletionStatusFunc: func(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+					changed = true
+					return nil, nil
+				},
+				GetSubtaskCountsFunc: noSubtasks,
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+			completed := true
+			version := int64(1)
+			_, err := ntuc.ChangeTaskCompletionStatus(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.ChangeTaskCompletionStatusCommand{
+				Completed: &completed,
+				Version:   &version,
+				Force:     tt.force,
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if changed != (tt.expectedErr == nil) {
+				t.Errorf("expected status change only without error, changed: %v", changed)
+			}
+		})
+	}
+}
+
+func TestRecurringTaskCompletionUseCase(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+
+	test := []struct {
+		name            string
+		recurrence      string
+		dueDate         string
+		occurrence      int
+		lastOccurrence  int
+		loc             *time.Location
+		expectedDueDate string
+	}{
+		{name: "next day", recurrence: "FREQ=DAILY", dueDate: "2024-11-22", occurrence: 1, lastOccurrence: 1, expectedDueDate: "2024-11-23"},
+		{name: "completed late skips past dates", recurrence: "FREQ=WEEKLY;BYDAY=MO,TH", dueDate: "2024-11-04", occurrence: 3, lastOccurrence: 3, expectedDueDate: "2024-11-25"},
+		{name: "count reached", recurrence: "FREQ=DAILY;COUNT=3", dueDate: "2024-11-22", occurrence: 3, lastOccurrence: 3},
+		{name: "until passed", recurrence: "FREQ=MONTHLY;BYMONTHDAY=22;UNTIL=20241231", dueDate: "2024-12-22", occurrence: 2, lastOccurrence: 2},
+		{name: "next occurrence exists", recurrence: "FREQ=DAILY", dueDate: "2024-11-22", occurrence: 1, lastOccurrence: 2},
+		{name: "due time is kept", recurrence: "FREQ=DAILY", dueDate: "2024-11-21T15:00:00Z", occurrence: 1, lastOccurrence: 1, expectedDueDate: "2024-11-22T15:00:00Z"},
+		{name: "due time is kept in the user's time zone", recurrence: "FREQ=WEEKLY;BYDAY=FR", dueDate: "2024-11-21T23:30:00Z", occurrence: 1, lastOccurrence: 1, loc: moscow, expectedDueDate: "2024-11-29T02:30:00+03:00"},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *models.Task
+			var attached [2]string
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					return &models.Task{
+						Id: id, Title: "Water plants", DueDate: tt.dueDate, Priority: models.PriorityHigh, Version: 1, OwnerId: testUserId,
+						Recurrence: tt.recurrence, SeriesId: "series", Occurrence: tt.occurrence, Tags: []string{"home"},
+					}, nil
+				},
+				ChangeCompletionStatusFunc: func(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+					return nil, nil
+				},
+				LastOccurrenceFunc: func(ctx context.Context, seriesId string) (int, error) {
+					return tt.lastOccurrence, nil
+				},
+				SaveFunc: func(ctx context.Context, task *models.Task, record repository.Recorder) error {
+					saved = task
+					return nil
+				},
+				GetSubtaskCountsFunc: noSubtasks,
+			}
+			mockTagRepository := &sqlite.MockTagRepository{
+				GetByNameFunc: func(ctx context.Context, ownerId, name string) (*models.Tag, error) {
+					return &models.Tag{Id: "tag-" + name, Name: name, OwnerId: ownerId}, nil
+				},
+				AttachFunc: func(ctx context.Context, taskId, tagId string, record repository.Recorder) error {
+					attached = [2]string{taskId, tagId}
+					return nil
+				},
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, mockTagRepository)
+
+			completed := true
+			version := int64(1)
+			ctx := auth.WithTimeZone(userContext(), tt.loc)
+			_, err := ntuc.ChangeTaskCompletionStatus(ctx, "a495465c-d177-48e1-8954-516bba76d541", &dtos.ChangeTaskCompletionStatusCommand{
+				Completed: &completed,
+				Version:   &version,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectedDueDate == "" {
+				if saved != nil {
+					t.Errorf("expected no next occurrence, got %+v", saved)
+				}
+				return
+			}
+
+			if saved == nil {
+				t.Fatal("expected next occurrence to be created")
+			}
+			if saved.DueDate != tt.expectedDueDate || saved.SeriesId != "series" || saved.Occurrence != tt.occurrence+1 {
+				t.Errorf("expected occurrence %d of series due %s, got %+v", tt.occurrence+1, tt.expectedDueDate, saved)
+			}
+			if saved.Title != "Water plants" || saved.Priority != models.PriorityHigh || saved.Recurrence != tt.recurrence || saved.Completed {
+				t.Errorf("expected an open copy of the completed task, got %+v", saved)
+			}
+			if attached != [2]string{saved.Id, "tag-home"} {
+				t.Errorf("expected tags to be copied, got %v", attached)
+			}
+		})
+	}
+}
+
+// recorder is a publisher that keeps the types of the published events.
+type recorder struct {
+	types []string
+}
+
+func (r *recorder) Publish(ctx context.Context, event *events.Event) {
+	if event.Id == "" || event.Task == nil || event.OwnerId != event.Task.OwnerId {
+		panic("incomplete event")
+	}
+	r.types = append(r.types, event.Type)
+}
+
+func TestTaskEvents(t *testing.T) {
+	ctx := userContext()
+
+	mockRepository := &sqlite.MockTaskRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return &models.Task{Id: id, Version: 1, OwnerId: testUserId}, nil
+		},
+		ChangeCompletionStatusFunc: func(ctx context.Context, id string, completionStatus bool, version int64, completedAt, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+			return nil, nil
+		},
+		DeleteByIdFunc: func(ctx context.Context, id string, version int64, deletedAt string, record repository.Recorder) ([]*models.Task, error) {
+			return []*models.Task{
+				{Id: id, OwnerId: testUserId, DeletedAt: deletedAt},
+				{Id: "3", ParentId: id, OwnerId: testUserId, DeletedAt: deletedAt},
+			}, nil
+		},
+		GetTrashedByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return &models.Task{Id: id, Version: 2, OwnerId: testUserId, DeletedAt: "2024-11-20T10:00:00Z"}, nil
+		},
+		RestoreFunc: func(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+			return []*models.Task{
+				{Id: id, OwnerId: testUserId},
+				{Id: "3", ParentId: id, OwnerId: testUserId},
+			}, nil
+		},
+		UpdateOverdueTasksFunc: func(ctx context.Context, now time.Time, record repository.Recorder) ([]*models.Task, error) {
+			return []*models.Task{
+				{Id: "1", Overdue: true, OwnerId: testUserId},
+				{Id: "2", Overdue: true, Completed: true, OwnerId: testUserId},
+			}, nil
+		},
+		GetSubtaskCountsFunc: noSubtasks,
+	}
+
+	published := &recorder{}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+	ntuc.SetPublisher(published)
+
+	completed, version := true, int64(1)
+	if _, err := ntuc.ChangeTaskCompletionStatus(ctx, "1", &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ntuc.DeleteTask(ctx, "1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.RestoreTask(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ntuc.UpdateOverdueTasks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting and restoring a task tells about each subtask it reached.
+	expected := []string{events.TaskCompleted, events.TaskDeleted, events.TaskDeleted, events.TaskRestored, events.TaskRestored, events.TaskOverdue}
+	if len(published.types) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, published.types)
+	}
+	for i := range expected {
+		if published.types[i] != expected[i] {
+			t.Errorf("expected events %v, got %v", expected, published.types)
+		}
+	}
+}
+
+func TestRestoreTaskUseCase(t *testing.T) {
+	trash := map[string]*models.Task{
+		"alone":   {Id: "alone", Version: 2, OwnerId: testUserId, DeletedAt: "2024-11-20T10:00:00Z"},
+		"orphan":  {Id: "orphan", Version: 2, OwnerId: testUserId, ParentId: "trashed", DeletedAt: "2024-11-20T10:00:00Z"},
+		"adopted": {Id: "adopted", Version: 2, OwnerId: testUserId, ParentId: "live", DeletedAt: "2024-11-20T10:00:00Z"},
+		"foreign": {Id: "foreign", Version: 2, OwnerId: "another-user", DeletedAt: "2024-11-20T10:00:00Z"},
+		"trashed": {Id: "trashed", Version: 2, OwnerId: testUserId, DeletedAt: "2024-11-20T09:00:00Z"},
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		expectedErr error
+	}{
+		{name: "success", id: "alone"},
+		{name: "parent outside the trash", id: "adopted"},
+		{name: "parent in the trash", id: "orphan", expectedErr: internalErrors.ParentTaskDeleted},
+		{name: "foreign task", id: "foreign", expectedErr: internalErrors.TaskNotFound},
+		{name: "not in the trash", id: "live", expectedErr: internalErrors.TaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+			clock.Freeze(t, frozen)
+
+			restored := map[string]string{}
+
+			mockRepository := &sqlite.MockTaskRepository{
+				GetTrashedByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					task, ok := trash[id]
+					if !ok {
+						return nil, internalErrors.TaskNotFound
+					}
+					return task, nil
+				},
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+					if updatedAt, ok := restored[id]; ok {
+						return &models.Task{Id: id, Version: 3, OwnerId: testUserId, UpdatedAt: updatedAt}, nil
+					}
+					if _, ok := trash[id]; ok {
+						return nil, internalErrors.TaskNotFound
+					}
+					return &models.Task{Id: id, Version: 1, OwnerId: testUserId}, nil
+				},
+				RestoreFunc: func(ctx context.Context, id string, updatedAt string, record repository.Recorder) ([]*models.Task, error) {
+					restored[id] = updatedAt
+					return []*models.Task{{Id: id, Version: 3, OwnerId: testUserId, UpdatedAt: updatedAt}}, nil
+				},
+				GetSubtaskCountsFunc: noSubtasks,
+			}
+
+			ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+			task, err := ntuc.RestoreTask(userContext(), tt.id)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				if len(restored) != 0 {
+					t.Errorf("expected nothing to be restored, got %v", restored)
+				}
+				return
+			}
+
+			if task.Id != tt.id || task.UpdatedAt != "2024-11-22T10:30:00Z" {
+				t.Errorf("expected the restored task, got %+v", task)
+			}
+		})
+	}
+}
+
+func TestPurgeTaskUseCase(t *testing.T) {
+	var purged []string
+
+	mockRepository := &sqlite.MockTaskRepository{
+		GetTrashedByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			if id == "live" {
+				return nil, internalErrors.TaskNotFound
+			}
+			return &models.Task{Id: id, OwnerId: id, DeletedAt: "2024-11-20T10:00:00Z"}, nil
+		},
+		PurgeFunc: func(ctx context.Context, id string) error {
+			purged = append(purged, id)
+			return nil
+		},
+		PurgeDeletedBeforeFunc: func(ctx context.Context, before string) (int64, error) {
+			if before != "2024-10-23T10:30:00Z" {
+				t.Errorf("expected tasks trashed before 2024-10-23T10:30:00Z to be purged, got %s", before)
+			}
+			return 2, nil
+		},
+	}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+
+	if err := ntuc.PurgeTask(userContext(), testUserId); err != nil {
+		t.Fatal(err)
+	}
+	if err := ntuc.PurgeTask(userContext(), "another-user"); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected %v for a foreign task, got %v", internalErrors.TaskNotFound, err)
+	}
+	if err := ntuc.PurgeTask(userContext(), "live"); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected %v for a task outside the trash, got %v", internalErrors.TaskNotFound, err)
+	}
+	if !reflect.DeepEqual(purged, []string{testUserId}) {
+		t.Errorf("expected only the own trashed task to be purged, got %v", purged)
+	}
+
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	count, err := ntuc.PurgeTrash(context.Background(), 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 purged tasks, got %d", count)
+	}
+}
+
+func TestTaskHistoryUseCase(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	ctx := userContext()
+
+	store := memory.NewStore()
+	ntuc := NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	ntuc.SetHistory(memory.NewHistoryRepository(store))
+
+	task, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Draft", DueDate: "2024-11-23"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version := task.Version
+	if task, err = ntuc.UpdateTask(ctx, task.Id, &dtos.UpdateTaskCommand{Title: "Report", Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	if task, err = ntuc.AddTaskTag(ctx, task.Id, &dtos.TagCommand{Name: "work"}); err != nil {
+		t.Fatal(err)
+	}
+	// Attaching the tag again changes nothing and is not recorded.
+	if task, err = ntuc.AddTaskTag(ctx, task.Id, &dtos.TagCommand{Name: "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	completed := true
+	version = task.Version
+	if task, err = ntuc.ChangeTaskCompletionStatus(ctx, task.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rest happens the day after the task was due.
+	clock.Freeze(t, frozen.AddDate(0, 0, 2))
+
+	if err := ntuc.UpdateOverdueTasks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if task, err = ntuc.GetTask(ctx, task.Id); err != nil || !task.Overdue {
+		t.Fatalf("expected the task to be overdue, got %+v, %v", task, err)
+	}
+
+	if err := ntuc.DeleteTask(ctx, task.Id, task.Version); err != nil {
+		t.Fatal(err)
+	}
+	if page, err := ntuc.GetTaskHistory(ctx, task.Id, &dtos.GetHistoryQuery{Limit: 1}); err != nil || page.Entries[0].Action != models.HistoryDeleted {
+		t.Errorf("expected the history of a task in the trash, got %+v, %v", page, err)
+	}
+	if _, err := ntuc.RestoreTask(ctx, task.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := ntuc.GetTaskHistory(ctx, task.Id, &dtos.GetHistoryQuery{Limit: dtos.DefaultTasksLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actions []string
+	for _, entry := range page.Entries {
+		actions = append(actions, entry.Action)
+
+		expectedActor, expectedAt := testUserId, "2024-11-22T10:30:00Z"
+		if entry.Action == models.HistoryOverdue {
+			expectedActor = models.ActorSystem
+		}
+		if entry.Action == models.HistoryOverdue || entry.Action == models.HistoryDeleted || entry.Action == models.HistoryRestored {
+			expectedAt = "2024-11-24T10:30:00Z"
+		}
+		if entry.ActorId != expectedActor || entry.TaskId != task.Id || entry.CreatedAt != expectedAt {
+			t.Errorf("expected an entry of the task by %s, got %+v", expectedActor, entry)
+		}
+	}
+
+	expected := []string{
+		models.HistoryRestored,
+		models.HistoryDeleted,
+		models.HistoryOverdue,
+		models.HistoryCompleted,
+		models.HistoryUpdated,
+		models.HistoryUpdated,
+		models.HistoryCreated,
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, actions)
+	}
+
+	changes := func(entry *models.HistoryEntry) string {
+		data, _ := json.Marshal(entry.Changes)
+		return string(data)
+	}
+	if got := changes(page.Entries[5]); got != `[{"field":"title","before":"Draft","after":"Report"}]` {
+		t.Errorf("expected the title change, got %s", got)
+	}
+	if got := changes(page.Entries[4]); got != `[{"field":"tags","before":[],"after":["work"]}]` {
+		t.Errorf("expected the tag change, got %s", got)
+	}
+	if got := changes(page.Entries[2]); got != `[{"field":"overdue","before":false,"after":true}]` {
+		t.Errorf("expected the overdue change, got %s", got)
+	}
+	if page.Entries[2].Version != 5 || page.Entries[0].Version != 7 || page.Entries[6].Version != 1 {
+		t.Errorf("expected entries to hold the version after the change, got %d, %d and %d", page.Entries[2].Version, page.Entries[0].Version, page.Entries[6].Version)
+	}
+
+	first, err := ntuc.GetTaskHistory(ctx, task.Id, &dtos.GetHistoryQuery{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := ntuc.GetTaskHistory(ctx, task.Id, &dtos.GetHistoryQuery{Limit: 4, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Entries) != 4 || len(rest.Entries) != 3 || rest.NextCursor != "" || rest.Entries[2].Action != models.HistoryCreated {
+		t.Errorf("expected the history on two pages, got %+v and %+v", first, rest)
+	}
+
+	foreign := auth.WithUserId(context.Background(), "another-user")
+	if _, err := ntuc.GetTaskHistory(foreign, task.Id, &dtos.GetHistoryQuery{Limit: 10}); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected %v for a foreign task, got %v", internalErrors.TaskNotFound, err)
+	}
+}
+
+func TestSubtaskHistoryUseCase(t *testing.T) {
+	clock.Freeze(t, time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	ctx := userContext()
+
+	store := memory.NewStore()
+	ntuc := NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	ntuc.SetHistory(memory.NewHistoryRepository(store))
+
+	parent, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Move"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ntuc.CreateSubtask(ctx, parent.Id, &dtos.CreateTaskCommand{Title: "Pack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grandchild, err := ntuc.CreateSubtask(ctx, child.Id, &dtos.CreateTaskCommand{Title: "Buy boxes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ntuc.DeleteTask(ctx, parent.Id, parent.Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.RestoreTask(ctx, parent.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, task := range []*models.Task{parent, child, grandchild} {
+		page, err := ntuc.GetTaskHistory(ctx, task.Id, &dtos.GetHistoryQuery{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actions []string
+		for _, entry := range page.Entries {
+			actions = append(actions, entry.Action)
+		}
+		if expected := []string{models.HistoryRestored, models.HistoryDeleted, models.HistoryCreated}; !reflect.DeepEqual(actions, expected) {
+			t.Errorf("expected %s to be recorded as deleted and restored %v, got %v", task.Title, expected, actions)
+		}
+		if page.Entries[1].ActorId != testUserId || page.Entries[1].Version != 2 {
+			t.Errorf("expected the deletion of %s by the user, got %+v", task.Title, page.Entries[1])
+		}
+	}
+}
+
+func TestTaskHistoryRecordedWithChange(t *testing.T) {
+	ctx := userContext()
+
+	var actions []string
+	record := func(record repository.Recorder, before, after *models.Task) {
+		if entry := record(before, after); entry != nil {
+			actions = append(actions, entry.Action)
+		}
+	}
+	stored := func() *models.Task {
+		return &models.Task{Id: "1", Title: "Test", Version: 1, ProjectId: "home", OwnerId: testUserId}
+	}
+	changed := func(change func(task *models.Task)) *models.Task {
+		task := stored()
+		change(task)
+		task.Version++
+		return task
+	}
+
+	mockRepository := &sqlite.MockTaskRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Task, error) {
+			return stored(), nil
+		},
+		SaveFunc: func(ctx context.Context, task *models.Task, r repository.Recorder) error {
+			record(r, nil, task)
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, cmd *dtos.UpdateTaskCommand, updatedAt string, r repository.Recorder) error {
+			record(r, stored(), changed(func(task *models.Task) { task.Title = cmd.Title }))
+			return nil
+		},
+		ChangeProjectFunc: func(ctx context.Context, id string, projectId string, version int64, updatedAt string, r repository.Recorder) error {
+			record(r, stored(), changed(func(task *models.Task) { task.ProjectId = projectId }))
+			return nil
+		},
+		AddBlockerFunc: func(ctx context.Context, taskId, blockerId string, r repository.Recorder) error {
+			record(r, stored(), changed(func(task *models.Task) { task.BlockedBy = []string{blockerId} }))
+			return nil
+		},
+		UpdateOverdueTasksFunc: func(ctx context.Context, now time.Time, r repository.Recorder) ([]*models.Task, error) {
+			overdue := changed(func(task *models.Task) { task.Overdue = true })
+			record(r, stored(), overdue)
+			return []*models.Task{overdue}, nil
+		},
+		GetSubtaskCountsFunc: noSubtasks,
+	}
+	mockHistory := &sqlite.MockHistoryRepository{
+		SaveFunc: func(ctx context.Context, entry *models.HistoryEntry) error {
+			t.Errorf("expected %s to be recorded by the repository with the change", entry.Action)
+			return nil
+		},
+	}
+
+	ntuc := NewTaskUseCase(mockRepository, &sqlite.MockProjectRepository{}, &sqlite.MockTagRepository{})
+	ntuc.SetHistory(mockHistory)
+
+	version := int64(1)
+	if _, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.UpdateTask(ctx, "1", &dtos.UpdateTaskCommand{Title: "Renamed", Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.MoveTask(ctx, "1", &dtos.MoveTaskCommand{Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.AddTaskBlocker(ctx, "1", &dtos.BlockerCommand{BlockerId: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ntuc.UpdateOverdueTasks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{models.HistoryCreated, models.HistoryUpdated, models.HistoryUpdated, models.HistoryUpdated, models.HistoryOverdue}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("expected %v, got %v", expected, actions)
+	}
+}
+
+func TestUndoRedoUseCase(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+
+	ctx := userContext()
+
+	store := memory.NewStore()
+	taskRep := memory.NewTaskRepository(store)
+	ntuc := NewTaskUseCase(taskRep, memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	ntuc.SetHistory(memory.NewHistoryRepository(store))
+
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1}); !errors.Is(err, internalErrors.NothingToUndo) {
+		t.Errorf("expected %v without changes, got %v", internalErrors.NothingToUndo, err)
+	}
+
+	report, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Draft", DueDate: "2024-11-23"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := report.Version
+	if report, err = ntuc.UpdateTask(ctx, report.Id, &dtos.UpdateTaskCommand{Title: "Report", Description: "Q4", DueDate: "2024-11-25T18:00:00Z", Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	completed := true
+	version = report.Version
+	if report, err = ntuc.ChangeTaskCompletionStatus(ctx, report.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	draft, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Old draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ntuc.DeleteTask(ctx, draft.Id, draft.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating the draft is skipped, it cannot be undone.
+	result, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || len(result.Tasks) != 2 || result.Entries[0].TaskId != draft.Id || result.Entries[1].TaskId != report.Id {
+		t.Fatalf("expected the deletion and the completion to be undone, got %+v", result)
+	}
+	for _, entry := range result.Entries {
+		if entry.Action != models.HistoryUndone || entry.Reverts == "" || entry.ActorId != testUserId {
+			t.Errorf("expected an undone entry of the user, got %+v", entry)
+		}
+	}
+	if got, err := ntuc.GetTask(ctx, draft.Id); err != nil || got.DeletedAt != "" {
+		t.Errorf("expected the draft to be out of the trash, got %+v, %v", got, err)
+	}
+	got, err := ntuc.GetTask(ctx, report.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Completed || got.CompletedAt != "" || got.Version != report.Version+1 {
+		t.Errorf("expected the report to be open again, got %+v", got)
+	}
+
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = ntuc.GetTask(ctx, report.Id)
+	if got.Title != "Draft" || got.Description != "" || got.DueDate != "2024-11-23" || !got.AllDay || got.DueAt != "2024-11-24T00:00:00Z" {
+		t.Errorf("expected the update to be undone, got %+v", got)
+	}
+
+	if _, err := ntuc.Redo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = ntuc.GetTask(ctx, report.Id)
+	if got.Title != "Report" || got.Description != "Q4" || got.AllDay || got.DueAt != "2024-11-25T18:00:00Z" {
+		t.Errorf("expected the update to be redone, got %+v", got)
+	}
+
+	// Fewer changes than asked for are left to redo.
+	result, err = ntuc.Redo(ctx, &dtos.UndoCommand{Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].TaskId != report.Id || result.Entries[1].TaskId != draft.Id {
+		t.Fatalf("expected the completion and the deletion to be redone, got %+v", result)
+	}
+	if _, err := ntuc.Redo(ctx, &dtos.UndoCommand{Count: 1}); !errors.Is(err, internalErrors.NothingToRedo) {
+		t.Errorf("expected %v, got %v", internalErrors.NothingToRedo, err)
+	}
+	if got, err := ntuc.GetTask(ctx, report.Id); err != nil || !got.Completed {
+		t.Errorf("expected the report to be completed again, got %+v, %v", got, err)
+	}
+	if _, err := ntuc.GetTask(ctx, draft.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected the draft to be in the trash again, got %v", err)
+	}
+
+	// The report is reopened behind the back of the history, so undoing both
+	// changes fails and leaves the draft in the trash.
+	got, _ = ntuc.GetTask(ctx, report.Id)
+	if _, err := taskRep.ChangeCompletionStatus(ctx, report.Id, false, got.Version, "", clock.Timestamp(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 2}); !errors.Is(err, internalErrors.UndoConflict) {
+		t.Errorf("expected %v, got %v", internalErrors.UndoConflict, err)
+	}
+	if _, err := ntuc.GetTask(ctx, draft.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected the conflict to leave the draft in the trash, got %v", err)
+	}
+
+	// A new change after an undo drops what could be redone.
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	draft, _ = ntuc.GetTask(ctx, draft.Id)
+	version = draft.Version
+	if _, err := ntuc.UpdateTask(ctx, draft.Id, &dtos.UpdateTaskCommand{Title: "Kept draft", Version: &version}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.Redo(ctx, &dtos.UndoCommand{Count: 1}); !errors.Is(err, internalErrors.NothingToRedo) {
+		t.Errorf("expected %v after a new change, got %v", internalErrors.NothingToRedo, err)
+	}
+
+	foreign := auth.WithUserId(context.Background(), "another-user")
+	if _, err := ntuc.Undo(foreign, &dtos.UndoCommand{Count: 1}); !errors.Is(err, internalErrors.NothingToUndo) {
+		t.Errorf("expected %v for another user, got %v", internalErrors.NothingToUndo, err)
+	}
+}
+
+func TestUndoSubtasksUseCase(t *testing.T) {
+	clock.Freeze(t, time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	ctx := userContext()
+
+	store := memory.NewStore()
+	ntuc := NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+	ntuc.SetHistory(memory.NewHistoryRepository(store))
+
+	parent, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Move"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ntuc.CreateSubtask(ctx, parent.Id, &dtos.CreateTaskCommand{Title: "Pack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the parent takes the child to the trash, and one undo brings
+	// both back.
+	if err := ntuc.DeleteTask(ctx, parent.Id, parent.Version); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].TaskId != parent.Id || result.Entries[1].TaskId != child.Id || result.Entries[1].Cause != result.Entries[0].Id {
+		t.Fatalf("expected the deletion of the parent and the child to be undone together, got %+v", result.Entries)
+	}
+	for _, task := range []*models.Task{parent, child} {
+		if got, err := ntuc.GetTask(ctx, task.Id); err != nil || got.DeletedAt != "" {
+			t.Errorf("expected %s to be out of the trash, got %+v, %v", task.Title, got, err)
+		}
+	}
+
+	if _, err := ntuc.Redo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []*models.Task{parent, child} {
+		if _, err := ntuc.GetTask(ctx, task.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+			t.Errorf("expected %s to be in the trash again, got %v", task.Title, err)
+		}
+	}
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Completing the parent completes the child, and one undo reopens both.
+	parent, _ = ntuc.GetTask(ctx, parent.Id)
+	completed := true
+	if _, err := ntuc.ChangeTaskCompletionStatus(ctx, parent.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &parent.Version}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.Undo(ctx, &dtos.UndoCommand{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range []*models.Task{parent, child} {
+		if got, err := ntuc.GetTask(ctx, task.Id); err != nil || got.Completed {
+			t.Errorf("expected %s to be open again, got %+v, %v", task.Title, got, err)
+		}
+	}
+}
+
+// TestTaskUseCaseOnMemoryRepository runs the use case against real
+// repositories instead of mocks, so the repository calls have to fit
+// together.
+func TestTaskUseCaseOnMemoryRepository(t *testing.T) {
+	ctx := userContext()
+
+	store := memory.NewStore()
+	ntuc := NewTaskUseCase(memory.NewTaskRepository(store), memory.NewProjectRepository(store), memory.NewTagRepository(store))
+
+	parent, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Move"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtask, err := ntuc.CreateSubtask(ctx, parent.Id, &dtos.CreateTaskCommand{Title: "Pack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocker, err := ntuc.CreateTask(ctx, &dtos.CreateTaskCommand{Title: "Book a van"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ntuc.AddTaskBlocker(ctx, parent.Id, &dtos.BlockerCommand{BlockerId: blocker.Id}); err != nil {
+		t.Fatal(err)
+	}
+	tagged, err := ntuc.AddTaskTag(ctx, parent.Id, &dtos.TagCommand{Name: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	completed := true
+	version := tagged.Version
+	_, err = ntuc.ChangeTaskCompletionStatus(ctx, parent.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &version})
+	if !errors.Is(err, internalErrors.TaskBlocked) {
+		t.Fatalf("expected %v, got %v", internalErrors.TaskBlocked, err)
+	}
+
+	if _, err := ntuc.ChangeTaskCompletionStatus(ctx, blocker.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &blocker.Version}); err != nil {
+		t.Fatal(err)
+	}
+	done, err := ntuc.ChangeTaskCompletionStatus(ctx, parent.Id, &dtos.ChangeTaskCompletionStatusCommand{Completed: &completed, Version: &version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Progress == nil || *done.Progress != 100 {
+		t.Errorf("expected subtasks to be completed with the parent, got %+v", done)
+	}
+
+	page, err := ntuc.GetTasks(ctx, &dtos.GetTasksQuery{Tags: []string{"HOME"}, Limit: dtos.DefaultTasksLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].Id != parent.Id {
+		t.Errorf("expected the tagged task, got %+v", page.Tasks)
+	}
+
+	if err := ntuc.DeleteTask(ctx, parent.Id, done.Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ntuc.GetTask(ctx, subtask.Id); !errors.Is(err, internalErrors.TaskNotFound) {
+		t.Errorf("expected subtask to be deleted with its parent, got %v", err)
+	}
+}
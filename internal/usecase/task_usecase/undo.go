@@ -0,0 +1,392 @@
+package task_usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"reflect"
+	"time"
+)
+
+// revertibleFields are the history fields undo sets back, by their JSON
+// names, with the field of the task that holds each one. Tags, blockers and
+// the overdue flag are left as they are.
+var revertibleFields = map[string]func(task *models.Task) interface{}{
+	"title":        func(task *models.Task) interface{} { return &task.Title },
+	"description":  func(task *models.Task) interface{} { return &task.Description },
+	"due_date":     func(task *models.Task) interface{} { return &task.DueDate },
+	"all_day":      func(task *models.Task) interface{} { return &task.AllDay },
+	"completed":    func(task *models.Task) interface{} { return &task.Completed },
+	"completed_at": func(task *models.Task) interface{} { return &task.CompletedAt },
+	"priority":     func(task *models.Task) interface{} { return &task.Priority },
+	"project_id":   func(task *models.Task) interface{} { return &task.ProjectId },
+	"recurrence":   func(task *models.Task) interface{} { return &task.Recurrence },
+	"deleted_at":   func(task *models.Task) interface{} { return &task.DeletedAt },
+}
+
+// revertCommandFields names the fields of dtos.RevertTaskCommand that are
+// set together with another one.
+var revertCommandFields = map[string]string{
+	"all_day":      "due_date",
+	"completed_at": "completed",
+}
+
+// revertible reports whether undo can set back anything the entry changed.
+// Creating a task is not undone, the task can be deleted instead.
+func revertible(entry *models.HistoryEntry) bool {
+	switch entry.Action {
+	case models.HistoryUpdated, models.HistoryCompleted, models.HistoryReopened, models.HistoryDeleted,
+		models.HistoryRestored, models.HistoryUndone, models.HistoryRedone:
+	default:
+		return false
+	}
+
+	for _, change := range entry.Changes {
+		if _, ok := revertibleFields[change.Field]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// latestEntries reads the history of the user newest first and returns up to
+// count changes whose entries pick takes, until it tells to stop. A change is
+// the entry of its cause followed by the entries it caused in the order they
+// were recorded.
+func (tuc *TaskUseCase) latestEntries(ctx context.Context, userId string, count int, pick func(entry *models.HistoryEntry) (take, stop bool)) ([][]*models.HistoryEntry, error) {
+	var changes [][]*models.HistoryEntry
+	caused := make(map[string][]*models.HistoryEntry)
+
+	query := &dtos.GetHistoryQuery{Limit: dtos.MaxTasksLimit, ActorId: userId}
+	for {
+		page, err := tuc.historyRep.GetByActor(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range page.Entries {
+			take, stop := pick(entry)
+			if stop {
+				return changes, nil
+			}
+			if !take {
+				continue
+			}
+
+			// Caused entries are newer than their cause, so they are read
+			// first.
+			if entry.Cause != "" {
+				caused[entry.Cause] = append([]*models.HistoryEntry{entry}, caused[entry.Cause]...)
+				continue
+			}
+
+			changes = append(changes, append([]*models.HistoryEntry{entry}, caused[entry.Id]...))
+			if len(changes) == count {
+				return changes, nil
+			}
+		}
+
+		if page.NextCursor == "" {
+			return changes, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// Undo reverts the latest cmd.Count changes of the user that are not undone
+// yet, newest first, or fewer if there are not as many. A change is reverted
+// with the changes of other tasks it caused, such as its subtasks. Either all
+// of them are reverted or, if any task changed since, none.
+func (tuc *TaskUseCase) Undo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	if tuc.historyRep == nil {
+		return nil, internalErrors.NothingToUndo
+	}
+
+	// An undone entry reverts an older one, which is then skipped. Redone
+	// changes are changes of their own and can be undone again.
+	undone := make(map[string]bool)
+	changes, err := tuc.latestEntries(ctx, userId, cmd.Count, func(entry *models.HistoryEntry) (bool, bool) {
+		if entry.Action == models.HistoryUndone {
+			undone[entry.Reverts] = true
+			return false, false
+		}
+		return !undone[entry.Id] && revertible(entry), false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, internalErrors.NothingToUndo
+	}
+
+	return tuc.revert(ctx, changes, models.HistoryUndone)
+}
+
+// Redo reverts the latest cmd.Count undos of the user, newest first. Undos
+// followed by a new change of the user cannot be redone any more.
+func (tuc *TaskUseCase) Redo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	if tuc.historyRep == nil {
+		return nil, internalErrors.NothingToRedo
+	}
+
+	redone := make(map[string]bool)
+	changes, err := tuc.latestEntries(ctx, userId, cmd.Count, func(entry *models.HistoryEntry) (bool, bool) {
+		switch {
+		case entry.Action == models.HistoryRedone:
+			redone[entry.Reverts] = true
+			return false, false
+		case entry.Action == models.HistoryUndone:
+			return !redone[entry.Id] && revertible(entry), false
+		default:
+			return false, revertible(entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, internalErrors.NothingToRedo
+	}
+
+	return tuc.revert(ctx, changes, models.HistoryRedone)
+}
+
+// revertedChange is an entry to revert with the task before and after it and
+// the index of the change the entry belongs to.
+type revertedChange struct {
+	entry  *models.HistoryEntry
+	change int
+	before models.Task
+	after  *models.Task
+}
+
+// revert sets the fields the entries of the changes changed back to their
+// values before, one entry after another, and records each as action in the
+// same transaction, the entries of a change caused by the first of them. A
+// field that no longer has the value the entry set means the task changed
+// since, which fails the whole batch with UndoConflict before anything is
+// written.
+func (tuc *TaskUseCase) revert(ctx context.Context, changes [][]*models.HistoryEntry, action string) (*dtos.UndoResult, error) {
+	loc := auth.TimeZone(ctx)
+	updatedAt := clock.Timestamp()
+
+	originals := make(map[string]models.Task)
+	states := make(map[string]*models.Task)
+	fields := make(map[string][]string)
+	var taskIds []string
+	var reverted []revertedChange
+
+	for i, entries := range changes {
+		for _, entry := range entries {
+			state, ok := states[entry.TaskId]
+			if !ok {
+				task, err := tuc.getOwnTask(ctx, entry.TaskId)
+				if errors.Is(err, internalErrors.TaskNotFound) {
+					task, err = tuc.getOwnTrashedTask(ctx, entry.TaskId)
+				}
+				if errors.Is(err, internalErrors.TaskNotFound) {
+					return nil, internalErrors.UndoConflict
+				}
+				if err != nil {
+					return nil, err
+				}
+
+				originals[task.Id] = *task
+				states[task.Id] = task
+				taskIds = append(taskIds, task.Id)
+				state = task
+			}
+
+			before := *state
+
+			for _, change := range entry.Changes {
+				field, ok := revertibleFields[change.Field]
+				if !ok {
+					continue
+				}
+
+				if !sameValue(change.Field, field(state), change.After) {
+					return nil, internalErrors.UndoConflict
+				}
+
+				if err := json.Unmarshal(change.Before, field(state)); err != nil {
+					return nil, err
+				}
+
+				name := change.Field
+				if commandField, ok := revertCommandFields[name]; ok {
+					name = commandField
+				}
+				fields[state.Id] = appendField(fields[state.Id], name)
+			}
+
+			if err := tuc.checkReverted(ctx, &before, state, states, loc, updatedAt); err != nil {
+				return nil, err
+			}
+
+			after := *state
+			reverted = append(reverted, revertedChange{entry: entry, change: i, before: before, after: &after})
+		}
+	}
+
+	cmds := make([]*dtos.RevertTaskCommand, len(taskIds))
+	for i, id := range taskIds {
+		cmds[i] = &dtos.RevertTaskCommand{Task: states[id], Fields: fields[id]}
+	}
+
+	result := &dtos.UndoResult{Entries: []*models.HistoryEntry{}, Tasks: []*models.Task{}}
+
+	causes := make(map[int]string)
+	for _, change := range reverted {
+		change.after.Version = states[change.after.Id].Version + 1
+		change.after.UpdatedAt = updatedAt
+
+		entry := tuc.newEntry(ctx, action, &change.before, change.after)
+		if entry == nil {
+			continue
+		}
+		entry.Reverts = change.entry.Id
+
+		if cause, ok := causes[change.change]; ok {
+			entry.Cause = cause
+		} else {
+			causes[change.change] = entry.Id
+		}
+
+		result.Entries = append(result.Entries, entry)
+	}
+
+	if err := tuc.taskRep.Revert(ctx, cmds, updatedAt, result.Entries); err != nil {
+		return nil, err
+	}
+
+	for _, id := range taskIds {
+		states[id].Version++
+		states[id].UpdatedAt = updatedAt
+	}
+
+	for _, id := range taskIds {
+		task, original := states[id], originals[id]
+
+		if task.DeletedAt == "" {
+			if err := tuc.fillProgress(ctx, task); err != nil {
+				return nil, err
+			}
+		}
+
+		eventType := events.TaskUpdated
+		switch {
+		case task.DeletedAt != "" && original.DeletedAt == "":
+			eventType = events.TaskDeleted
+		case task.DeletedAt == "" && original.DeletedAt != "":
+			eventType = events.TaskRestored
+		case task.Completed && !original.Completed:
+			eventType = events.TaskCompleted
+		}
+
+		tuc.publish(ctx, eventType, task)
+		result.Tasks = append(result.Tasks, task)
+	}
+
+	return result, nil
+}
+
+// checkReverted completes the state of a task reverted from before: it
+// resolves the due time of a reverted due date, stamps a task going back to
+// the trash with the current time and verifies that a project or a parent
+// the task goes back to can still hold it. A deleted project is not brought
+// back; as when restored from the trash, the task stays without it.
+func (tuc *TaskUseCase) checkReverted(ctx context.Context, before, state *models.Task, states map[string]*models.Task, loc *time.Location, updatedAt string) error {
+	if state.DueDate != before.DueDate || state.AllDay != before.AllDay {
+		dueDate := storedDue(state.DueDate, state.AllDay, loc)
+		state.DueAt = dueDate.dueAt()
+		state.Overdue = false
+	}
+
+	if state.ProjectId != before.ProjectId && state.ProjectId != "" {
+		err := tuc.checkTargetProject(ctx, state.OwnerId, state.ProjectId)
+		if errors.Is(err, internalErrors.ProjectNotFound) {
+			state.ProjectId = before.ProjectId
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if state.DeletedAt != "" && before.DeletedAt == "" {
+		state.DeletedAt = updatedAt
+	}
+
+	if state.DeletedAt == "" && before.DeletedAt != "" && state.ParentId != "" {
+		parent, ok := states[state.ParentId]
+		if !ok {
+			var err error
+			parent, err = tuc.taskRep.GetById(ctx, state.ParentId)
+			if errors.Is(err, internalErrors.TaskNotFound) {
+				return internalErrors.ParentTaskDeleted
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if parent.DeletedAt != "" {
+			return internalErrors.ParentTaskDeleted
+		}
+	}
+
+	return nil
+}
+
+// sameValue reports whether the field still has the value an entry set. A
+// task is only compared on being in the trash, as going back to the trash
+// stamps it anew.
+func sameValue(name string, field interface{}, value json.RawMessage) bool {
+	if name == "deleted_at" {
+		var deletedAt string
+		if err := json.Unmarshal(value, &deletedAt); err != nil {
+			return false
+		}
+		return (*field.(*string) == "") == (deletedAt == "")
+	}
+
+	current, err := json.Marshal(field)
+	if err != nil {
+		return false
+	}
+
+	var expected interface{}
+	var actual interface{}
+	if json.Unmarshal(value, &expected) != nil || json.Unmarshal(current, &actual) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+func appendField(fields []string, field string) []string {
+	for _, f := range fields {
+		if f == field {
+			return fields
+		}
+	}
+	return append(fields, field)
+}
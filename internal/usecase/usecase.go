@@ -4,13 +4,89 @@ import (
 	"context"
 	"github.com/DanKo-code/TODO-list/internal/dtos"
 	"github.com/DanKo-code/TODO-list/internal/models"
+	"time"
 )
 
+type AuthUseCase interface {
+	Register(ctx context.Context, cmd *dtos.RegisterCommand) (*models.User, error)
+	Login(ctx context.Context, cmd *dtos.LoginCommand) (*dtos.AuthToken, error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (*models.User, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, cmd *dtos.UpdateUserCommand) (*models.User, error)
+}
+
+type ProjectUseCase interface {
+	CreateProject(ctx context.Context, cmd *dtos.CreateProjectCommand) (*models.Project, error)
+	GetProject(ctx context.Context, id string) (*models.Project, error)
+	GetProjects(ctx context.Context, includeArchived bool) ([]*models.Project, error)
+	GetProjectTasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	UpdateProject(ctx context.Context, id string, cmd *dtos.UpdateProjectCommand) (*models.Project, error)
+	ArchiveProject(ctx context.Context, id string, cmd *dtos.ArchiveProjectCommand) (*models.Project, error)
+	DeleteProject(ctx context.Context, id string, cascade bool) error
+}
+
+type TagUseCase interface {
+	CreateTag(ctx context.Context, cmd *dtos.TagCommand) (*models.Tag, error)
+	GetTags(ctx context.Context) ([]*models.Tag, error)
+	RenameTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id string) error
+}
+
+type ReminderUseCase interface {
+	CreateReminder(ctx context.Context, taskId string, cmd *dtos.CreateReminderCommand) (*models.Reminder, error)
+	GetReminders(ctx context.Context, taskId string) ([]*models.Reminder, error)
+	DeleteReminder(ctx context.Context, taskId, reminderId string) error
+	SendDueReminders(ctx context.Context) error
+}
+
+type WebhookUseCase interface {
+	CreateWebhook(ctx context.Context, cmd *dtos.CreateWebhookCommand) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, cmd *dtos.UpdateWebhookCommand) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, id string) ([]*models.WebhookDelivery, error)
+	DeliverPending(ctx context.Context) error
+}
+
 type TaskUseCase interface {
 	CreateTask(ctx context.Context, cmd *dtos.CreateTaskCommand) (*models.Task, error)
-	GetTasks(ctx context.Context) ([]*models.Task, error)
+	CreateSubtask(ctx context.Context, parentId string, cmd *dtos.CreateTaskCommand) (*models.Task, error)
+	GetTask(ctx context.Context, id string) (*models.Task, error)
+	// GetTasksByIds returns the tasks of the user among ids, skipping the
+	// ones that do not exist, with a constant number of queries.
+	GetTasksByIds(ctx context.Context, ids []string) ([]*models.Task, error)
+	// GetSubtasksOf returns the direct subtasks of each of the tasks of the
+	// user among parentIds, keyed by the parent id.
+	GetSubtasksOf(ctx context.Context, parentIds []string) (map[string][]*models.Task, error)
+	GetTasks(ctx context.Context, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	GetSubtasks(ctx context.Context, id string, query *dtos.GetTasksQuery) (*dtos.TaskPage, error)
+	SearchTasks(ctx context.Context, query *dtos.SearchTasksQuery) (*dtos.SearchPage, error)
 	UpdateTask(ctx context.Context, id string, updateTaskCommand *dtos.UpdateTaskCommand) (*models.Task, error)
-	DeleteTask(ctx context.Context, id string) error
-	ChangeTaskCompletionStatus(ctx context.Context, id string, completionStatus bool) (*models.Task, error)
+	// DeleteTask moves the task and its subtasks to the trash.
+	DeleteTask(ctx context.Context, id string, version int64) error
+	// DeleteProject deletes the project together with the changes it makes
+	// to the tasks of the project: with cascade they and their subtasks go
+	// to the trash, and tasks in the trash are left without a project.
+	DeleteProject(ctx context.Context, projectId string, cascade bool) error
+	GetTrash(ctx context.Context) ([]*models.Task, error)
+	RestoreTask(ctx context.Context, id string) (*models.Task, error)
+	// PurgeTask deletes a task in the trash for good.
+	PurgeTask(ctx context.Context, id string) error
+	// PurgeTrash deletes for good the tasks of all users that have been in
+	// the trash longer than retention and returns how many there were.
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	ChangeTaskCompletionStatus(ctx context.Context, id string, cmd *dtos.ChangeTaskCompletionStatusCommand) (*models.Task, error)
+	MoveTask(ctx context.Context, id string, cmd *dtos.MoveTaskCommand) (*models.Task, error)
+	AddTaskTag(ctx context.Context, id string, cmd *dtos.TagCommand) (*models.Task, error)
+	RemoveTaskTag(ctx context.Context, id string, name string) (*models.Task, error)
+	AddTaskBlocker(ctx context.Context, id string, cmd *dtos.BlockerCommand) (*models.Task, error)
+	RemoveTaskBlocker(ctx context.Context, id string, blockerId string) (*models.Task, error)
 	UpdateOverdueTasks(ctx context.Context) error
+	// GetTaskHistory returns the recorded changes of the task, newest first.
+	GetTaskHistory(ctx context.Context, id string, query *dtos.GetHistoryQuery) (*dtos.HistoryPage, error)
+	// Undo reverts the latest changes of the user, Redo the latest undos.
+	Undo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)
+	Redo(ctx context.Context, cmd *dtos.UndoCommand) (*dtos.UndoResult, error)
 }
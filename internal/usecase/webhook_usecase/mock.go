@@ -0,0 +1,47 @@
+package webhook_usecase
+
+import (
+	"context"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	"github.com/DanKo-code/TODO-list/internal/models"
+)
+
+type MockWebhookUseCase struct {
+	CreateWebhookFunc  func(ctx context.Context, cmd *dtos.CreateWebhookCommand) (*models.Webhook, error)
+	GetWebhooksFunc    func(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhookFunc     func(ctx context.Context, id string) (*models.Webhook, error)
+	UpdateWebhookFunc  func(ctx context.Context, id string, cmd *dtos.UpdateWebhookCommand) (*models.Webhook, error)
+	DeleteWebhookFunc  func(ctx context.Context, id string) error
+	GetDeliveriesFunc  func(ctx context.Context, id string) ([]*models.WebhookDelivery, error)
+	DeliverPendingFunc func(ctx context.Context) error
+	Called             bool
+}
+
+func (m *MockWebhookUseCase) CreateWebhook(ctx context.Context, cmd *dtos.CreateWebhookCommand) (*models.Webhook, error) {
+	return m.CreateWebhookFunc(ctx, cmd)
+}
+
+func (m *MockWebhookUseCase) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return m.GetWebhooksFunc(ctx)
+}
+
+func (m *MockWebhookUseCase) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	return m.GetWebhookFunc(ctx, id)
+}
+
+func (m *MockWebhookUseCase) UpdateWebhook(ctx context.Context, id string, cmd *dtos.UpdateWebhookCommand) (*models.Webhook, error) {
+	return m.UpdateWebhookFunc(ctx, id, cmd)
+}
+
+func (m *MockWebhookUseCase) DeleteWebhook(ctx context.Context, id string) error {
+	return m.DeleteWebhookFunc(ctx, id)
+}
+
+func (m *MockWebhookUseCase) GetDeliveries(ctx context.Context, id string) ([]*models.WebhookDelivery, error) {
+	return m.GetDeliveriesFunc(ctx, id)
+}
+
+func (m *MockWebhookUseCase) DeliverPending(ctx context.Context) error {
+	m.Called = true
+	return m.DeliverPendingFunc(ctx)
+}
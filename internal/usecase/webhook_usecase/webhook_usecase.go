@@ -0,0 +1,375 @@
+package webhook_usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository"
+	"github.com/DanKo-code/TODO-list/pkg/helper"
+	"github.com/DanKo-code/TODO-list/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+
+	// MaxAttempts is how often a delivery is tried before it is marked as
+	// failed; with retryDelay the last attempt is about an hour after the first.
+	MaxAttempts = 8
+
+	deliveryTimeout = 10 * time.Second
+	batchSize       = 100
+	// claimLease is how long a claimed delivery is reserved for the dispatcher
+	// that claimed it. Sending a batch takes up to batchSize times
+	// deliveryTimeout, so the lease covers that with a minute to spare;
+	// otherwise another dispatcher could claim and send the rest again.
+	claimLease      = batchSize*deliveryTimeout + time.Minute
+	deliveriesLimit = 50
+	secretBytes     = 32
+)
+
+// retryDelay doubles the wait after every failed attempt, starting at 30 seconds.
+func retryDelay(attempts int) time.Duration {
+	return 30 * time.Second << (attempts - 1)
+}
+
+// Sign returns the value of SignatureHeader for a delivery: the hex encoded
+// HMAC-SHA256 of the timestamp and the body joined by a dot, keyed with the
+// secret of the webhook.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type WebhookUseCase struct {
+	webhookRep repository.WebhookRepository
+	client     *http.Client
+}
+
+func NewWebhookUseCase(webhookRep repository.WebhookRepository) *WebhookUseCase {
+	return &WebhookUseCase{
+		webhookRep: webhookRep,
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+			// A redirect is reported as a failed delivery instead of
+			// resending the signed payload somewhere else.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// getOwnWebhook returns the webhook only if it belongs to the authenticated
+// user; webhooks of other users are reported as not found.
+func (wuc *WebhookUseCase) getOwnWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	webhook, err := wuc.webhookRep.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.OwnerId != userId {
+		return nil, internalErrors.WebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+// uniqueEvents drops repeated event types, keeping the first occurrence.
+func uniqueEvents(eventTypes []string) []string {
+	unique := []string{}
+	seen := map[string]bool{}
+
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+
+	return unique
+}
+
+// CreateWebhook saves a webhook of the authenticated user. The returned
+// webhook carries the secret; it is not shown again unless it is changed.
+func (wuc *WebhookUseCase) CreateWebhook(ctx context.Context, cmd *dtos.CreateWebhookCommand) (*models.Webhook, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	secret := cmd.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhookId, _ := helper.GenerateUUID()
+	createdAt := clock.Timestamp()
+
+	webhook := &models.Webhook{
+		Id:        webhookId,
+		URL:       cmd.URL,
+		Secret:    secret,
+		Events:    uniqueEvents(cmd.Events),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		OwnerId:   userId,
+	}
+
+	err := wuc.webhookRep.Save(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (wuc *WebhookUseCase) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	userId, ok := auth.UserId(ctx)
+	if !ok {
+		return nil, internalErrors.Unauthorized
+	}
+
+	webhooks, err := wuc.webhookRep.GetAll(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (wuc *WebhookUseCase) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	webhook, err := wuc.getOwnWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+func (wuc *WebhookUseCase) UpdateWebhook(ctx context.Context, id string, cmd *dtos.UpdateWebhookCommand) (*models.Webhook, error) {
+	webhook, err := wuc.getOwnWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.URL != "" {
+		webhook.URL = cmd.URL
+	}
+
+	if cmd.Secret != "" {
+		webhook.Secret = cmd.Secret
+	}
+
+	if cmd.Events != nil {
+		webhook.Events = uniqueEvents(*cmd.Events)
+	}
+
+	webhook.UpdatedAt = clock.Timestamp()
+
+	err = wuc.webhookRep.Update(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Secret == "" {
+		webhook.Secret = ""
+	}
+
+	return webhook, nil
+}
+
+func (wuc *WebhookUseCase) DeleteWebhook(ctx context.Context, id string) error {
+	webhook, err := wuc.getOwnWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return wuc.webhookRep.Delete(ctx, webhook.Id)
+}
+
+// GetDeliveries returns the latest deliveries of the webhook, newest first.
+func (wuc *WebhookUseCase) GetDeliveries(ctx context.Context, id string) ([]*models.WebhookDelivery, error) {
+	webhook, err := wuc.getOwnWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return wuc.webhookRep.GetDeliveries(ctx, webhook.Id, deliveriesLimit)
+}
+
+// Publish queues a delivery of the event for every webhook of its owner that
+// subscribes to it. The deliveries are sent by DeliverPending, so a slow or
+// unreachable receiver never delays the change that caused the event.
+func (wuc *WebhookUseCase) Publish(ctx context.Context, event *events.Event) {
+	webhooks, err := wuc.webhookRep.GetAll(ctx, event.OwnerId)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to queue event %s: %v", event.Id, err)
+		return
+	}
+
+	var payload []byte
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(event)
+			if err != nil {
+				logger.ErrorLogger.Printf("Failed to encode event %s: %v", event.Id, err)
+				return
+			}
+		}
+
+		deliveryId, _ := helper.GenerateUUID()
+		createdAt := clock.Timestamp()
+
+		delivery := &models.WebhookDelivery{
+			Id:            deliveryId,
+			WebhookId:     webhook.Id,
+			EventId:       event.Id,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: createdAt,
+			CreatedAt:     createdAt,
+		}
+
+		if err := wuc.webhookRep.SaveDelivery(ctx, delivery); err != nil {
+			logger.ErrorLogger.Printf("Failed to queue event %s for webhook %s: %v", event.Id, webhook.Id, err)
+		}
+	}
+}
+
+// DeliverPending claims the deliveries that are due and sends them. A
+// delivery is marked as delivered once the receiver answered with a 2xx
+// status; failed ones are retried with a growing delay up to MaxAttempts
+// times. A delivery that cannot be handled is logged and left to the next
+// claim once its lease expires, so it does not hold up the rest of the batch.
+func (wuc *WebhookUseCase) DeliverPending(ctx context.Context) error {
+	current := clock.Now()
+
+	deliveries, err := wuc.webhookRep.ClaimDeliveries(ctx, current.Format(time.RFC3339), current.Add(claimLease).Format(time.RFC3339), batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := wuc.deliver(ctx, delivery); err != nil {
+			logger.ErrorLogger.Printf("Failed to handle webhook delivery %s: %v", delivery.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (wuc *WebhookUseCase) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	webhook, err := wuc.webhookRep.GetById(ctx, delivery.WebhookId)
+	if errors.Is(err, internalErrors.WebhookNotFound) {
+		// The webhook was deleted after the claim, its deliveries are gone.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	statusCode, err := wuc.send(ctx, webhook, delivery)
+
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = clock.Timestamp()
+	case delivery.Attempts >= MaxAttempts:
+		logger.ErrorLogger.Printf("Giving up on webhook delivery %s after %d attempts: %v", delivery.Id, delivery.Attempts, err)
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		logger.ErrorLogger.Printf("Failed to deliver webhook delivery %s, will retry: %v", delivery.Id, err)
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = clock.Now().Add(retryDelay(delivery.Attempts)).Format(time.RFC3339)
+	}
+
+	return wuc.webhookRep.UpdateDelivery(ctx, delivery)
+}
+
+// send posts the payload of the delivery to the webhook and returns the
+// status code of the response, zero if there was none.
+func (wuc *WebhookUseCase) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	sentAt := strconv.FormatInt(clock.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TODO-list-webhooks")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.Id)
+	req.Header.Set(TimestampHeader, sentAt)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, sentAt, delivery.Payload))
+
+	resp, err := wuc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,264 @@
+package webhook_usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DanKo-code/TODO-list/internal/auth"
+	"github.com/DanKo-code/TODO-list/internal/clock"
+	"github.com/DanKo-code/TODO-list/internal/dtos"
+	internalErrors "github.com/DanKo-code/TODO-list/internal/errors"
+	"github.com/DanKo-code/TODO-list/internal/events"
+	"github.com/DanKo-code/TODO-list/internal/models"
+	"github.com/DanKo-code/TODO-list/internal/repository/sqlite"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testUserId = "5f0c6a1e-2b1d-4f7e-9a51-0c2f3b7d8e90"
+
+func freeze(t *testing.T) {
+	frozen := time.Date(2024, 11, 22, 10, 30, 0, 0, time.UTC)
+	clock.Freeze(t, frozen)
+}
+
+func TestCreateWebhookUseCase(t *testing.T) {
+	var saved *models.Webhook
+
+	mockWebhookRepository := &sqlite.MockWebhookRepository{
+		SaveFunc: func(ctx context.Context, webhook *models.Webhook) error {
+			saved = webhook
+			return nil
+		},
+	}
+
+	wuc := NewWebhookUseCase(mockWebhookRepository)
+
+	cmd := &dtos.CreateWebhookCommand{URL: "https://example.com/hook", Events: []string{events.TaskCreated, events.TaskCreated, events.TaskDeleted}}
+	webhook, err := wuc.CreateWebhook(auth.WithUserId(context.Background(), testUserId), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved != webhook || webhook.OwnerId != testUserId || len(webhook.Secret) != 2*secretBytes {
+		t.Errorf("expected a webhook with a generated secret, got %+v", webhook)
+	}
+	if len(webhook.Events) != 2 || webhook.Events[0] != events.TaskCreated || webhook.Events[1] != events.TaskDeleted {
+		t.Errorf("expected repeated events to be dropped, got %v", webhook.Events)
+	}
+}
+
+func TestGetWebhookUseCase(t *testing.T) {
+	mockWebhookRepository := &sqlite.MockWebhookRepository{
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Webhook, error) {
+			return &models.Webhook{Id: id, Secret: "0123456789abcdef", OwnerId: map[string]string{"own": testUserId}[id]}, nil
+		},
+	}
+
+	wuc := NewWebhookUseCase(mockWebhookRepository)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	webhook, err := wuc.GetWebhook(ctx, "own")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhook.Secret != "" {
+		t.Errorf("expected the secret to be hidden, got %q", webhook.Secret)
+	}
+
+	if _, err := wuc.GetWebhook(ctx, "foreign"); !errors.Is(err, internalErrors.WebhookNotFound) {
+		t.Errorf("expected a webhook of another user to be not found, got %v", err)
+	}
+}
+
+func TestPublishUseCase(t *testing.T) {
+	freeze(t)
+
+	var queued []*models.WebhookDelivery
+
+	mockWebhookRepository := &sqlite.MockWebhookRepository{
+		GetAllFunc: func(ctx context.Context, ownerId string) ([]*models.Webhook, error) {
+			if ownerId != testUserId {
+				t.Errorf("expected the webhooks of the event owner, got %s", ownerId)
+			}
+			return []*models.Webhook{
+				{Id: "all", Events: []string{}},
+				{Id: "deleted-only", Events: []string{events.TaskDeleted}},
+				{Id: "created-only", Events: []string{events.TaskCreated}},
+			}, nil
+		},
+		SaveDeliveryFunc: func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			queued = append(queued, delivery)
+			return nil
+		},
+	}
+
+	wuc := NewWebhookUseCase(mockWebhookRepository)
+
+	wuc.Publish(context.Background(), &events.Event{Id: "e1", Type: events.TaskCreated, OwnerId: testUserId, Task: &models.Task{Id: "t1", Title: "report"}})
+
+	if len(queued) != 2 || queued[0].WebhookId != "all" || queued[1].WebhookId != "created-only" {
+		t.Fatalf("expected deliveries for the subscribed webhooks, got %+v", queued)
+	}
+
+	delivery := queued[0]
+	if delivery.Status != models.DeliveryPending || delivery.EventId != "e1" || delivery.NextAttemptAt != "2024-11-22T10:30:00Z" {
+		t.Errorf("expected a pending delivery due now, got %+v", delivery)
+	}
+
+	event := events.Event{}
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil || event.Type != events.TaskCreated || event.Task.Title != "report" {
+		t.Errorf("expected the event as payload, got %s", delivery.Payload)
+	}
+}
+
+func TestDeliverPendingUseCase(t *testing.T) {
+	freeze(t)
+
+	const secret = "0123456789abcdef"
+	payload := []byte(`{"id":"e1","type":"task.created"}`)
+
+	test := []struct {
+		name               string
+		status             int
+		attempts           int
+		expectedStatus     string
+		expectedNext       string
+		expectedStatusCode int
+	}{
+		{name: "delivered", status: http.StatusNoContent, attempts: 1, expectedStatus: models.DeliveryDelivered, expectedNext: "2024-11-22T10:30:00Z", expectedStatusCode: 204},
+		{name: "failure is retried", status: http.StatusInternalServerError, attempts: 3, expectedStatus: models.DeliveryPending, expectedNext: "2024-11-22T10:32:00Z", expectedStatusCode: 500},
+		{name: "redirect is a failure", status: http.StatusFound, attempts: 1, expectedStatus: models.DeliveryPending, expectedNext: "2024-11-22T10:30:30Z", expectedStatusCode: 302},
+		{name: "last failure gives up", status: http.StatusBadGateway, attempts: MaxAttempts, expectedStatus: models.DeliveryFailed, expectedNext: "2024-11-22T10:30:00Z", expectedStatusCode: 502},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				sentAt := r.Header.Get(TimestampHeader)
+				if sentAt != strconv.FormatInt(clock.Now().Unix(), 10) || r.Header.Get(SignatureHeader) != Sign(secret, sentAt, body) {
+					t.Errorf("expected a valid signature, got %q at %q", r.Header.Get(SignatureHeader), sentAt)
+				}
+				if r.Header.Get(EventHeader) != "task.created" || r.Header.Get(DeliveryHeader) != "d1" || string(body) != string(payload) {
+					t.Errorf("unexpected request %v with body %s", r.Header, body)
+				}
+
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			var updated *models.WebhookDelivery
+
+			mockWebhookRepository := &sqlite.MockWebhookRepository{
+				ClaimDeliveriesFunc: func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+					// The lease must outlast a full batch of timed out deliveries.
+					if now != "2024-11-22T10:30:00Z" || claimedUntil <= "2024-11-22T10:46:40Z" || limit != batchSize {
+						t.Errorf("unexpected claim of %d from %s until %s", limit, now, claimedUntil)
+					}
+					return []*models.WebhookDelivery{{Id: "d1", WebhookId: "w1", EventType: "task.created", Payload: payload, Status: models.DeliveryPending, Attempts: tt.attempts, NextAttemptAt: "2024-11-22T10:30:00Z"}}, nil
+				},
+				GetByIdFunc: func(ctx context.Context, id string) (*models.Webhook, error) {
+					return &models.Webhook{Id: id, URL: receiver.URL, Secret: secret}, nil
+				},
+				UpdateDeliveryFunc: func(ctx context.Context, delivery *models.WebhookDelivery) error {
+					updated = delivery
+					return nil
+				},
+			}
+
+			wuc := NewWebhookUseCase(mockWebhookRepository)
+
+			if err := wuc.DeliverPending(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if updated == nil {
+				t.Fatal("expected the outcome to be recorded")
+			}
+			if updated.Status != tt.expectedStatus || updated.NextAttemptAt != tt.expectedNext || updated.LastStatusCode != tt.expectedStatusCode {
+				t.Errorf("expected %s with next attempt at %s and status code %d, got %+v", tt.expectedStatus, tt.expectedNext, tt.expectedStatusCode, updated)
+			}
+			if (updated.Status == models.DeliveryDelivered) != (updated.DeliveredAt != "") || (updated.Status == models.DeliveryDelivered) == (updated.LastError != "") {
+				t.Errorf("expected delivered_at only for delivered and last_error only for failed attempts, got %+v", updated)
+			}
+		})
+	}
+}
+
+func TestDeliverPendingUnreachable(t *testing.T) {
+	freeze(t)
+
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	var updated *models.WebhookDelivery
+
+	mockWebhookRepository := &sqlite.MockWebhookRepository{
+		ClaimDeliveriesFunc: func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+			return []*models.WebhookDelivery{{Id: "d1", WebhookId: "w1", Payload: []byte(`{}`), Status: models.DeliveryPending, Attempts: 1}}, nil
+		},
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Webhook, error) {
+			return &models.Webhook{Id: id, URL: url, Secret: "0123456789abcdef"}, nil
+		},
+		UpdateDeliveryFunc: func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			updated = delivery
+			return nil
+		},
+	}
+
+	if err := NewWebhookUseCase(mockWebhookRepository).DeliverPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Status != models.DeliveryPending || updated.LastStatusCode != 0 || updated.LastError == "" || updated.NextAttemptAt != "2024-11-22T10:30:30Z" {
+		t.Errorf("expected a connection error to be retried, got %+v", updated)
+	}
+}
+
+func TestDeliverPendingContinuesAfterFailure(t *testing.T) {
+	freeze(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	var updated []string
+
+	mockWebhookRepository := &sqlite.MockWebhookRepository{
+		ClaimDeliveriesFunc: func(ctx context.Context, now, claimedUntil string, limit int) ([]*models.WebhookDelivery, error) {
+			return []*models.WebhookDelivery{
+				{Id: "d1", WebhookId: "w1", Payload: []byte(`{}`), Status: models.DeliveryPending, Attempts: 1},
+				{Id: "d2", WebhookId: "w1", Payload: []byte(`{}`), Status: models.DeliveryPending, Attempts: 1},
+			}, nil
+		},
+		GetByIdFunc: func(ctx context.Context, id string) (*models.Webhook, error) {
+			return &models.Webhook{Id: id, URL: receiver.URL, Secret: "0123456789abcdef"}, nil
+		},
+		UpdateDeliveryFunc: func(ctx context.Context, delivery *models.WebhookDelivery) error {
+			updated = append(updated, delivery.Id)
+			if delivery.Id == "d1" {
+				return errors.New("database is locked")
+			}
+			return nil
+		},
+	}
+
+	if err := NewWebhookUseCase(mockWebhookRepository).DeliverPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated) != 2 || updated[1] != "d2" {
+		t.Errorf("expected the batch to go on after a failed update, got %v", updated)
+	}
+}
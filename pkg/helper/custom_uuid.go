@@ -4,8 +4,11 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"regexp"
 )
 
+var uuidRegex = regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[1-5][a-f0-9]{3}-[89ab][a-f0-9]{3}-[a-f0-9]{12}$`)
+
 func GenerateUUID() (string, error) {
 	uuid := make([]byte, 16)
 
@@ -23,3 +26,7 @@ func GenerateUUID() (string, error) {
 		uuid[8:10],
 		uuid[10:16]), nil
 }
+
+func IsValidUUID(uuid string) bool {
+	return uuidRegex.MatchString(uuid)
+}